@@ -11,17 +11,60 @@ import (
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/zlog"
 
-	"github.com/aliskhannn/warehouse-control/internal/api/handler/audit"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/adjustment"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/alert"
+	audithandler "github.com/aliskhannn/warehouse-control/internal/api/handler/audit"
 	"github.com/aliskhannn/warehouse-control/internal/api/handler/auth"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/count"
+	currencyhandler "github.com/aliskhannn/warehouse-control/internal/api/handler/currency"
 	"github.com/aliskhannn/warehouse-control/internal/api/handler/item"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/location"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/lot"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/purchase"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/receipt"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/report"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/reservation"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/sales"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/scan"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/search"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/serial"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/supplier"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/transfer"
 	"github.com/aliskhannn/warehouse-control/internal/api/handler/user"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/warehouse"
 	"github.com/aliskhannn/warehouse-control/internal/api/router"
 	"github.com/aliskhannn/warehouse-control/internal/api/server"
+	"github.com/aliskhannn/warehouse-control/internal/audit"
 	"github.com/aliskhannn/warehouse-control/internal/config"
+	"github.com/aliskhannn/warehouse-control/internal/currency"
+	repoadjustment "github.com/aliskhannn/warehouse-control/internal/repository/adjustment"
+	repoalert "github.com/aliskhannn/warehouse-control/internal/repository/alert"
+	repocurrency "github.com/aliskhannn/warehouse-control/internal/repository/currency"
 	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	repolocation "github.com/aliskhannn/warehouse-control/internal/repository/location"
+	reposearch "github.com/aliskhannn/warehouse-control/internal/repository/search"
+	reposupplier "github.com/aliskhannn/warehouse-control/internal/repository/supplier"
 	repouser "github.com/aliskhannn/warehouse-control/internal/repository/user"
+	repowarehouse "github.com/aliskhannn/warehouse-control/internal/repository/warehouse"
+	serviceadjustment "github.com/aliskhannn/warehouse-control/internal/service/adjustment"
+	servicealert "github.com/aliskhannn/warehouse-control/internal/service/alert"
+	servicecount "github.com/aliskhannn/warehouse-control/internal/service/count"
+	servicecurrency "github.com/aliskhannn/warehouse-control/internal/service/currency"
 	serviceitem "github.com/aliskhannn/warehouse-control/internal/service/item"
+	servicelocation "github.com/aliskhannn/warehouse-control/internal/service/location"
+	servicelot "github.com/aliskhannn/warehouse-control/internal/service/lot"
+	servicepurchase "github.com/aliskhannn/warehouse-control/internal/service/purchase"
+	servicereceipt "github.com/aliskhannn/warehouse-control/internal/service/receipt"
+	servicereport "github.com/aliskhannn/warehouse-control/internal/service/report"
+	servicereservation "github.com/aliskhannn/warehouse-control/internal/service/reservation"
+	servicesales "github.com/aliskhannn/warehouse-control/internal/service/sales"
+	servicescan "github.com/aliskhannn/warehouse-control/internal/service/scan"
+	servicesearch "github.com/aliskhannn/warehouse-control/internal/service/search"
+	serviceserial "github.com/aliskhannn/warehouse-control/internal/service/serial"
+	servicesupplier "github.com/aliskhannn/warehouse-control/internal/service/supplier"
+	servicetransfer "github.com/aliskhannn/warehouse-control/internal/service/transfer"
 	serviceuser "github.com/aliskhannn/warehouse-control/internal/service/user"
+	servicewarehouse "github.com/aliskhannn/warehouse-control/internal/service/warehouse"
 )
 
 func main() {
@@ -54,16 +97,117 @@ func main() {
 	authHandler := auth.NewHandler(userService, val)
 	userHandler := user.NewHandler(userService)
 
-	// Initialize item repository, service.
+	// Initialize item repository, unit of work and service.
+	// In app audit mode the service writes item history instead of the database triggers.
 	itemRepo := repoitem.NewRepository(db)
-	itemService := serviceitem.NewService(itemRepo)
+	itemUoW := repoitem.NewUnitOfWork(db, cfg.Audit.Mode)
 
-	// Initialize handlers for item and audit endpoints.
-	itemHandler := item.NewHandler(itemService, val)
-	auditHandler := audit.NewHandler(itemService)
+	var auditWriter audit.AuditWriter
+	switch cfg.Audit.Mode {
+	case audit.ModeTrigger:
+	case audit.ModeApp:
+		auditWriter = audit.NewWriter(itemRepo)
+	default:
+		zlog.Logger.Fatal().Str("mode", cfg.Audit.Mode).Msg("unknown audit mode")
+	}
+
+	// Initialize low-stock alerts: the checker runs in the background and is told about
+	// every item change by the item service.
+	alertRepo := repoalert.NewRepository(db)
+	alertChecker := servicealert.NewChecker(alertRepo, servicealert.LogNotifier{}, cfg.Alerts.CheckInterval)
+	alertService := servicealert.NewService(alertRepo)
+
+	// Initialize exchange rate repository and currency service, which converts prices and
+	// report values between currencies.
+	base, err := currency.Normalize(cfg.Currencies.Base)
+	if err != nil {
+		zlog.Logger.Fatal().Err(err).Msg("invalid base currency")
+	}
+
+	rounding := make(map[string]currency.Rounding, len(cfg.Currencies.Rounding))
+	for code, rule := range cfg.Currencies.Rounding {
+		rounding[code] = currency.Rounding{Places: rule.Places, Mode: rule.Mode}
+		if err := rounding[code].Validate(); err != nil {
+			zlog.Logger.Fatal().Err(err).Str("currency", code).Msg("invalid rounding rule")
+		}
+	}
+
+	currencyRepo := repocurrency.NewRepository(db)
+	currencyService := servicecurrency.NewService(currencyRepo, base, rounding)
+
+	itemService := serviceitem.NewService(
+		itemRepo, itemUoW, auditWriter, alertChecker, cfg.Pricing.MaxChangePercent, currencyService,
+	)
+
+	// Initialize search repository and service.
+	searchRepo := reposearch.NewRepository(db)
+	searchService := servicesearch.NewService(searchRepo)
+
+	// Initialize scan service.
+	scanService := servicescan.NewService(itemRepo)
+
+	// Initialize warehouse repository and service.
+	warehouseRepo := repowarehouse.NewRepository(db)
+	warehouseService := servicewarehouse.NewService(warehouseRepo)
+
+	// Initialize adjustment reason repository and service.
+	adjustmentRepo := repoadjustment.NewRepository(db)
+	adjustmentService := serviceadjustment.NewService(adjustmentRepo)
+
+	// Initialize location repository and service.
+	locationRepo := repolocation.NewRepository(db)
+	locationService := servicelocation.NewService(locationRepo)
+
+	// Initialize transfer order service; it moves stock through the item service.
+	transferService := servicetransfer.NewService(itemRepo, itemUoW, itemService)
+
+	// Initialize supplier repository and service, and the purchase order and goods receipt
+	// services, which receive deliveries through the item service.
+	supplierRepo := reposupplier.NewRepository(db)
+	supplierService := servicesupplier.NewService(supplierRepo)
+	purchaseService := servicepurchase.NewService(itemRepo, itemUoW, itemService)
+	receiptService := servicereceipt.NewService(itemRepo, itemUoW, itemService)
+
+	// Initialize sales order service; it ships stock through the item service.
+	salesService := servicesales.NewService(itemRepo, itemUoW, itemService)
+
+	// Initialize stock reservations: the expirer releases expired ones in the background.
+	reservationService := servicereservation.NewService(itemRepo, cfg.Reservations.DefaultTTL)
+	reservationExpirer := servicereservation.NewExpirer(itemRepo, cfg.Reservations.ExpiryInterval)
+
+	// Initialize cycle count service; approved variances are adjusted through the item service.
+	countService := servicecount.NewService(itemRepo, itemUoW, itemService)
+
+	// Initialize lot, serial and report services.
+	lotService := servicelot.NewService(itemRepo)
+	serialService := serviceserial.NewService(itemRepo)
+	reportService := servicereport.NewService(itemRepo, currencyService)
+
+	// Initialize handlers for item, audit, search, scan, warehouse, location, transfer, supplier, purchase order,
+	// goods receipt, sales order, reservation, count session, adjustment reason, exchange rate, lot, serial, alert and
+	// report endpoints.
+	itemHandler := item.NewHandler(itemService, countService, val)
+	auditHandler := audithandler.NewHandler(itemService)
+	searchHandler := search.NewHandler(searchService)
+	scanHandler := scan.NewHandler(scanService, val)
+	warehouseHandler := warehouse.NewHandler(warehouseService, countService, val)
+	locationHandler := location.NewHandler(locationService, countService, val)
+	transferHandler := transfer.NewHandler(transferService, val)
+	supplierHandler := supplier.NewHandler(supplierService, val)
+	purchaseHandler := purchase.NewHandler(purchaseService, val)
+	receiptHandler := receipt.NewHandler(receiptService, val)
+	salesHandler := sales.NewHandler(salesService, val)
+	reservationHandler := reservation.NewHandler(reservationService, val)
+	countHandler := count.NewHandler(countService, val)
+	adjustmentHandler := adjustment.NewHandler(adjustmentService, val)
+	currencyHandler := currencyhandler.NewHandler(currencyService, val)
+	lotHandler := lot.NewHandler(lotService, val)
+	serialHandler := serial.NewHandler(serialService)
+	alertHandler := alert.NewHandler(alertService)
+	reportHandler := report.NewHandler(reportService)
 
 	// Initialize API router and HTTP server.
-	r := router.New(authHandler, userHandler, itemHandler, auditHandler, cfg)
+	r := router.New(authHandler, userHandler, itemHandler, auditHandler, searchHandler, scanHandler, warehouseHandler, locationHandler, transferHandler, lotHandler, reportHandler, serialHandler, alertHandler, supplierHandler, purchaseHandler, receiptHandler, salesHandler, reservationHandler, countHandler, adjustmentHandler, currencyHandler, cfg)
 	s := server.New(cfg.Server.HTTPPort, r)
 
 	// Start HTTP server in a separate goroutine.
@@ -77,6 +221,12 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Start the low-stock checker; it stops with the shutdown signal.
+	go alertChecker.Run(ctx)
+
+	// Start the reservation expirer; it stops with the shutdown signal.
+	go reservationExpirer.Run(ctx)
+
 	// Wait for shutdown signal.
 	<-ctx.Done()
 	zlog.Logger.Print("shutdown signal received")
@@ -57,6 +57,6 @@ require (
 )
 
 require (
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/lib/pq v1.10.9
 	github.com/shopspring/decimal v1.4.0
 )
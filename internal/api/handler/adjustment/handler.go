@@ -0,0 +1,120 @@
+package adjustment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoadjustment "github.com/aliskhannn/warehouse-control/internal/repository/adjustment"
+)
+
+// service defines the interface for adjustment reason service used by the handler.
+type service interface {
+	// Create adds an active adjustment reason.
+	Create(ctx context.Context, code, name string) (*model.AdjustmentReason, error)
+
+	// GetAll retrieves the adjustment reasons, or only the active ones.
+	GetAll(ctx context.Context, activeOnly bool) ([]*model.AdjustmentReason, error)
+
+	// Update renames an adjustment reason and activates or deactivates it.
+	Update(ctx context.Context, code, name string, active bool) (*model.AdjustmentReason, error)
+}
+
+// Handler provides HTTP handlers for adjustment reason endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new adjustment reason handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for adding an adjustment reason.
+type CreateRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+	Name string `json:"name" validate:"required"`
+}
+
+// UpdateRequest represents the JSON request body for updating an adjustment reason.
+type UpdateRequest struct {
+	Name   string `json:"name" validate:"required"`
+	Active *bool  `json:"active" validate:"required"`
+}
+
+// Create handles adding an adjustment reason to the catalogue.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	ar, err := h.service.Create(c.Request.Context(), req.Code, req.Name)
+	if err != nil {
+		failReason(c, err, "failed to create adjustment reason")
+		return
+	}
+
+	response.Created(c, ar)
+}
+
+// GetAll handles listing the adjustment reasons; ?active=true leaves out inactive ones.
+func (h *Handler) GetAll(c *ginext.Context) {
+	active, err := request.QueryBool(c, "active")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	reasons, err := h.service.GetAll(c.Request.Context(), active != nil && *active)
+	if err != nil {
+		failReason(c, err, "failed to get adjustment reasons")
+		return
+	}
+
+	response.OK(c, reasons)
+}
+
+// Update handles renaming, activating or deactivating an adjustment reason.
+func (h *Handler) Update(c *ginext.Context) {
+	var req UpdateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	ar, err := h.service.Update(c.Request.Context(), c.Param("code"), req.Name, *req.Active)
+	if err != nil {
+		failReason(c, err, "failed to update adjustment reason")
+		return
+	}
+
+	response.OK(c, ar)
+}
+
+// failReason answers a failed adjustment reason request: 404 for an unknown reason, 409 for a
+// code already taken and 400 for an invalid code. Anything else is logged with msg and answered
+// with 500.
+func failReason(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, repoadjustment.ErrReasonNotFound):
+		response.Fail(c, http.StatusNotFound, repoadjustment.ErrReasonNotFound)
+	case errors.Is(err, repoadjustment.ErrCodeTaken):
+		response.Fail(c, http.StatusConflict, repoadjustment.ErrCodeTaken)
+	case errors.Is(err, repoadjustment.ErrInvalidCode):
+		response.Fail(c, http.StatusBadRequest, repoadjustment.ErrInvalidCode)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
@@ -0,0 +1,101 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoalert "github.com/aliskhannn/warehouse-control/internal/repository/alert"
+)
+
+// service defines the interface for alert service used by the handler.
+type service interface {
+	// GetLowStock retrieves the low-stock alerts matching the filter, newest first.
+	GetLowStock(ctx context.Context, filter model.AlertFilter) ([]*model.LowStockAlert, error)
+
+	// Acknowledge records that a user is taking care of an open low-stock alert.
+	Acknowledge(ctx context.Context, userID, alertID uuid.UUID) (*model.LowStockAlert, error)
+}
+
+// Handler provides HTTP handlers for alert endpoints.
+type Handler struct {
+	service service
+}
+
+// NewHandler creates a new alert handler.
+func NewHandler(s service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// GetLowStock handles listing low-stock alerts. Only open alerts are listed unless
+// ?resolved=true; ?acknowledged filters by whether someone acknowledged them.
+func (h *Handler) GetLowStock(c *ginext.Context) {
+	var filter model.AlertFilter
+
+	acknowledged, err := request.QueryBool(c, "acknowledged")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resolved, err := request.QueryBool(c, "resolved")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	filter.Acknowledged = acknowledged
+	filter.Resolved = resolved != nil && *resolved
+
+	alerts, err := h.service.GetLowStock(c.Request.Context(), filter)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to get low-stock alerts")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get low-stock alerts"))
+		return
+	}
+
+	response.OK(c, alerts)
+}
+
+// Acknowledge handles acknowledging an open low-stock alert.
+func (h *Handler) Acknowledge(c *ginext.Context) {
+	alertID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid alert ID"))
+		return
+	}
+
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	a, err := h.service.Acknowledge(c.Request.Context(), userID, alertID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repoalert.ErrAlertNotFound):
+			response.Fail(c, http.StatusNotFound, repoalert.ErrAlertNotFound)
+		case errors.Is(err, repoalert.ErrAlertAcknowledged):
+			response.Fail(c, http.StatusConflict, repoalert.ErrAlertAcknowledged)
+		case errors.Is(err, repoalert.ErrAlertResolved):
+			response.Fail(c, http.StatusConflict, repoalert.ErrAlertResolved)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to acknowledge low-stock alert")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to acknowledge low-stock alert"))
+		}
+
+		return
+	}
+
+	response.OK(c, a)
+}
@@ -0,0 +1,311 @@
+package count
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	servicecount "github.com/aliskhannn/warehouse-control/internal/service/count"
+)
+
+// service defines the interface for count session service used by the handler.
+type service interface {
+	// Create starts a count session for the places of a warehouse within scope.
+	Create(ctx context.Context, userID uuid.UUID, cs *model.CountSession, scope model.CountScope) (*model.CountSession, error)
+
+	// GetByID retrieves a count session by its ID.
+	GetByID(ctx context.Context, sessionID uuid.UUID) (*model.CountSession, error)
+
+	// GetAll retrieves the count sessions with a status, or all if it is empty.
+	GetAll(ctx context.Context, status model.CountStatus) ([]*model.CountSession, error)
+
+	// Record records what a counter found at the places of some lines of a session.
+	Record(ctx context.Context, userID, sessionID uuid.UUID, entries []model.CountEntry) (*model.CountSession, error)
+
+	// Submit hands a fully counted session to a manager for review.
+	Submit(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error)
+
+	// Approve accepts the counts of a session and posts its variances with the given reason codes.
+	Approve(ctx context.Context, userID, sessionID uuid.UUID, shortageCode, surplusCode string) (*model.CountSession, error)
+
+	// Reject sends a session under review back to counting.
+	Reject(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error)
+
+	// Cancel abandons a session that has not been approved.
+	Cancel(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error)
+}
+
+// Handler provides HTTP handlers for count session endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new count session handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for starting a count session.
+// The warehouse defaults to the default warehouse; without item_ids every item it holds is
+// counted, and without locations (bin codes) every place.
+type CreateRequest struct {
+	WarehouseID uuid.UUID   `json:"warehouse_id"`
+	ItemIDs     []uuid.UUID `json:"item_ids" validate:"dive,required"`
+	Locations   []string    `json:"locations" validate:"dive,required,max=64"`
+	Note        string      `json:"note"`
+}
+
+// RecordRequest represents the JSON request body for recording counts.
+type RecordRequest struct {
+	Entries []EntryRequest `json:"entries" validate:"required,min=1,dive"`
+}
+
+// EntryRequest is the quantity counted at the place of one line of a session.
+type EntryRequest struct {
+	LineID   uuid.UUID `json:"line_id" validate:"required"`
+	Quantity *int      `json:"quantity" validate:"required,gte=0"`
+}
+
+// ApproveRequest represents the JSON request body for approving a count session: the adjustment
+// reason codes of its shortfalls and of its surpluses. Each is required if the session has
+// such variances, and the body may be omitted if it has none.
+type ApproveRequest struct {
+	ShortageCode string `json:"shortage_code" validate:"max=32"`
+	SurplusCode  string `json:"surplus_code" validate:"max=32"`
+}
+
+// Create handles starting a count session.
+func (h *Handler) Create(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	cs := &model.CountSession{WarehouseID: req.WarehouseID, Note: req.Note}
+	scope := model.CountScope{ItemIDs: req.ItemIDs, Locations: req.Locations}
+
+	cs, err := h.service.Create(c.Request.Context(), userID, cs, scope)
+	if err != nil {
+		failCount(c, err, "failed to create count session")
+		return
+	}
+
+	respond(c, http.StatusCreated, cs)
+}
+
+// GetByID handles retrieving a count session by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	sessionID, ok := getSessionID(c)
+	if !ok {
+		return
+	}
+
+	cs, err := h.service.GetByID(c.Request.Context(), sessionID)
+	if err != nil {
+		failCount(c, err, "failed to get count session")
+		return
+	}
+
+	respond(c, http.StatusOK, cs)
+}
+
+// GetAll handles listing count sessions, optionally filtered by ?status.
+func (h *Handler) GetAll(c *ginext.Context) {
+	status := model.CountStatus(c.Query("status"))
+
+	switch status {
+	case "", model.CountCounting, model.CountReview, model.CountApproved, model.CountCancelled:
+	default:
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid status"))
+		return
+	}
+
+	sessions, err := h.service.GetAll(c.Request.Context(), status)
+	if err != nil {
+		failCount(c, err, "failed to get count sessions")
+		return
+	}
+
+	if request.Blind(c) {
+		for _, cs := range sessions {
+			cs.Blind()
+		}
+	}
+
+	response.OK(c, sessions)
+}
+
+// Record handles recording counts for the lines of a session.
+func (h *Handler) Record(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, ok := getSessionID(c)
+	if !ok {
+		return
+	}
+
+	var req RecordRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	entries := make([]model.CountEntry, 0, len(req.Entries))
+	for _, entry := range req.Entries {
+		entries = append(entries, model.CountEntry{LineID: entry.LineID, Quantity: *entry.Quantity})
+	}
+
+	cs, err := h.service.Record(c.Request.Context(), userID, sessionID, entries)
+	if err != nil {
+		failCount(c, err, "failed to record counts")
+		return
+	}
+
+	respond(c, http.StatusOK, cs)
+}
+
+// Submit handles handing a count session to a manager for review.
+func (h *Handler) Submit(c *ginext.Context) {
+	h.transition(c, h.service.Submit, "failed to submit count session")
+}
+
+// Approve handles approving a count session.
+func (h *Handler) Approve(c *ginext.Context) {
+	var req ApproveRequest
+	if !request.BindOptional(c, h.validator, &req) {
+		return
+	}
+
+	h.transition(c, func(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error) {
+		return h.service.Approve(ctx, userID, sessionID, req.ShortageCode, req.SurplusCode)
+	}, "failed to approve count session")
+}
+
+// Reject handles sending a count session back to counting.
+func (h *Handler) Reject(c *ginext.Context) {
+	h.transition(c, h.service.Reject, "failed to reject count session")
+}
+
+// Cancel handles cancelling a count session.
+func (h *Handler) Cancel(c *ginext.Context) {
+	h.transition(c, h.service.Cancel, "failed to cancel count session")
+}
+
+// transition applies a step of the workflow to the count session named in the request path.
+func (h *Handler) transition(
+	c *ginext.Context,
+	apply func(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error),
+	msg string,
+) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, ok := getSessionID(c)
+	if !ok {
+		return
+	}
+
+	cs, err := apply(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		failCount(c, err, msg)
+		return
+	}
+
+	respond(c, http.StatusOK, cs)
+}
+
+// respond sends a count session with the given status, blind for viewers (see request.Blind).
+func respond(c *ginext.Context, status int, cs *model.CountSession) {
+	if request.Blind(c) {
+		cs.Blind()
+	}
+
+	if status == http.StatusCreated {
+		response.Created(c, cs)
+		return
+	}
+
+	response.OK(c, cs)
+}
+
+// failCount answers a failed count session request: 404 for unknown sessions, lines and stock
+// places, 409 for status changes and approvals that cannot go ahead and 400 for invalid counts.
+// Anything else is logged with msg and answered with 500.
+func failCount(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, repoitem.ErrCountNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrCountNotFound)
+	case errors.Is(err, repoitem.ErrCountLineNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrCountLineNotFound)
+	case errors.Is(err, servicecount.ErrInvalidTransition):
+		response.Fail(c, http.StatusConflict, servicecount.ErrInvalidTransition)
+	case errors.Is(err, repoitem.ErrCountStatusChange):
+		response.Fail(c, http.StatusConflict, repoitem.ErrCountStatusChange)
+	case errors.Is(err, servicecount.ErrUncountedLines):
+		response.Fail(c, http.StatusConflict, servicecount.ErrUncountedLines)
+	case errors.Is(err, repoitem.ErrInsufficientStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrInsufficientStock)
+	case errors.Is(err, repoitem.ErrNoExchangeRate):
+		response.Fail(c, http.StatusConflict, repoitem.ErrNoExchangeRate)
+	case errors.Is(err, servicecount.ErrNothingToCount):
+		response.Fail(c, http.StatusBadRequest, servicecount.ErrNothingToCount)
+	case errors.Is(err, servicecount.ErrNoEntries):
+		response.Fail(c, http.StatusBadRequest, servicecount.ErrNoEntries)
+	case errors.Is(err, servicecount.ErrInvalidQuantity):
+		response.Fail(c, http.StatusBadRequest, servicecount.ErrInvalidQuantity)
+	case errors.Is(err, servicecount.ErrDuplicateLine):
+		response.Fail(c, http.StatusBadRequest, servicecount.ErrDuplicateLine)
+	case errors.Is(err, repoitem.ErrSerializedCount):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrSerializedCount)
+	case errors.Is(err, repoitem.ErrNotABin):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrNotABin)
+	case errors.Is(err, repoitem.ErrReasonCodeRequired):
+		response.Fail(c, http.StatusBadRequest, err)
+	case errors.Is(err, repoitem.ErrUnknownReasonCode):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrUnknownReasonCode)
+	case errors.Is(err, repoitem.ErrLocationNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLocationNotFound)
+	case errors.Is(err, repoitem.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+	case errors.Is(err, repoitem.ErrWarehouseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
+
+// getSessionID parses the count session ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getSessionID(c *ginext.Context) (uuid.UUID, bool) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid count session ID"))
+		return uuid.Nil, false
+	}
+
+	return sessionID, true
+}
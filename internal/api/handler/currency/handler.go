@@ -0,0 +1,127 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/currency"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repocurrency "github.com/aliskhannn/warehouse-control/internal/repository/currency"
+	servicecurrency "github.com/aliskhannn/warehouse-control/internal/service/currency"
+)
+
+// service defines the interface for exchange rate service used by the handler.
+type service interface {
+	// Create adds an exchange rate of a currency other than the base currency.
+	Create(ctx context.Context, userID uuid.UUID, rate *model.ExchangeRate) error
+
+	// GetAll retrieves the exchange rates of a currency, or of all currencies if it is empty.
+	GetAll(ctx context.Context, code string) ([]*model.ExchangeRate, error)
+
+	// Delete removes an exchange rate.
+	Delete(ctx context.Context, rateID uuid.UUID) error
+}
+
+// Handler provides HTTP handlers for exchange rate endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new exchange rate handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for adding an exchange rate: what one unit of
+// the currency is worth in the base currency from EffectiveFrom, by default now, on.
+type CreateRequest struct {
+	Currency      string          `json:"currency" validate:"required,len=3"`
+	Rate          decimal.Decimal `json:"rate" validate:"required"`
+	EffectiveFrom *time.Time      `json:"effective_from"`
+}
+
+// Create handles adding an exchange rate.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	rate := &model.ExchangeRate{Currency: req.Currency, Rate: req.Rate}
+	if req.EffectiveFrom != nil {
+		rate.EffectiveFrom = req.EffectiveFrom.UTC()
+	}
+
+	if err := h.service.Create(c.Request.Context(), userID, rate); err != nil {
+		failRate(c, err, "failed to create exchange rate")
+		return
+	}
+
+	response.Created(c, rate)
+}
+
+// GetAll handles listing the exchange rates, of one ?currency if given.
+func (h *Handler) GetAll(c *ginext.Context) {
+	rates, err := h.service.GetAll(c.Request.Context(), c.Query("currency"))
+	if err != nil {
+		failRate(c, err, "failed to get exchange rates")
+		return
+	}
+
+	response.OK(c, rates)
+}
+
+// Delete handles removing an exchange rate.
+func (h *Handler) Delete(c *ginext.Context) {
+	rateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid exchange rate ID"))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), rateID); err != nil {
+		failRate(c, err, "failed to delete exchange rate")
+		return
+	}
+
+	response.OK(c, map[string]string{"id": rateID.String()})
+}
+
+// failRate answers a failed exchange rate request: 400 for invalid codes and rates and for the
+// base currency, 404 for unknown rates and 409 for a second rate of a currency at the same time.
+// Anything else is logged with msg and answered with 500.
+func failRate(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, currency.ErrInvalidCode),
+		errors.Is(err, servicecurrency.ErrInvalidRate),
+		errors.Is(err, servicecurrency.ErrBaseCurrency):
+		response.Fail(c, http.StatusBadRequest, err)
+	case errors.Is(err, repocurrency.ErrRateNotFound):
+		response.Fail(c, http.StatusNotFound, repocurrency.ErrRateNotFound)
+	case errors.Is(err, repocurrency.ErrRateExists):
+		response.Fail(c, http.StatusConflict, repocurrency.ErrRateExists)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -12,57 +15,189 @@ import (
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
 	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/currency"
+	"github.com/aliskhannn/warehouse-control/internal/gs1"
 	"github.com/aliskhannn/warehouse-control/internal/model"
 	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	serviceitem "github.com/aliskhannn/warehouse-control/internal/service/item"
+)
+
+var (
+	ErrIfMatchRequired = errors.New("If-Match header with the item's ETag is required")
+	ErrInvalidIfMatch  = errors.New("invalid If-Match header")
+
+	ErrOverrideExpiryDenied = errors.New("only admins can override lot expiry")
+	ErrCostSortDenied       = errors.New("sign in to sort items by cost price")
+	ErrItemCounted          = errors.New("item is being counted; its stock is hidden until the count is closed")
 )
 
 // service defines the interface for item service used by the handler.
 type service interface {
-	// Create adds a new item with the specified fields.
-	Create(ctx context.Context, userID uuid.UUID, name, description string, quantity int, price decimal.Decimal) (uuid.UUID, error)
+	// Create adds a new item and returns its ID.
+	Create(ctx context.Context, userID uuid.UUID, item *model.Item) (uuid.UUID, error)
 
 	// GetByID retrieves an item by its ID.
 	GetByID(ctx context.Context, itemID uuid.UUID) (*model.Item, error)
 
-	// GetAll retrieves all items, optionally filtered by name.
-	GetAll(ctx context.Context, nameFilter string) ([]*model.Item, error)
+	// GetBySKU retrieves an item by its SKU.
+	GetBySKU(ctx context.Context, sku string) (*model.Item, error)
+
+	// GetByBarcode retrieves an item by one of its barcodes.
+	GetByBarcode(ctx context.Context, code string) (*model.Item, error)
+
+	// GetAll retrieves one page of items matching the filter and the cursor of the next page.
+	GetAll(ctx context.Context, filter model.ItemFilter) ([]*model.Item, string, error)
+
+	// Update replaces the fields of an existing item if it is still at item.Version and returns its new version.
+	Update(ctx context.Context, userID uuid.UUID, item *model.Item) (int, error)
+
+	// Patch applies a partial update to an item if it is still at the given version and returns its new version.
+	Patch(ctx context.Context, userID, itemID uuid.UUID, version int, patch model.ItemPatch) (int, error)
+
+	// Delete removes an item by its ID if it is still at the given version.
+	Delete(ctx context.Context, userID, itemID uuid.UUID, version int) error
+
+	// Receive adds stock to an item at a place in the warehouses.
+	Receive(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error)
+
+	// Issue removes stock from an item in a warehouse.
+	Issue(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error)
+
+	// Adjust corrects an item's stock in a warehouse by a signed delta with an adjustment reason code.
+	Adjust(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, delta int, code, reason, reference string) (*model.StockMovement, error)
+
+	// Transfer moves stock of an item in a warehouse to or from another site by a signed delta.
+	Transfer(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, delta int, reason, reference string) (*model.StockMovement, error)
 
-	// Update modifies an existing item.
-	Update(ctx context.Context, userID, itemID uuid.UUID, name, description string, quantity int, price decimal.Decimal) error
+	// Increment raises an item's quantity in a warehouse relative to its current value.
+	Increment(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, amount int, code, reason string) (*model.StockMovement, error)
 
-	// Delete removes an item by its ID.
-	Delete(ctx context.Context, userID, itemID uuid.UUID) error
+	// Decrement lowers an item's quantity in a warehouse relative to its current value.
+	Decrement(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, amount int, code, reason string) (*model.StockMovement, error)
+
+	// GetMovements retrieves the stock movements of an item.
+	GetMovements(ctx context.Context, itemID uuid.UUID) ([]*model.StockMovement, error)
+
+	// GetStock retrieves the quantity of an item in each warehouse that holds it.
+	GetStock(ctx context.Context, itemID uuid.UUID) ([]*model.StockLevel, error)
+
+	// GetLocations retrieves the quantity of an item in each bin that holds it.
+	GetLocations(ctx context.Context, itemID uuid.UUID) ([]*model.LocationStock, error)
+
+	// GetPrices retrieves the prices of an item in effect at some time in [from, to), oldest
+	// first, converted into a currency unless it is empty.
+	GetPrices(ctx context.Context, itemID uuid.UUID, from, to *time.Time, code string) ([]*model.ItemPrice, error)
+
+	// ConvertPrices converts the prices of items into a currency at the exchange rates in effect now.
+	ConvertPrices(ctx context.Context, code string, items ...*model.Item) error
+}
+
+// counts tells which items are being counted; it is implemented by the count service.
+type counts interface {
+	// Counted reports which of the items have a line in a count session that is still open.
+	Counted(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error)
 }
 
 // Handler provides HTTP handlers for item endpoints.
 type Handler struct {
 	service   service
+	counts    counts
 	validator *validator.Validate
 }
 
-// NewHandler creates a new item handler.
-func NewHandler(s service, v *validator.Validate) *Handler {
+// NewHandler creates a new item handler and registers the gtin validation tag on v.
+func NewHandler(s service, n counts, v *validator.Validate) *Handler {
+	if err := v.RegisterValidation("gtin", validGTIN); err != nil {
+		panic(fmt.Sprintf("register gtin validation: %v", err))
+	}
+
 	return &Handler{
 		service:   s,
+		counts:    n,
 		validator: v,
 	}
 }
 
+// validGTIN validates a GTIN barcode with its check digit; the service trims it the same way
+// before storing it as a 14-digit GTIN.
+func validGTIN(fl validator.FieldLevel) bool {
+	return gs1.ValidGTIN(strings.TrimSpace(fl.Field().String()))
+}
+
 // CreateRequest represents the JSON request body for creating an item.
+// CostingMethod defaults to fifo and Currency, that of the prices, to the base currency.
 type CreateRequest struct {
-	Name        string          `json:"name" validate:"required"`
-	Description string          `json:"description"`
-	Quantity    int             `json:"quantity" validate:"required,min=0"`
-	Price       decimal.Decimal `json:"price" validate:"required"`
+	Name            string              `json:"name" validate:"required"`
+	SKU             string              `json:"sku"`
+	Barcodes        []string            `json:"barcodes" validate:"dive,gtin"`
+	Description     string              `json:"description"`
+	Quantity        int                 `json:"quantity" validate:"min=0"`
+	CostPrice       decimal.Decimal     `json:"cost_price" validate:"required"`
+	ListPrice       decimal.Decimal     `json:"list_price" validate:"required"`
+	Currency        string              `json:"currency" validate:"omitempty,len=3"`
+	CostingMethod   model.CostingMethod `json:"costing_method" validate:"omitempty,oneof=fifo lifo average"`
+	Serialized      bool                `json:"serialized"`
+	ReorderPoint    int                 `json:"reorder_point" validate:"min=0"`
+	ReorderQuantity int                 `json:"reorder_quantity" validate:"min=0"`
 }
 
 // UpdateRequest represents the JSON request body for updating an item.
+// ReasonCode is the adjustment reason code required when the quantity changes. The costing
+// method is left unchanged if it is empty, while an empty currency puts the prices in the base
+// currency. Only admins may change a price by more than the configured limit or change their
+// currency.
 type UpdateRequest struct {
-	Name        string          `json:"name" validate:"required"`
-	Description string          `json:"description"`
-	Quantity    int             `json:"quantity" validate:"required,min=0"`
-	Price       decimal.Decimal `json:"price" validate:"required"`
+	Name            string              `json:"name" validate:"required"`
+	SKU             string              `json:"sku"`
+	Barcodes        []string            `json:"barcodes" validate:"dive,gtin"`
+	Description     string              `json:"description"`
+	Quantity        int                 `json:"quantity" validate:"min=0"`
+	CostPrice       decimal.Decimal     `json:"cost_price" validate:"required"`
+	ListPrice       decimal.Decimal     `json:"list_price" validate:"required"`
+	Currency        string              `json:"currency" validate:"omitempty,len=3"`
+	CostingMethod   model.CostingMethod `json:"costing_method" validate:"omitempty,oneof=fifo lifo average"`
+	Serialized      bool                `json:"serialized"`
+	ReorderPoint    int                 `json:"reorder_point" validate:"min=0"`
+	ReorderQuantity int                 `json:"reorder_quantity" validate:"min=0"`
+	ReasonCode      string              `json:"reason_code" validate:"max=32"`
+}
+
+// MovementRequest represents the JSON request body for recording a stock movement.
+// Quantity is positive for receive and issue, and signed for adjust and transfer.
+// Without a warehouse ID the movement applies to the default warehouse;
+// Location optionally names a bin of that warehouse by its code and Lot a lot of the item
+// by its number. OverrideExpiry lets admins issue from an expired lot. Movements of serialized
+// items list the serial of every unit moved. Adjustments require an adjustment reason code.
+// UnitCost is what each unit added cost, by default the item's current unit cost.
+type MovementRequest struct {
+	Type           model.MovementType `json:"type" validate:"required,oneof=receive issue adjust transfer"`
+	WarehouseID    uuid.UUID          `json:"warehouse_id"`
+	Location       string             `json:"location"`
+	Lot            string             `json:"lot"`
+	Serials        []string           `json:"serials" validate:"dive,required,max=64"`
+	Quantity       int                `json:"quantity" validate:"required"`
+	Reason         string             `json:"reason" validate:"required"`
+	ReasonCode     string             `json:"reason_code" validate:"max=32"`
+	Reference      string             `json:"reference"`
+	OverrideExpiry bool               `json:"override_expiry"`
+	UnitCost       *decimal.Decimal   `json:"unit_cost"`
+}
+
+// StockChangeRequest represents the JSON request body for incrementing or decrementing stock.
+// Without a warehouse ID the change applies to the default warehouse;
+// Location optionally names a bin of that warehouse by its code and Lot a lot of the item.
+// Changes of serialized items list the serial of every unit. ReasonCode is the required
+// adjustment reason code.
+type StockChangeRequest struct {
+	WarehouseID uuid.UUID `json:"warehouse_id"`
+	Location    string    `json:"location"`
+	Lot         string    `json:"lot"`
+	Serials     []string  `json:"serials" validate:"dive,required,max=64"`
+	Amount      int       `json:"amount" validate:"required,min=1"`
+	ReasonCode  string    `json:"reason_code" validate:"required,max=32"`
+	Reason      string    `json:"reason"`
 }
 
 // Create handles creating a new item.
@@ -92,8 +227,27 @@ func (h *Handler) Create(c *ginext.Context) {
 		return
 	}
 
-	id, err := h.service.Create(c.Request.Context(), userID, req.Name, req.Description, req.Quantity, req.Price)
+	item := &model.Item{
+		Name:            req.Name,
+		SKU:             req.SKU,
+		Barcodes:        req.Barcodes,
+		Description:     req.Description,
+		Quantity:        req.Quantity,
+		CostPrice:       req.CostPrice,
+		ListPrice:       req.ListPrice,
+		Currency:        req.Currency,
+		CostingMethod:   req.CostingMethod,
+		Serialized:      req.Serialized,
+		ReorderPoint:    req.ReorderPoint,
+		ReorderQuantity: req.ReorderQuantity,
+	}
+
+	id, err := h.service.Create(c.Request.Context(), userID, item)
 	if err != nil {
+		if h.failItemWrite(c, err) {
+			return
+		}
+
 		zlog.Logger.Error().Err(err).Msg("failed to create item")
 		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to create item"))
 		return
@@ -122,21 +276,124 @@ func (h *Handler) Update(c *ginext.Context) {
 		return
 	}
 
-	if err := h.service.Update(c.Request.Context(), userID, itemID, req.Name, req.Description, req.Quantity, req.Price); err != nil {
+	version, ok := h.getIfMatchVersion(c, itemID)
+	if !ok {
+		return
+	}
+
+	item := &model.Item{
+		ID:              itemID,
+		Name:            req.Name,
+		SKU:             req.SKU,
+		Barcodes:        req.Barcodes,
+		Description:     req.Description,
+		Quantity:        req.Quantity,
+		CostPrice:       req.CostPrice,
+		ListPrice:       req.ListPrice,
+		Currency:        req.Currency,
+		CostingMethod:   req.CostingMethod,
+		Serialized:      req.Serialized,
+		ReorderPoint:    req.ReorderPoint,
+		ReorderQuantity: req.ReorderQuantity,
+		Version:         version,
+		ReasonCode:      req.ReasonCode,
+	}
+
+	newVersion, err := h.service.Update(priceChangeContext(c), userID, item)
+	if err != nil {
+		if h.failItemWrite(c, err) {
+			return
+		}
+
 		if errors.Is(err, repoitem.ErrItemNotFound) {
 			zlog.Logger.Error().Err(err).Msg("failed to update item")
 			response.Fail(c, http.StatusNotFound, err)
 			return
 		}
 
+		if errors.Is(err, repoitem.ErrVersionConflict) {
+			zlog.Logger.Error().Err(err).Msg("failed to update item")
+			response.Fail(c, http.StatusPreconditionFailed, repoitem.ErrVersionConflict)
+			return
+		}
+
 		zlog.Logger.Error().Err(err).Msg("failed to update item")
 		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to update item"))
 		return
 	}
 
+	c.Header("ETag", etag(newVersion))
 	response.OK(c, map[string]string{"id": itemID.String()})
 }
 
+// Patch handles a partial update of an item with a JSON Merge Patch (RFC 7396).
+func (h *Handler) Patch(c *ginext.Context) {
+	if c.ContentType() != MergePatchContentType {
+		response.Fail(c, http.StatusUnsupportedMediaType, fmt.Errorf("content type must be %s", MergePatchContentType))
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to read body")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	patch, err := decodeMergePatch(body)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, itemID, ok := h.getUserAndItemIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	version, ok := h.getIfMatchVersion(c, itemID)
+	if !ok {
+		return
+	}
+
+	newVersion, err := h.service.Patch(priceChangeContext(c), userID, itemID, version, patch)
+	if err != nil {
+		if h.failItemWrite(c, err) {
+			return
+		}
+
+		if errors.Is(err, repoitem.ErrItemNotFound) {
+			zlog.Logger.Error().Err(err).Msg("failed to patch item")
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+			return
+		}
+
+		if errors.Is(err, repoitem.ErrVersionConflict) {
+			zlog.Logger.Error().Err(err).Msg("failed to patch item")
+			response.Fail(c, http.StatusPreconditionFailed, repoitem.ErrVersionConflict)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to patch item")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to patch item"))
+		return
+	}
+
+	c.Header("ETag", etag(newVersion))
+	response.OK(c, map[string]string{"id": itemID.String()})
+}
+
+// priceChangeContext returns the request context, in which admins may change item prices by
+// more than the configured limit.
+func priceChangeContext(c *ginext.Context) context.Context {
+	ctx := c.Request.Context()
+	if c.GetString("role") == "admin" {
+		ctx = serviceitem.WithLargePriceChanges(ctx)
+	}
+
+	return ctx
+}
+
 // Delete handles deleting an item.
 func (h *Handler) Delete(c *ginext.Context) {
 	userID, itemID, ok := h.getUserAndItemIDFromContext(c)
@@ -144,13 +401,29 @@ func (h *Handler) Delete(c *ginext.Context) {
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), userID, itemID); err != nil {
+	version, ok := h.getIfMatchVersion(c, itemID)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, itemID, version); err != nil {
 		if errors.Is(err, repoitem.ErrItemNotFound) {
 			zlog.Logger.Error().Err(err).Msg("failed to delete item")
 			response.Fail(c, http.StatusNotFound, err)
 			return
 		}
 
+		if errors.Is(err, repoitem.ErrVersionConflict) {
+			zlog.Logger.Error().Err(err).Msg("failed to delete item")
+			response.Fail(c, http.StatusPreconditionFailed, repoitem.ErrVersionConflict)
+			return
+		}
+
+		if errors.Is(err, repoitem.ErrItemReferenced) {
+			response.Fail(c, http.StatusConflict, repoitem.ErrItemReferenced)
+			return
+		}
+
 		zlog.Logger.Error().Err(err).Msg("failed to delete item")
 		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to delete item"))
 		return
@@ -159,7 +432,194 @@ func (h *Handler) Delete(c *ginext.Context) {
 	response.OK(c, map[string]string{"id": itemID.String()})
 }
 
+// CreateMovement handles recording a stock movement for an item.
+func (h *Handler) CreateMovement(c *ginext.Context) {
+	var req MovementRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	userID, itemID, ok := h.getUserAndItemIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	place := model.StockPlace{
+		WarehouseID: req.WarehouseID, Location: req.Location, Lot: req.Lot, Serials: req.Serials, UnitCost: req.UnitCost,
+	}
+
+	if req.OverrideExpiry {
+		if c.GetString("role") != "admin" {
+			response.Fail(c, http.StatusForbidden, ErrOverrideExpiryDenied)
+			return
+		}
+
+		zlog.Logger.Warn().Str("itemID", itemID.String()).Str("lot", req.Lot).Msg("lot expiry overridden by admin")
+		ctx = serviceitem.WithExpiryOverride(ctx)
+	}
+
+	var (
+		movement *model.StockMovement
+		err      error
+	)
+
+	switch req.Type {
+	case model.MovementReceive:
+		movement, err = h.service.Receive(ctx, userID, itemID, place, req.Quantity, req.Reason, req.Reference)
+	case model.MovementIssue:
+		movement, err = h.service.Issue(ctx, userID, itemID, place, req.Quantity, req.Reason, req.Reference)
+	case model.MovementAdjust:
+		movement, err = h.service.Adjust(ctx, userID, itemID, place, req.Quantity, req.ReasonCode, req.Reason, req.Reference)
+	case model.MovementTransfer:
+		movement, err = h.service.Transfer(ctx, userID, itemID, place, req.Quantity, req.Reason, req.Reference)
+	}
+
+	if err != nil {
+		h.failMovement(c, err)
+		return
+	}
+
+	response.Created(c, movement)
+}
+
+// Increment handles raising an item's quantity by a relative amount.
+func (h *Handler) Increment(c *ginext.Context) {
+	h.changeStock(c, h.service.Increment)
+}
+
+// Decrement handles lowering an item's quantity by a relative amount.
+// Responds with 409 Conflict if stock would become negative.
+func (h *Handler) Decrement(c *ginext.Context) {
+	h.changeStock(c, h.service.Decrement)
+}
+
+// changeStock binds a StockChangeRequest and applies it with the given service method.
+func (h *Handler) changeStock(
+	c *ginext.Context,
+	apply func(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, amount int, code, reason string) (*model.StockMovement, error),
+) {
+	var req StockChangeRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	userID, itemID, ok := h.getUserAndItemIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	place := model.StockPlace{WarehouseID: req.WarehouseID, Location: req.Location, Lot: req.Lot, Serials: req.Serials}
+
+	movement, err := apply(c.Request.Context(), userID, itemID, place, req.Amount, req.ReasonCode, req.Reason)
+	if err != nil {
+		h.failMovement(c, err)
+		return
+	}
+
+	response.OK(c, movement)
+}
+
+// GetMovements handles retrieving the stock movements of an item.
+// Responds with 403 Forbidden to users who count blind while the item is being counted, as the
+// movements carry its balances.
+func (h *Handler) GetMovements(c *ginext.Context) {
+	itemIDStr := c.Param("id")
+	itemID, err := uuid.Parse(itemIDStr)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid item ID"))
+		return
+	}
+
+	if !h.checkNotCounted(c, itemID) {
+		return
+	}
+
+	movements, err := h.service.GetMovements(c.Request.Context(), itemID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Str("itemID", itemIDStr).Msg("failed to get movements")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get movements"))
+		return
+	}
+
+	response.OK(c, movements)
+}
+
+// GetStock handles retrieving an item's quantity per warehouse.
+// Responds with 403 Forbidden to users who count blind while the item is being counted.
+func (h *Handler) GetStock(c *ginext.Context) {
+	itemIDStr := c.Param("id")
+	itemID, err := uuid.Parse(itemIDStr)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid item ID"))
+		return
+	}
+
+	if !h.checkNotCounted(c, itemID) {
+		return
+	}
+
+	levels, err := h.service.GetStock(c.Request.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, repoitem.ErrItemNotFound) {
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Str("itemID", itemIDStr).Msg("failed to get item stock")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get item stock"))
+		return
+	}
+
+	response.OK(c, levels)
+}
+
+// GetPrices handles retrieving the price history of an item.
+// ?from= and ?to= (RFC 3339) limit it to the prices in effect at some time in that range, and
+// ?currency= converts them at the exchange rates in effect when they took effect.
+func (h *Handler) GetPrices(c *ginext.Context) {
+	itemIDStr := c.Param("id")
+	itemID, err := uuid.Parse(itemIDStr)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid item ID"))
+		return
+	}
+
+	from, err := request.QueryTime(c, "from")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	to, err := request.QueryTime(c, "to")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	prices, err := h.service.GetPrices(c.Request.Context(), itemID, from, to, c.Query("currency"))
+	if err != nil {
+		switch {
+		case errors.Is(err, serviceitem.ErrInvalidPriceRange):
+			response.Fail(c, http.StatusBadRequest, serviceitem.ErrInvalidPriceRange)
+		case errors.Is(err, currency.ErrInvalidCode), errors.Is(err, currency.ErrRateNotFound):
+			response.Fail(c, http.StatusBadRequest, err)
+		case errors.Is(err, repoitem.ErrItemNotFound):
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+		default:
+			zlog.Logger.Error().Err(err).Str("itemID", itemIDStr).Msg("failed to get item prices")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get item prices"))
+		}
+
+		return
+	}
+
+	response.OK(c, prices)
+}
+
 // GetByID handles retrieving an item by ID.
+// ?include=locations adds the bins holding stock of the item, and ?currency= converts its prices
+// at the exchange rates in effect now; the same holds for the other item lookups.
 func (h *Handler) GetByID(c *ginext.Context) {
 	itemIDStr := c.Param("id")
 	itemID, err := uuid.Parse(itemIDStr)
@@ -181,21 +641,350 @@ func (h *Handler) GetByID(c *ginext.Context) {
 		return
 	}
 
-	response.OK(c, item)
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+		case "locations":
+			item.Locations, err = h.service.GetLocations(c.Request.Context(), itemID)
+			if err != nil {
+				zlog.Logger.Error().Err(err).Str("itemID", itemIDStr).Msg("failed to get item locations")
+				response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get item"))
+				return
+			}
+		default:
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid include: %s", include))
+			return
+		}
+	}
+
+	if !h.convertPrices(c, item) {
+		return
+	}
+
+	counted, ok := request.Counted(c, h.counts.Counted, item.ID)
+	if !ok {
+		return
+	}
+
+	c.Header("ETag", etag(item.Version))
+	response.OK(c, shownItem(c, item, counted))
 }
 
-// GetAll handles retrieving all items, optionally filtered by name query param.
+// GetBySKU handles retrieving an item by its SKU.
+func (h *Handler) GetBySKU(c *ginext.Context) {
+	sku := c.Param("sku")
+
+	item, err := h.service.GetBySKU(c.Request.Context(), sku)
+	if err != nil {
+		if errors.Is(err, repoitem.ErrItemNotFound) {
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Str("sku", sku).Msg("failed to get item by sku")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get item"))
+		return
+	}
+
+	if !h.convertPrices(c, item) {
+		return
+	}
+
+	counted, ok := request.Counted(c, h.counts.Counted, item.ID)
+	if !ok {
+		return
+	}
+
+	c.Header("ETag", etag(item.Version))
+	response.OK(c, shownItem(c, item, counted))
+}
+
+// GetByBarcode handles retrieving an item by one of its barcodes (GTIN-8, -12, -13 or -14).
+func (h *Handler) GetByBarcode(c *ginext.Context) {
+	code := c.Param("code")
+
+	item, err := h.service.GetByBarcode(c.Request.Context(), code)
+	if err != nil {
+		if errors.Is(err, serviceitem.ErrInvalidBarcode) {
+			response.Fail(c, http.StatusBadRequest, serviceitem.ErrInvalidBarcode)
+			return
+		}
+
+		if errors.Is(err, repoitem.ErrItemNotFound) {
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Str("barcode", code).Msg("failed to get item by barcode")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get item"))
+		return
+	}
+
+	if !h.convertPrices(c, item) {
+		return
+	}
+
+	counted, ok := request.Counted(c, h.counts.Counted, item.ID)
+	if !ok {
+		return
+	}
+
+	c.Header("ETag", etag(item.Version))
+	response.OK(c, shownItem(c, item, counted))
+}
+
+// GetAll handles retrieving a page of items.
+//
+// Query parameters:
+//   - name, description: substring filters (case-insensitive).
+//   - min_quantity, max_quantity, min_price, max_price: inclusive ranges; prices are list prices.
+//   - updated_since: RFC 3339 time.
+//   - sort: name, quantity, cost_price, list_price, created_at or updated_at; prefix with "-" for descending order.
+//     Sorting by cost_price requires signing in, as the order and the cursors reveal the cost prices.
+//   - currency: converts the prices at the exchange rates in effect now; filters and sorting use them unconverted.
+//   - limit: page size; cursor: next_cursor of the previous page.
 func (h *Handler) GetAll(c *ginext.Context) {
-	nameFilter := c.Query("name")
+	filter, err := parseItemFilter(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
 
-	items, err := h.service.GetAll(c.Request.Context(), nameFilter)
+	if filter.Sort == "cost_price" && !request.Authenticated(c) {
+		response.Fail(c, http.StatusUnauthorized, ErrCostSortDenied)
+		return
+	}
+
+	items, nextCursor, err := h.service.GetAll(c.Request.Context(), filter)
 	if err != nil {
+		if errors.Is(err, repoitem.ErrInvalidSort) || errors.Is(err, repoitem.ErrInvalidCursor) {
+			response.Fail(c, http.StatusBadRequest, err)
+			return
+		}
+
 		zlog.Logger.Error().Err(err).Msg("failed to get all items")
 		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get items"))
 		return
 	}
 
-	response.OK(c, items)
+	if !h.convertPrices(c, items...) {
+		return
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+
+	counted, ok := request.Counted(c, h.counts.Counted, itemIDs...)
+	if !ok {
+		return
+	}
+
+	response.Page(c, shownItems(c, items, counted), nextCursor)
+}
+
+// hiddenItem is an item with fields the user may not see left out: its CostPrice and Quantity,
+// nil unless the user may see them, hide those of the embedded item.
+type hiddenItem struct {
+	*model.Item
+	CostPrice *decimal.Decimal `json:"cost_price,omitempty"`
+	Quantity  *int             `json:"quantity,omitempty"`
+}
+
+// shownItem returns item as the user may see it: without its cost price unless signed in, and
+// without its quantities while it is among counted, the items being counted for a user who
+// counts blind (see request.Counted).
+func shownItem(c *ginext.Context, item *model.Item, counted map[uuid.UUID]bool) interface{} {
+	if request.Authenticated(c) && !counted[item.ID] {
+		return item
+	}
+
+	hidden := hiddenItem{Item: item}
+	if request.Authenticated(c) {
+		hidden.CostPrice = &item.CostPrice
+	}
+
+	if counted[item.ID] {
+		blind := *item
+		blind.Reserved, blind.AvailableToPromise, blind.Locations = nil, nil, nil
+		hidden.Item = &blind
+	} else {
+		hidden.Quantity = &item.Quantity
+	}
+
+	return hidden
+}
+
+// shownItems returns items as the user may see them, like shownItem.
+func shownItems(c *ginext.Context, items []*model.Item, counted map[uuid.UUID]bool) interface{} {
+	if (request.Authenticated(c) && len(counted) == 0) || items == nil {
+		return items
+	}
+
+	shown := make([]interface{}, len(items))
+	for i, item := range items {
+		shown[i] = shownItem(c, item, counted)
+	}
+
+	return shown
+}
+
+// checkNotCounted checks that itemID is not being counted, unless the user may see the
+// quantities of items that are.
+// Returns false and automatically sends a response if it is.
+func (h *Handler) checkNotCounted(c *ginext.Context, itemID uuid.UUID) bool {
+	counted, ok := request.Counted(c, h.counts.Counted, itemID)
+	if !ok {
+		return false
+	}
+
+	if counted[itemID] {
+		response.Fail(c, http.StatusForbidden, ErrItemCounted)
+		return false
+	}
+
+	return true
+}
+
+// convertPrices converts the prices of items into ?currency, if given.
+// Returns false and automatically sends a response if they cannot be converted.
+func (h *Handler) convertPrices(c *ginext.Context, items ...*model.Item) bool {
+	err := h.service.ConvertPrices(c.Request.Context(), c.Query("currency"), items...)
+	if err != nil {
+		if errors.Is(err, currency.ErrInvalidCode) || errors.Is(err, currency.ErrRateNotFound) {
+			response.Fail(c, http.StatusBadRequest, err)
+			return false
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to convert item prices")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to convert item prices"))
+		return false
+	}
+
+	return true
+}
+
+// parseItemFilter reads the item list query parameters.
+func parseItemFilter(c *ginext.Context) (model.ItemFilter, error) {
+	filter := model.ItemFilter{
+		Name:        c.Query("name"),
+		Description: c.Query("description"),
+		Cursor:      c.Query("cursor"),
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		filter.Sort = strings.TrimPrefix(sort, "-")
+		filter.Desc = strings.HasPrefix(sort, "-")
+	}
+
+	var err error
+
+	if filter.MinQuantity, err = request.QueryInt(c, "min_quantity"); err != nil {
+		return filter, err
+	}
+	if filter.MaxQuantity, err = request.QueryInt(c, "max_quantity"); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice, err = request.QueryDecimal(c, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = request.QueryDecimal(c, "max_price"); err != nil {
+		return filter, err
+	}
+	if filter.UpdatedSince, err = request.QueryTime(c, "updated_since"); err != nil {
+		return filter, err
+	}
+
+	if filter.Limit, err = request.QueryIntOr(c, "limit", 0); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// failItemWrite responds to a failed create, update or patch: with 400 Bad Request to an
+// invalid barcode and with 409 Conflict to a SKU or barcode already used by another item,
+// to a quantity edit the default warehouse does not hold enough stock for or a quantity edit
+// of a serialized item, or to a change of the serialized flag of an item with stock, and
+// with 403 Forbidden to a price change beyond the limit allowed without the admin role.
+// Prices in a currency without an exchange rate in effect are a bad request.
+// Returns false if err is none of these.
+func (h *Handler) failItemWrite(c *ginext.Context, err error) bool {
+	switch {
+	case errors.Is(err, serviceitem.ErrInvalidBarcode), errors.Is(err, serviceitem.ErrInvalidCostingMethod),
+		errors.Is(err, currency.ErrInvalidCode), errors.Is(err, currency.ErrRateNotFound):
+		response.Fail(c, http.StatusBadRequest, err)
+	case errors.Is(err, repoitem.ErrSKUTaken):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSKUTaken)
+	case errors.Is(err, repoitem.ErrBarcodeTaken):
+		response.Fail(c, http.StatusConflict, repoitem.ErrBarcodeTaken)
+	case errors.Is(err, repoitem.ErrInsufficientStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrInsufficientStock)
+	case errors.Is(err, repoitem.ErrSerialsRequired):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialsRequired)
+	case errors.Is(err, repoitem.ErrSerializedChange):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerializedChange)
+	case errors.Is(err, repoitem.ErrCostingMethodChange):
+		response.Fail(c, http.StatusConflict, repoitem.ErrCostingMethodChange)
+	case errors.Is(err, repoitem.ErrReasonCodeRequired):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrReasonCodeRequired)
+	case errors.Is(err, repoitem.ErrUnknownReasonCode):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrUnknownReasonCode)
+	case errors.Is(err, serviceitem.ErrPriceChangeDenied):
+		response.Fail(c, http.StatusForbidden, err)
+	case errors.Is(err, repoitem.ErrNoExchangeRate):
+		response.Fail(c, http.StatusConflict, repoitem.ErrNoExchangeRate)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// failMovement maps an error returned by a stock movement to an HTTP response.
+func (h *Handler) failMovement(c *ginext.Context, err error) {
+	switch {
+	case errors.Is(err, serviceitem.ErrInvalidQuantity),
+		errors.Is(err, serviceitem.ErrZeroAdjustment),
+		errors.Is(err, serviceitem.ErrReferenceRequired),
+		errors.Is(err, serviceitem.ErrInvalidUnitCost):
+		response.Fail(c, http.StatusBadRequest, err)
+	case errors.Is(err, repoitem.ErrReasonCodeRequired):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrReasonCodeRequired)
+	case errors.Is(err, repoitem.ErrUnknownReasonCode):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrUnknownReasonCode)
+	case errors.Is(err, repoitem.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+	case errors.Is(err, repoitem.ErrWarehouseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+	case errors.Is(err, repoitem.ErrLocationNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLocationNotFound)
+	case errors.Is(err, repoitem.ErrNotABin):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrNotABin)
+	case errors.Is(err, repoitem.ErrLotNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLotNotFound)
+	case errors.Is(err, repoitem.ErrLotExpired):
+		response.Fail(c, http.StatusConflict, repoitem.ErrLotExpired)
+	case errors.Is(err, repoitem.ErrInsufficientStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrInsufficientStock)
+	case errors.Is(err, repoitem.ErrNoExchangeRate):
+		response.Fail(c, http.StatusConflict, repoitem.ErrNoExchangeRate)
+	case errors.Is(err, serviceitem.ErrDuplicateSerial),
+		errors.Is(err, repoitem.ErrSerialsRequired),
+		errors.Is(err, repoitem.ErrNotSerialized):
+		response.Fail(c, http.StatusBadRequest, err)
+	case errors.Is(err, repoitem.ErrSerialNotFound):
+		response.Fail(c, http.StatusNotFound, err)
+	case errors.Is(err, repoitem.ErrSerialTaken),
+		errors.Is(err, repoitem.ErrSerialInStock),
+		errors.Is(err, repoitem.ErrSerialNotInStock),
+		errors.Is(err, repoitem.ErrSerialScrapped):
+		response.Fail(c, http.StatusConflict, err)
+	default:
+		zlog.Logger.Error().Err(err).Msg("failed to create movement")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to create movement"))
+	}
 }
 
 // getUserAndItemIDFromContext retrieves the userID from the context and the itemID from the request parameters.
@@ -222,3 +1011,104 @@ func (h *Handler) getUserAndItemIDFromContext(c *ginext.Context) (uuid.UUID, uui
 
 	return userID, itemID, true
 }
+
+// getIfMatchVersion reads the item version a write must find from the If-Match header.
+// A single tag is that version. "*" and lists of tags are resolved against the item's current
+// version, which "*" always matches and a list must contain; weak tags and tags that are not versions
+// match nothing.
+// Returns false and automatically sends a response if the header is missing, malformed or not matched.
+func (h *Handler) getIfMatchVersion(c *ginext.Context, itemID uuid.UUID) (int, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		response.Fail(c, http.StatusPreconditionRequired, ErrIfMatchRequired)
+		return 0, false
+	}
+
+	var tags []string
+	if ifMatch != "*" {
+		var err error
+		if tags, err = parseETags(ifMatch); err != nil {
+			response.Fail(c, http.StatusBadRequest, err)
+			return 0, false
+		}
+
+		if len(tags) == 1 {
+			version, err := strconv.Atoi(tags[0])
+			if err != nil {
+				response.Fail(c, http.StatusPreconditionFailed, repoitem.ErrVersionConflict)
+				return 0, false
+			}
+
+			return version, true
+		}
+	}
+
+	item, err := h.service.GetByID(c.Request.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, repoitem.ErrItemNotFound) {
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+			return 0, false
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to get item")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get item"))
+		return 0, false
+	}
+
+	if tags == nil {
+		return item.Version, true
+	}
+
+	current := strconv.Itoa(item.Version)
+	for _, tag := range tags {
+		if tag == current {
+			return item.Version, true
+		}
+	}
+
+	response.Fail(c, http.StatusPreconditionFailed, repoitem.ErrVersionConflict)
+	return 0, false
+}
+
+// parseETags returns the opaque tags of a comma-separated list of entity tags.
+// Weak tags keep their W/ prefix: If-Match uses the strong comparison (RFC 7232, section 3.1),
+// so they never equal a version.
+func parseETags(header string) ([]string, error) {
+	var tags []string
+
+	for rest := header; ; {
+		rest = strings.TrimLeft(rest, " \t")
+
+		weak := ""
+		if strings.HasPrefix(rest, "W/") {
+			weak, rest = "W/", rest[2:]
+		}
+
+		if !strings.HasPrefix(rest, `"`) {
+			return nil, ErrInvalidIfMatch
+		}
+
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return nil, ErrInvalidIfMatch
+		}
+
+		tags = append(tags, weak+rest[1:end+1])
+
+		rest = strings.TrimLeft(rest[end+2:], " \t")
+		if rest == "" {
+			return tags, nil
+		}
+
+		if rest[0] != ',' {
+			return nil, ErrInvalidIfMatch
+		}
+
+		rest = rest[1:]
+	}
+}
+
+// etag formats an item version as an HTTP entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
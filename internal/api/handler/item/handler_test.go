@@ -0,0 +1,201 @@
+package item
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+func TestAnonymousUsersAreNotShownCostPrices(t *testing.T) {
+	item := &model.Item{Name: "bolt", CostPrice: decimal.RequireFromString("1.25"), ListPrice: decimal.RequireFromString("2.50")}
+
+	for _, signedIn := range []bool{false, true} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if signedIn {
+			c.Set("userID", uuid.New())
+		}
+
+		for _, shown := range []interface{}{shownItem(c, item, nil), shownItems(c, []*model.Item{item}, nil)} {
+			data, err := json.Marshal(shown)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			if got := strings.Contains(string(data), `"cost_price"`); got != signedIn {
+				t.Errorf("signed in %t: %s, want cost price shown %t", signedIn, data, signedIn)
+			}
+
+			if !strings.Contains(string(data), `"list_price":"2.5"`) {
+				t.Errorf("signed in %t: %s, want the list price", signedIn, data)
+			}
+		}
+	}
+}
+
+// fakeService serves one item; the methods it does not override are left to the nil service.
+type fakeService struct {
+	service
+	item *model.Item
+}
+
+func (s *fakeService) GetByID(context.Context, uuid.UUID) (*model.Item, error) {
+	item := *s.item
+	return &item, nil
+}
+
+func (s *fakeService) GetStock(context.Context, uuid.UUID) ([]*model.StockLevel, error) {
+	return []*model.StockLevel{{ItemID: s.item.ID, Quantity: s.item.Quantity}}, nil
+}
+
+func (s *fakeService) ConvertPrices(context.Context, string, ...*model.Item) error {
+	return nil
+}
+
+// fakeCounts reports the items in it as being counted.
+type fakeCounts map[uuid.UUID]bool
+
+func (f fakeCounts) Counted(_ context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	counted := map[uuid.UUID]bool{}
+	for _, id := range itemIDs {
+		counted[id] = f[id]
+	}
+
+	return counted, nil
+}
+
+func TestViewersAreNotShownQuantitiesOfCountedItems(t *testing.T) {
+	item := &model.Item{ID: uuid.New(), Name: "bolt", Quantity: 7, ListPrice: decimal.NewFromInt(2)}
+	reserved := 2
+	item.Reserved = &reserved
+
+	tests := []struct {
+		role    string
+		counted bool
+		shown   bool
+	}{
+		{"viewer", true, false},
+		{"", true, false},
+		{"viewer", false, true},
+		{"manager", true, true},
+		{"admin", true, true},
+	}
+
+	for _, tt := range tests {
+		h := NewHandler(&fakeService{item: item}, fakeCounts{item.ID: tt.counted}, validator.New())
+
+		get := func(handle func(*gin.Context), path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+			c.Params = gin.Params{{Key: "id", Value: item.ID.String()}}
+			if tt.role != "" {
+				c.Set("userID", uuid.New())
+				c.Set("role", tt.role)
+			}
+
+			handle(c)
+			return w
+		}
+
+		w := get(h.GetByID, "/api/items/"+item.ID.String())
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q counted %t: item status %d, want %d", tt.role, tt.counted, w.Code, http.StatusOK)
+		}
+
+		body := w.Body.String()
+		if got := strings.Contains(body, `"quantity":7`) && strings.Contains(body, `"reserved":2`); got != tt.shown {
+			t.Errorf("%q counted %t: item %s, want quantities shown %t", tt.role, tt.counted, body, tt.shown)
+		}
+
+		if !strings.Contains(body, `"name":"bolt"`) {
+			t.Errorf("%q counted %t: item %s, want the rest of the item", tt.role, tt.counted, body)
+		}
+
+		wantStatus := http.StatusOK
+		if !tt.shown {
+			wantStatus = http.StatusForbidden
+		}
+
+		if w := get(h.GetStock, "/api/items/"+item.ID.String()+"/stock"); w.Code != wantStatus {
+			t.Errorf("%q counted %t: stock status %d, want %d", tt.role, tt.counted, w.Code, wantStatus)
+		}
+	}
+}
+
+func TestRequestsValidateBarcodes(t *testing.T) {
+	h := NewHandler(nil, nil, validator.New())
+
+	tests := []struct {
+		barcodes []string
+		valid    bool
+	}{
+		{nil, true},
+		{[]string{"4006381333931", " 036000291452 "}, true},
+		{[]string{"4006381333932"}, false},
+		{[]string{"4006381333931", "ABC"}, false},
+	}
+
+	for _, tt := range tests {
+		create := CreateRequest{Name: "bolt", Barcodes: tt.barcodes, CostPrice: decimal.NewFromInt(1), ListPrice: decimal.NewFromInt(1)}
+		update := UpdateRequest{Name: "bolt", Barcodes: tt.barcodes, CostPrice: decimal.NewFromInt(1), ListPrice: decimal.NewFromInt(1)}
+
+		for _, req := range []interface{}{create, update} {
+			if err := h.validator.Struct(req); (err == nil) != tt.valid {
+				t.Errorf("%T with barcodes %q: error %v, want valid %t", req, tt.barcodes, err, tt.valid)
+			}
+		}
+	}
+}
+
+func TestParseETags(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    []string
+		wantErr bool
+	}{
+		{header: `"3"`, want: []string{"3"}},
+		{header: `W/"3"`, want: []string{"W/3"}},
+		{header: `"3", W/"4" ,"5"`, want: []string{"3", "W/4", "5"}},
+		{header: `"a,b", "4"`, want: []string{"a,b", "4"}},
+		{header: `3`, wantErr: true},
+		{header: `"3`, wantErr: true},
+		{header: `"3" "4"`, wantErr: true},
+		{header: `"3",`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseETags(tt.header)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseETags(%q) error = %v, want error %v", tt.header, err, tt.wantErr)
+			continue
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseETags(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestWeakETagsDoNotMatch(t *testing.T) {
+	h := NewHandler(nil, nil, validator.New())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/items/"+uuid.NewString(), nil)
+	c.Request.Header.Set("If-Match", `W/"3"`)
+
+	if _, ok := h.getIfMatchVersion(c, uuid.New()); ok || w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("matched %t with status %d, want no match with %d", ok, w.Code, http.StatusPreconditionFailed)
+	}
+}
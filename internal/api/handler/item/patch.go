@@ -0,0 +1,147 @@
+package item
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// MergePatchContentType is the media type of a JSON Merge Patch (RFC 7396).
+const MergePatchContentType = "application/merge-patch+json"
+
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// decodeMergePatch decodes a JSON Merge Patch document into an item patch.
+// Members that are absent are left unchanged; null clears the SKU, the barcodes
+// and the description, puts the prices in the base currency and resets the reorder point and
+// quantity to 0. Name, quantity, the
+// prices, costing_method and serialized cannot be removed. reason_code is not a field of the item but the adjustment
+// reason code a change of quantity is made with.
+func decodeMergePatch(body []byte) (model.ItemPatch, error) {
+	var patch model.ItemPatch
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil || doc == nil {
+		return patch, fmt.Errorf("%w: body must be a JSON object", ErrInvalidPatch)
+	}
+
+	for key, raw := range doc {
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+		switch key {
+		case "name":
+			if isNull {
+				return patch, fmt.Errorf("%w: name cannot be removed", ErrInvalidPatch)
+			}
+
+			var name string
+			if err := json.Unmarshal(raw, &name); err != nil || name == "" {
+				return patch, fmt.Errorf("%w: name must be a non-empty string", ErrInvalidPatch)
+			}
+
+			patch.Name = &name
+		case "sku":
+			var sku string
+			if !isNull {
+				if err := json.Unmarshal(raw, &sku); err != nil {
+					return patch, fmt.Errorf("%w: sku must be a string", ErrInvalidPatch)
+				}
+			}
+
+			patch.SKU = &sku
+		case "barcodes":
+			barcodes := []string{}
+			if !isNull {
+				if err := json.Unmarshal(raw, &barcodes); err != nil {
+					return patch, fmt.Errorf("%w: barcodes must be an array of strings", ErrInvalidPatch)
+				}
+			}
+
+			patch.Barcodes = &barcodes
+		case "description":
+			var description string
+			if !isNull {
+				if err := json.Unmarshal(raw, &description); err != nil {
+					return patch, fmt.Errorf("%w: description must be a string", ErrInvalidPatch)
+				}
+			}
+
+			patch.Description = &description
+		case "quantity":
+			if isNull {
+				return patch, fmt.Errorf("%w: quantity cannot be removed", ErrInvalidPatch)
+			}
+
+			var quantity int
+			if err := json.Unmarshal(raw, &quantity); err != nil || quantity < 0 {
+				return patch, fmt.Errorf("%w: quantity must be a non-negative integer", ErrInvalidPatch)
+			}
+
+			patch.Quantity = &quantity
+		case "cost_price", "list_price":
+			if isNull {
+				return patch, fmt.Errorf("%w: %s cannot be removed", ErrInvalidPatch, key)
+			}
+
+			var price decimal.Decimal
+			if err := json.Unmarshal(raw, &price); err != nil {
+				return patch, fmt.Errorf("%w: %s must be a number", ErrInvalidPatch, key)
+			}
+
+			if key == "cost_price" {
+				patch.CostPrice = &price
+			} else {
+				patch.ListPrice = &price
+			}
+		case "currency":
+			var code string
+			if !isNull {
+				if err := json.Unmarshal(raw, &code); err != nil {
+					return patch, fmt.Errorf("%w: currency must be a string", ErrInvalidPatch)
+				}
+			}
+
+			patch.Currency = &code
+		case "costing_method":
+			var method model.CostingMethod
+			if isNull || json.Unmarshal(raw, &method) != nil {
+				return patch, fmt.Errorf("%w: costing_method must be fifo, lifo or average", ErrInvalidPatch)
+			}
+
+			patch.CostingMethod = &method
+		case "serialized":
+			var serialized bool
+			if isNull || json.Unmarshal(raw, &serialized) != nil {
+				return patch, fmt.Errorf("%w: serialized must be a boolean", ErrInvalidPatch)
+			}
+
+			patch.Serialized = &serialized
+		case "reorder_point", "reorder_quantity":
+			var value int
+			if !isNull {
+				if err := json.Unmarshal(raw, &value); err != nil || value < 0 {
+					return patch, fmt.Errorf("%w: %s must be a non-negative integer", ErrInvalidPatch, key)
+				}
+			}
+
+			if key == "reorder_point" {
+				patch.ReorderPoint = &value
+			} else {
+				patch.ReorderQuantity = &value
+			}
+		case "reason_code":
+			if isNull || json.Unmarshal(raw, &patch.ReasonCode) != nil {
+				return patch, fmt.Errorf("%w: reason_code must be a string", ErrInvalidPatch)
+			}
+		default:
+			return patch, fmt.Errorf("%w: unknown field %q", ErrInvalidPatch, key)
+		}
+	}
+
+	return patch, nil
+}
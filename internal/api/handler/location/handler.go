@@ -0,0 +1,276 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repolocation "github.com/aliskhannn/warehouse-control/internal/repository/location"
+	servicelocation "github.com/aliskhannn/warehouse-control/internal/service/location"
+)
+
+// service defines the interface for location service used by the handler.
+type service interface {
+	// Create adds a new location to a warehouse.
+	Create(ctx context.Context, l *model.Location) (*model.Location, error)
+
+	// GetByID retrieves a location by its ID.
+	GetByID(ctx context.Context, locationID uuid.UUID) (*model.Location, error)
+
+	// GetTree retrieves the locations of a warehouse as a tree.
+	GetTree(ctx context.Context, warehouseID uuid.UUID) ([]*model.Location, error)
+
+	// Update changes the code and name of a location.
+	Update(ctx context.Context, locationID uuid.UUID, code, name string) (*model.Location, error)
+
+	// Delete removes a location without child locations or stock.
+	Delete(ctx context.Context, locationID uuid.UUID) error
+
+	// GetItems retrieves the quantity of each item held in the bins under a location.
+	GetItems(ctx context.Context, locationID uuid.UUID) ([]*model.LocationStock, error)
+}
+
+// counts tells which items are being counted; it is implemented by the count service.
+type counts interface {
+	// Counted reports which of the items have a line in a count session that is still open.
+	Counted(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+// Handler provides HTTP handlers for location endpoints.
+type Handler struct {
+	service   service
+	counts    counts
+	validator *validator.Validate
+}
+
+// NewHandler creates a new location handler.
+func NewHandler(s service, n counts, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		counts:    n,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for creating a location.
+type CreateRequest struct {
+	WarehouseID uuid.UUID          `json:"warehouse_id" validate:"required"`
+	ParentID    *uuid.UUID         `json:"parent_id"`
+	Kind        model.LocationKind `json:"kind" validate:"required,oneof=zone aisle rack bin"`
+	Code        string             `json:"code" validate:"required,max=64"`
+	Name        string             `json:"name"`
+}
+
+// UpdateRequest represents the JSON request body for updating a location.
+type UpdateRequest struct {
+	Code string `json:"code" validate:"required,max=64"`
+	Name string `json:"name"`
+}
+
+// Create handles creating a new location.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	l, err := h.service.Create(c.Request.Context(), &model.Location{
+		WarehouseID: req.WarehouseID,
+		ParentID:    req.ParentID,
+		Kind:        req.Kind,
+		Code:        req.Code,
+		Name:        req.Name,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, servicelocation.ErrInvalidKind), errors.Is(err, servicelocation.ErrInvalidParent):
+			response.Fail(c, http.StatusBadRequest, err)
+		case errors.Is(err, repolocation.ErrLocationNotFound):
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("%w: not found", servicelocation.ErrInvalidParent))
+		case errors.Is(err, repolocation.ErrWarehouseNotFound):
+			response.Fail(c, http.StatusNotFound, repolocation.ErrWarehouseNotFound)
+		case errors.Is(err, repolocation.ErrCodeTaken):
+			response.Fail(c, http.StatusConflict, repolocation.ErrCodeTaken)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to create location")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to create location"))
+		}
+
+		return
+	}
+
+	response.Created(c, l)
+}
+
+// Update handles updating the code and name of a location.
+func (h *Handler) Update(c *ginext.Context) {
+	locationID, ok := getLocationID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	l, err := h.service.Update(c.Request.Context(), locationID, req.Code, req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, repolocation.ErrLocationNotFound):
+			response.Fail(c, http.StatusNotFound, repolocation.ErrLocationNotFound)
+		case errors.Is(err, repolocation.ErrCodeTaken):
+			response.Fail(c, http.StatusConflict, repolocation.ErrCodeTaken)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to update location")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to update location"))
+		}
+
+		return
+	}
+
+	response.OK(c, l)
+}
+
+// Delete handles deleting a location.
+func (h *Handler) Delete(c *ginext.Context) {
+	locationID, ok := getLocationID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), locationID); err != nil {
+		switch {
+		case errors.Is(err, repolocation.ErrLocationNotFound):
+			response.Fail(c, http.StatusNotFound, repolocation.ErrLocationNotFound)
+		case errors.Is(err, repolocation.ErrLocationInUse):
+			response.Fail(c, http.StatusConflict, repolocation.ErrLocationInUse)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to delete location")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to delete location"))
+		}
+
+		return
+	}
+
+	response.OK(c, map[string]string{"id": locationID.String()})
+}
+
+// GetByID handles retrieving a location by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	locationID, ok := getLocationID(c)
+	if !ok {
+		return
+	}
+
+	l, err := h.service.GetByID(c.Request.Context(), locationID)
+	if err != nil {
+		if errors.Is(err, repolocation.ErrLocationNotFound) {
+			response.Fail(c, http.StatusNotFound, repolocation.ErrLocationNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to get location")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get location"))
+		return
+	}
+
+	response.OK(c, l)
+}
+
+// GetTree handles retrieving the location tree of the warehouse given by ?warehouse_id.
+func (h *Handler) GetTree(c *ginext.Context) {
+	warehouseID, err := uuid.Parse(c.Query("warehouse_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid or missing warehouse_id"))
+		return
+	}
+
+	locations, err := h.service.GetTree(c.Request.Context(), warehouseID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to get locations")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get locations"))
+		return
+	}
+
+	response.OK(c, locations)
+}
+
+// GetItems handles retrieving the stock held in the bins under a location.
+// The quantities of items being counted are left out for users who count blind.
+func (h *Handler) GetItems(c *ginext.Context) {
+	locationID, ok := getLocationID(c)
+	if !ok {
+		return
+	}
+
+	stock, err := h.service.GetItems(c.Request.Context(), locationID)
+	if err != nil {
+		if errors.Is(err, repolocation.ErrLocationNotFound) {
+			response.Fail(c, http.StatusNotFound, repolocation.ErrLocationNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to get location items")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get location items"))
+		return
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(stock))
+	for _, level := range stock {
+		itemIDs = append(itemIDs, level.ItemID)
+	}
+
+	counted, ok := request.Counted(c, h.counts.Counted, itemIDs...)
+	if !ok {
+		return
+	}
+
+	response.OK(c, shownLevels(stock, counted))
+}
+
+// hiddenLevel is stock with its quantities, nil while the item is being counted, hiding those
+// of the embedded stock.
+type hiddenLevel struct {
+	*model.LocationStock
+	Quantity *int `json:"quantity,omitempty"`
+}
+
+// shownLevels returns stock as the user may see it: without the quantities of counted, the
+// items being counted for a user who counts blind (see request.Counted).
+func shownLevels(stock []*model.LocationStock, counted map[uuid.UUID]bool) interface{} {
+	if len(counted) == 0 {
+		return stock
+	}
+
+	shown := make([]hiddenLevel, len(stock))
+	for i, level := range stock {
+		shown[i].LocationStock = level
+		if !counted[level.ItemID] {
+			shown[i].Quantity = &level.Quantity
+		}
+	}
+
+	return shown
+}
+
+// getLocationID parses the location ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getLocationID(c *ginext.Context) (uuid.UUID, bool) {
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid location ID"))
+		return uuid.Nil, false
+	}
+
+	return locationID, true
+}
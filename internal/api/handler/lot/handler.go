@@ -0,0 +1,168 @@
+package lot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	servicelot "github.com/aliskhannn/warehouse-control/internal/service/lot"
+)
+
+// dateLayout is the format of lot expiry dates in requests.
+const dateLayout = "2006-01-02"
+
+// service defines the interface for lot service used by the handler.
+type service interface {
+	// Create adds a new lot of an item.
+	Create(ctx context.Context, itemID uuid.UUID, number string, expiresOn *time.Time) (*model.Lot, error)
+
+	// GetByItem retrieves the lots of an item with their stock per warehouse.
+	GetByItem(ctx context.Context, itemID uuid.UUID) ([]*model.Lot, error)
+
+	// SuggestPick proposes the lots to take a quantity of an item from, first expired first out.
+	SuggestPick(ctx context.Context, itemID, warehouseID uuid.UUID, quantity int) (*model.PickSuggestion, error)
+}
+
+// Handler provides HTTP handlers for lot endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new lot handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for creating a lot.
+// ExpiresOn is a date in the YYYY-MM-DD format.
+type CreateRequest struct {
+	Number    string `json:"number" validate:"required,max=64"`
+	ExpiresOn string `json:"expires_on" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// Create handles creating a lot of an item.
+func (h *Handler) Create(c *ginext.Context) {
+	itemID, ok := getItemID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	var expiresOn *time.Time
+	if req.ExpiresOn != "" {
+		day, _ := time.Parse(dateLayout, req.ExpiresOn) // validated above
+		expiresOn = &day
+	}
+
+	l, err := h.service.Create(c.Request.Context(), itemID, req.Number, expiresOn)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicelot.ErrNumberRequired):
+			response.Fail(c, http.StatusBadRequest, servicelot.ErrNumberRequired)
+		case errors.Is(err, repoitem.ErrItemNotFound):
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+		case errors.Is(err, repoitem.ErrLotTaken):
+			response.Fail(c, http.StatusConflict, repoitem.ErrLotTaken)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to create lot")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to create lot"))
+		}
+
+		return
+	}
+
+	response.Created(c, l)
+}
+
+// GetByItem handles retrieving the lots of an item.
+func (h *Handler) GetByItem(c *ginext.Context) {
+	itemID, ok := getItemID(c)
+	if !ok {
+		return
+	}
+
+	lots, err := h.service.GetByItem(c.Request.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, repoitem.ErrItemNotFound) {
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to get lots")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get lots"))
+		return
+	}
+
+	response.OK(c, lots)
+}
+
+// SuggestPick handles proposing the lots to pick ?quantity units of an item from,
+// in the warehouse given by ?warehouse_id or the default one.
+func (h *Handler) SuggestPick(c *ginext.Context) {
+	itemID, ok := getItemID(c)
+	if !ok {
+		return
+	}
+
+	quantity, err := request.QueryInt(c, "quantity")
+	if err != nil || quantity == nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("quantity must be an integer"))
+		return
+	}
+
+	warehouseID, err := request.QueryUUID(c, "warehouse_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	suggestion, err := h.service.SuggestPick(c.Request.Context(), itemID, warehouseID, *quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicelot.ErrInvalidQuantity):
+			response.Fail(c, http.StatusBadRequest, servicelot.ErrInvalidQuantity)
+		case errors.Is(err, repoitem.ErrItemNotFound):
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+		case errors.Is(err, repoitem.ErrWarehouseNotFound):
+			response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to suggest pick")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to suggest pick"))
+		}
+
+		return
+	}
+
+	response.OK(c, suggestion)
+}
+
+// getItemID parses the item ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getItemID(c *ginext.Context) (uuid.UUID, bool) {
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid item ID"))
+		return uuid.Nil, false
+	}
+
+	return itemID, true
+}
@@ -0,0 +1,317 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	serviceitem "github.com/aliskhannn/warehouse-control/internal/service/item"
+	servicepurchase "github.com/aliskhannn/warehouse-control/internal/service/purchase"
+)
+
+// dateLayout is the format of expected delivery dates in requests.
+const dateLayout = "2006-01-02"
+
+// service defines the interface for purchase order service used by the handler.
+type service interface {
+	// Create adds a draft purchase order.
+	Create(ctx context.Context, userID uuid.UUID, o *model.PurchaseOrder) (*model.PurchaseOrder, error)
+
+	// GetByID retrieves a purchase order by its ID.
+	GetByID(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error)
+
+	// GetAll retrieves the purchase orders matching filter.
+	GetAll(ctx context.Context, filter model.PurchaseFilter) ([]*model.PurchaseOrder, error)
+
+	// Submit places a draft order with its supplier.
+	Submit(ctx context.Context, userID, orderID uuid.UUID) (*model.PurchaseOrder, error)
+
+	// Receive records a delivery against an order and adds it to stock.
+	Receive(ctx context.Context, userID, orderID uuid.UUID, delivery []model.PurchaseDelivery) (*model.PurchaseOrder, error)
+
+	// Cancel cancels an order that has not been received in full.
+	Cancel(ctx context.Context, userID, orderID uuid.UUID) (*model.PurchaseOrder, error)
+}
+
+// Handler provides HTTP handlers for purchase order endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new purchase order handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for creating a purchase order.
+// The warehouse defaults to the default warehouse and the location is an optional bin code.
+// ExpectedOn is a date in the YYYY-MM-DD format, by default today plus the supplier's lead time.
+type CreateRequest struct {
+	SupplierID  uuid.UUID     `json:"supplier_id" validate:"required"`
+	WarehouseID uuid.UUID     `json:"warehouse_id"`
+	Location    string        `json:"location"`
+	ExpectedOn  string        `json:"expected_on" validate:"omitempty,datetime=2006-01-02"`
+	Note        string        `json:"note"`
+	Lines       []LineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+// LineRequest represents one line of a purchase order. UnitCost defaults to the supplier's cost.
+type LineRequest struct {
+	ItemID   uuid.UUID        `json:"item_id" validate:"required"`
+	Quantity int              `json:"quantity" validate:"required,gt=0"`
+	UnitCost *decimal.Decimal `json:"unit_cost"`
+}
+
+// ReceiveRequest represents the JSON request body for receiving a delivery.
+// Without lines everything outstanding is received.
+type ReceiveRequest struct {
+	Lines []ReceiveLineRequest `json:"lines" validate:"dive"`
+}
+
+// ReceiveLineRequest represents the quantity of one item delivered.
+type ReceiveLineRequest struct {
+	ItemID   uuid.UUID `json:"item_id" validate:"required"`
+	Quantity int       `json:"quantity" validate:"required,gt=0"`
+	Lot      string    `json:"lot" validate:"max=64"`
+	Serials  []string  `json:"serials" validate:"dive,required,max=64"`
+}
+
+// Create handles creating a draft purchase order.
+func (h *Handler) Create(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	o := &model.PurchaseOrder{
+		SupplierID:  req.SupplierID,
+		WarehouseID: req.WarehouseID,
+		Location:    req.Location,
+		Note:        req.Note,
+	}
+
+	if req.ExpectedOn != "" {
+		day, _ := time.Parse(dateLayout, req.ExpectedOn) // validated above
+		o.ExpectedOn = &day
+	}
+
+	for _, line := range req.Lines {
+		o.Lines = append(o.Lines, &model.PurchaseLine{ItemID: line.ItemID, Quantity: line.Quantity, UnitCost: line.UnitCost})
+	}
+
+	o, err := h.service.Create(c.Request.Context(), userID, o)
+	if err != nil {
+		failPurchase(c, err, "failed to create purchase order")
+		return
+	}
+
+	response.Created(c, o)
+}
+
+// GetByID handles retrieving a purchase order by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	o, err := h.service.GetByID(c.Request.Context(), orderID)
+	if err != nil {
+		failPurchase(c, err, "failed to get purchase order")
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// GetAll handles listing purchase orders, optionally filtered by ?status, ?supplier_id and ?item_id.
+func (h *Handler) GetAll(c *ginext.Context) {
+	filter := model.PurchaseFilter{Status: model.PurchaseStatus(c.Query("status"))}
+
+	switch filter.Status {
+	case "", model.PurchaseDraft, model.PurchaseOrdered, model.PurchasePartiallyReceived,
+		model.PurchaseReceived, model.PurchaseCancelled:
+	default:
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid status"))
+		return
+	}
+
+	for param, id := range map[string]*uuid.UUID{"supplier_id": &filter.SupplierID, "item_id": &filter.ItemID} {
+		if value := c.Query(param); value != "" {
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid %s", param))
+				return
+			}
+
+			*id = parsed
+		}
+	}
+
+	orders, err := h.service.GetAll(c.Request.Context(), filter)
+	if err != nil {
+		failPurchase(c, err, "failed to get purchase orders")
+		return
+	}
+
+	response.OK(c, orders)
+}
+
+// Submit handles placing a draft purchase order with its supplier.
+func (h *Handler) Submit(c *ginext.Context) {
+	h.transition(c, h.service.Submit, "failed to submit purchase order")
+}
+
+// Cancel handles cancelling a purchase order.
+func (h *Handler) Cancel(c *ginext.Context) {
+	h.transition(c, h.service.Cancel, "failed to cancel purchase order")
+}
+
+// Receive handles receiving a delivery against a purchase order. The body is optional.
+func (h *Handler) Receive(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req ReceiveRequest
+	if !request.BindOptional(c, h.validator, &req) {
+		return
+	}
+
+	delivery := make([]model.PurchaseDelivery, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		delivery = append(delivery, model.PurchaseDelivery{
+			ItemID:   line.ItemID,
+			Quantity: line.Quantity,
+			Lot:      line.Lot,
+			Serials:  line.Serials,
+		})
+	}
+
+	o, err := h.service.Receive(c.Request.Context(), userID, orderID, delivery)
+	if err != nil {
+		failPurchase(c, err, "failed to receive purchase order")
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// transition applies a status change to the purchase order named in the request path.
+func (h *Handler) transition(
+	c *ginext.Context,
+	apply func(ctx context.Context, userID, orderID uuid.UUID) (*model.PurchaseOrder, error),
+	msg string,
+) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	o, err := apply(c.Request.Context(), userID, orderID)
+	if err != nil {
+		failPurchase(c, err, msg)
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// failPurchase answers a failed purchase order request: 404 for unknown orders, suppliers and
+// stock places, 409 for status changes and receipts the order rules out and 400 for invalid
+// lines and serials. Anything else is logged with msg and answered with 500.
+func failPurchase(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, repoitem.ErrPurchaseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrPurchaseNotFound)
+	case errors.Is(err, repoitem.ErrSupplierNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrSupplierNotFound)
+	case errors.Is(err, servicepurchase.ErrInvalidTransition):
+		response.Fail(c, http.StatusConflict, servicepurchase.ErrInvalidTransition)
+	case errors.Is(err, repoitem.ErrPurchaseStatusChange):
+		response.Fail(c, http.StatusConflict, repoitem.ErrPurchaseStatusChange)
+	case errors.Is(err, servicepurchase.ErrOverReceipt):
+		response.Fail(c, http.StatusConflict, servicepurchase.ErrOverReceipt)
+	case errors.Is(err, servicepurchase.ErrNoLines):
+		response.Fail(c, http.StatusBadRequest, servicepurchase.ErrNoLines)
+	case errors.Is(err, servicepurchase.ErrInvalidQuantity):
+		response.Fail(c, http.StatusBadRequest, servicepurchase.ErrInvalidQuantity)
+	case errors.Is(err, servicepurchase.ErrDuplicateItem):
+		response.Fail(c, http.StatusBadRequest, servicepurchase.ErrDuplicateItem)
+	case errors.Is(err, servicepurchase.ErrNegativeCost):
+		response.Fail(c, http.StatusBadRequest, servicepurchase.ErrNegativeCost)
+	case errors.Is(err, servicepurchase.ErrItemNotOrdered):
+		response.Fail(c, http.StatusBadRequest, servicepurchase.ErrItemNotOrdered)
+	case errors.Is(err, repoitem.ErrUnitCostRequired):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrUnitCostRequired)
+	case errors.Is(err, serviceitem.ErrDuplicateSerial):
+		response.Fail(c, http.StatusBadRequest, serviceitem.ErrDuplicateSerial)
+	case errors.Is(err, repoitem.ErrSerialsRequired):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrSerialsRequired)
+	case errors.Is(err, repoitem.ErrNotSerialized):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrNotSerialized)
+	case errors.Is(err, repoitem.ErrSerialTaken):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialTaken)
+	case errors.Is(err, repoitem.ErrSerialInStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialInStock)
+	case errors.Is(err, repoitem.ErrSerialScrapped):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialScrapped)
+	case errors.Is(err, repoitem.ErrNotABin):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrNotABin)
+	case errors.Is(err, repoitem.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+	case errors.Is(err, repoitem.ErrLotNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLotNotFound)
+	case errors.Is(err, repoitem.ErrWarehouseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+	case errors.Is(err, repoitem.ErrLocationNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLocationNotFound)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
+
+// getOrderID parses the purchase order ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getOrderID(c *ginext.Context) (uuid.UUID, bool) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid purchase order ID"))
+		return uuid.Nil, false
+	}
+
+	return orderID, true
+}
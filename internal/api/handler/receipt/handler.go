@@ -0,0 +1,365 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	serviceitem "github.com/aliskhannn/warehouse-control/internal/service/item"
+	servicereceipt "github.com/aliskhannn/warehouse-control/internal/service/receipt"
+)
+
+// service defines the interface for goods receipt service used by the handler.
+type service interface {
+	// Create adds a draft goods receipt.
+	Create(ctx context.Context, userID uuid.UUID, g *model.GoodsReceipt) (*model.GoodsReceipt, error)
+
+	// GetByID retrieves a goods receipt by its ID.
+	GetByID(ctx context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error)
+
+	// GetAll retrieves the goods receipts matching filter.
+	GetAll(ctx context.Context, filter model.ReceiptFilter) ([]*model.GoodsReceipt, error)
+
+	// Delete deletes a draft goods receipt.
+	Delete(ctx context.Context, userID, receiptID uuid.UUID) error
+
+	// Post adds a draft receipt to stock and records its discrepancies.
+	Post(ctx context.Context, userID, receiptID uuid.UUID) (*model.GoodsReceipt, error)
+
+	// Reverse undoes a posted receipt with a compensating receipt.
+	Reverse(ctx context.Context, userID, receiptID uuid.UUID) (*model.GoodsReceipt, error)
+
+	// GetDiscrepancies retrieves the discrepancies matching filter.
+	GetDiscrepancies(ctx context.Context, filter model.DiscrepancyFilter) ([]*model.Discrepancy, error)
+
+	// ResolveDiscrepancy marks an open discrepancy as resolved.
+	ResolveDiscrepancy(ctx context.Context, userID, discrepancyID uuid.UUID, resolution string) (*model.Discrepancy, error)
+}
+
+// Handler provides HTTP handlers for goods receipt and discrepancy endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new goods receipt handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for creating a goods receipt against a
+// purchase order, an advance shipping notice number or both. Without a warehouse and
+// location the receipt goes to the purchase order's or the default warehouse.
+type CreateRequest struct {
+	PurchaseOrderID *uuid.UUID    `json:"purchase_order_id"`
+	ASNNumber       string        `json:"asn_number" validate:"max=64"`
+	WarehouseID     uuid.UUID     `json:"warehouse_id"`
+	Location        string        `json:"location"`
+	Note            string        `json:"note"`
+	Lines           []LineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+// LineRequest represents what arrived of one item. ExpectedQuantity defaults to the quantity
+// outstanding on the purchase order; ReceivedQuantity includes the damaged units.
+type LineRequest struct {
+	ItemID           uuid.UUID `json:"item_id" validate:"required"`
+	ExpectedQuantity *int      `json:"expected_quantity" validate:"omitempty,gte=0"`
+	ReceivedQuantity int       `json:"received_quantity" validate:"gte=0"`
+	DamagedQuantity  int       `json:"damaged_quantity" validate:"gte=0"`
+	Lot              string    `json:"lot" validate:"max=64"`
+	Serials          []string  `json:"serials" validate:"dive,required,max=64"`
+}
+
+// ResolveRequest represents the JSON request body for resolving a discrepancy.
+type ResolveRequest struct {
+	Resolution string `json:"resolution" validate:"required,max=1000"`
+}
+
+// Create handles creating a draft goods receipt.
+func (h *Handler) Create(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	g := &model.GoodsReceipt{
+		PurchaseOrderID: req.PurchaseOrderID,
+		ASNNumber:       req.ASNNumber,
+		WarehouseID:     req.WarehouseID,
+		Location:        req.Location,
+		Note:            req.Note,
+	}
+
+	for _, line := range req.Lines {
+		g.Lines = append(g.Lines, &model.ReceiptLine{
+			ItemID:           line.ItemID,
+			ExpectedQuantity: line.ExpectedQuantity,
+			ReceivedQuantity: line.ReceivedQuantity,
+			DamagedQuantity:  line.DamagedQuantity,
+			Lot:              line.Lot,
+			Serials:          line.Serials,
+		})
+	}
+
+	g, err := h.service.Create(c.Request.Context(), userID, g)
+	if err != nil {
+		failReceipt(c, err, "failed to create goods receipt")
+		return
+	}
+
+	response.Created(c, g)
+}
+
+// GetByID handles retrieving a goods receipt by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	receiptID, ok := getID(c, "goods receipt")
+	if !ok {
+		return
+	}
+
+	g, err := h.service.GetByID(c.Request.Context(), receiptID)
+	if err != nil {
+		failReceipt(c, err, "failed to get goods receipt")
+		return
+	}
+
+	response.OK(c, g)
+}
+
+// GetAll handles listing goods receipts, optionally filtered by ?status and ?purchase_order_id.
+func (h *Handler) GetAll(c *ginext.Context) {
+	filter := model.ReceiptFilter{Status: model.ReceiptStatus(c.Query("status"))}
+
+	switch filter.Status {
+	case "", model.ReceiptDraft, model.ReceiptPosted:
+	default:
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid status"))
+		return
+	}
+
+	purchaseOrderID, err := request.QueryUUID(c, "purchase_order_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	filter.PurchaseOrderID = purchaseOrderID
+
+	receipts, err := h.service.GetAll(c.Request.Context(), filter)
+	if err != nil {
+		failReceipt(c, err, "failed to get goods receipts")
+		return
+	}
+
+	response.OK(c, receipts)
+}
+
+// Delete handles deleting a draft goods receipt.
+func (h *Handler) Delete(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	receiptID, ok := getID(c, "goods receipt")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, receiptID); err != nil {
+		failReceipt(c, err, "failed to delete goods receipt")
+		return
+	}
+
+	response.OK(c, map[string]string{"id": receiptID.String()})
+}
+
+// Post handles posting a draft goods receipt.
+func (h *Handler) Post(c *ginext.Context) {
+	h.apply(c, h.service.Post, "failed to post goods receipt")
+}
+
+// Reverse handles reversing a posted goods receipt; it responds with the reversal.
+func (h *Handler) Reverse(c *ginext.Context) {
+	h.apply(c, h.service.Reverse, "failed to reverse goods receipt")
+}
+
+// apply runs a posting or reversal of the goods receipt named in the request path.
+func (h *Handler) apply(
+	c *ginext.Context,
+	fn func(ctx context.Context, userID, receiptID uuid.UUID) (*model.GoodsReceipt, error),
+	msg string,
+) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	receiptID, ok := getID(c, "goods receipt")
+	if !ok {
+		return
+	}
+
+	g, err := fn(c.Request.Context(), userID, receiptID)
+	if err != nil {
+		failReceipt(c, err, msg)
+		return
+	}
+
+	response.OK(c, g)
+}
+
+// GetDiscrepancies handles listing open discrepancies; ?resolved=true includes resolved ones
+// and ?receipt_id restricts them to one receipt.
+func (h *Handler) GetDiscrepancies(c *ginext.Context) {
+	resolved, err := request.QueryBool(c, "resolved")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	receiptID, err := request.QueryUUID(c, "receipt_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := model.DiscrepancyFilter{Resolved: resolved != nil && *resolved, ReceiptID: receiptID}
+
+	discrepancies, err := h.service.GetDiscrepancies(c.Request.Context(), filter)
+	if err != nil {
+		failReceipt(c, err, "failed to get discrepancies")
+		return
+	}
+
+	response.OK(c, discrepancies)
+}
+
+// ResolveDiscrepancy handles resolving an open discrepancy.
+func (h *Handler) ResolveDiscrepancy(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	discrepancyID, ok := getID(c, "discrepancy")
+	if !ok {
+		return
+	}
+
+	var req ResolveRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	d, err := h.service.ResolveDiscrepancy(c.Request.Context(), userID, discrepancyID, req.Resolution)
+	if err != nil {
+		failReceipt(c, err, "failed to resolve discrepancy")
+		return
+	}
+
+	response.OK(c, d)
+}
+
+// failReceipt answers a failed goods receipt request: 404 for unknown receipts, discrepancies,
+// orders and stock places, 409 for changes the state of the receipt or its order rules out and
+// 400 for invalid lines. Anything else is logged with msg and answered with 500.
+func failReceipt(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, repoitem.ErrReceiptNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrReceiptNotFound)
+	case errors.Is(err, repoitem.ErrDiscrepancyNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrDiscrepancyNotFound)
+	case errors.Is(err, repoitem.ErrPurchaseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrPurchaseNotFound)
+	case errors.Is(err, repoitem.ErrReceiptPosted):
+		response.Fail(c, http.StatusConflict, repoitem.ErrReceiptPosted)
+	case errors.Is(err, repoitem.ErrReceiptReversed):
+		response.Fail(c, http.StatusConflict, repoitem.ErrReceiptReversed)
+	case errors.Is(err, repoitem.ErrDiscrepancyResolved):
+		response.Fail(c, http.StatusConflict, repoitem.ErrDiscrepancyResolved)
+	case errors.Is(err, repoitem.ErrPurchaseStatusChange):
+		response.Fail(c, http.StatusConflict, repoitem.ErrPurchaseStatusChange)
+	case errors.Is(err, servicereceipt.ErrPurchaseClosed):
+		response.Fail(c, http.StatusConflict, servicereceipt.ErrPurchaseClosed)
+	case errors.Is(err, servicereceipt.ErrNotPosted):
+		response.Fail(c, http.StatusConflict, servicereceipt.ErrNotPosted)
+	case errors.Is(err, servicereceipt.ErrReversalReversed):
+		response.Fail(c, http.StatusConflict, servicereceipt.ErrReversalReversed)
+	case errors.Is(err, servicereceipt.ErrSerializedReversal):
+		response.Fail(c, http.StatusConflict, servicereceipt.ErrSerializedReversal)
+	case errors.Is(err, repoitem.ErrInsufficientStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrInsufficientStock)
+	case errors.Is(err, repoitem.ErrNoExchangeRate):
+		response.Fail(c, http.StatusConflict, repoitem.ErrNoExchangeRate)
+	case errors.Is(err, servicereceipt.ErrNoSource):
+		response.Fail(c, http.StatusBadRequest, servicereceipt.ErrNoSource)
+	case errors.Is(err, servicereceipt.ErrNoLines):
+		response.Fail(c, http.StatusBadRequest, servicereceipt.ErrNoLines)
+	case errors.Is(err, servicereceipt.ErrDuplicateItem):
+		response.Fail(c, http.StatusBadRequest, servicereceipt.ErrDuplicateItem)
+	case errors.Is(err, servicereceipt.ErrInvalidQuantity):
+		response.Fail(c, http.StatusBadRequest, servicereceipt.ErrInvalidQuantity)
+	case errors.Is(err, servicereceipt.ErrExpectedRequired):
+		response.Fail(c, http.StatusBadRequest, servicereceipt.ErrExpectedRequired)
+	case errors.Is(err, servicereceipt.ErrEmptyReceiptLine):
+		response.Fail(c, http.StatusBadRequest, servicereceipt.ErrEmptyReceiptLine)
+	case errors.Is(err, servicereceipt.ErrResolutionRequired):
+		response.Fail(c, http.StatusBadRequest, servicereceipt.ErrResolutionRequired)
+	case errors.Is(err, serviceitem.ErrDuplicateSerial):
+		response.Fail(c, http.StatusBadRequest, serviceitem.ErrDuplicateSerial)
+	case errors.Is(err, repoitem.ErrSerialsRequired):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrSerialsRequired)
+	case errors.Is(err, repoitem.ErrNotSerialized):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrNotSerialized)
+	case errors.Is(err, repoitem.ErrSerialTaken):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialTaken)
+	case errors.Is(err, repoitem.ErrSerialInStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialInStock)
+	case errors.Is(err, repoitem.ErrSerialScrapped):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialScrapped)
+	case errors.Is(err, repoitem.ErrNotABin):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrNotABin)
+	case errors.Is(err, repoitem.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+	case errors.Is(err, repoitem.ErrLotNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLotNotFound)
+	case errors.Is(err, repoitem.ErrWarehouseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+	case errors.Is(err, repoitem.ErrLocationNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLocationNotFound)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
+
+// getID parses the ID of the named resource from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getID(c *ginext.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid %s ID", name))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,151 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/currency"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	servicereport "github.com/aliskhannn/warehouse-control/internal/service/report"
+)
+
+// defaultExpiringWithin is the period of the expiry report when ?within is absent.
+const defaultExpiringWithin = 30 * 24 * time.Hour
+
+// defaultWriteOffSpan is how far back the write-off report reaches when ?from is absent.
+const defaultWriteOffSpan = 365 * 24 * time.Hour
+
+// service defines the interface for report service used by the handler.
+type service interface {
+	// Expiring retrieves the lot stock that expires within the given period from today.
+	Expiring(ctx context.Context, within time.Duration) ([]*model.LotStock, error)
+
+	// WriteOffs sums the stock adjusted with each reason code by period and values it in a
+	// currency, the base currency if it is empty.
+	WriteOffs(
+		ctx context.Context, from, to time.Time, period model.ReportPeriod, warehouseID uuid.UUID, code string,
+	) ([]*model.WriteOff, error)
+
+	// Valuation values the stock owned at a point in time in a currency, the base currency if it is empty.
+	Valuation(ctx context.Context, asOf time.Time, code string) (*model.Valuation, error)
+}
+
+// Handler provides HTTP handlers for report endpoints.
+type Handler struct {
+	service service
+}
+
+// NewHandler creates a new report handler.
+func NewHandler(s service) *Handler {
+	return &Handler{service: s}
+}
+
+// Expiring handles retrieving the lot stock that expires within ?within, 30d by default.
+func (h *Handler) Expiring(c *ginext.Context) {
+	within, err := request.QueryDuration(c, "within", defaultExpiringWithin)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	stock, err := h.service.Expiring(c.Request.Context(), within)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to get expiring lots")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get expiring lots"))
+		return
+	}
+
+	response.OK(c, stock)
+}
+
+// WriteOffs handles the write-off report: the stock adjusted with each reason code between
+// ?from and ?to (RFC 3339 times, by default the year up to now) by ?period (month by default),
+// optionally in one ?warehouse_id, valued in ?currency (the base currency by default).
+func (h *Handler) WriteOffs(c *ginext.Context) {
+	to, err := request.QueryTime(c, "to")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if to == nil {
+		now := time.Now().UTC()
+		to = &now
+	}
+
+	from, err := request.QueryTime(c, "from")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if from == nil {
+		start := to.Add(-defaultWriteOffSpan)
+		from = &start
+	}
+
+	warehouseID, err := request.QueryUUID(c, "warehouse_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	period := model.ReportPeriod(c.DefaultQuery("period", string(model.PeriodMonth)))
+
+	writeOffs, err := h.service.WriteOffs(c.Request.Context(), *from, *to, period, warehouseID, c.Query("currency"))
+	if err != nil {
+		switch {
+		case errors.Is(err, servicereport.ErrInvalidPeriod):
+			response.Fail(c, http.StatusBadRequest, servicereport.ErrInvalidPeriod)
+		case errors.Is(err, servicereport.ErrInvalidRange):
+			response.Fail(c, http.StatusBadRequest, servicereport.ErrInvalidRange)
+		case errors.Is(err, currency.ErrInvalidCode), errors.Is(err, currency.ErrRateNotFound):
+			response.Fail(c, http.StatusBadRequest, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to get write-offs")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get write-offs"))
+		}
+
+		return
+	}
+
+	response.OK(c, writeOffs)
+}
+
+// Valuation handles the valuation report: the stock owned at ?as_of (an RFC 3339 time, now by
+// default) and its value in ?currency (the base currency by default).
+func (h *Handler) Valuation(c *ginext.Context) {
+	asOf, err := request.QueryTime(c, "as_of")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if asOf == nil {
+		now := time.Now().UTC()
+		asOf = &now
+	}
+
+	valuation, err := h.service.Valuation(c.Request.Context(), *asOf, c.Query("currency"))
+	if err != nil {
+		if errors.Is(err, currency.ErrInvalidCode) || errors.Is(err, currency.ErrRateNotFound) {
+			response.Fail(c, http.StatusBadRequest, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to get valuation")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get valuation"))
+		return
+	}
+
+	response.OK(c, valuation)
+}
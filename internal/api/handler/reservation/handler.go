@@ -0,0 +1,176 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	servicereservation "github.com/aliskhannn/warehouse-control/internal/service/reservation"
+)
+
+// service defines the interface for reservation service used by the handler.
+type service interface {
+	// Create reserves stock of an item for an owner for ttl, or the default TTL if it is zero.
+	Create(ctx context.Context, userID uuid.UUID, rv *model.Reservation, ttl time.Duration) (*model.Reservation, error)
+
+	// GetByItem retrieves the reservations of an item with a status, or all if it is empty.
+	GetByItem(ctx context.Context, itemID uuid.UUID, status model.ReservationStatus) ([]*model.Reservation, error)
+
+	// Release releases an active reservation before it expires.
+	Release(ctx context.Context, userID, reservationID uuid.UUID) (*model.Reservation, error)
+}
+
+// Handler provides HTTP handlers for reservation endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new reservation handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for reserving stock of an item.
+// The warehouse defaults to the default warehouse and TTL, a duration such as "4h",
+// to the configured default.
+type CreateRequest struct {
+	WarehouseID uuid.UUID `json:"warehouse_id"`
+	Owner       string    `json:"owner" validate:"required,max=255"`
+	Quantity    int       `json:"quantity" validate:"required,gt=0"`
+	TTL         string    `json:"ttl"`
+}
+
+// Create handles reserving stock of an item.
+func (h *Handler) Create(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	itemID, ok := getID(c, "item")
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("ttl must be a duration such as 4h"))
+			return
+		}
+	}
+
+	rv := &model.Reservation{
+		ItemID:      itemID,
+		WarehouseID: req.WarehouseID,
+		Owner:       req.Owner,
+		Quantity:    req.Quantity,
+	}
+
+	rv, err := h.service.Create(c.Request.Context(), userID, rv, ttl)
+	if err != nil {
+		failReservation(c, err, "failed to create reservation")
+		return
+	}
+
+	response.Created(c, rv)
+}
+
+// GetByItem handles listing the reservations of an item, optionally filtered by ?status.
+func (h *Handler) GetByItem(c *ginext.Context) {
+	itemID, ok := getID(c, "item")
+	if !ok {
+		return
+	}
+
+	reservations, err := h.service.GetByItem(c.Request.Context(), itemID, model.ReservationStatus(c.Query("status")))
+	if err != nil {
+		failReservation(c, err, "failed to get reservations")
+		return
+	}
+
+	response.OK(c, reservations)
+}
+
+// Release handles releasing a reservation.
+func (h *Handler) Release(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	reservationID, ok := getID(c, "reservation")
+	if !ok {
+		return
+	}
+
+	rv, err := h.service.Release(c.Request.Context(), userID, reservationID)
+	if err != nil {
+		failReservation(c, err, "failed to release reservation")
+		return
+	}
+
+	response.OK(c, rv)
+}
+
+// failReservation answers a failed reservation request: 400 for invalid requests, 404 for
+// unknown reservations, items and warehouses and 409 for reservations no longer active or
+// stock that is short. Anything else is logged with msg and answered with 500.
+func failReservation(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, servicereservation.ErrOwnerRequired):
+		response.Fail(c, http.StatusBadRequest, servicereservation.ErrOwnerRequired)
+	case errors.Is(err, servicereservation.ErrInvalidQuantity):
+		response.Fail(c, http.StatusBadRequest, servicereservation.ErrInvalidQuantity)
+	case errors.Is(err, servicereservation.ErrInvalidTTL):
+		response.Fail(c, http.StatusBadRequest, servicereservation.ErrInvalidTTL)
+	case errors.Is(err, servicereservation.ErrInvalidStatus):
+		response.Fail(c, http.StatusBadRequest, servicereservation.ErrInvalidStatus)
+	case errors.Is(err, repoitem.ErrReservationNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrReservationNotFound)
+	case errors.Is(err, repoitem.ErrReservationNotActive):
+		response.Fail(c, http.StatusConflict, repoitem.ErrReservationNotActive)
+	case errors.Is(err, repoitem.ErrInsufficientStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrInsufficientStock)
+	case errors.Is(err, repoitem.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+	case errors.Is(err, repoitem.ErrWarehouseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
+
+// getID parses the ID of the named resource from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getID(c *ginext.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid %s ID", name))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
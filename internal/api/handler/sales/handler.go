@@ -0,0 +1,277 @@
+package sales
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	servicesales "github.com/aliskhannn/warehouse-control/internal/service/sales"
+)
+
+// service defines the interface for sales order service used by the handler.
+type service interface {
+	// Create adds a draft sales order.
+	Create(ctx context.Context, userID uuid.UUID, o *model.SalesOrder) (*model.SalesOrder, error)
+
+	// GetByID retrieves a sales order by its ID.
+	GetByID(ctx context.Context, orderID uuid.UUID) (*model.SalesOrder, error)
+
+	// GetAll retrieves the sales orders matching filter.
+	GetAll(ctx context.Context, filter model.SalesFilter) ([]*model.SalesOrder, error)
+
+	// Allocate reserves available stock for the lines of an order.
+	Allocate(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error)
+
+	// Pick records the pick list of an allocated order.
+	Pick(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error)
+
+	// GetPickList retrieves the pick list of an order grouped by bin.
+	GetPickList(ctx context.Context, orderID uuid.UUID) (*model.PickList, error)
+
+	// Pack confirms that a picked order has been packed.
+	Pack(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error)
+
+	// Ship issues the stock of a packed order.
+	Ship(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error)
+
+	// Cancel cancels an order that has not been shipped.
+	Cancel(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error)
+}
+
+// Handler provides HTTP handlers for sales order endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new sales order handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for creating a sales order.
+// The warehouse defaults to the default warehouse.
+type CreateRequest struct {
+	Customer    string        `json:"customer" validate:"required,max=255"`
+	ShipTo      string        `json:"ship_to" validate:"max=1000"`
+	WarehouseID uuid.UUID     `json:"warehouse_id"`
+	Note        string        `json:"note"`
+	Lines       []LineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+// LineRequest represents one line of a sales order. UnitPrice defaults to the item's price.
+type LineRequest struct {
+	ItemID    uuid.UUID        `json:"item_id" validate:"required"`
+	Quantity  int              `json:"quantity" validate:"required,gt=0"`
+	UnitPrice *decimal.Decimal `json:"unit_price"`
+}
+
+// Create handles creating a draft sales order.
+func (h *Handler) Create(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	o := &model.SalesOrder{
+		Customer:    req.Customer,
+		ShipTo:      req.ShipTo,
+		WarehouseID: req.WarehouseID,
+		Note:        req.Note,
+	}
+
+	for _, line := range req.Lines {
+		o.Lines = append(o.Lines, &model.SalesLine{ItemID: line.ItemID, Quantity: line.Quantity, UnitPrice: line.UnitPrice})
+	}
+
+	o, err := h.service.Create(c.Request.Context(), userID, o)
+	if err != nil {
+		failSales(c, err, "failed to create sales order")
+		return
+	}
+
+	response.Created(c, o)
+}
+
+// GetByID handles retrieving a sales order by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	o, err := h.service.GetByID(c.Request.Context(), orderID)
+	if err != nil {
+		failSales(c, err, "failed to get sales order")
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// GetAll handles listing sales orders, optionally filtered by ?status and ?item_id.
+func (h *Handler) GetAll(c *ginext.Context) {
+	filter := model.SalesFilter{Status: model.SalesStatus(c.Query("status"))}
+
+	switch filter.Status {
+	case "", model.SalesDraft, model.SalesPartiallyAllocated, model.SalesAllocated, model.SalesPicking,
+		model.SalesPacked, model.SalesShipped, model.SalesCancelled:
+	default:
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid status"))
+		return
+	}
+
+	if value := c.Query("item_id"); value != "" {
+		itemID, err := uuid.Parse(value)
+		if err != nil {
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid item_id"))
+			return
+		}
+
+		filter.ItemID = itemID
+	}
+
+	orders, err := h.service.GetAll(c.Request.Context(), filter)
+	if err != nil {
+		failSales(c, err, "failed to get sales orders")
+		return
+	}
+
+	response.OK(c, orders)
+}
+
+// GetPickList handles retrieving the pick list of a sales order.
+func (h *Handler) GetPickList(c *ginext.Context) {
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.service.GetPickList(c.Request.Context(), orderID)
+	if err != nil {
+		failSales(c, err, "failed to get pick list")
+		return
+	}
+
+	response.OK(c, list)
+}
+
+// Allocate handles allocating stock to a sales order.
+func (h *Handler) Allocate(c *ginext.Context) {
+	h.transition(c, h.service.Allocate, "failed to allocate sales order")
+}
+
+// Pick handles creating the pick list of a sales order.
+func (h *Handler) Pick(c *ginext.Context) {
+	h.transition(c, h.service.Pick, "failed to pick sales order")
+}
+
+// Pack handles confirming that a sales order has been packed.
+func (h *Handler) Pack(c *ginext.Context) {
+	h.transition(c, h.service.Pack, "failed to pack sales order")
+}
+
+// Ship handles shipping a sales order.
+func (h *Handler) Ship(c *ginext.Context) {
+	h.transition(c, h.service.Ship, "failed to ship sales order")
+}
+
+// Cancel handles cancelling a sales order.
+func (h *Handler) Cancel(c *ginext.Context) {
+	h.transition(c, h.service.Cancel, "failed to cancel sales order")
+}
+
+// transition applies a step of the workflow to the sales order named in the request path.
+func (h *Handler) transition(
+	c *ginext.Context,
+	apply func(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error),
+	msg string,
+) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	o, err := apply(c.Request.Context(), userID, orderID)
+	if err != nil {
+		failSales(c, err, msg)
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// failSales answers a failed sales order request: 404 for unknown orders, items and warehouses,
+// 409 for status changes and shipments that cannot go ahead and 400 for invalid orders.
+// Anything else is logged with msg and answered with 500.
+func failSales(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, repoitem.ErrSalesNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrSalesNotFound)
+	case errors.Is(err, servicesales.ErrInvalidTransition):
+		response.Fail(c, http.StatusConflict, servicesales.ErrInvalidTransition)
+	case errors.Is(err, repoitem.ErrSalesStatusChange):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSalesStatusChange)
+	case errors.Is(err, repoitem.ErrInsufficientStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrInsufficientStock)
+	case errors.Is(err, repoitem.ErrNoExchangeRate):
+		response.Fail(c, http.StatusConflict, repoitem.ErrNoExchangeRate)
+	case errors.Is(err, repoitem.ErrLotExpired):
+		response.Fail(c, http.StatusConflict, repoitem.ErrLotExpired)
+	case errors.Is(err, servicesales.ErrCustomerRequired):
+		response.Fail(c, http.StatusBadRequest, servicesales.ErrCustomerRequired)
+	case errors.Is(err, servicesales.ErrNoLines):
+		response.Fail(c, http.StatusBadRequest, servicesales.ErrNoLines)
+	case errors.Is(err, servicesales.ErrInvalidQuantity):
+		response.Fail(c, http.StatusBadRequest, servicesales.ErrInvalidQuantity)
+	case errors.Is(err, servicesales.ErrDuplicateItem):
+		response.Fail(c, http.StatusBadRequest, servicesales.ErrDuplicateItem)
+	case errors.Is(err, servicesales.ErrNegativePrice):
+		response.Fail(c, http.StatusBadRequest, servicesales.ErrNegativePrice)
+	case errors.Is(err, repoitem.ErrSerializedSale):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrSerializedSale)
+	case errors.Is(err, repoitem.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+	case errors.Is(err, repoitem.ErrWarehouseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
+
+// getOrderID parses the sales order ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getOrderID(c *ginext.Context) (uuid.UUID, bool) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid sales order ID"))
+		return uuid.Nil, false
+	}
+
+	return orderID, true
+}
@@ -0,0 +1,74 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/gs1"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	servicescan "github.com/aliskhannn/warehouse-control/internal/service/scan"
+)
+
+// service defines the interface for scan service used by the handler.
+type service interface {
+	// Scan parses raw GS1-128 scanner data and looks up the item with the scanned GTIN.
+	Scan(ctx context.Context, data string) (*model.ScanResult, error)
+}
+
+// Handler provides HTTP handlers for scanner endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new scan handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// ScanRequest represents the JSON request body for resolving a scan.
+// Data is the raw scanner output, with FNC1 sent as the group separator character (\u001d).
+type ScanRequest struct {
+	Data string `json:"data" validate:"required"`
+}
+
+// Scan handles resolving a GS1-128 barcode to an item.
+func (h *Handler) Scan(c *ginext.Context) {
+	var req ScanRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	result, err := h.service.Scan(c.Request.Context(), req.Data)
+	if err != nil {
+		switch {
+		case errors.Is(err, gs1.ErrEmptyData),
+			errors.Is(err, gs1.ErrUnknownAI),
+			errors.Is(err, gs1.ErrInvalidElement),
+			errors.Is(err, gs1.ErrDuplicateAI),
+			errors.Is(err, servicescan.ErrNoGTIN):
+			response.Fail(c, http.StatusBadRequest, err)
+		case errors.Is(err, repoitem.ErrItemNotFound):
+			response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to resolve scan")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to resolve scan"))
+		}
+
+		return
+	}
+
+	response.OK(c, result)
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	servicesearch "github.com/aliskhannn/warehouse-control/internal/service/search"
+)
+
+// service defines the interface for search service used by the handler.
+type service interface {
+	// Search finds items by words in their name or description, tolerating misspelled names.
+	Search(ctx context.Context, q string, limit int) ([]*model.ItemSearchResult, error)
+
+	// Suggest returns item names starting with prefix for typeahead.
+	Suggest(ctx context.Context, prefix string, limit int) ([]*model.ItemSuggestion, error)
+}
+
+// anonymousResult is a search result as shown to anonymous users, without its cost price.
+type anonymousResult struct {
+	*model.ItemSearchResult
+	CostPrice *decimal.Decimal `json:"cost_price,omitempty"`
+}
+
+// Handler provides HTTP handlers for item search endpoints.
+type Handler struct {
+	service service
+}
+
+// NewHandler creates a new search handler.
+func NewHandler(s service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// Search handles full-text and fuzzy item search by the q query parameter.
+func (h *Handler) Search(c *ginext.Context) {
+	limit, err := request.QueryIntOr(c, "limit", 0)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := h.service.Search(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		if errors.Is(err, servicesearch.ErrEmptyQuery) {
+			response.Fail(c, http.StatusBadRequest, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to search items")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to search items"))
+		return
+	}
+
+	if request.Authenticated(c) || results == nil {
+		response.OK(c, results)
+		return
+	}
+
+	// Anonymous users are not shown cost prices: the nil CostPrice hides that of the result.
+	shown := make([]anonymousResult, len(results))
+	for i, res := range results {
+		shown[i] = anonymousResult{ItemSearchResult: res}
+	}
+
+	response.OK(c, shown)
+}
+
+// Suggest handles typeahead suggestions by the prefix query parameter.
+func (h *Handler) Suggest(c *ginext.Context) {
+	limit, err := request.QueryIntOr(c, "limit", 0)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	suggestions, err := h.service.Suggest(c.Request.Context(), c.Query("prefix"), limit)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to suggest items")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to suggest items"))
+		return
+	}
+
+	response.OK(c, suggestions)
+}
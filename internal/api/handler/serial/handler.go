@@ -0,0 +1,52 @@
+package serial
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+// service defines the interface for serial service used by the handler.
+type service interface {
+	// Get retrieves a unit by its serial together with its lifecycle.
+	Get(ctx context.Context, serial string) (*model.Serial, error)
+}
+
+// Handler provides HTTP handlers for serial endpoints.
+type Handler struct {
+	service service
+}
+
+// NewHandler creates a new serial handler.
+func NewHandler(s service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// Get handles retrieving a unit of a serialized item and its lifecycle by its serial.
+func (h *Handler) Get(c *ginext.Context) {
+	serial := c.Param("serial")
+
+	unit, err := h.service.Get(c.Request.Context(), serial)
+	if err != nil {
+		if errors.Is(err, repoitem.ErrSerialNotFound) {
+			response.Fail(c, http.StatusNotFound, repoitem.ErrSerialNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Str("serial", serial).Msg("failed to get serial")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get serial"))
+		return
+	}
+
+	response.OK(c, unit)
+}
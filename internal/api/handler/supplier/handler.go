@@ -0,0 +1,251 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	reposupplier "github.com/aliskhannn/warehouse-control/internal/repository/supplier"
+	servicesupplier "github.com/aliskhannn/warehouse-control/internal/service/supplier"
+)
+
+// service defines the interface for supplier service used by the handler.
+type service interface {
+	// Create adds a new supplier.
+	Create(ctx context.Context, s *model.Supplier) (*model.Supplier, error)
+
+	// GetByID retrieves a supplier by its ID.
+	GetByID(ctx context.Context, supplierID uuid.UUID) (*model.Supplier, error)
+
+	// GetAll retrieves all suppliers.
+	GetAll(ctx context.Context) ([]*model.Supplier, error)
+
+	// Update changes the name, contact details and lead time of a supplier.
+	Update(ctx context.Context, s *model.Supplier) (*model.Supplier, error)
+
+	// SetItem records the SKU and unit cost an item is bought at from a supplier.
+	SetItem(ctx context.Context, supplierID, itemID uuid.UUID, sku string, cost decimal.Decimal) (*model.SupplierItem, error)
+
+	// DeleteItem removes an item from the items a supplier sells.
+	DeleteItem(ctx context.Context, supplierID, itemID uuid.UUID) error
+
+	// GetItems retrieves the items a supplier sells.
+	GetItems(ctx context.Context, supplierID uuid.UUID) ([]*model.SupplierItem, error)
+}
+
+// Handler provides HTTP handlers for supplier endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new supplier handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// SupplierRequest represents the JSON request body for creating or updating a supplier.
+type SupplierRequest struct {
+	Name         string `json:"name" validate:"required,max=255"`
+	ContactName  string `json:"contact_name"`
+	Email        string `json:"email" validate:"omitempty,email"`
+	Phone        string `json:"phone" validate:"max=32"`
+	LeadTimeDays int    `json:"lead_time_days" validate:"gte=0"`
+}
+
+// ItemRequest represents the JSON request body for setting the terms an item is bought at.
+type ItemRequest struct {
+	SupplierSKU string          `json:"supplier_sku" validate:"max=64"`
+	Cost        decimal.Decimal `json:"cost"`
+}
+
+// Create handles creating a new supplier.
+func (h *Handler) Create(c *ginext.Context) {
+	var req SupplierRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	s, err := h.service.Create(c.Request.Context(), req.supplier(uuid.Nil))
+	if err != nil {
+		failSupplier(c, err, "failed to create supplier")
+		return
+	}
+
+	response.Created(c, s)
+}
+
+// Update handles updating a supplier.
+func (h *Handler) Update(c *ginext.Context) {
+	supplierID, ok := getSupplierID(c)
+	if !ok {
+		return
+	}
+
+	var req SupplierRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	s, err := h.service.Update(c.Request.Context(), req.supplier(supplierID))
+	if err != nil {
+		failSupplier(c, err, "failed to update supplier")
+		return
+	}
+
+	response.OK(c, s)
+}
+
+// GetByID handles retrieving a supplier by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	supplierID, ok := getSupplierID(c)
+	if !ok {
+		return
+	}
+
+	s, err := h.service.GetByID(c.Request.Context(), supplierID)
+	if err != nil {
+		failSupplier(c, err, "failed to get supplier")
+		return
+	}
+
+	response.OK(c, s)
+}
+
+// GetAll handles retrieving all suppliers.
+func (h *Handler) GetAll(c *ginext.Context) {
+	suppliers, err := h.service.GetAll(c.Request.Context())
+	if err != nil {
+		failSupplier(c, err, "failed to get suppliers")
+		return
+	}
+
+	response.OK(c, suppliers)
+}
+
+// SetItem handles setting the supplier SKU and unit cost of an item.
+func (h *Handler) SetItem(c *ginext.Context) {
+	supplierID, itemID, ok := getSupplierItemIDs(c)
+	if !ok {
+		return
+	}
+
+	var req ItemRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	si, err := h.service.SetItem(c.Request.Context(), supplierID, itemID, req.SupplierSKU, req.Cost)
+	if err != nil {
+		failSupplier(c, err, "failed to set supplier item")
+		return
+	}
+
+	response.OK(c, si)
+}
+
+// DeleteItem handles removing an item from the items a supplier sells.
+func (h *Handler) DeleteItem(c *ginext.Context) {
+	supplierID, itemID, ok := getSupplierItemIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteItem(c.Request.Context(), supplierID, itemID); err != nil {
+		failSupplier(c, err, "failed to delete supplier item")
+		return
+	}
+
+	response.OK(c, map[string]string{"supplier_id": supplierID.String(), "item_id": itemID.String()})
+}
+
+// GetItems handles retrieving the items a supplier sells.
+func (h *Handler) GetItems(c *ginext.Context) {
+	supplierID, ok := getSupplierID(c)
+	if !ok {
+		return
+	}
+
+	items, err := h.service.GetItems(c.Request.Context(), supplierID)
+	if err != nil {
+		failSupplier(c, err, "failed to get supplier items")
+		return
+	}
+
+	response.OK(c, items)
+}
+
+// supplier builds the supplier described by the request.
+func (req SupplierRequest) supplier(supplierID uuid.UUID) *model.Supplier {
+	return &model.Supplier{
+		ID:           supplierID,
+		Name:         req.Name,
+		ContactName:  req.ContactName,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		LeadTimeDays: req.LeadTimeDays,
+	}
+}
+
+// failSupplier answers a failed supplier request: 404 for unknown suppliers, items and catalogue
+// entries, 409 for a name already taken and 400 for a negative cost.
+// Anything else is logged with msg and answered with 500.
+func failSupplier(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, reposupplier.ErrSupplierNotFound):
+		response.Fail(c, http.StatusNotFound, reposupplier.ErrSupplierNotFound)
+	case errors.Is(err, reposupplier.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, reposupplier.ErrItemNotFound)
+	case errors.Is(err, reposupplier.ErrSupplierItemNotFound):
+		response.Fail(c, http.StatusNotFound, reposupplier.ErrSupplierItemNotFound)
+	case errors.Is(err, reposupplier.ErrNameTaken):
+		response.Fail(c, http.StatusConflict, reposupplier.ErrNameTaken)
+	case errors.Is(err, servicesupplier.ErrNegativeCost):
+		response.Fail(c, http.StatusBadRequest, servicesupplier.ErrNegativeCost)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
+
+// getSupplierID parses the supplier ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getSupplierID(c *ginext.Context) (uuid.UUID, bool) {
+	supplierID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid supplier ID"))
+		return uuid.Nil, false
+	}
+
+	return supplierID, true
+}
+
+// getSupplierItemIDs parses the supplier and item IDs from the request parameters.
+// Returns false and automatically sends a response if either is invalid.
+func getSupplierItemIDs(c *ginext.Context) (uuid.UUID, uuid.UUID, bool) {
+	supplierID, ok := getSupplierID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid item ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return supplierID, itemID, true
+}
@@ -0,0 +1,262 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+	servicetransfer "github.com/aliskhannn/warehouse-control/internal/service/transfer"
+)
+
+// service defines the interface for transfer order service used by the handler.
+type service interface {
+	// Create adds a draft transfer order.
+	Create(ctx context.Context, userID uuid.UUID, o *model.TransferOrder) (*model.TransferOrder, error)
+
+	// GetByID retrieves a transfer order by its ID.
+	GetByID(ctx context.Context, orderID uuid.UUID) (*model.TransferOrder, error)
+
+	// GetAll retrieves the transfer orders matching filter.
+	GetAll(ctx context.Context, filter model.TransferFilter) ([]*model.TransferOrder, error)
+
+	// GetHistory retrieves the status changes of a transfer order.
+	GetHistory(ctx context.Context, orderID uuid.UUID) ([]*model.TransferEvent, error)
+
+	// Ship takes the stock of an order between warehouses out of its source.
+	Ship(ctx context.Context, userID, orderID uuid.UUID) (*model.TransferOrder, error)
+
+	// Receive brings the stock of an order to its destination.
+	Receive(ctx context.Context, userID, orderID uuid.UUID) (*model.TransferOrder, error)
+
+	// Cancel cancels an order that has not been received.
+	Cancel(ctx context.Context, userID, orderID uuid.UUID) (*model.TransferOrder, error)
+}
+
+// Handler provides HTTP handlers for transfer order endpoints.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new transfer order handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON request body for creating a transfer order.
+// Warehouses default to the default warehouse; locations are optional bin codes.
+type CreateRequest struct {
+	SourceWarehouseID      uuid.UUID     `json:"source_warehouse_id"`
+	SourceLocation         string        `json:"source_location"`
+	DestinationWarehouseID uuid.UUID     `json:"destination_warehouse_id"`
+	DestinationLocation    string        `json:"destination_location"`
+	Note                   string        `json:"note"`
+	Lines                  []LineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+// LineRequest represents one line of a transfer order.
+type LineRequest struct {
+	ItemID   uuid.UUID `json:"item_id" validate:"required"`
+	Quantity int       `json:"quantity" validate:"required,gt=0"`
+}
+
+// Create handles creating a draft transfer order.
+func (h *Handler) Create(c *ginext.Context) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	o := &model.TransferOrder{
+		SourceWarehouseID:      req.SourceWarehouseID,
+		SourceLocation:         req.SourceLocation,
+		DestinationWarehouseID: req.DestinationWarehouseID,
+		DestinationLocation:    req.DestinationLocation,
+		Note:                   req.Note,
+	}
+
+	for _, line := range req.Lines {
+		o.Lines = append(o.Lines, &model.TransferLine{ItemID: line.ItemID, Quantity: line.Quantity})
+	}
+
+	o, err := h.service.Create(c.Request.Context(), userID, o)
+	if err != nil {
+		failTransfer(c, err, "failed to create transfer order")
+		return
+	}
+
+	response.Created(c, o)
+}
+
+// GetByID handles retrieving a transfer order by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	o, err := h.service.GetByID(c.Request.Context(), orderID)
+	if err != nil {
+		failTransfer(c, err, "failed to get transfer order")
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// GetAll handles listing transfer orders, optionally filtered by ?status, ?item_id and ?warehouse_id.
+func (h *Handler) GetAll(c *ginext.Context) {
+	filter := model.TransferFilter{Status: model.TransferStatus(c.Query("status"))}
+
+	switch filter.Status {
+	case "", model.TransferDraft, model.TransferInTransit, model.TransferReceived, model.TransferCancelled:
+	default:
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid status"))
+		return
+	}
+
+	for param, id := range map[string]*uuid.UUID{"item_id": &filter.ItemID, "warehouse_id": &filter.WarehouseID} {
+		if value := c.Query(param); value != "" {
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid %s", param))
+				return
+			}
+
+			*id = parsed
+		}
+	}
+
+	orders, err := h.service.GetAll(c.Request.Context(), filter)
+	if err != nil {
+		failTransfer(c, err, "failed to get transfer orders")
+		return
+	}
+
+	response.OK(c, orders)
+}
+
+// GetHistory handles retrieving the status changes of a transfer order.
+func (h *Handler) GetHistory(c *ginext.Context) {
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetHistory(c.Request.Context(), orderID)
+	if err != nil {
+		failTransfer(c, err, "failed to get transfer order history")
+		return
+	}
+
+	response.OK(c, history)
+}
+
+// Ship handles shipping a transfer order between warehouses.
+func (h *Handler) Ship(c *ginext.Context) {
+	h.transition(c, h.service.Ship, "failed to ship transfer order")
+}
+
+// Receive handles receiving a transfer order at its destination.
+func (h *Handler) Receive(c *ginext.Context) {
+	h.transition(c, h.service.Receive, "failed to receive transfer order")
+}
+
+// Cancel handles cancelling a transfer order.
+func (h *Handler) Cancel(c *ginext.Context) {
+	h.transition(c, h.service.Cancel, "failed to cancel transfer order")
+}
+
+// transition applies a status change to the transfer order named in the request path.
+func (h *Handler) transition(
+	c *ginext.Context,
+	apply func(ctx context.Context, userID, orderID uuid.UUID) (*model.TransferOrder, error),
+	msg string,
+) {
+	userID, ok := request.UserID(c)
+	if !ok {
+		return
+	}
+
+	orderID, ok := getOrderID(c)
+	if !ok {
+		return
+	}
+
+	o, err := apply(c.Request.Context(), userID, orderID)
+	if err != nil {
+		failTransfer(c, err, msg)
+		return
+	}
+
+	response.OK(c, o)
+}
+
+// failTransfer answers a failed transfer order request: 404 for unknown orders and stock places,
+// 409 for status changes and moves that cannot go ahead and 400 for invalid lines.
+// Anything else is logged with msg and answered with 500.
+func failTransfer(c *ginext.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, repoitem.ErrTransferNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrTransferNotFound)
+	case errors.Is(err, servicetransfer.ErrInvalidTransition):
+		response.Fail(c, http.StatusConflict, servicetransfer.ErrInvalidTransition)
+	case errors.Is(err, repoitem.ErrTransferStatusChange):
+		response.Fail(c, http.StatusConflict, repoitem.ErrTransferStatusChange)
+	case errors.Is(err, repoitem.ErrInsufficientStock):
+		response.Fail(c, http.StatusConflict, repoitem.ErrInsufficientStock)
+	case errors.Is(err, servicetransfer.ErrNoLines):
+		response.Fail(c, http.StatusBadRequest, servicetransfer.ErrNoLines)
+	case errors.Is(err, servicetransfer.ErrInvalidQuantity):
+		response.Fail(c, http.StatusBadRequest, servicetransfer.ErrInvalidQuantity)
+	case errors.Is(err, servicetransfer.ErrDuplicateItem):
+		response.Fail(c, http.StatusBadRequest, servicetransfer.ErrDuplicateItem)
+	case errors.Is(err, repoitem.ErrTransferSamePlace):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrTransferSamePlace)
+	case errors.Is(err, repoitem.ErrSerializedTransfer):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrSerializedTransfer)
+	case errors.Is(err, repoitem.ErrSerialsRequired):
+		response.Fail(c, http.StatusConflict, repoitem.ErrSerialsRequired)
+	case errors.Is(err, repoitem.ErrNotABin):
+		response.Fail(c, http.StatusBadRequest, repoitem.ErrNotABin)
+	case errors.Is(err, repoitem.ErrItemNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrItemNotFound)
+	case errors.Is(err, repoitem.ErrWarehouseNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrWarehouseNotFound)
+	case errors.Is(err, repoitem.ErrLocationNotFound):
+		response.Fail(c, http.StatusNotFound, repoitem.ErrLocationNotFound)
+	default:
+		zlog.Logger.Error().Err(err).Msg(msg)
+		response.Fail(c, http.StatusInternalServerError, errors.New(msg))
+	}
+}
+
+// getOrderID parses the transfer order ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getOrderID(c *ginext.Context) (uuid.UUID, bool) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid transfer order ID"))
+		return uuid.Nil, false
+	}
+
+	return orderID, true
+}
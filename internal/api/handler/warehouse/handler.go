@@ -0,0 +1,222 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/request"
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repowarehouse "github.com/aliskhannn/warehouse-control/internal/repository/warehouse"
+)
+
+// service defines the interface for warehouse service used by the handler.
+type service interface {
+	// Create adds a new warehouse.
+	Create(ctx context.Context, code, name, address string) (*model.Warehouse, error)
+
+	// GetByID retrieves a warehouse by its ID.
+	GetByID(ctx context.Context, warehouseID uuid.UUID) (*model.Warehouse, error)
+
+	// GetAll retrieves all warehouses.
+	GetAll(ctx context.Context) ([]*model.Warehouse, error)
+
+	// Update changes the code, name and address of a warehouse.
+	Update(ctx context.Context, warehouseID uuid.UUID, code, name, address string) (*model.Warehouse, error)
+
+	// GetItems retrieves the quantity of each item held in a warehouse.
+	GetItems(ctx context.Context, warehouseID uuid.UUID) ([]*model.StockLevel, error)
+}
+
+// counts tells which items are being counted; it is implemented by the count service.
+type counts interface {
+	// Counted reports which of the items have a line in a count session that is still open.
+	Counted(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+// Handler provides HTTP handlers for warehouse endpoints.
+type Handler struct {
+	service   service
+	counts    counts
+	validator *validator.Validate
+}
+
+// NewHandler creates a new warehouse handler.
+func NewHandler(s service, n counts, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		counts:    n,
+		validator: v,
+	}
+}
+
+// WarehouseRequest represents the JSON request body for creating or updating a warehouse.
+type WarehouseRequest struct {
+	Code    string `json:"code" validate:"required,max=32"`
+	Name    string `json:"name" validate:"required"`
+	Address string `json:"address"`
+}
+
+// Create handles creating a new warehouse.
+func (h *Handler) Create(c *ginext.Context) {
+	var req WarehouseRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	w, err := h.service.Create(c.Request.Context(), req.Code, req.Name, req.Address)
+	if err != nil {
+		if errors.Is(err, repowarehouse.ErrCodeTaken) {
+			response.Fail(c, http.StatusConflict, repowarehouse.ErrCodeTaken)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to create warehouse")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to create warehouse"))
+		return
+	}
+
+	response.Created(c, w)
+}
+
+// Update handles updating a warehouse.
+func (h *Handler) Update(c *ginext.Context) {
+	warehouseID, ok := getWarehouseID(c)
+	if !ok {
+		return
+	}
+
+	var req WarehouseRequest
+	if !request.Bind(c, h.validator, &req) {
+		return
+	}
+
+	w, err := h.service.Update(c.Request.Context(), warehouseID, req.Code, req.Name, req.Address)
+	if err != nil {
+		switch {
+		case errors.Is(err, repowarehouse.ErrWarehouseNotFound):
+			response.Fail(c, http.StatusNotFound, repowarehouse.ErrWarehouseNotFound)
+		case errors.Is(err, repowarehouse.ErrCodeTaken):
+			response.Fail(c, http.StatusConflict, repowarehouse.ErrCodeTaken)
+		default:
+			zlog.Logger.Error().Err(err).Msg("failed to update warehouse")
+			response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to update warehouse"))
+		}
+
+		return
+	}
+
+	response.OK(c, w)
+}
+
+// GetByID handles retrieving a warehouse by ID.
+func (h *Handler) GetByID(c *ginext.Context) {
+	warehouseID, ok := getWarehouseID(c)
+	if !ok {
+		return
+	}
+
+	w, err := h.service.GetByID(c.Request.Context(), warehouseID)
+	if err != nil {
+		if errors.Is(err, repowarehouse.ErrWarehouseNotFound) {
+			response.Fail(c, http.StatusNotFound, repowarehouse.ErrWarehouseNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to get warehouse")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get warehouse"))
+		return
+	}
+
+	response.OK(c, w)
+}
+
+// GetAll handles retrieving all warehouses.
+func (h *Handler) GetAll(c *ginext.Context) {
+	warehouses, err := h.service.GetAll(c.Request.Context())
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to get warehouses")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get warehouses"))
+		return
+	}
+
+	response.OK(c, warehouses)
+}
+
+// GetItems handles retrieving the stock held in a warehouse.
+// The quantities of items being counted are left out for users who count blind.
+func (h *Handler) GetItems(c *ginext.Context) {
+	warehouseID, ok := getWarehouseID(c)
+	if !ok {
+		return
+	}
+
+	levels, err := h.service.GetItems(c.Request.Context(), warehouseID)
+	if err != nil {
+		if errors.Is(err, repowarehouse.ErrWarehouseNotFound) {
+			response.Fail(c, http.StatusNotFound, repowarehouse.ErrWarehouseNotFound)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("failed to get warehouse items")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get warehouse items"))
+		return
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(levels))
+	for _, level := range levels {
+		itemIDs = append(itemIDs, level.ItemID)
+	}
+
+	counted, ok := request.Counted(c, h.counts.Counted, itemIDs...)
+	if !ok {
+		return
+	}
+
+	response.OK(c, shownLevels(levels, counted))
+}
+
+// hiddenLevel is stock with its quantities, nil while the item is being counted, hiding those
+// of the embedded stock.
+type hiddenLevel struct {
+	*model.StockLevel
+	Quantity *int `json:"quantity,omitempty"`
+	Reserved *int `json:"reserved,omitempty"`
+}
+
+// shownLevels returns stock as the user may see it: without the quantities of counted, the
+// items being counted for a user who counts blind (see request.Counted).
+func shownLevels(levels []*model.StockLevel, counted map[uuid.UUID]bool) interface{} {
+	if len(counted) == 0 {
+		return levels
+	}
+
+	shown := make([]hiddenLevel, len(levels))
+	for i, level := range levels {
+		shown[i].StockLevel = level
+		if !counted[level.ItemID] {
+			shown[i].Quantity, shown[i].Reserved = &level.Quantity, &level.Reserved
+		}
+	}
+
+	return shown
+}
+
+// getWarehouseID parses the warehouse ID from the request parameters.
+// Returns false and automatically sends a response if it is invalid.
+func getWarehouseID(c *ginext.Context) (uuid.UUID, bool) {
+	warehouseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid warehouse ID"))
+		return uuid.Nil, false
+	}
+
+	return warehouseID, true
+}
@@ -0,0 +1,68 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+)
+
+// Bind binds the JSON request body into req and validates it with v.
+// Returns false and automatically sends a response if the body is invalid.
+func Bind(c *ginext.Context, v *validator.Validate, req interface{}) bool {
+	return bind(c, v, req, false)
+}
+
+// BindOptional is Bind for requests whose body may be left out: an empty body leaves req as
+// it is, and it is validated all the same.
+func BindOptional(c *ginext.Context, v *validator.Validate, req interface{}) bool {
+	return bind(c, v, req, true)
+}
+
+// bind binds and validates req, accepting an empty body if optional is set.
+func bind(c *ginext.Context, v *validator.Validate, req interface{}, optional bool) bool {
+	if err := c.ShouldBindJSON(req); err != nil && !(optional && errors.Is(err, io.EOF)) {
+		zlog.Logger.Error().Err(err).Msg("failed to bind JSON")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return false
+	}
+
+	if err := v.Struct(req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("validation failed")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("validation error: %s", err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// UserID reads the authenticated user's ID from the context.
+// Returns false and automatically sends a response if it is missing.
+func UserID(c *ginext.Context) (uuid.UUID, bool) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		response.Fail(c, http.StatusUnauthorized, fmt.Errorf("userID not found in context"))
+		return uuid.Nil, false
+	}
+
+	userID, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		response.Fail(c, http.StatusUnauthorized, fmt.Errorf("invalid userID type"))
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// Authenticated reports whether the request was made by a signed-in user.
+func Authenticated(c *ginext.Context) bool {
+	_, ok := c.Get("userID")
+	return ok
+}
@@ -0,0 +1,42 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/api/response"
+)
+
+// Blind reports whether the user must not see the quantities of items that are being counted:
+// everyone but admins and managers, so that viewers count without being told what to find.
+func Blind(c *ginext.Context) bool {
+	role := c.GetString("role")
+	return role != "admin" && role != "manager"
+}
+
+// Counted looks up with counted which of the items are being counted if the user counts blind,
+// and returns nil if the user may see every quantity.
+// Returns false and automatically sends a response if they cannot be looked up.
+func Counted(
+	c *ginext.Context,
+	counted func(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error),
+	itemIDs ...uuid.UUID,
+) (map[uuid.UUID]bool, bool) {
+	if !Blind(c) || len(itemIDs) == 0 {
+		return nil, true
+	}
+
+	items, err := counted(c.Request.Context(), itemIDs)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to get counted items")
+		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("failed to get counted items"))
+		return nil, false
+	}
+
+	return items, true
+}
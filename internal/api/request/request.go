@@ -0,0 +1,124 @@
+// Package request provides helpers for parsing requests and reading the authenticated user.
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+)
+
+// QueryInt parses an optional integer query parameter. Returns nil if the parameter is absent.
+func QueryInt(c *ginext.Context, key string) (*int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", key)
+	}
+
+	return &v, nil
+}
+
+// QueryIntOr parses an optional integer query parameter. Returns def if the parameter is absent.
+func QueryIntOr(c *ginext.Context, key string, def int) (int, error) {
+	v, err := QueryInt(c, key)
+	if err != nil || v == nil {
+		return def, err
+	}
+
+	return *v, nil
+}
+
+// QueryDecimal parses an optional decimal query parameter. Returns nil if the parameter is absent.
+func QueryDecimal(c *ginext.Context, key string) (*decimal.Decimal, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	v, err := decimal.NewFromString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+
+	return &v, nil
+}
+
+// QueryTime parses an optional RFC 3339 time query parameter. Returns nil if the parameter is absent.
+func QueryTime(c *ginext.Context, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	v, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 time", key)
+	}
+
+	return &v, nil
+}
+
+// QueryUUID parses an optional UUID query parameter. Returns uuid.Nil if the parameter is absent.
+func QueryUUID(c *ginext.Context, key string) (uuid.UUID, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return uuid.Nil, nil
+	}
+
+	v, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s must be a UUID", key)
+	}
+
+	return v, nil
+}
+
+// QueryDuration parses an optional duration query parameter such as "30d" or "12h".
+// Besides the units of time.ParseDuration it accepts whole days with a "d" suffix.
+// Returns def if the parameter is absent.
+func QueryDuration(c *ginext.Context, key string, def time.Duration) (time.Duration, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return def, nil
+	}
+
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%s must be a duration such as 30d", key)
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	v, err := time.ParseDuration(raw)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%s must be a duration such as 30d", key)
+	}
+
+	return v, nil
+}
+
+// QueryBool parses an optional boolean query parameter. Returns nil if the parameter is absent.
+func QueryBool(c *ginext.Context, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", key)
+	}
+
+	return &v, nil
+}
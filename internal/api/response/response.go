@@ -7,7 +7,8 @@ import (
 )
 
 type Success struct {
-	Result interface{} `json:"result"`
+	Result     interface{} `json:"result"`
+	NextCursor string      `json:"next_cursor,omitempty"` // set on paginated lists that have more results
 }
 
 type Error struct {
@@ -24,6 +25,11 @@ func OK(c *ginext.Context, result interface{}) {
 	JSON(c, http.StatusOK, Success{Result: result})
 }
 
+// Page sends a 200 OK response with one page of a list and the cursor of the next page.
+func Page(c *ginext.Context, result interface{}, nextCursor string) {
+	JSON(c, http.StatusOK, Success{Result: result, NextCursor: nextCursor})
+}
+
 // Created sends a 201 Created response
 func Created(c *ginext.Context, result interface{}) {
 	JSON(c, http.StatusCreated, Success{Result: result})
@@ -4,10 +4,27 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/wb-go/wbf/ginext"
 
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/adjustment"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/alert"
 	"github.com/aliskhannn/warehouse-control/internal/api/handler/audit"
 	"github.com/aliskhannn/warehouse-control/internal/api/handler/auth"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/count"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/currency"
 	"github.com/aliskhannn/warehouse-control/internal/api/handler/item"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/location"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/lot"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/purchase"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/receipt"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/report"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/reservation"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/sales"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/scan"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/search"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/serial"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/supplier"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/transfer"
 	"github.com/aliskhannn/warehouse-control/internal/api/handler/user"
+	"github.com/aliskhannn/warehouse-control/internal/api/handler/warehouse"
 	"github.com/aliskhannn/warehouse-control/internal/config"
 	"github.com/aliskhannn/warehouse-control/internal/middleware"
 )
@@ -18,18 +35,36 @@ func New(
 	userHandler *user.Handler,
 	itemHandler *item.Handler,
 	auditHandler *audit.Handler,
+	searchHandler *search.Handler,
+	scanHandler *scan.Handler,
+	warehouseHandler *warehouse.Handler,
+	locationHandler *location.Handler,
+	transferHandler *transfer.Handler,
+	lotHandler *lot.Handler,
+	reportHandler *report.Handler,
+	serialHandler *serial.Handler,
+	alertHandler *alert.Handler,
+	supplierHandler *supplier.Handler,
+	purchaseHandler *purchase.Handler,
+	receiptHandler *receipt.Handler,
+	salesHandler *sales.Handler,
+	reservationHandler *reservation.Handler,
+	countHandler *count.Handler,
+	adjustmentHandler *adjustment.Handler,
+	currencyHandler *currency.Handler,
 	cfg *config.Config,
 ) *ginext.Engine {
 	e := ginext.New()
 
 	e.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", middleware.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
+	e.Use(middleware.RequestID())
 	e.Use(ginext.Logger())
 	e.Use(ginext.Recovery())
 
@@ -45,9 +80,14 @@ func New(
 		// --- Item routes ---
 		itemGroup := api.Group("/items")
 		{
-			// Public GET routes (all roles).
-			itemGroup.GET("", itemHandler.GetAll)
-			itemGroup.GET("/:id", itemHandler.GetByID)
+			// Public GET routes (all roles). Anonymous users are not shown cost prices.
+			optionalAuth := middleware.OptionalAuth(cfg.JWT.Secret, cfg.JWT.TTL)
+			itemGroup.GET("", optionalAuth, itemHandler.GetAll)
+			itemGroup.GET("/search", optionalAuth, searchHandler.Search)
+			itemGroup.GET("/suggest", searchHandler.Suggest)
+			itemGroup.GET("/by-barcode/:code", optionalAuth, itemHandler.GetByBarcode)
+			itemGroup.GET("/by-sku/:sku", optionalAuth, itemHandler.GetBySKU)
+			itemGroup.GET("/:id", optionalAuth, itemHandler.GetByID)
 
 			// Protected routes (requires JWT).
 			itemGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
@@ -58,8 +98,40 @@ func New(
 				// PUT /items/:id: admin and manager.
 				itemGroup.PUT("/:id", middleware.RequireRole("admin", "manager"), itemHandler.Update)
 
+				// PATCH /items/:id: admin and manager.
+				itemGroup.PATCH("/:id", middleware.RequireRole("admin", "manager"), itemHandler.Patch)
+
 				// DELETE /items/:id: admin only.
 				itemGroup.DELETE("/:id", middleware.RequireRole("admin"), itemHandler.Delete)
+
+				// POST /items/:id/movements: admin and manager.
+				itemGroup.POST("/:id/movements", middleware.RequireRole("admin", "manager"), itemHandler.CreateMovement)
+
+				// POST /items/:id/stock/increment and /decrement: admin and manager.
+				itemGroup.POST("/:id/stock/increment", middleware.RequireRole("admin", "manager"), itemHandler.Increment)
+				itemGroup.POST("/:id/stock/decrement", middleware.RequireRole("admin", "manager"), itemHandler.Decrement)
+
+				// GET /items/:id/movements: all roles.
+				itemGroup.GET("/:id/movements", middleware.RequireRole("admin", "manager", "viewer"), itemHandler.GetMovements)
+
+				// GET /items/:id/stock: all roles.
+				itemGroup.GET("/:id/stock", middleware.RequireRole("admin", "manager", "viewer"), itemHandler.GetStock)
+
+				// GET /items/:id/prices: all roles.
+				itemGroup.GET("/:id/prices", middleware.RequireRole("admin", "manager", "viewer"), itemHandler.GetPrices)
+
+				// GET /items/:id/lots and /items/:id/pick: all roles.
+				itemGroup.GET("/:id/lots", middleware.RequireRole("admin", "manager", "viewer"), lotHandler.GetByItem)
+				itemGroup.GET("/:id/pick", middleware.RequireRole("admin", "manager", "viewer"), lotHandler.SuggestPick)
+
+				// POST /items/:id/lots: admin and manager.
+				itemGroup.POST("/:id/lots", middleware.RequireRole("admin", "manager"), lotHandler.Create)
+
+				// GET /items/:id/reservations: all roles.
+				itemGroup.GET("/:id/reservations", middleware.RequireRole("admin", "manager", "viewer"), reservationHandler.GetByItem)
+
+				// POST /items/:id/reservations: admin and manager.
+				itemGroup.POST("/:id/reservations", middleware.RequireRole("admin", "manager"), reservationHandler.Create)
 			}
 		}
 
@@ -79,6 +151,218 @@ func New(
 			auditGroup.GET("/items/:id/history", auditHandler.GetHistory)
 			auditGroup.POST("/items/compare", auditHandler.CompareVersions)
 		}
+
+		// --- Warehouse routes ---
+		warehouseGroup := api.Group("/warehouses")
+		warehouseGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /warehouses, /warehouses/:id and /warehouses/:id/items: all roles.
+			warehouseGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), warehouseHandler.GetAll)
+			warehouseGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), warehouseHandler.GetByID)
+			warehouseGroup.GET("/:id/items", middleware.RequireRole("admin", "manager", "viewer"), warehouseHandler.GetItems)
+
+			// POST /warehouses and PUT /warehouses/:id: admin only.
+			warehouseGroup.POST("", middleware.RequireRole("admin"), warehouseHandler.Create)
+			warehouseGroup.PUT("/:id", middleware.RequireRole("admin"), warehouseHandler.Update)
+		}
+
+		// --- Adjustment reason routes ---
+		adjustmentGroup := api.Group("/adjustment-reasons")
+		adjustmentGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /adjustment-reasons?active=: all roles.
+			adjustmentGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), adjustmentHandler.GetAll)
+
+			// POST /adjustment-reasons and PUT /adjustment-reasons/:code: admin only.
+			adjustmentGroup.POST("", middleware.RequireRole("admin"), adjustmentHandler.Create)
+			adjustmentGroup.PUT("/:code", middleware.RequireRole("admin"), adjustmentHandler.Update)
+		}
+
+		// --- Exchange rate routes ---
+		rateGroup := api.Group("/exchange-rates")
+		rateGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /exchange-rates?currency=: all roles.
+			rateGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), currencyHandler.GetAll)
+
+			// POST /exchange-rates and DELETE /exchange-rates/:id: admin only.
+			rateGroup.POST("", middleware.RequireRole("admin"), currencyHandler.Create)
+			rateGroup.DELETE("/:id", middleware.RequireRole("admin"), currencyHandler.Delete)
+		}
+
+		// --- Location routes ---
+		locationGroup := api.Group("/locations")
+		locationGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /locations?warehouse_id=, /locations/:id and /locations/:id/items: all roles.
+			locationGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), locationHandler.GetTree)
+			locationGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), locationHandler.GetByID)
+			locationGroup.GET("/:id/items", middleware.RequireRole("admin", "manager", "viewer"), locationHandler.GetItems)
+
+			// POST /locations, PUT and DELETE /locations/:id: admin and manager.
+			locationGroup.POST("", middleware.RequireRole("admin", "manager"), locationHandler.Create)
+			locationGroup.PUT("/:id", middleware.RequireRole("admin", "manager"), locationHandler.Update)
+			locationGroup.DELETE("/:id", middleware.RequireRole("admin", "manager"), locationHandler.Delete)
+		}
+
+		// --- Transfer order routes ---
+		transferGroup := api.Group("/transfers")
+		transferGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /transfers and /transfers/:id: all roles.
+			transferGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), transferHandler.GetAll)
+			transferGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), transferHandler.GetByID)
+
+			// GET /transfers/:id/history: admin and manager.
+			transferGroup.GET("/:id/history", middleware.RequireRole("admin", "manager"), transferHandler.GetHistory)
+
+			// POST /transfers and status changes: admin and manager.
+			transferGroup.POST("", middleware.RequireRole("admin", "manager"), transferHandler.Create)
+			transferGroup.POST("/:id/ship", middleware.RequireRole("admin", "manager"), transferHandler.Ship)
+			transferGroup.POST("/:id/receive", middleware.RequireRole("admin", "manager"), transferHandler.Receive)
+			transferGroup.POST("/:id/cancel", middleware.RequireRole("admin", "manager"), transferHandler.Cancel)
+		}
+
+		// --- Supplier routes ---
+		supplierGroup := api.Group("/suppliers")
+		supplierGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /suppliers, /suppliers/:id and /suppliers/:id/items: all roles.
+			supplierGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), supplierHandler.GetAll)
+			supplierGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), supplierHandler.GetByID)
+			supplierGroup.GET("/:id/items", middleware.RequireRole("admin", "manager", "viewer"), supplierHandler.GetItems)
+
+			// POST /suppliers, PUT /suppliers/:id and supplier items: admin and manager.
+			supplierGroup.POST("", middleware.RequireRole("admin", "manager"), supplierHandler.Create)
+			supplierGroup.PUT("/:id", middleware.RequireRole("admin", "manager"), supplierHandler.Update)
+			supplierGroup.PUT("/:id/items/:item_id", middleware.RequireRole("admin", "manager"), supplierHandler.SetItem)
+			supplierGroup.DELETE("/:id/items/:item_id", middleware.RequireRole("admin", "manager"), supplierHandler.DeleteItem)
+		}
+
+		// --- Purchase order routes ---
+		purchaseGroup := api.Group("/purchase-orders")
+		purchaseGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /purchase-orders and /purchase-orders/:id: all roles.
+			purchaseGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), purchaseHandler.GetAll)
+			purchaseGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), purchaseHandler.GetByID)
+
+			// POST /purchase-orders and status changes: admin and manager.
+			purchaseGroup.POST("", middleware.RequireRole("admin", "manager"), purchaseHandler.Create)
+			purchaseGroup.POST("/:id/submit", middleware.RequireRole("admin", "manager"), purchaseHandler.Submit)
+			purchaseGroup.POST("/:id/receive", middleware.RequireRole("admin", "manager"), purchaseHandler.Receive)
+			purchaseGroup.POST("/:id/cancel", middleware.RequireRole("admin", "manager"), purchaseHandler.Cancel)
+		}
+
+		// --- Goods receipt routes ---
+		receiptGroup := api.Group("/goods-receipts")
+		receiptGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /goods-receipts and /goods-receipts/:id: all roles.
+			receiptGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), receiptHandler.GetAll)
+			receiptGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), receiptHandler.GetByID)
+
+			// POST /goods-receipts, posting, reversal and DELETE /goods-receipts/:id: admin and manager.
+			receiptGroup.POST("", middleware.RequireRole("admin", "manager"), receiptHandler.Create)
+			receiptGroup.POST("/:id/post", middleware.RequireRole("admin", "manager"), receiptHandler.Post)
+			receiptGroup.POST("/:id/reverse", middleware.RequireRole("admin", "manager"), receiptHandler.Reverse)
+			receiptGroup.DELETE("/:id", middleware.RequireRole("admin", "manager"), receiptHandler.Delete)
+		}
+
+		// --- Discrepancy routes ---
+		discrepancyGroup := api.Group("/discrepancies")
+		discrepancyGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL), middleware.RequireRole("admin", "manager"))
+		{
+			// GET /discrepancies and POST /discrepancies/:id/resolve: admin and manager.
+			discrepancyGroup.GET("", receiptHandler.GetDiscrepancies)
+			discrepancyGroup.POST("/:id/resolve", receiptHandler.ResolveDiscrepancy)
+		}
+
+		// --- Sales order routes ---
+		salesGroup := api.Group("/sales-orders")
+		salesGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /sales-orders, /sales-orders/:id and /sales-orders/:id/pick-list: all roles.
+			salesGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), salesHandler.GetAll)
+			salesGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), salesHandler.GetByID)
+			salesGroup.GET("/:id/pick-list", middleware.RequireRole("admin", "manager", "viewer"), salesHandler.GetPickList)
+
+			// POST /sales-orders and the workflow steps: admin and manager.
+			salesGroup.POST("", middleware.RequireRole("admin", "manager"), salesHandler.Create)
+			salesGroup.POST("/:id/allocate", middleware.RequireRole("admin", "manager"), salesHandler.Allocate)
+			salesGroup.POST("/:id/pick", middleware.RequireRole("admin", "manager"), salesHandler.Pick)
+			salesGroup.POST("/:id/pack", middleware.RequireRole("admin", "manager"), salesHandler.Pack)
+			salesGroup.POST("/:id/ship", middleware.RequireRole("admin", "manager"), salesHandler.Ship)
+			salesGroup.POST("/:id/cancel", middleware.RequireRole("admin", "manager"), salesHandler.Cancel)
+		}
+
+		// --- Reservation routes ---
+		// DELETE /api/reservations/:id: admin and manager.
+		api.DELETE("/reservations/:id",
+			middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL),
+			middleware.RequireRole("admin", "manager"),
+			reservationHandler.Release,
+		)
+
+		// --- Count session routes ---
+		countGroup := api.Group("/counts")
+		countGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /counts?status= and /counts/:id: all roles; viewers see sessions blind.
+			countGroup.GET("", middleware.RequireRole("admin", "manager", "viewer"), countHandler.GetAll)
+			countGroup.GET("/:id", middleware.RequireRole("admin", "manager", "viewer"), countHandler.GetByID)
+
+			// POST /counts/:id/counts and /counts/:id/submit: all roles.
+			countGroup.POST("/:id/counts", middleware.RequireRole("admin", "manager", "viewer"), countHandler.Record)
+			countGroup.POST("/:id/submit", middleware.RequireRole("admin", "manager", "viewer"), countHandler.Submit)
+
+			// POST /counts and the review steps: admin and manager.
+			countGroup.POST("", middleware.RequireRole("admin", "manager"), countHandler.Create)
+			countGroup.POST("/:id/approve", middleware.RequireRole("admin", "manager"), countHandler.Approve)
+			countGroup.POST("/:id/reject", middleware.RequireRole("admin", "manager"), countHandler.Reject)
+			countGroup.POST("/:id/cancel", middleware.RequireRole("admin", "manager"), countHandler.Cancel)
+		}
+
+		// --- Serial routes ---
+		// GET /api/serials/:serial: all roles.
+		api.GET("/serials/:serial",
+			middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL),
+			middleware.RequireRole("admin", "manager", "viewer"),
+			serialHandler.Get,
+		)
+
+		// --- Alert routes ---
+		alertGroup := api.Group("/alerts")
+		alertGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /alerts/low-stock: all roles.
+			alertGroup.GET("/low-stock", middleware.RequireRole("admin", "manager", "viewer"), alertHandler.GetLowStock)
+
+			// POST /alerts/low-stock/:id/acknowledge: admin and manager.
+			alertGroup.POST("/low-stock/:id/acknowledge", middleware.RequireRole("admin", "manager"), alertHandler.Acknowledge)
+		}
+
+		// --- Report routes ---
+		reportGroup := api.Group("/reports")
+		reportGroup.Use(middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL))
+		{
+			// GET /reports/expiring?within=: all roles.
+			reportGroup.GET("/expiring", middleware.RequireRole("admin", "manager", "viewer"), reportHandler.Expiring)
+
+			// GET /reports/write-offs?from=&to=&period=&warehouse_id=: admin and manager.
+			reportGroup.GET("/write-offs", middleware.RequireRole("admin", "manager"), reportHandler.WriteOffs)
+
+			// GET /reports/valuation?as_of=: admin and manager.
+			reportGroup.GET("/valuation", middleware.RequireRole("admin", "manager"), reportHandler.Valuation)
+		}
+
+		// --- Scan routes ---
+		// POST /api/scan: all roles.
+		api.POST("/scan",
+			middleware.Auth(cfg.JWT.Secret, cfg.JWT.TTL),
+			middleware.RequireRole("admin", "manager", "viewer"),
+			scanHandler.Scan,
+		)
 	}
 
 	return e
@@ -0,0 +1,160 @@
+// Package audit records item changes from the application instead of database triggers.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// Audit modes selectable in the configuration.
+const (
+	// ModeTrigger lets the database triggers write item history.
+	ModeTrigger = "trigger"
+
+	// ModeApp lets the service write item history through an AuditWriter.
+	ModeApp = "app"
+)
+
+// Actor describes who performed a change and where the request came from.
+type Actor struct {
+	UserID    uuid.UUID
+	Role      string
+	RequestID string
+	ClientIP  string
+}
+
+// actorKey is the context key under which the Actor is stored.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFromContext returns the actor stored in ctx, if any.
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	a, ok := ctx.Value(actorKey{}).(Actor)
+	return a, ok
+}
+
+// Entry is a single change of an item to be recorded.
+// OldData is nil for inserts and NewData is nil for deletes.
+type Entry struct {
+	ItemID  uuid.UUID
+	Action  model.ItemAction
+	Actor   Actor
+	OldData json.RawMessage
+	NewData json.RawMessage
+}
+
+// AuditWriter records item changes. Write must be called with the context of the
+// transaction that made the change, so the entry is committed or rolled back with it.
+type AuditWriter interface {
+	Write(ctx context.Context, e Entry) error
+}
+
+// store persists item history rows.
+type store interface {
+	// InsertItemHistory adds a row to item_history.
+	InsertItemHistory(ctx context.Context, h *model.ItemHistory) error
+}
+
+// Writer is an AuditWriter that stores entries in item_history.
+type Writer struct {
+	store store
+}
+
+// NewWriter creates a new audit writer backed by the given store.
+func NewWriter(s store) *Writer {
+	return &Writer{store: s}
+}
+
+// Write computes the diff of the entry and stores it as an item history row.
+func (w *Writer) Write(ctx context.Context, e Entry) error {
+	diff, err := Diff(e.OldData, e.NewData)
+	if err != nil {
+		return fmt.Errorf("compute diff: %w", err)
+	}
+
+	h := &model.ItemHistory{
+		ItemID:    e.ItemID,
+		Action:    e.Action,
+		ChangedBy: e.Actor.UserID,
+		ActorRole: e.Actor.Role,
+		RequestID: e.Actor.RequestID,
+		ClientIP:  e.Actor.ClientIP,
+		OldData:   e.OldData,
+		NewData:   e.NewData,
+		Diff:      diff,
+	}
+
+	if err := w.store.InsertItemHistory(ctx, h); err != nil {
+		return fmt.Errorf("insert item history: %w", err)
+	}
+
+	return nil
+}
+
+// Diff returns the fields that differ between two JSON objects as
+// {"field": {"old": <old value>, "new": <new value>}}, or nil if nothing changed.
+// It matches the item_history_diff SQL function used by the triggers.
+func Diff(oldData, newData json.RawMessage) (json.RawMessage, error) {
+	oldMap, err := decodeObject(oldData)
+	if err != nil {
+		return nil, fmt.Errorf("decode old data: %w", err)
+	}
+
+	newMap, err := decodeObject(newData)
+	if err != nil {
+		return nil, fmt.Errorf("decode new data: %w", err)
+	}
+
+	type change struct {
+		Old interface{} `json:"old"`
+		New interface{} `json:"new"`
+	}
+
+	diff := make(map[string]change)
+
+	for key, oldVal := range oldMap {
+		if newVal, ok := newMap[key]; !ok || !reflect.DeepEqual(oldVal, newVal) {
+			diff[key] = change{Old: oldVal, New: newMap[key]}
+		}
+	}
+
+	for key, newVal := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			diff[key] = change{Old: nil, New: newVal}
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(diff)
+}
+
+// decodeObject decodes a JSON object keeping numbers exact. Empty input yields an empty map.
+func decodeObject(data json.RawMessage) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if len(data) == 0 || string(data) == "null" {
+		return m, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -10,9 +10,14 @@ import (
 )
 
 type Config struct {
-	Server   Server   `mapstructure:"server"`
-	Database Database `mapstructure:"database"`
-	JWT      JWT      `mapstructure:"jwt"`
+	Server       Server       `mapstructure:"server"`
+	Database     Database     `mapstructure:"database"`
+	JWT          JWT          `mapstructure:"jwt"`
+	Audit        Audit        `mapstructure:"audit"`
+	Alerts       Alerts       `mapstructure:"alerts"`
+	Reservations Reservations `mapstructure:"reservations"`
+	Pricing      Pricing      `mapstructure:"pricing"`
+	Currencies   Currencies   `mapstructure:"currencies"`
 }
 
 // Server holds HTTP server-related configuration.
@@ -56,6 +61,40 @@ type JWT struct {
 	TTL    time.Duration `mapstructure:"ttl"`
 }
 
+// Audit holds item history configuration.
+type Audit struct {
+	Mode string `mapstructure:"mode"` // "trigger" (database triggers) or "app" (application writes history)
+}
+
+// Alerts holds low-stock alert configuration.
+type Alerts struct {
+	CheckInterval time.Duration `mapstructure:"check_interval"` // how often all items are checked, besides after each change
+}
+
+// Reservations holds stock reservation configuration.
+type Reservations struct {
+	DefaultTTL     time.Duration `mapstructure:"default_ttl"`     // how long a reservation holds stock when no TTL is given
+	ExpiryInterval time.Duration `mapstructure:"expiry_interval"` // how often expired reservations are released
+}
+
+// Pricing holds item price configuration.
+type Pricing struct {
+	MaxChangePercent float64 `mapstructure:"max_change_percent"` // largest price change, in percent, made without the admin role
+}
+
+// Currencies holds the base currency, in which prices without a currency are kept and stock is
+// valued, and how amounts converted into each currency are rounded.
+type Currencies struct {
+	Base     string              `mapstructure:"base"`     // ISO 4217 code
+	Rounding map[string]Rounding `mapstructure:"rounding"` // by currency code; others round half up to 2 places
+}
+
+// Rounding holds the rounding rule of one currency.
+type Rounding struct {
+	Places int32  `mapstructure:"places"` // decimal places kept
+	Mode   string `mapstructure:"mode"`   // "half_up", "half_even", "down" or "up"
+}
+
 func MustLoad() *Config {
 	v := viper.New()
 	v.SetConfigName("config")
@@ -76,5 +115,13 @@ func MustLoad() *Config {
 
 	cfg.JWT.Secret = os.Getenv("JWT_SECRET")
 
+	if cfg.Audit.Mode == "" {
+		cfg.Audit.Mode = "trigger"
+	}
+
+	if cfg.Currencies.Base == "" {
+		cfg.Currencies.Base = "RUB"
+	}
+
 	return &cfg
 }
@@ -0,0 +1,136 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rounding modes, the way converted amounts are rounded to the places of their currency.
+const (
+	HalfUp   = "half_up"   // halves away from zero
+	HalfEven = "half_even" // halves to the even neighbour (banker's rounding)
+	Down     = "down"      // towards zero
+	Up       = "up"        // away from zero
+)
+
+var (
+	ErrInvalidCode     = errors.New("currency must be a three-letter ISO 4217 code")
+	ErrInvalidRounding = errors.New("rounding mode must be half_up, half_even, down or up")
+	ErrRateNotFound    = errors.New("no exchange rate in effect for the currency")
+)
+
+// DefaultRounding is how amounts in currencies without a rounding rule are rounded.
+var DefaultRounding = Rounding{Places: 2, Mode: HalfUp}
+
+// Rounding is how amounts in one currency are rounded: to Places decimal places in Mode.
+type Rounding struct {
+	Places int32
+	Mode   string
+}
+
+// Validate returns ErrInvalidRounding unless r has a known mode and does not round to a
+// negative number of places.
+func (r Rounding) Validate() error {
+	switch r.Mode {
+	case HalfUp, HalfEven, Down, Up:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidRounding, r.Mode)
+	}
+
+	if r.Places < 0 {
+		return fmt.Errorf("%w: places must not be negative", ErrInvalidRounding)
+	}
+
+	return nil
+}
+
+// Round rounds d by r. An unknown mode rounds half up.
+func (r Rounding) Round(d decimal.Decimal) decimal.Decimal {
+	switch r.Mode {
+	case HalfEven:
+		return d.RoundBank(r.Places)
+	case Down:
+		return d.RoundDown(r.Places)
+	case Up:
+		return d.RoundUp(r.Places)
+	default:
+		return d.Round(r.Places)
+	}
+}
+
+// Normalize trims a currency code and converts it to upper case.
+// Returns ErrInvalidCode unless it then consists of three letters.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if len(code) != 3 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCode, code)
+	}
+
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCode, code)
+		}
+	}
+
+	return code, nil
+}
+
+// Converter converts amounts into one currency at the exchange rates in effect at one time.
+type Converter struct {
+	base     string
+	to       string
+	rates    map[string]decimal.Decimal
+	rounding Rounding
+}
+
+// NewConverter creates a converter into the currency to, rounding by rounding. rates holds
+// what one unit of each currency but the base currency is worth in the base currency.
+// Returns ErrRateNotFound if there is no rate for to.
+func NewConverter(base, to string, rates map[string]decimal.Decimal, rounding Rounding) (*Converter, error) {
+	c := &Converter{base: base, to: to, rates: rates, rounding: rounding}
+
+	if _, err := c.rate(to); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Currency returns the currency the converter converts into.
+func (c *Converter) Currency() string {
+	return c.to
+}
+
+// Convert converts amount in the currency from, the base currency if it is empty, and rounds
+// the result. Returns ErrRateNotFound if there is no rate for from.
+func (c *Converter) Convert(amount decimal.Decimal, from string) (decimal.Decimal, error) {
+	fromRate, err := c.rate(from)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	toRate, err := c.rate(c.to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return c.rounding.Round(amount.Mul(fromRate).Div(toRate)), nil
+}
+
+// rate returns what one unit of code is worth in the base currency.
+func (c *Converter) rate(code string) (decimal.Decimal, error) {
+	if code == "" || code == c.base {
+		return decimal.NewFromInt(1), nil
+	}
+
+	rate, ok := c.rates[code]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrRateNotFound, code)
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,88 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		rounding Rounding
+		amount   string
+		want     string
+	}{
+		{Rounding{Places: 2, Mode: HalfUp}, "2.345", "2.35"},
+		{Rounding{Places: 2, Mode: HalfUp}, "-2.345", "-2.35"},
+		{Rounding{Places: 2, Mode: HalfEven}, "2.345", "2.34"},
+		{Rounding{Places: 2, Mode: HalfEven}, "2.355", "2.36"},
+		{Rounding{Places: 2, Mode: Down}, "2.349", "2.34"},
+		{Rounding{Places: 2, Mode: Up}, "2.341", "2.35"},
+		{Rounding{Places: 0, Mode: HalfUp}, "149.5", "150"},
+	}
+
+	for _, tt := range tests {
+		got := tt.rounding.Round(decimal.RequireFromString(tt.amount))
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%+v rounds %s to %s, want %s", tt.rounding, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if code, err := Normalize(" eur "); err != nil || code != "EUR" {
+		t.Fatalf("Normalize(\" eur \") = %q, %v", code, err)
+	}
+
+	for _, code := range []string{"", "EU", "EURO", "E1R"} {
+		if _, err := Normalize(code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("Normalize(%q) = %v, want ErrInvalidCode", code, err)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rates := map[string]decimal.Decimal{
+		"EUR": decimal.RequireFromString("95.5"),
+		"USD": decimal.RequireFromString("82.1"),
+	}
+
+	tests := []struct {
+		to     string
+		amount string
+		from   string
+		want   string
+	}{
+		{"RUB", "10", "EUR", "955"},
+		{"RUB", "10", "", "10"},
+		{"EUR", "955", "RUB", "10"},
+		{"USD", "10", "EUR", "11.63"},
+		{"EUR", "10", "EUR", "10"},
+	}
+
+	for _, tt := range tests {
+		c, err := NewConverter("RUB", tt.to, rates, DefaultRounding)
+		if err != nil {
+			t.Fatalf("converter into %s: %v", tt.to, err)
+		}
+
+		got, err := c.Convert(decimal.RequireFromString(tt.amount), tt.from)
+		if err != nil {
+			t.Fatalf("convert %s %s into %s: %v", tt.amount, tt.from, tt.to, err)
+		}
+
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s %s is %s %s, want %s", tt.amount, tt.from, got, tt.to, tt.want)
+		}
+	}
+
+	if _, err := NewConverter("RUB", "JPY", rates, DefaultRounding); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("converter into a currency without a rate: %v, want ErrRateNotFound", err)
+	}
+
+	c, _ := NewConverter("RUB", "EUR", rates, DefaultRounding)
+	if _, err := c.Convert(decimal.NewFromInt(1), "JPY"); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("converting from a currency without a rate: %v, want ErrRateNotFound", err)
+	}
+}
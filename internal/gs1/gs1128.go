@@ -0,0 +1,304 @@
+package gs1
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FNC1 is the group separator (ASCII 29) that scanners transmit in place of the FNC1
+// character terminating a variable-length element.
+const FNC1 = '\x1d'
+
+var (
+	ErrEmptyData      = errors.New("empty GS1 data")
+	ErrUnknownAI      = errors.New("unknown application identifier")
+	ErrInvalidElement = errors.New("invalid element value")
+	ErrDuplicateAI    = errors.New("application identifier occurs more than once")
+)
+
+// aiSpec describes the data field of an application identifier: fixed-length fields
+// have exactly length characters, variable-length fields at most length.
+type aiSpec struct {
+	length  int
+	fixed   bool
+	numeric bool
+}
+
+// ais lists the supported application identifiers.
+var ais = map[string]aiSpec{
+	"00":  {length: 18, fixed: true, numeric: true}, // SSCC
+	"01":  {length: 14, fixed: true, numeric: true}, // GTIN
+	"02":  {length: 14, fixed: true, numeric: true}, // GTIN of contained trade items
+	"10":  {length: 20},                             // batch or lot number
+	"11":  {length: 6, fixed: true, numeric: true},  // production date
+	"13":  {length: 6, fixed: true, numeric: true},  // packaging date
+	"15":  {length: 6, fixed: true, numeric: true},  // best before date
+	"17":  {length: 6, fixed: true, numeric: true},  // expiration date
+	"21":  {length: 20},                             // serial number
+	"30":  {length: 8, numeric: true},               // variable count of items
+	"37":  {length: 8, numeric: true},               // count of trade items contained
+	"400": {length: 30},                             // customer's purchase order number
+}
+
+// Element is a single application identifier and its data.
+type Element struct {
+	AI    string `json:"ai"`
+	Value string `json:"value"`
+}
+
+// Message is a parsed GS1-128 (or GS1 DataMatrix) element string.
+type Message struct {
+	Elements []Element // in the order they were scanned
+
+	GTIN   string     // AI 01, or 02 if 01 is absent
+	Lot    string     // AI 10
+	Serial string     // AI 21
+	Expiry *time.Time // AI 17
+	Count  *int       // AI 30, or 37 if 30 is absent
+}
+
+// Get returns the data of the given application identifier and whether it is present.
+func (m *Message) Get(ai string) (string, bool) {
+	for _, e := range m.Elements {
+		if e.AI == ai {
+			return e.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Parse parses GS1 element data as transmitted by a scanner: an optional symbology
+// identifier such as "]C1", then application identifiers and their data, with variable-length
+// fields terminated by FNC1 (ASCII 29) unless they come last. The human-readable form with
+// AIs in parentheses, e.g. "(01)04006381333931(10)ABC", is accepted as well.
+func Parse(data string) (*Message, error) {
+	return parse(data, time.Now())
+}
+
+// parse is Parse with a fixed current time for the interpretation of two-digit years.
+func parse(data string, now time.Time) (*Message, error) {
+	data = strings.TrimSpace(data)
+	for _, id := range []string{"]C1", "]e0", "]d2", "]Q3"} {
+		data = strings.TrimPrefix(data, id)
+	}
+
+	data = strings.TrimLeft(data, string(FNC1))
+	if data == "" {
+		return nil, ErrEmptyData
+	}
+
+	var elements []Element
+	var err error
+
+	if data[0] == '(' {
+		elements, err = splitBracketed(data)
+	} else {
+		elements, err = splitRaw(data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newMessage(elements, now)
+}
+
+// splitRaw splits scanner data into elements using the AI table.
+func splitRaw(data string) ([]Element, error) {
+	var elements []Element
+
+	for len(data) > 0 {
+		ai, spec, ok := lookupAI(data)
+		if !ok {
+			return nil, fmt.Errorf("%w at %q", ErrUnknownAI, truncate(data, 4))
+		}
+
+		data = data[len(ai):]
+
+		var value string
+		if spec.fixed {
+			if len(data) < spec.length {
+				return nil, fmt.Errorf("%w: AI (%s) needs %d characters", ErrInvalidElement, ai, spec.length)
+			}
+
+			value, data = data[:spec.length], data[spec.length:]
+		} else {
+			end := strings.IndexRune(data, FNC1)
+			if end < 0 {
+				end = len(data)
+			}
+
+			value, data = data[:end], data[end:]
+		}
+
+		// Some encoders also terminate fixed-length fields with FNC1.
+		data = strings.TrimPrefix(data, string(FNC1))
+
+		elements = append(elements, Element{AI: ai, Value: value})
+	}
+
+	return elements, nil
+}
+
+// splitBracketed splits human-readable data such as "(01)...(17)..." into elements.
+func splitBracketed(data string) ([]Element, error) {
+	var elements []Element
+
+	for len(data) > 0 {
+		end := strings.IndexByte(data, ')')
+		if data[0] != '(' || end < 0 {
+			return nil, fmt.Errorf("%w: malformed application identifier at %q", ErrUnknownAI, truncate(data, 6))
+		}
+
+		ai := data[1:end]
+		if _, ok := ais[ai]; !ok {
+			return nil, fmt.Errorf("%w (%s)", ErrUnknownAI, ai)
+		}
+
+		data = data[end+1:]
+
+		next := strings.IndexByte(data, '(')
+		if next < 0 {
+			next = len(data)
+		}
+
+		elements = append(elements, Element{AI: ai, Value: data[:next]})
+		data = data[next:]
+	}
+
+	return elements, nil
+}
+
+// lookupAI finds the application identifier at the start of data.
+// GS1 application identifiers are prefix-free, so at most one length matches.
+func lookupAI(data string) (string, aiSpec, bool) {
+	for n := 2; n <= 4 && n <= len(data); n++ {
+		if spec, ok := ais[data[:n]]; ok {
+			return data[:n], spec, true
+		}
+	}
+
+	return "", aiSpec{}, false
+}
+
+// newMessage validates the elements and extracts the well-known fields.
+func newMessage(elements []Element, now time.Time) (*Message, error) {
+	m := &Message{Elements: elements}
+	seen := make(map[string]bool, len(elements))
+
+	for _, e := range elements {
+		if seen[e.AI] {
+			return nil, fmt.Errorf("%w: (%s)", ErrDuplicateAI, e.AI)
+		}
+
+		seen[e.AI] = true
+
+		if err := validateElement(e); err != nil {
+			return nil, err
+		}
+	}
+
+	if gtin, ok := m.Get("01"); ok {
+		m.GTIN = gtin
+	} else if gtin, ok := m.Get("02"); ok {
+		m.GTIN = gtin
+	}
+
+	m.Lot, _ = m.Get("10")
+	m.Serial, _ = m.Get("21")
+
+	if v, ok := m.Get("17"); ok {
+		expiry, err := parseDate(v, now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: (17) %v", ErrInvalidElement, err)
+		}
+
+		m.Expiry = &expiry
+	}
+
+	count, ok := m.Get("30")
+	if !ok {
+		count, ok = m.Get("37")
+	}
+
+	if ok {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("%w: count %q", ErrInvalidElement, count)
+		}
+
+		m.Count = &n
+	}
+
+	return m, nil
+}
+
+// validateElement checks the length and character set of an element's data
+// and the check digit of GTINs.
+func validateElement(e Element) error {
+	spec := ais[e.AI]
+
+	switch {
+	case e.Value == "":
+		return fmt.Errorf("%w: (%s) is empty", ErrInvalidElement, e.AI)
+	case spec.fixed && len(e.Value) != spec.length:
+		return fmt.Errorf("%w: (%s) must have %d characters", ErrInvalidElement, e.AI, spec.length)
+	case len(e.Value) > spec.length:
+		return fmt.Errorf("%w: (%s) must have at most %d characters", ErrInvalidElement, e.AI, spec.length)
+	case spec.numeric && strings.Trim(e.Value, "0123456789") != "":
+		return fmt.Errorf("%w: (%s) must be numeric", ErrInvalidElement, e.AI)
+	}
+
+	if (e.AI == "01" || e.AI == "02") && !ValidGTIN(e.Value) {
+		return fmt.Errorf("%w: (%s) %s", ErrInvalidElement, e.AI, ErrInvalidGTIN)
+	}
+
+	return nil
+}
+
+// parseDate parses a GS1 YYMMDD date. A day of 00 means the last day of the month.
+// The century is chosen so that the date lies no more than 49 years in the past
+// or 50 years in the future of now, as the GS1 General Specifications require.
+func parseDate(yymmdd string, now time.Time) (time.Time, error) {
+	yy, _ := strconv.Atoi(yymmdd[0:2])
+	mm, _ := strconv.Atoi(yymmdd[2:4])
+	dd, _ := strconv.Atoi(yymmdd[4:6])
+
+	if mm < 1 || mm > 12 {
+		return time.Time{}, fmt.Errorf("invalid month in %q", yymmdd)
+	}
+
+	century := now.Year() / 100 * 100
+	switch diff := yy - now.Year()%100; {
+	case diff >= 51:
+		century -= 100
+	case diff <= -50:
+		century += 100
+	}
+
+	year := century + yy
+	lastDay := time.Date(year, time.Month(mm)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	if dd == 0 {
+		dd = lastDay
+	}
+
+	if dd > lastDay {
+		return time.Time{}, fmt.Errorf("invalid day in %q", yymmdd)
+	}
+
+	return time.Date(year, time.Month(mm), dd, 0, 0, 0, 0, time.UTC), nil
+}
+
+// truncate returns at most n bytes of s, for error messages.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
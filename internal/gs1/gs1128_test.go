@@ -0,0 +1,174 @@
+package gs1
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func count(n int) *int {
+	return &n
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, time.October, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		data string
+		want *Message
+	}{
+		{
+			name: "GTIN only",
+			data: "]C10104006381333931",
+			want: &Message{
+				Elements: []Element{{AI: "01", Value: "04006381333931"}},
+				GTIN:     "04006381333931",
+			},
+		},
+		{
+			name: "GTIN, expiry and lot: fixed fields need no separator",
+			data: "]C1010400638133393117261231" + "10ABC123",
+			want: &Message{
+				Elements: []Element{
+					{AI: "01", Value: "04006381333931"},
+					{AI: "17", Value: "261231"},
+					{AI: "10", Value: "ABC123"},
+				},
+				GTIN:   "04006381333931",
+				Lot:    "ABC123",
+				Expiry: date(2026, time.December, 31),
+			},
+		},
+		{
+			name: "lot before expiry is terminated by FNC1",
+			data: "]C1010400638133393110LOT-7\x1d17260300",
+			want: &Message{
+				Elements: []Element{
+					{AI: "01", Value: "04006381333931"},
+					{AI: "10", Value: "LOT-7"},
+					{AI: "17", Value: "260300"},
+				},
+				GTIN:   "04006381333931",
+				Lot:    "LOT-7",
+				Expiry: date(2026, time.March, 31),
+			},
+		},
+		{
+			name: "logistic unit: content, count and lot",
+			data: "\x1d0210614141000415" + "3048\x1d" + "10B-42",
+			want: &Message{
+				Elements: []Element{
+					{AI: "02", Value: "10614141000415"},
+					{AI: "30", Value: "48"},
+					{AI: "10", Value: "B-42"},
+				},
+				GTIN:  "10614141000415",
+				Lot:   "B-42",
+				Count: count(48),
+			},
+		},
+		{
+			name: "SSCC, serial and purchase order",
+			data: "]C100106141411234567897" + "21SN0001\x1d" + "400PO-2025-17",
+			want: &Message{
+				Elements: []Element{
+					{AI: "00", Value: "106141411234567897"},
+					{AI: "21", Value: "SN0001"},
+					{AI: "400", Value: "PO-2025-17"},
+				},
+				Serial: "SN0001",
+			},
+		},
+		{
+			name: "FNC1 after a fixed-length field is tolerated",
+			data: "0104006381333931\x1d3712",
+			want: &Message{
+				Elements: []Element{
+					{AI: "01", Value: "04006381333931"},
+					{AI: "37", Value: "12"},
+				},
+				GTIN:  "04006381333931",
+				Count: count(12),
+			},
+		},
+		{
+			name: "human-readable form",
+			data: "(01)04006381333931(17)290115(10)L1(30)6",
+			want: &Message{
+				Elements: []Element{
+					{AI: "01", Value: "04006381333931"},
+					{AI: "17", Value: "290115"},
+					{AI: "10", Value: "L1"},
+					{AI: "30", Value: "6"},
+				},
+				GTIN:   "04006381333931",
+				Lot:    "L1",
+				Expiry: date(2029, time.January, 15),
+				Count:  count(6),
+			},
+		},
+		{
+			name: "two-digit year more than 50 years ahead is in the past century",
+			data: "(01)04006381333931(17)991231",
+			want: &Message{
+				Elements: []Element{
+					{AI: "01", Value: "04006381333931"},
+					{AI: "17", Value: "991231"},
+				},
+				GTIN:   "04006381333931",
+				Expiry: date(1999, time.December, 31),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.data, now)
+			if err != nil {
+				t.Fatalf("parse(%q): %v", tt.data, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse(%q)\ngot:  %+v\nwant: %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2025, time.October, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{name: "empty", data: "]C1", want: ErrEmptyData},
+		{name: "unknown AI", data: "990123", want: ErrUnknownAI},
+		{name: "unknown bracketed AI", data: "(99)0123", want: ErrUnknownAI},
+		{name: "truncated GTIN", data: "01040063813339", want: ErrInvalidElement},
+		{name: "wrong GTIN check digit", data: "0104006381333932", want: ErrInvalidElement},
+		{name: "non-numeric count", data: "0104006381333931" + "30ab", want: ErrInvalidElement},
+		{name: "invalid month", data: "0104006381333931" + "17251301", want: ErrInvalidElement},
+		{name: "invalid day", data: "0104006381333931" + "17250230", want: ErrInvalidElement},
+		{name: "lot too long", data: "10ABCDEFGHIJKLMNOPQRSTU", want: ErrInvalidElement},
+		{name: "empty lot", data: "10\x1d0104006381333931", want: ErrInvalidElement},
+		{name: "duplicate AI", data: "10A\x1d10B", want: ErrDuplicateAI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.data, now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("parse(%q): got error %v, want %v", tt.data, err, tt.want)
+			}
+		})
+	}
+}
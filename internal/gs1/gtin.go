@@ -0,0 +1,54 @@
+// Package gs1 implements GS1 identification keys and barcode data.
+package gs1
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidGTIN = errors.New("invalid GTIN")
+
+// ValidGTIN reports whether code is a GTIN-8 (EAN-8), GTIN-12 (UPC-A), GTIN-13 (EAN-13)
+// or GTIN-14 with a correct check digit.
+func ValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return checkDigit(code[:len(code)-1]) == code[len(code)-1]
+}
+
+// NormalizeGTIN validates code and returns it as a 14-digit GTIN, padded with leading zeros,
+// so that the same product scanned as UPC-A or EAN-13 yields the same key.
+func NormalizeGTIN(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if !ValidGTIN(code) {
+		return "", ErrInvalidGTIN
+	}
+
+	return strings.Repeat("0", 14-len(code)) + code, nil
+}
+
+// checkDigit computes the GS1 mod-10 check digit of the given digits.
+// Weights 3 and 1 alternate starting with 3 at the rightmost digit.
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+
+		sum += d
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
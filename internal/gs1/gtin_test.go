@@ -0,0 +1,25 @@
+package gs1
+
+import "testing"
+
+func TestValidGTIN(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"96385074", true},         // GTIN-8
+		{"036000291452", true},     // GTIN-12 (UPC-A)
+		{"4006381333931", true},    // GTIN-13 (EAN-13)
+		{"10614141000415", true},   // GTIN-14
+		{"4006381333932", false},   // wrong check digit
+		{"400638133393", false},    // wrong length for the check digit
+		{"40063813339A1", false},   // not numeric
+		{"400638133393100", false}, // too long
+	}
+
+	for _, tt := range tests {
+		if got := ValidGTIN(tt.code); got != tt.want {
+			t.Errorf("ValidGTIN(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/wb-go/wbf/ginext"
 
 	"github.com/aliskhannn/warehouse-control/internal/api/response"
+	"github.com/aliskhannn/warehouse-control/internal/audit"
 )
 
 var (
@@ -27,7 +28,8 @@ var (
 // Auth returns a Gin middleware that validates JWT tokens.
 // It expects the token in the "Authorization" header in the format "Bearer <token>".
 // If the token is missing, malformed, invalid, or expired, it aborts the request with 401 Unauthorized.
-// On success, the middleware sets "userID" in the Gin context for downstream handlers.
+// On success, the middleware sets "userID" and "role" in the Gin context for downstream handlers
+// and stores the audit.Actor of the request in the request context.
 func Auth(secret string, ttl time.Duration) ginext.HandlerFunc {
 	return func(c *ginext.Context) {
 		tokenStr := c.GetHeader("Authorization")
@@ -50,10 +52,33 @@ func Auth(secret string, ttl time.Duration) ginext.HandlerFunc {
 
 		c.Set("userID", userID)
 		c.Set("role", role)
+
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			UserID:    userID,
+			Role:      role,
+			RequestID: c.GetString(RequestIDKey),
+			ClientIP:  c.ClientIP(),
+		}))
+
 		c.Next()
 	}
 }
 
+// OptionalAuth is Auth for routes that are open to anonymous users as well: a request without
+// an Authorization header goes through without a user, while a bad token is refused as by Auth.
+func OptionalAuth(secret string, ttl time.Duration) ginext.HandlerFunc {
+	auth := Auth(secret, ttl)
+
+	return func(c *ginext.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+
+		auth(c)
+	}
+}
+
 // RequireRole checks that the user has the required role.
 func RequireRole(roles ...string) gin.HandlerFunc {
 	allowed := make(map[string]struct{}, len(roles))
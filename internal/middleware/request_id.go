@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+)
+
+const (
+	// RequestIDHeader is the header carrying the request ID.
+	RequestIDHeader = "X-Request-ID"
+
+	// RequestIDKey is the Gin context key under which the request ID is stored.
+	RequestIDKey = "requestID"
+)
+
+// RequestID returns a Gin middleware that assigns an ID to every request.
+// An ID sent by the client in the X-Request-ID header is kept, otherwise a new one is generated.
+// The ID is stored in the Gin context and echoed in the response header.
+func RequestID() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
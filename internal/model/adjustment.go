@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AdjustmentReason is a code from the catalogue explaining adjustments of stock, such as
+// damage or theft. Inactive codes stay on the movements made with them but cannot be used again.
+type AdjustmentReason struct {
+	Code      string    `db:"code" json:"code"`
+	Name      string    `db:"name" json:"name"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ReportPeriod is the length of the periods a report groups by.
+type ReportPeriod string
+
+const (
+	PeriodDay     ReportPeriod = "day"
+	PeriodWeek    ReportPeriod = "week"
+	PeriodMonth   ReportPeriod = "month"
+	PeriodQuarter ReportPeriod = "quarter"
+	PeriodYear    ReportPeriod = "year"
+)
+
+// WriteOff is the stock adjusted with one reason code in one period. Quantity and Value
+// are signed like the movements: negative for stock lost, positive for stock found.
+// Value is the cost of the stock adjusted.
+type WriteOff struct {
+	Period     time.Time       `db:"period" json:"period"` // start of the period, UTC
+	ReasonCode string          `db:"reason_code" json:"reason_code"`
+	ReasonName string          `db:"reason_name" json:"reason_name"`
+	Movements  int             `db:"movements" json:"movements"`
+	Quantity   int             `db:"quantity" json:"quantity"`
+	Value      decimal.Decimal `db:"value" json:"value"`
+	Currency   string          `db:"-" json:"currency"` // of the value
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LowStockAlert is raised when an item's quantity falls to its reorder point and resolved
+// when it rises above it again. Quantity, ReorderPoint and ReorderQuantity are the values
+// at the time the alert was raised.
+type LowStockAlert struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	ItemID          uuid.UUID  `db:"item_id" json:"item_id"`
+	ItemName        string     `db:"item_name" json:"item_name"`
+	SKU             string     `db:"sku,omitempty" json:"sku,omitempty"`
+	Quantity        int        `db:"quantity" json:"quantity"`
+	ReorderPoint    int        `db:"reorder_point" json:"reorder_point"`
+	ReorderQuantity int        `db:"reorder_quantity" json:"reorder_quantity"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	AcknowledgedBy  *uuid.UUID `db:"acknowledged_by,omitempty" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt  *time.Time `db:"acknowledged_at,omitempty" json:"acknowledged_at,omitempty"`
+	ResolvedAt      *time.Time `db:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// AlertFilter selects low-stock alerts. Resolved alerts are only included if Resolved is set.
+type AlertFilter struct {
+	Acknowledged *bool
+	Resolved     bool
+}
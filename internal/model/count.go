@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CountStatus string
+
+const (
+	CountCounting  CountStatus = "counting"
+	CountReview    CountStatus = "review"
+	CountApproved  CountStatus = "approved"
+	CountCancelled CountStatus = "cancelled"
+)
+
+// CountSession is a stocktake of part of a warehouse. It snapshots the quantity expected at each
+// place it counts when it is created; counters then record what they find, and once a manager
+// approves the counts, the variances are posted as adjustments.
+type CountSession struct {
+	ID          uuid.UUID    `db:"id" json:"id"`
+	WarehouseID uuid.UUID    `db:"warehouse_id" json:"warehouse_id"`
+	Status      CountStatus  `db:"status" json:"status"`
+	Note        string       `db:"note,omitempty" json:"note,omitempty"`
+	Lines       []*CountLine `db:"-" json:"lines"`
+	CreatedBy   *uuid.UUID   `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+	SubmittedAt *time.Time   `db:"submitted_at,omitempty" json:"submitted_at,omitempty"`
+	ApprovedBy  *uuid.UUID   `db:"approved_by,omitempty" json:"approved_by,omitempty"`
+	ApprovedAt  *time.Time   `db:"approved_at,omitempty" json:"approved_at,omitempty"`
+	CancelledAt *time.Time   `db:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+}
+
+// Blind hides the expected quantities and variances of the session's lines, so that counters
+// record what they find rather than what they are told to expect.
+func (s *CountSession) Blind() {
+	for _, line := range s.Lines {
+		line.Expected = nil
+		line.Variance = nil
+	}
+}
+
+// CountLine is one place counted by a session: the stock of an item in a bin, or outside any
+// bin if LocationID is nil. Counted is nil until it has been counted, and Variance is the
+// counted less the expected quantity.
+type CountLine struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	ItemID       uuid.UUID  `db:"item_id" json:"item_id"`
+	ItemName     string     `db:"item_name" json:"item_name"`
+	SKU          string     `db:"sku,omitempty" json:"sku,omitempty"`
+	LocationID   *uuid.UUID `db:"location_id,omitempty" json:"location_id,omitempty"`
+	LocationCode string     `db:"location_code,omitempty" json:"location,omitempty"`
+	Expected     *int       `db:"expected" json:"expected,omitempty"`
+	Counted      *int       `db:"counted" json:"counted"`
+	Variance     *int       `db:"-" json:"variance,omitempty"`
+	CountedBy    *uuid.UUID `db:"counted_by,omitempty" json:"counted_by,omitempty"`
+	CountedAt    *time.Time `db:"counted_at,omitempty" json:"counted_at,omitempty"`
+}
+
+// CountEntry is the quantity a counter found at the place of a count line.
+type CountEntry struct {
+	LineID   uuid.UUID
+	Quantity int
+}
+
+// CountScope selects what a new session counts in its warehouse. Without items it counts every
+// item the warehouse holds, and without locations both its bins and the stock outside any bin.
+type CountScope struct {
+	ItemIDs   []uuid.UUID
+	Locations []string // bin codes
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate is what one unit of Currency is worth in the base currency from EffectiveFrom
+// until the next rate of the currency takes effect.
+type ExchangeRate struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	Currency      string          `db:"currency" json:"currency"` // ISO 4217 code
+	Rate          decimal.Decimal `db:"rate" json:"rate"`
+	EffectiveFrom time.Time       `db:"effective_from" json:"effective_from"`
+	CreatedBy     *uuid.UUID      `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+}
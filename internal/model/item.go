@@ -7,12 +7,151 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// CostingMethod decides the cost of the stock an item issues: that of its oldest receipts
+// still in stock (FIFO), of its newest (LIFO) or the average of all its stock.
+type CostingMethod string
+
+const (
+	CostingFIFO    CostingMethod = "fifo"
+	CostingLIFO    CostingMethod = "lifo"
+	CostingAverage CostingMethod = "average"
+)
+
 type Item struct {
-	ID          uuid.UUID       `db:"id" json:"id"`
-	Name        string          `db:"name" json:"name"`
-	Description string          `db:"description,omitempty" json:"description,omitempty"`
-	Quantity    int             `db:"quantity" json:"quantity"`
-	Price       decimal.Decimal `db:"price" json:"price"`
-	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+	ID              uuid.UUID       `db:"id" json:"id"`
+	Name            string          `db:"name" json:"name"`
+	SKU             string          `db:"sku,omitempty" json:"sku,omitempty"`
+	Barcodes        []string        `db:"barcodes" json:"barcodes"` // GTINs normalized to 14 digits
+	Description     string          `db:"description,omitempty" json:"description,omitempty"`
+	Quantity        int             `db:"quantity" json:"quantity"`                     // total over all warehouses
+	CostPrice       decimal.Decimal `db:"cost_price" json:"cost_price"`                 // what is paid for the item
+	ListPrice       decimal.Decimal `db:"list_price" json:"list_price"`                 // what the item sells for
+	Currency        string          `db:"currency,omitempty" json:"currency,omitempty"` // of the prices; empty for the base currency
+	CostingMethod   CostingMethod   `db:"costing_method" json:"costing_method"`
+	Serialized      bool            `db:"serialized" json:"serialized"`             // every unit has a serial number
+	ReorderPoint    int             `db:"reorder_point" json:"reorder_point"`       // low on stock at or below this quantity; 0 disables alerts
+	ReorderQuantity int             `db:"reorder_quantity" json:"reorder_quantity"` // how much to order when low on stock
+	Version         int             `db:"version" json:"version"`                   // incremented on every change, used for optimistic locking
+	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+
+	// Reserved is the stock allocated to orders and AvailableToPromise the quantity less it;
+	// both are only filled when a single item is retrieved.
+	Reserved           *int `db:"-" json:"reserved,omitempty"`
+	AvailableToPromise *int `db:"-" json:"available_to_promise,omitempty"`
+
+	// Locations lists the bins holding the item; only filled when requested.
+	Locations []*LocationStock `db:"-" json:"locations,omitempty"`
+
+	// ReasonCode is the adjustment reason code an update changing Quantity is made with;
+	// it is recorded with the adjustment and not stored with the item.
+	ReasonCode string `db:"-" json:"-"`
+}
+
+// ItemPatch is a partial update of an item. Nil fields are left unchanged,
+// so a zero value such as a quantity of 0 is distinguishable from an absent field.
+type ItemPatch struct {
+	Name            *string
+	SKU             *string
+	Barcodes        *[]string
+	Description     *string
+	Quantity        *int
+	CostPrice       *decimal.Decimal
+	ListPrice       *decimal.Decimal
+	Currency        *string
+	CostingMethod   *CostingMethod
+	Serialized      *bool
+	ReorderPoint    *int
+	ReorderQuantity *int
+
+	// ReasonCode is the adjustment reason code a change of Quantity is made with.
+	ReasonCode string
+}
+
+// Apply sets the fields present in the patch on the item.
+func (p ItemPatch) Apply(item *Item) {
+	if p.Name != nil {
+		item.Name = *p.Name
+	}
+
+	if p.SKU != nil {
+		item.SKU = *p.SKU
+	}
+
+	if p.Barcodes != nil {
+		item.Barcodes = *p.Barcodes
+	}
+
+	if p.Description != nil {
+		item.Description = *p.Description
+	}
+
+	if p.Quantity != nil {
+		item.Quantity = *p.Quantity
+	}
+
+	if p.CostPrice != nil {
+		item.CostPrice = *p.CostPrice
+	}
+
+	if p.ListPrice != nil {
+		item.ListPrice = *p.ListPrice
+	}
+
+	if p.Currency != nil {
+		item.Currency = *p.Currency
+	}
+
+	if p.CostingMethod != nil {
+		item.CostingMethod = *p.CostingMethod
+	}
+
+	if p.Serialized != nil {
+		item.Serialized = *p.Serialized
+	}
+
+	if p.ReorderPoint != nil {
+		item.ReorderPoint = *p.ReorderPoint
+	}
+
+	if p.ReorderQuantity != nil {
+		item.ReorderQuantity = *p.ReorderQuantity
+	}
+
+	item.ReasonCode = p.ReasonCode
+}
+
+// IsEmpty reports whether the patch changes nothing. A reason code alone changes nothing.
+func (p ItemPatch) IsEmpty() bool {
+	return p.Name == nil && p.SKU == nil && p.Barcodes == nil &&
+		p.Description == nil && p.Quantity == nil && p.CostPrice == nil && p.ListPrice == nil &&
+		p.Currency == nil && p.CostingMethod == nil && p.Serialized == nil && p.ReorderPoint == nil && p.ReorderQuantity == nil
+}
+
+// ItemFilter selects, orders and pages items.
+type ItemFilter struct {
+	Name         string // substring of the name
+	Description  string // substring of the description
+	MinQuantity  *int
+	MaxQuantity  *int
+	MinPrice     *decimal.Decimal // of the list price, in the item's currency
+	MaxPrice     *decimal.Decimal
+	UpdatedSince *time.Time
+
+	Sort   string // name, quantity, cost_price, list_price, created_at or updated_at
+	Desc   bool
+	Limit  int
+	Cursor string // opaque position returned with the previous page
+}
+
+// ItemPrice is the cost and list price of an item from EffectiveFrom until EffectiveTo, when
+// they changed again; EffectiveTo is nil for the prices in effect.
+type ItemPrice struct {
+	ItemID        uuid.UUID       `db:"item_id" json:"item_id"`
+	CostPrice     decimal.Decimal `db:"cost_price" json:"cost_price"`
+	ListPrice     decimal.Decimal `db:"list_price" json:"list_price"`
+	Currency      string          `db:"currency,omitempty" json:"currency,omitempty"`
+	EffectiveFrom time.Time       `db:"effective_from" json:"effective_from"`
+	EffectiveTo   *time.Time      `db:"effective_to,omitempty" json:"effective_to,omitempty"`
+	ChangedBy     *uuid.UUID      `db:"changed_by,omitempty" json:"changed_by,omitempty"`
 }
@@ -16,11 +16,23 @@ const (
 )
 
 type ItemHistory struct {
-	ID        uuid.UUID       `db:"id" json:"id"`
-	ItemID    uuid.UUID       `db:"item_id" json:"item_id"`
-	Action    ItemAction      `db:"action" json:"action"`
-	ChangedBy uuid.UUID       `db:"changed_by" json:"changed_by"`
-	ChangedAt time.Time       `db:"changed_at" json:"changed_at"`
+	ID        uuid.UUID  `db:"id" json:"id"`
+	ItemID    uuid.UUID  `db:"item_id" json:"item_id"`
+	Action    ItemAction `db:"action" json:"action"`
+	ChangedBy uuid.UUID  `db:"changed_by" json:"changed_by"`
+	ChangedAt time.Time  `db:"changed_at" json:"changed_at"`
+	ActorRole string     `db:"actor_role,omitempty" json:"actor_role,omitempty"`
+	RequestID string     `db:"request_id,omitempty" json:"request_id,omitempty"`
+	ClientIP  string     `db:"client_ip,omitempty" json:"client_ip,omitempty"`
+	// WarehouseID is the warehouse whose stock the change affected, nil if it did not touch stock.
+	WarehouseID *uuid.UUID `db:"warehouse_id,omitempty" json:"warehouse_id,omitempty"`
+	// Reason is why the change was made, taken from the stock movement behind it.
+	Reason string `db:"reason,omitempty" json:"reason,omitempty"`
+	// ReasonCode is the adjustment reason code of the movement behind the change, if any.
+	ReasonCode string `db:"reason_code,omitempty" json:"reason_code,omitempty"`
+	// Reference identifies the document the change was made for, such as a purchase order.
+	Reference string          `db:"reference,omitempty" json:"reference,omitempty"`
 	OldData   json.RawMessage `db:"old_data,omitempty" json:"old_data,omitempty"`
 	NewData   json.RawMessage `db:"new_data,omitempty" json:"new_data,omitempty"`
+	Diff      json.RawMessage `db:"diff,omitempty" json:"diff,omitempty"`
 }
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type LocationKind string
+
+const (
+	LocationZone  LocationKind = "zone"
+	LocationAisle LocationKind = "aisle"
+	LocationRack  LocationKind = "rack"
+	LocationBin   LocationKind = "bin"
+)
+
+// ParentKind returns the kind of location that contains locations of kind k,
+// or "" for zones, which are the roots of a warehouse's location tree.
+func (k LocationKind) ParentKind() LocationKind {
+	switch k {
+	case LocationAisle:
+		return LocationZone
+	case LocationRack:
+		return LocationAisle
+	case LocationBin:
+		return LocationRack
+	default:
+		return ""
+	}
+}
+
+// Location is a place in a warehouse: a zone, aisle, rack or bin. Only bins hold stock.
+type Location struct {
+	ID          uuid.UUID    `db:"id" json:"id"`
+	WarehouseID uuid.UUID    `db:"warehouse_id" json:"warehouse_id"`
+	ParentID    *uuid.UUID   `db:"parent_id,omitempty" json:"parent_id,omitempty"`
+	Kind        LocationKind `db:"kind" json:"kind"`
+	Code        string       `db:"code" json:"code"` // full code, unique within the warehouse
+	Name        string       `db:"name,omitempty" json:"name,omitempty"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+	Children    []*Location  `db:"-" json:"children,omitempty"`
+}
+
+// LocationStock is the quantity of an item held in a bin.
+type LocationStock struct {
+	ItemID        uuid.UUID `db:"item_id" json:"item_id"`
+	ItemName      string    `db:"item_name" json:"item_name"`
+	LocationID    uuid.UUID `db:"location_id" json:"location_id"`
+	LocationCode  string    `db:"location_code" json:"location_code"`
+	WarehouseID   uuid.UUID `db:"warehouse_id" json:"warehouse_id"`
+	WarehouseCode string    `db:"warehouse_code" json:"warehouse_code"`
+	Quantity      int       `db:"quantity" json:"quantity"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// StockPlace is where in the warehouses a stock movement applies: a warehouse, uuid.Nil
+// for the default one, optionally the code of a bin in it and optionally a lot of the item.
+// Movements of serialized items also name the serials of the units they move, and movements
+// adding stock may name what each unit cost.
+type StockPlace struct {
+	WarehouseID uuid.UUID
+	Location    string
+	Lot         string
+	Serials     []string
+	UnitCost    *decimal.Decimal // nil for the item's current unit cost
+}
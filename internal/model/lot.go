@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lot is a batch of an item, such as one production run, with an optional expiry date.
+type Lot struct {
+	ID        uuid.UUID   `db:"id" json:"id"`
+	ItemID    uuid.UUID   `db:"item_id" json:"item_id"`
+	Number    string      `db:"number" json:"number"`
+	ExpiresOn *time.Time  `db:"expires_on,omitempty" json:"expires_on,omitempty"` // a date, midnight UTC
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	Stock     []*LotStock `db:"-" json:"stock,omitempty"`
+}
+
+// Expired reports whether the lot has expired on the given day.
+// A lot is usable up to and including its expiry date.
+func (l *Lot) Expired(today time.Time) bool {
+	if l.ExpiresOn == nil {
+		return false
+	}
+
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	return l.ExpiresOn.Before(day)
+}
+
+// LotStock is the quantity of a lot held in a warehouse.
+type LotStock struct {
+	LotID         uuid.UUID  `db:"lot_id" json:"lot_id"`
+	LotNumber     string     `db:"lot_number" json:"lot_number"`
+	ExpiresOn     *time.Time `db:"expires_on,omitempty" json:"expires_on,omitempty"`
+	ItemID        uuid.UUID  `db:"item_id" json:"item_id"`
+	ItemName      string     `db:"item_name" json:"item_name"`
+	WarehouseID   uuid.UUID  `db:"warehouse_id" json:"warehouse_id"`
+	WarehouseCode string     `db:"warehouse_code" json:"warehouse_code"`
+	Quantity      int        `db:"quantity" json:"quantity"`
+	Expired       bool       `db:"-" json:"expired"`
+}
+
+// PickSuggestion proposes where to take a quantity of an item from in a warehouse,
+// first expired first out.
+type PickSuggestion struct {
+	ItemID      uuid.UUID   `json:"item_id"`
+	WarehouseID uuid.UUID   `json:"warehouse_id"`
+	Requested   int         `json:"requested"`
+	Allocated   int         `json:"allocated"`
+	Shortfall   int         `json:"shortfall"` // what the usable stock cannot cover
+	Lines       []*PickLine `json:"lines"`
+}
+
+// PickLine is the quantity to take from one lot; an empty lot number stands for the
+// stock of the warehouse that is in no lot.
+type PickLine struct {
+	LotID     *uuid.UUID `json:"lot_id,omitempty"`
+	LotNumber string     `json:"lot_number,omitempty"`
+	ExpiresOn *time.Time `json:"expires_on,omitempty"`
+	Quantity  int        `json:"quantity"`
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type PurchaseStatus string
+
+const (
+	PurchaseDraft             PurchaseStatus = "draft"
+	PurchaseOrdered           PurchaseStatus = "ordered"
+	PurchasePartiallyReceived PurchaseStatus = "partially_received"
+	PurchaseReceived          PurchaseStatus = "received"
+	PurchaseCancelled         PurchaseStatus = "cancelled"
+)
+
+// PurchaseOrder orders items from a supplier for delivery to a warehouse and optionally
+// a bin in it. It is received in one or more deliveries, each adding the delivered
+// quantities to stock.
+type PurchaseOrder struct {
+	ID           uuid.UUID       `db:"id" json:"id"`
+	SupplierID   uuid.UUID       `db:"supplier_id" json:"supplier_id"`
+	SupplierName string          `db:"supplier_name" json:"supplier_name,omitempty"`
+	WarehouseID  uuid.UUID       `db:"warehouse_id" json:"warehouse_id"`
+	Location     string          `db:"location,omitempty" json:"location,omitempty"` // bin code
+	Status       PurchaseStatus  `db:"status" json:"status"`
+	ExpectedOn   *time.Time      `db:"expected_on" json:"expected_on,omitempty"` // nil on creation for the supplier's lead time
+	Note         string          `db:"note,omitempty" json:"note,omitempty"`
+	Lines        []*PurchaseLine `db:"-" json:"lines"`
+	CreatedBy    *uuid.UUID      `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
+	OrderedAt    *time.Time      `db:"ordered_at,omitempty" json:"ordered_at,omitempty"`
+	ReceivedAt   *time.Time      `db:"received_at,omitempty" json:"received_at,omitempty"`
+	CancelledAt  *time.Time      `db:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+}
+
+// Destination returns the place the order's deliveries are received at.
+func (o *PurchaseOrder) Destination() StockPlace {
+	return StockPlace{WarehouseID: o.WarehouseID, Location: o.Location}
+}
+
+// Line returns the line of the order for an item, or nil if the item is not ordered.
+func (o *PurchaseOrder) Line(itemID uuid.UUID) *PurchaseLine {
+	for _, line := range o.Lines {
+		if line.ItemID == itemID {
+			return line
+		}
+	}
+
+	return nil
+}
+
+// ReceivingStatus returns the status an order being received is in given its lines: received
+// once nothing is outstanding, partially received after anything arrived and ordered before.
+func (o *PurchaseOrder) ReceivingStatus() PurchaseStatus {
+	outstanding, received := false, false
+	for _, line := range o.Lines {
+		outstanding = outstanding || line.Outstanding() > 0
+		received = received || line.ReceivedQuantity > 0
+	}
+
+	switch {
+	case !outstanding:
+		return PurchaseReceived
+	case received:
+		return PurchasePartiallyReceived
+	default:
+		return PurchaseOrdered
+	}
+}
+
+// PurchaseLine is the quantity of one item ordered by a purchase order and how much of it
+// has been received.
+type PurchaseLine struct {
+	ItemID           uuid.UUID        `db:"item_id" json:"item_id"`
+	ItemName         string           `db:"item_name" json:"item_name,omitempty"`
+	SupplierSKU      string           `db:"supplier_sku,omitempty" json:"supplier_sku,omitempty"`
+	Quantity         int              `db:"quantity" json:"quantity"`
+	ReceivedQuantity int              `db:"received_quantity" json:"received_quantity"`
+	UnitCost         *decimal.Decimal `db:"unit_cost" json:"unit_cost"` // nil on creation for the supplier's cost
+}
+
+// Outstanding returns the quantity of the line that has not been received yet.
+func (l *PurchaseLine) Outstanding() int {
+	return l.Quantity - l.ReceivedQuantity
+}
+
+// PurchaseDelivery is the quantity of one item received against a purchase order.
+// Lot and Serials identify the stock received as for any receipt.
+type PurchaseDelivery struct {
+	ItemID   uuid.UUID
+	Quantity int
+	Lot      string
+	Serials  []string
+}
+
+// PurchaseFilter restricts the purchase orders returned by a list. Zero fields do not filter.
+type PurchaseFilter struct {
+	Status     PurchaseStatus
+	SupplierID uuid.UUID
+	ItemID     uuid.UUID // orders with a line for the item
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReceiptStatus string
+
+const (
+	ReceiptDraft  ReceiptStatus = "draft"
+	ReceiptPosted ReceiptStatus = "posted"
+)
+
+// GoodsReceipt records what a delivery actually contained against a purchase order or an
+// advance shipping notice (ASN). Posting it adds the accepted quantities to stock and
+// records its discrepancies; a posted receipt never changes and is undone by a reversal,
+// a posted receipt whose ReversesID names it.
+type GoodsReceipt struct {
+	ID              uuid.UUID      `db:"id" json:"id"`
+	PurchaseOrderID *uuid.UUID     `db:"purchase_order_id,omitempty" json:"purchase_order_id,omitempty"`
+	ASNNumber       string         `db:"asn_number,omitempty" json:"asn_number,omitempty"`
+	WarehouseID     uuid.UUID      `db:"warehouse_id" json:"warehouse_id"`
+	Location        string         `db:"location,omitempty" json:"location,omitempty"` // bin code
+	Status          ReceiptStatus  `db:"status" json:"status"`
+	ReversesID      *uuid.UUID     `db:"reverses_id,omitempty" json:"reverses_id,omitempty"`
+	ReversedByID    *uuid.UUID     `db:"reversed_by_id,omitempty" json:"reversed_by_id,omitempty"`
+	Note            string         `db:"note,omitempty" json:"note,omitempty"`
+	Lines           []*ReceiptLine `db:"-" json:"lines"`
+	Discrepancies   []*Discrepancy `db:"-" json:"discrepancies"`
+	CreatedBy       *uuid.UUID     `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at" json:"updated_at"`
+	PostedBy        *uuid.UUID     `db:"posted_by,omitempty" json:"posted_by,omitempty"`
+	PostedAt        *time.Time     `db:"posted_at,omitempty" json:"posted_at,omitempty"`
+}
+
+// Destination returns the place the receipt's accepted stock goes to.
+func (g *GoodsReceipt) Destination() StockPlace {
+	return StockPlace{WarehouseID: g.WarehouseID, Location: g.Location}
+}
+
+// ReceiptLine is what arrived of one item. ReceivedQuantity counts every unit that arrived,
+// damaged ones included; only the rest is accepted into stock.
+type ReceiptLine struct {
+	ItemID           uuid.UUID `db:"item_id" json:"item_id"`
+	ItemName         string    `db:"item_name" json:"item_name,omitempty"`
+	ExpectedQuantity *int      `db:"expected_quantity" json:"expected_quantity"` // nil on creation for the order's outstanding quantity
+	ReceivedQuantity int       `db:"received_quantity" json:"received_quantity"`
+	DamagedQuantity  int       `db:"damaged_quantity" json:"damaged_quantity"`
+	PurchaseQuantity int       `db:"purchase_quantity" json:"purchase_quantity"` // credited to the purchase order on posting
+	Lot              string    `db:"lot,omitempty" json:"lot,omitempty"`
+	Serials          []string  `db:"serials" json:"serials,omitempty"` // of the accepted units
+}
+
+// Accepted returns the quantity of the line that goes into stock.
+func (l *ReceiptLine) Accepted() int {
+	return l.ReceivedQuantity - l.DamagedQuantity
+}
+
+type DiscrepancyKind string
+
+const (
+	DiscrepancyOver    DiscrepancyKind = "over"
+	DiscrepancyShort   DiscrepancyKind = "short"
+	DiscrepancyDamaged DiscrepancyKind = "damaged"
+)
+
+// Discrepancy is a difference a posted goods receipt found between what was expected of an
+// item and what arrived. It stays open until it is resolved.
+type Discrepancy struct {
+	ID         uuid.UUID       `db:"id" json:"id"`
+	ReceiptID  uuid.UUID       `db:"receipt_id" json:"receipt_id"`
+	ItemID     uuid.UUID       `db:"item_id" json:"item_id"`
+	ItemName   string          `db:"item_name" json:"item_name,omitempty"`
+	Kind       DiscrepancyKind `db:"kind" json:"kind"`
+	Quantity   int             `db:"quantity" json:"quantity"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	Resolution string          `db:"resolution,omitempty" json:"resolution,omitempty"`
+	ResolvedBy *uuid.UUID      `db:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time      `db:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// ReceiptFilter restricts the goods receipts returned by a list. Zero fields do not filter.
+type ReceiptFilter struct {
+	Status          ReceiptStatus
+	PurchaseOrderID uuid.UUID
+}
+
+// DiscrepancyFilter restricts the discrepancies returned by a list. Only open ones are
+// returned unless Resolved is set.
+type DiscrepancyFilter struct {
+	Resolved  bool
+	ReceiptID uuid.UUID
+}
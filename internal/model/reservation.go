@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationActive   ReservationStatus = "active"
+	ReservationReleased ReservationStatus = "released"
+	ReservationExpired  ReservationStatus = "expired"
+)
+
+// Reservation holds a quantity of an item in a warehouse for an owner without moving it.
+// While active the quantity is not available to promise and cannot be issued; it is given
+// back when the reservation is released or expires.
+type Reservation struct {
+	ID          uuid.UUID         `db:"id" json:"id"`
+	ItemID      uuid.UUID         `db:"item_id" json:"item_id"`
+	WarehouseID uuid.UUID         `db:"warehouse_id" json:"warehouse_id"`
+	Owner       string            `db:"owner" json:"owner"`
+	Quantity    int               `db:"quantity" json:"quantity"`
+	Status      ReservationStatus `db:"status" json:"status"`
+	ExpiresAt   time.Time         `db:"expires_at" json:"expires_at"`
+	CreatedBy   *uuid.UUID        `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
+	ReleasedBy  *uuid.UUID        `db:"released_by,omitempty" json:"released_by,omitempty"`
+	ReleasedAt  *time.Time        `db:"released_at,omitempty" json:"released_at,omitempty"`
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type SalesStatus string
+
+const (
+	SalesDraft              SalesStatus = "draft"
+	SalesPartiallyAllocated SalesStatus = "partially_allocated"
+	SalesAllocated          SalesStatus = "allocated"
+	SalesPicking            SalesStatus = "picking"
+	SalesPacked             SalesStatus = "packed"
+	SalesShipped            SalesStatus = "shipped"
+	SalesCancelled          SalesStatus = "cancelled"
+)
+
+// SalesOrder ships items from a warehouse to a customer. Its lines are allocated against the
+// stock available in the warehouse, picked from the bins and lots of a pick list, packed and
+// shipped, which issues the stock.
+type SalesOrder struct {
+	ID          uuid.UUID    `db:"id" json:"id"`
+	Customer    string       `db:"customer" json:"customer"`
+	ShipTo      string       `db:"ship_to,omitempty" json:"ship_to,omitempty"`
+	WarehouseID uuid.UUID    `db:"warehouse_id" json:"warehouse_id"`
+	Status      SalesStatus  `db:"status" json:"status"`
+	Note        string       `db:"note,omitempty" json:"note,omitempty"`
+	Lines       []*SalesLine `db:"-" json:"lines"`
+	CreatedBy   *uuid.UUID   `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+	AllocatedAt *time.Time   `db:"allocated_at,omitempty" json:"allocated_at,omitempty"`
+	PickedAt    *time.Time   `db:"picked_at,omitempty" json:"picked_at,omitempty"`
+	PackedAt    *time.Time   `db:"packed_at,omitempty" json:"packed_at,omitempty"`
+	ShippedAt   *time.Time   `db:"shipped_at,omitempty" json:"shipped_at,omitempty"`
+	CancelledAt *time.Time   `db:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+}
+
+// AllocationStatus returns the status an order being allocated is in given its lines: allocated
+// once every line is allocated in full, partially allocated after anything was allocated and
+// draft before.
+func (o *SalesOrder) AllocationStatus() SalesStatus {
+	unallocated, allocated := false, false
+	for _, line := range o.Lines {
+		unallocated = unallocated || line.Unallocated() > 0
+		allocated = allocated || line.AllocatedQuantity > 0
+	}
+
+	switch {
+	case !unallocated:
+		return SalesAllocated
+	case allocated:
+		return SalesPartiallyAllocated
+	default:
+		return SalesDraft
+	}
+}
+
+// SalesLine is the quantity of one item ordered by a sales order and how much of it is allocated.
+type SalesLine struct {
+	ItemID            uuid.UUID        `db:"item_id" json:"item_id"`
+	ItemName          string           `db:"item_name" json:"item_name,omitempty"`
+	Quantity          int              `db:"quantity" json:"quantity"`
+	AllocatedQuantity int              `db:"allocated_quantity" json:"allocated_quantity"`
+	UnitPrice         *decimal.Decimal `db:"unit_price" json:"unit_price"` // nil on creation for the item's list price
+}
+
+// Unallocated returns the quantity of the line that is not allocated yet.
+func (l *SalesLine) Unallocated() int {
+	return l.Quantity - l.AllocatedQuantity
+}
+
+// SalesFilter restricts the sales orders returned by a list. Zero fields do not filter.
+type SalesFilter struct {
+	Status SalesStatus
+	ItemID uuid.UUID // orders with a line for the item
+}
+
+// PickSource is stock of an item in a warehouse that a pick list can take from: a bin or a lot,
+// or with a nil ID the stock in no bin or in no lot.
+type PickSource struct {
+	ID       *uuid.UUID
+	Code     string // bin code or lot number
+	Quantity int
+}
+
+// PickListLine is the quantity of an item to take from a bin and a lot; empty codes stand for
+// the stock in no bin or in no lot.
+type PickListLine struct {
+	ItemID       uuid.UUID  `db:"item_id" json:"item_id"`
+	ItemName     string     `db:"item_name" json:"item_name,omitempty"`
+	LocationID   *uuid.UUID `db:"location_id,omitempty" json:"-"`
+	LocationCode string     `db:"location_code,omitempty" json:"-"`
+	LotID        *uuid.UUID `db:"lot_id,omitempty" json:"lot_id,omitempty"`
+	LotNumber    string     `db:"lot_number,omitempty" json:"lot,omitempty"`
+	Quantity     int        `db:"quantity" json:"quantity"`
+}
+
+// PickList is what to pick for a sales order, grouped by the bins to visit in the order of
+// their codes; the stock in no bin comes last.
+type PickList struct {
+	SalesOrderID uuid.UUID       `json:"sales_order_id"`
+	WarehouseID  uuid.UUID       `json:"warehouse_id"`
+	Locations    []*PickLocation `json:"locations"`
+}
+
+// PickLocation is what to pick in one bin, or with no location outside the bins.
+type PickLocation struct {
+	LocationID   *uuid.UUID      `json:"location_id,omitempty"`
+	LocationCode string          `json:"location,omitempty"`
+	Lines        []*PickListLine `json:"lines"`
+}
+
+// NewPickList groups the pick lines of an order, sorted by bin, into a pick list.
+func NewPickList(orderID, warehouseID uuid.UUID, lines []*PickListLine) *PickList {
+	list := &PickList{
+		SalesOrderID: orderID,
+		WarehouseID:  warehouseID,
+		Locations:    []*PickLocation{},
+	}
+
+	var current *PickLocation
+	for _, line := range lines {
+		if current == nil || current.LocationCode != line.LocationCode {
+			current = &PickLocation{LocationID: line.LocationID, LocationCode: line.LocationCode}
+			list.Locations = append(list.Locations, current)
+		}
+
+		current.Lines = append(current.Lines, line)
+	}
+
+	return list
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// ScanResult is a scanned GS1 barcode resolved to an item, with the data needed
+// to prefill a stock movement.
+type ScanResult struct {
+	Item     *Item             `json:"item"`
+	GTIN     string            `json:"gtin"`
+	Lot      string            `json:"lot,omitempty"`
+	Serial   string            `json:"serial,omitempty"`
+	Expiry   *time.Time        `json:"expiry,omitempty"`
+	Quantity int               `json:"quantity"`           // the scanned count, 1 if the barcode has none
+	Elements map[string]string `json:"elements,omitempty"` // all application identifiers and their data
+}
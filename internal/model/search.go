@@ -0,0 +1,16 @@
+package model
+
+import "github.com/google/uuid"
+
+// ItemSearchResult is an item matched by a search query.
+type ItemSearchResult struct {
+	Item
+	Rank    float64 `db:"rank" json:"rank"`
+	Snippet string  `db:"snippet" json:"snippet"` // HTML-escaped name and description with matches wrapped in <mark></mark>
+}
+
+// ItemSuggestion is a typeahead suggestion for the item search box.
+type ItemSuggestion struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Name string    `db:"name" json:"name"`
+}
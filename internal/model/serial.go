@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SerialStatus string
+
+const (
+	SerialInStock  SerialStatus = "in_stock"
+	SerialIssued   SerialStatus = "issued"
+	SerialReturned SerialStatus = "returned" // back in stock after being issued
+	SerialScrapped SerialStatus = "scrapped"
+)
+
+// Serial is one unit of a serialized item. A unit is on hand while it has a warehouse;
+// units in transit between warehouses have none.
+type Serial struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	ItemID        uuid.UUID      `db:"item_id" json:"item_id"`
+	ItemName      string         `db:"item_name" json:"item_name"`
+	Serial        string         `db:"serial" json:"serial"`
+	Status        SerialStatus   `db:"status" json:"status"`
+	WarehouseID   *uuid.UUID     `db:"warehouse_id,omitempty" json:"warehouse_id,omitempty"`
+	WarehouseCode string         `db:"warehouse_code,omitempty" json:"warehouse_code,omitempty"`
+	LocationID    *uuid.UUID     `db:"location_id,omitempty" json:"location_id,omitempty"`
+	LocationCode  string         `db:"location_code,omitempty" json:"location,omitempty"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
+	History       []*SerialEvent `db:"-" json:"history,omitempty"` // oldest first
+}
+
+// SerialEvent is a movement of a serialized unit and the status it left the unit in.
+type SerialEvent struct {
+	MovementID    uuid.UUID    `db:"movement_id" json:"movement_id"`
+	Type          MovementType `db:"movement_type" json:"type"`
+	Status        SerialStatus `db:"status" json:"status"`
+	WarehouseID   uuid.UUID    `db:"warehouse_id" json:"warehouse_id"`
+	WarehouseCode string       `db:"warehouse_code" json:"warehouse_code"`
+	LocationCode  string       `db:"location_code,omitempty" json:"location,omitempty"`
+	Reason        string       `db:"reason" json:"reason"`
+	Reference     string       `db:"reference,omitempty" json:"reference,omitempty"`
+	CreatedBy     *uuid.UUID   `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt     time.Time    `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type MovementType string
+
+const (
+	MovementReceive  MovementType = "receive"
+	MovementIssue    MovementType = "issue"
+	MovementAdjust   MovementType = "adjust"
+	MovementTransfer MovementType = "transfer"
+)
+
+// StockMovement is a single signed change of an item's quantity in a warehouse.
+// The item's quantity in a warehouse is always the sum of its movements there,
+// and its total quantity the sum of all its movements.
+type StockMovement struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	ItemID         uuid.UUID       `db:"item_id" json:"item_id"`
+	WarehouseID    uuid.UUID       `db:"warehouse_id" json:"warehouse_id"`
+	LocationID     *uuid.UUID      `db:"location_id,omitempty" json:"location_id,omitempty"`
+	LocationCode   string          `db:"location_code,omitempty" json:"location,omitempty"` // the bin, if any
+	LotID          *uuid.UUID      `db:"lot_id,omitempty" json:"lot_id,omitempty"`
+	LotNumber      string          `db:"lot_number,omitempty" json:"lot,omitempty"`
+	ExpiryOverride bool            `db:"expiry_override" json:"expiry_override,omitempty"` // an admin allowed issuing from an expired lot
+	Serials        []string        `db:"-" json:"serials,omitempty"`                       // the units moved, for serialized items
+	Type           MovementType    `db:"movement_type" json:"type"`
+	Quantity       int             `db:"quantity" json:"quantity"`           // positive adds stock, negative removes it
+	BalanceAfter   int             `db:"balance_after" json:"balance_after"` // the item's quantity in the warehouse
+	Reason         string          `db:"reason" json:"reason"`
+	ReasonCode     string          `db:"reason_code,omitempty" json:"reason_code,omitempty"` // the catalogue code of an adjustment
+	Cost           decimal.Decimal `db:"cost" json:"cost"`                                   // signed like Quantity; for issues the cost of goods issued
+	Reference      string          `db:"reference,omitempty" json:"reference,omitempty"`
+	CreatedBy      *uuid.UUID      `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+
+	// UnitCost is what each unit of stock added by the movement cost; nil for the item's
+	// current unit cost. It is recorded in Cost and the movement's cost layer.
+	UnitCost *decimal.Decimal `db:"-" json:"-"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Supplier is a company items are bought from. LeadTimeDays is how long it usually takes
+// to deliver an order.
+type Supplier struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	Name         string    `db:"name" json:"name"`
+	ContactName  string    `db:"contact_name,omitempty" json:"contact_name,omitempty"`
+	Email        string    `db:"email,omitempty" json:"email,omitempty"`
+	Phone        string    `db:"phone,omitempty" json:"phone,omitempty"`
+	LeadTimeDays int       `db:"lead_time_days" json:"lead_time_days"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SupplierItem is an item a supplier sells, with the supplier's own SKU and unit cost.
+type SupplierItem struct {
+	SupplierID  uuid.UUID       `db:"supplier_id" json:"supplier_id"`
+	ItemID      uuid.UUID       `db:"item_id" json:"item_id"`
+	ItemName    string          `db:"item_name" json:"item_name,omitempty"`
+	SupplierSKU string          `db:"supplier_sku,omitempty" json:"supplier_sku,omitempty"`
+	Cost        decimal.Decimal `db:"cost" json:"cost"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TransferStatus string
+
+const (
+	TransferDraft     TransferStatus = "draft"
+	TransferInTransit TransferStatus = "in_transit"
+	TransferReceived  TransferStatus = "received"
+	TransferCancelled TransferStatus = "cancelled"
+)
+
+// TransferOrder moves stock of one or more items from a source to a destination place.
+// Between warehouses the stock leaves the source when the order is shipped and is in
+// transit until the order is received; within a warehouse both happen at once.
+type TransferOrder struct {
+	ID                     uuid.UUID       `db:"id" json:"id"`
+	SourceWarehouseID      uuid.UUID       `db:"source_warehouse_id" json:"source_warehouse_id"`
+	SourceLocation         string          `db:"source_location,omitempty" json:"source_location,omitempty"` // bin code
+	DestinationWarehouseID uuid.UUID       `db:"destination_warehouse_id" json:"destination_warehouse_id"`
+	DestinationLocation    string          `db:"destination_location,omitempty" json:"destination_location,omitempty"`
+	Status                 TransferStatus  `db:"status" json:"status"`
+	Note                   string          `db:"note,omitempty" json:"note,omitempty"`
+	Lines                  []*TransferLine `db:"-" json:"lines"`
+	CreatedBy              *uuid.UUID      `db:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt              time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt              time.Time       `db:"updated_at" json:"updated_at"`
+	ShippedAt              *time.Time      `db:"shipped_at,omitempty" json:"shipped_at,omitempty"`
+	ReceivedAt             *time.Time      `db:"received_at,omitempty" json:"received_at,omitempty"`
+	CancelledAt            *time.Time      `db:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+}
+
+// Source returns the place the order takes stock from.
+func (o *TransferOrder) Source() StockPlace {
+	return StockPlace{WarehouseID: o.SourceWarehouseID, Location: o.SourceLocation}
+}
+
+// Destination returns the place the order brings stock to.
+func (o *TransferOrder) Destination() StockPlace {
+	return StockPlace{WarehouseID: o.DestinationWarehouseID, Location: o.DestinationLocation}
+}
+
+// CrossSite reports whether the order moves stock between warehouses.
+func (o *TransferOrder) CrossSite() bool {
+	return o.SourceWarehouseID != o.DestinationWarehouseID
+}
+
+// TransferLine is the quantity of one item moved by a transfer order.
+type TransferLine struct {
+	ItemID   uuid.UUID `db:"item_id" json:"item_id"`
+	ItemName string    `db:"item_name" json:"item_name,omitempty"`
+	Quantity int       `db:"quantity" json:"quantity"`
+}
+
+// TransferFilter restricts the transfer orders returned by a list. Zero fields do not filter.
+type TransferFilter struct {
+	Status      TransferStatus
+	ItemID      uuid.UUID // orders with a line for the item
+	WarehouseID uuid.UUID // orders from or to the warehouse
+}
+
+// TransferEvent is an audited status change of a transfer order.
+type TransferEvent struct {
+	ID              uuid.UUID      `db:"id" json:"id"`
+	TransferOrderID uuid.UUID      `db:"transfer_order_id" json:"transfer_order_id"`
+	FromStatus      TransferStatus `db:"from_status,omitempty" json:"from_status,omitempty"` // empty for the creation
+	ToStatus        TransferStatus `db:"to_status" json:"to_status"`
+	ChangedBy       uuid.UUID      `db:"changed_by" json:"changed_by"`
+	ChangedAt       time.Time      `db:"changed_at" json:"changed_at"`
+	ActorRole       string         `db:"actor_role,omitempty" json:"actor_role,omitempty"`
+	RequestID       string         `db:"request_id,omitempty" json:"request_id,omitempty"`
+	ClientIP        string         `db:"client_ip,omitempty" json:"client_ip,omitempty"`
+}
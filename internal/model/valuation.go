@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Valuation is the value of the stock owned at a point in time, stock in transit between
+// warehouses included.
+type Valuation struct {
+	AsOf     time.Time        `json:"as_of"`
+	Currency string           `json:"currency"` // of the values
+	Value    decimal.Decimal  `json:"value"`    // the sum of the items' values
+	Items    []*ItemValuation `json:"items"`
+}
+
+// ItemValuation is the stock of one item owned at a point in time and its extended value,
+// the cost of the movements that brought it there. UnitCost is the value per unit.
+type ItemValuation struct {
+	ItemID        uuid.UUID       `db:"item_id" json:"item_id"`
+	ItemName      string          `db:"item_name" json:"item_name"`
+	SKU           string          `db:"sku,omitempty" json:"sku,omitempty"`
+	CostingMethod CostingMethod   `db:"costing_method" json:"costing_method"`
+	Quantity      int             `db:"quantity" json:"quantity"`
+	UnitCost      decimal.Decimal `db:"unit_cost" json:"unit_cost"`
+	Value         decimal.Decimal `db:"value" json:"value"`
+}
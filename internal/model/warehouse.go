@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Warehouse is a site that holds stock. Stock that is not assigned to a warehouse
+// explicitly goes to the default warehouse.
+type Warehouse struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Code      string    `db:"code" json:"code"`
+	Name      string    `db:"name" json:"name"`
+	Address   string    `db:"address,omitempty" json:"address,omitempty"`
+	IsDefault bool      `db:"is_default" json:"is_default"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// StockLevel is the quantity of an item held in a warehouse.
+type StockLevel struct {
+	ItemID        uuid.UUID `db:"item_id" json:"item_id"`
+	ItemName      string    `db:"item_name" json:"item_name"`
+	SKU           string    `db:"sku,omitempty" json:"sku,omitempty"`
+	WarehouseID   uuid.UUID `db:"warehouse_id" json:"warehouse_id"`
+	WarehouseCode string    `db:"warehouse_code" json:"warehouse_code"`
+	WarehouseName string    `db:"warehouse_name" json:"warehouse_name"`
+	Quantity      int       `db:"quantity" json:"quantity"`
+	Reserved      int       `db:"reserved" json:"reserved"` // allocated to orders
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
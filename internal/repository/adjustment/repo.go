@@ -0,0 +1,114 @@
+package adjustment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrReasonNotFound = errors.New("adjustment reason not found")
+	ErrCodeTaken      = errors.New("adjustment reason code is already used")
+	ErrInvalidCode    = errors.New("adjustment reason code must be lower case letters, digits and underscores")
+)
+
+// Repository provides methods to interact with the adjustment_reasons table.
+type Repository struct {
+	db *dbpg.DB
+}
+
+// NewRepository creates a new adjustment reason repository.
+func NewRepository(db *dbpg.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateReason adds a new adjustment reason to the catalogue.
+func (r *Repository) CreateReason(ctx context.Context, ar *model.AdjustmentReason) error {
+	query := `
+		INSERT INTO adjustment_reasons (code, name, active)
+		VALUES ($1, $2, $3)
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, ar.Code, ar.Name, ar.Active).Scan(&ar.CreatedAt, &ar.UpdatedAt)
+	if err != nil {
+		return codeError(err, "failed to create adjustment reason")
+	}
+
+	return nil
+}
+
+// GetAllReasons retrieves the adjustment reasons ordered by code, or only the active ones.
+func (r *Repository) GetAllReasons(ctx context.Context, activeOnly bool) ([]*model.AdjustmentReason, error) {
+	query := `
+		SELECT code, name, active, created_at, updated_at
+		FROM adjustment_reasons
+		WHERE active OR NOT $1
+		ORDER BY code
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query adjustment reasons: %w", err)
+	}
+	defer rows.Close()
+
+	reasons := []*model.AdjustmentReason{}
+	for rows.Next() {
+		var ar model.AdjustmentReason
+		if err := rows.Scan(&ar.Code, &ar.Name, &ar.Active, &ar.CreatedAt, &ar.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan adjustment reason: %w", err)
+		}
+
+		reasons = append(reasons, &ar)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate adjustment reasons: %w", err)
+	}
+
+	return reasons, nil
+}
+
+// UpdateReason updates the name of an adjustment reason and whether it can be used.
+func (r *Repository) UpdateReason(ctx context.Context, ar *model.AdjustmentReason) error {
+	query := `
+		UPDATE adjustment_reasons
+		SET name = $1, active = $2, updated_at = NOW()
+		WHERE code = $3
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, ar.Name, ar.Active, ar.Code).Scan(&ar.CreatedAt, &ar.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReasonNotFound
+		}
+
+		return fmt.Errorf("failed to update adjustment reason: %w", err)
+	}
+
+	return nil
+}
+
+// codeError maps a unique violation of the code to ErrCodeTaken and a malformed code to
+// ErrInvalidCode, and wraps any other error with msg.
+func codeError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505" && pqErr.Constraint == "adjustment_reasons_pkey":
+			return ErrCodeTaken
+		case pqErr.Code == "23514" && pqErr.Constraint == "adjustment_reasons_code_check":
+			return ErrInvalidCode
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
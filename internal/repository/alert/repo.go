@@ -0,0 +1,227 @@
+package alert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrAlertNotFound     = errors.New("alert not found")
+	ErrAlertAcknowledged = errors.New("alert is already acknowledged")
+	ErrAlertResolved     = errors.New("alert is already resolved")
+)
+
+// alertColumns is the column list scanned by scanAlert; it expects low_stock_alerts as a
+// and items as i.
+const alertColumns = `
+	a.id, a.item_id, i.name, COALESCE(i.sku, ''), a.quantity, a.reorder_point, a.reorder_quantity, a.created_at,
+	a.acknowledged_by, a.acknowledged_at, a.resolved_at
+`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAlert scans a row selected with alertColumns.
+func scanAlert(row rowScanner) (*model.LowStockAlert, error) {
+	var a model.LowStockAlert
+	var acknowledgedBy uuid.NullUUID
+	var acknowledgedAt, resolvedAt sql.NullTime
+
+	if err := row.Scan(
+		&a.ID, &a.ItemID, &a.ItemName, &a.SKU, &a.Quantity, &a.ReorderPoint, &a.ReorderQuantity, &a.CreatedAt,
+		&acknowledgedBy, &acknowledgedAt, &resolvedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if acknowledgedBy.Valid {
+		a.AcknowledgedBy = &acknowledgedBy.UUID
+	}
+
+	if acknowledgedAt.Valid {
+		a.AcknowledgedAt = &acknowledgedAt.Time
+	}
+
+	if resolvedAt.Valid {
+		a.ResolvedAt = &resolvedAt.Time
+	}
+
+	return &a, nil
+}
+
+// Repository provides methods to interact with the low_stock_alerts table.
+type Repository struct {
+	db *dbpg.DB
+}
+
+// NewRepository creates a new alert repository.
+func NewRepository(db *dbpg.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// SyncLowStockAlerts brings the alerts of an item, or of all items if itemID is uuid.Nil, in
+// line with their stock: open alerts of items above their reorder point are resolved, and an
+// alert is raised for every item at or below it that has no open alert yet. Items never have
+// more than one open alert, even when checked concurrently. Returns the alerts raised.
+func (r *Repository) SyncLowStockAlerts(ctx context.Context, itemID uuid.UUID) ([]*model.LowStockAlert, error) {
+	item := uuid.NullUUID{UUID: itemID, Valid: itemID != uuid.Nil}
+
+	resolve := `
+		UPDATE low_stock_alerts a
+		SET resolved_at = NOW()
+		FROM items i
+		WHERE i.id = a.item_id AND a.resolved_at IS NULL
+		  AND (i.reorder_point = 0 OR i.quantity > i.reorder_point)
+		  AND ($1::UUID IS NULL OR a.item_id = $1)
+	`
+
+	if _, err := r.db.ExecContext(ctx, resolve, item); err != nil {
+		return nil, fmt.Errorf("failed to resolve low-stock alerts: %w", err)
+	}
+
+	raise := `
+		WITH a AS (
+			INSERT INTO low_stock_alerts (item_id, quantity, reorder_point, reorder_quantity)
+			SELECT id, quantity, reorder_point, reorder_quantity
+			FROM items
+			WHERE reorder_point > 0 AND quantity <= reorder_point
+			  AND ($1::UUID IS NULL OR id = $1)
+			ON CONFLICT (item_id) WHERE resolved_at IS NULL DO NOTHING
+			RETURNING *
+		)
+		SELECT ` + alertColumns + `
+		FROM a
+		JOIN items i ON i.id = a.item_id
+		ORDER BY i.name
+	`
+
+	// Run on the master: it writes, and must see the change that triggered the check.
+	return r.queryAlerts(ctx, r.db.Master, raise, item)
+}
+
+// GetLowStockAlerts retrieves the alerts matching the filter, newest first.
+func (r *Repository) GetLowStockAlerts(ctx context.Context, filter model.AlertFilter) ([]*model.LowStockAlert, error) {
+	var conds []string
+	var args []interface{}
+
+	if !filter.Resolved {
+		conds = append(conds, "a.resolved_at IS NULL")
+	}
+
+	if filter.Acknowledged != nil {
+		args = append(args, *filter.Acknowledged)
+		conds = append(conds, fmt.Sprintf("(a.acknowledged_at IS NOT NULL) = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query := `
+		SELECT ` + alertColumns + `
+		FROM low_stock_alerts a
+		JOIN items i ON i.id = a.item_id
+		` + where + `
+		ORDER BY a.created_at DESC
+	`
+
+	return r.queryAlerts(ctx, r.db, query, args...)
+}
+
+// AcknowledgeLowStockAlert records that a user is taking care of an open alert.
+// Returns ErrAlertNotFound if there is no such alert, and ErrAlertAcknowledged or
+// ErrAlertResolved if it is no longer waiting for acknowledgement.
+func (r *Repository) AcknowledgeLowStockAlert(ctx context.Context, alertID, userID uuid.UUID) (*model.LowStockAlert, error) {
+	query := `
+		WITH a AS (
+			UPDATE low_stock_alerts
+			SET acknowledged_by = $2, acknowledged_at = NOW()
+			WHERE id = $1 AND acknowledged_at IS NULL AND resolved_at IS NULL
+			RETURNING *
+		)
+		SELECT ` + alertColumns + `
+		FROM a
+		JOIN items i ON i.id = a.item_id
+	`
+
+	a, err := scanAlert(r.db.Master.QueryRowContext(ctx, query, alertID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.acknowledgeRejection(ctx, alertID)
+		}
+
+		return nil, fmt.Errorf("failed to acknowledge low-stock alert: %w", err)
+	}
+
+	return a, nil
+}
+
+// acknowledgeRejection explains why an alert could not be acknowledged.
+func (r *Repository) acknowledgeRejection(ctx context.Context, alertID uuid.UUID) error {
+	var acknowledged, resolved bool
+
+	err := r.db.Master.QueryRowContext(ctx, `
+		SELECT acknowledged_at IS NOT NULL, resolved_at IS NOT NULL
+		FROM low_stock_alerts
+		WHERE id = $1
+	`, alertID).Scan(&acknowledged, &resolved)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAlertNotFound
+		}
+
+		return fmt.Errorf("failed to get low-stock alert: %w", err)
+	}
+
+	if resolved {
+		return ErrAlertResolved
+	}
+
+	return ErrAlertAcknowledged
+}
+
+// querier is implemented by *dbpg.DB and *sql.DB.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryAlerts runs a query selecting alertColumns on db and scans its rows.
+func (r *Repository) queryAlerts(
+	ctx context.Context,
+	db querier,
+	query string,
+	args ...interface{},
+) ([]*model.LowStockAlert, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query low-stock alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*model.LowStockAlert{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan low-stock alert: %w", err)
+		}
+
+		alerts = append(alerts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate low-stock alerts: %w", err)
+	}
+
+	return alerts, nil
+}
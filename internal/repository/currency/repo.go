@@ -0,0 +1,151 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrRateNotFound = errors.New("exchange rate not found")
+	ErrRateExists   = errors.New("the currency already has a rate taking effect at that time")
+)
+
+// Repository provides methods to interact with the exchange_rates table.
+type Repository struct {
+	db *dbpg.DB
+}
+
+// NewRepository creates a new exchange rate repository.
+func NewRepository(db *dbpg.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateRate adds an exchange rate.
+// Returns ErrRateExists if the currency has another rate taking effect at the same time.
+func (r *Repository) CreateRate(ctx context.Context, rate *model.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (currency, rate, effective_from, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, rate.Currency, rate.Rate, rate.EffectiveFrom, rate.CreatedBy).Scan(
+		&rate.ID, &rate.CreatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrRateExists
+		}
+
+		return fmt.Errorf("failed to create exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllRates retrieves the exchange rates of a currency, or of all currencies if it is empty,
+// in order of currency and the newest first.
+func (r *Repository) GetAllRates(ctx context.Context, currency string) ([]*model.ExchangeRate, error) {
+	query := `
+		SELECT id, currency, rate, effective_from, created_by, created_at
+		FROM exchange_rates
+		WHERE $1 = '' OR currency = $1
+		ORDER BY currency, effective_from DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []*model.ExchangeRate{}
+	for rows.Next() {
+		var rate model.ExchangeRate
+		var createdBy uuid.NullUUID
+
+		if err := rows.Scan(
+			&rate.ID, &rate.Currency, &rate.Rate, &rate.EffectiveFrom, &createdBy, &rate.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+
+		if createdBy.Valid {
+			rate.CreatedBy = &createdBy.UUID
+		}
+
+		rates = append(rates, &rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// GetRatesAt retrieves the exchange rate of each currency in effect at a point in time.
+// Currencies whose first rate takes effect later are left out.
+func (r *Repository) GetRatesAt(ctx context.Context, at time.Time) (map[string]decimal.Decimal, error) {
+	query := `
+		SELECT DISTINCT ON (currency) currency, rate
+		FROM exchange_rates
+		WHERE effective_from <= $1
+		ORDER BY currency, effective_from DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var currency string
+		var rate decimal.Decimal
+
+		if err := rows.Scan(&currency, &rate); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+
+		rates[currency] = rate
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// DeleteRate removes an exchange rate entered by mistake.
+func (r *Repository) DeleteRate(ctx context.Context, rateID uuid.UUID) error {
+	query := `DELETE FROM exchange_rates WHERE id = $1`
+
+	res, err := r.db.ExecContext(ctx, query, rateID)
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange rate: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRateNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrReasonCodeRequired = errors.New("an adjustment reason code is required")
+	ErrUnknownReasonCode  = errors.New("adjustment reason code is unknown or inactive")
+)
+
+// checkReasonCode returns ErrUnknownReasonCode unless code is an active adjustment reason.
+func (r *Repository) checkReasonCode(ctx context.Context, code string) error {
+	var active bool
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT active FROM adjustment_reasons WHERE code = $1`, code,
+	).Scan(&active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUnknownReasonCode
+		}
+
+		return fmt.Errorf("failed to check adjustment reason: %w", err)
+	}
+
+	if !active {
+		return ErrUnknownReasonCode
+	}
+
+	return nil
+}
+
+// GetWriteOffs sums the movements made with an adjustment reason code at or after from and
+// before to, grouped by period (in UTC) and code and valued at their cost, in order of period
+// and code. warehouseID limits them to one warehouse unless it is uuid.Nil.
+func (r *Repository) GetWriteOffs(
+	ctx context.Context, from, to time.Time, period model.ReportPeriod, warehouseID uuid.UUID,
+) ([]*model.WriteOff, error) {
+	query := `
+		SELECT date_trunc($3, m.created_at AT TIME ZONE 'UTC') AS period, m.reason_code, ar.name,
+		       COUNT(*), SUM(m.quantity), SUM(m.cost)
+		FROM stock_movements m
+		JOIN adjustment_reasons ar ON ar.code = m.reason_code
+		WHERE m.created_at >= $1 AND m.created_at < $2
+		  AND ($4::UUID IS NULL OR m.warehouse_id = $4)
+		GROUP BY 1, m.reason_code, ar.name
+		ORDER BY 1, m.reason_code
+	`
+
+	warehouse := uuid.NullUUID{UUID: warehouseID, Valid: warehouseID != uuid.Nil}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, from, to, string(period), warehouse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query write-offs: %w", err)
+	}
+	defer rows.Close()
+
+	writeOffs := []*model.WriteOff{}
+	for rows.Next() {
+		var w model.WriteOff
+		if err := rows.Scan(&w.Period, &w.ReasonCode, &w.ReasonName, &w.Movements, &w.Quantity, &w.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan write-off: %w", err)
+		}
+
+		w.Period = w.Period.UTC()
+		writeOffs = append(writeOffs, &w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate write-offs: %w", err)
+	}
+
+	return writeOffs, nil
+}
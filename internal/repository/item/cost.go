@@ -0,0 +1,265 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var ErrCostingMethodChange = errors.New("costing method can only change while the item owns no stock")
+
+// costLayer is what remains of stock of an item added at one unit cost.
+type costLayer struct {
+	id        uuid.UUID
+	unitCost  decimal.Decimal
+	remaining int
+}
+
+// layerTake is the quantity a movement takes from a cost layer.
+type layerTake struct {
+	layerID  uuid.UUID
+	quantity int
+}
+
+// costPlan is how a movement changes the cost layers of its item: the layer it opens at
+// unitCost if it adds stock, or the quantities it takes from layers if it removes stock.
+type costPlan struct {
+	opens    bool
+	unitCost decimal.Decimal
+	takes    []layerTake
+}
+
+// planCost values m and sets m.Cost. Stock added opens a layer at m.UnitCost, or at the
+// item's current unit cost if it is nil. Stock removed takes from the item's layers, the
+// oldest first unless the item is costed LIFO, and costs what it takes from them, or for
+// items costed at the average the same share of the value of all the stock the item owns.
+// Transfers keep the stock owned and cost nothing.
+// The item's open layers are locked, so that concurrent movements removing its stock take
+// from them in turn.
+// Returns the rejection of the movement if the layers hold less than it removes.
+func (r *Repository) planCost(ctx context.Context, m *model.StockMovement) (*costPlan, error) {
+	m.Cost = decimal.Zero
+
+	if m.Type == model.MovementTransfer {
+		return &costPlan{}, nil
+	}
+
+	if m.Quantity > 0 {
+		var unitCost decimal.Decimal
+		if m.UnitCost != nil {
+			unitCost = m.UnitCost.Round(2)
+		} else {
+			var err error
+			if unitCost, err = r.currentUnitCost(ctx, m.ItemID); err != nil {
+				return nil, err
+			}
+		}
+
+		m.Cost = unitCost.Mul(decimal.NewFromInt(int64(m.Quantity)))
+
+		return &costPlan{opens: true, unitCost: unitCost}, nil
+	}
+
+	var method model.CostingMethod
+	err := r.conn(ctx).QueryRowContext(ctx, `SELECT costing_method FROM items WHERE id = $1`, m.ItemID).Scan(&method)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get costing method: %w", err)
+	}
+
+	layers, err := r.lockOpenLayers(ctx, m.ItemID, method)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &costPlan{}
+	need, cost := -m.Quantity, decimal.Zero
+
+	for _, layer := range layers {
+		if need == 0 {
+			break
+		}
+
+		take := min(need, layer.remaining)
+		need -= take
+		cost = cost.Add(layer.unitCost.Mul(decimal.NewFromInt(int64(take))))
+		plan.takes = append(plan.takes, layerTake{layerID: layer.id, quantity: take})
+	}
+
+	if need > 0 {
+		return nil, r.movementRejection(ctx, m.ItemID, m.WarehouseID)
+	}
+
+	if method == model.CostingAverage {
+		owned, value, err := r.ownedStock(ctx, m.ItemID)
+		if err != nil {
+			return nil, err
+		}
+
+		if owned <= 0 {
+			return nil, r.movementRejection(ctx, m.ItemID, m.WarehouseID)
+		}
+
+		// Taking the whole share of what is left at once leaves no rounding behind.
+		cost = value.Mul(decimal.NewFromInt(int64(-m.Quantity))).Div(decimal.NewFromInt(int64(owned))).Round(2)
+	}
+
+	m.Cost = cost.Neg()
+
+	return plan, nil
+}
+
+// lockOpenLayers locks and returns the cost layers of an item with stock remaining, in the
+// order an item costed with method takes from them: newest first for LIFO, oldest first otherwise.
+func (r *Repository) lockOpenLayers(ctx context.Context, itemID uuid.UUID, method model.CostingMethod) ([]*costLayer, error) {
+	order := "ASC"
+	if method == model.CostingLIFO {
+		order = "DESC"
+	}
+
+	query := `
+		SELECT id, unit_cost, remaining
+		FROM cost_layers
+		WHERE item_id = $1 AND remaining > 0
+		ORDER BY created_at ` + order + `, id ` + order + `
+		FOR UPDATE
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cost layers: %w", err)
+	}
+	defer rows.Close()
+
+	var layers []*costLayer
+	for rows.Next() {
+		var l costLayer
+		if err := rows.Scan(&l.id, &l.unitCost, &l.remaining); err != nil {
+			return nil, fmt.Errorf("failed to scan cost layer: %w", err)
+		}
+
+		layers = append(layers, &l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate cost layers: %w", err)
+	}
+
+	return layers, nil
+}
+
+// applyCost writes plan, the cost of the recorded movement m, to the cost layers.
+func (r *Repository) applyCost(ctx context.Context, m *model.StockMovement, plan *costPlan) error {
+	if plan.opens {
+		query := `
+			INSERT INTO cost_layers (item_id, movement_id, unit_cost, quantity, remaining, created_at)
+			VALUES ($1, $2, $3, $4, $4, $5)
+		`
+
+		if _, err := r.conn(ctx).ExecContext(ctx, query, m.ItemID, m.ID, plan.unitCost, m.Quantity, m.CreatedAt); err != nil {
+			return fmt.Errorf("failed to open cost layer: %w", err)
+		}
+	}
+
+	for _, take := range plan.takes {
+		_, err := r.conn(ctx).ExecContext(
+			ctx, `UPDATE cost_layers SET remaining = remaining - $2 WHERE id = $1`, take.layerID, take.quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to consume cost layer: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// currentUnitCost returns the average cost of the stock an item owns, or its cost price in the
+// base currency while it owns none.
+func (r *Repository) currentUnitCost(ctx context.Context, itemID uuid.UUID) (decimal.Decimal, error) {
+	owned, value, err := r.ownedStock(ctx, itemID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if owned > 0 {
+		return value.Div(decimal.NewFromInt(int64(owned))).Round(2), nil
+	}
+
+	costPrice, _, err := r.basePrices(ctx, itemID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if costPrice.IsNegative() {
+		return decimal.Zero, nil
+	}
+
+	return costPrice, nil
+}
+
+// ownedStock returns the quantity of an item the company owns, in the warehouses or in transit
+// between them, and its value.
+func (r *Repository) ownedStock(ctx context.Context, itemID uuid.UUID) (int, decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity) FILTER (WHERE movement_type <> 'transfer'), 0), COALESCE(SUM(cost), 0)
+		FROM stock_movements
+		WHERE item_id = $1
+	`
+
+	var owned int
+	var value decimal.Decimal
+
+	if err := r.conn(ctx).QueryRowContext(ctx, query, itemID).Scan(&owned, &value); err != nil {
+		return 0, decimal.Zero, fmt.Errorf("failed to get owned stock: %w", err)
+	}
+
+	return owned, value, nil
+}
+
+// GetValuation retrieves the stock each item owned at asOf, in the warehouses or in transit
+// between them, and its value, in order of item name. Items that owned nothing worth anything
+// are left out.
+func (r *Repository) GetValuation(ctx context.Context, asOf time.Time) ([]*model.ItemValuation, error) {
+	query := `
+		SELECT i.id, i.name, COALESCE(i.sku, ''), i.costing_method,
+		       COALESCE(SUM(m.quantity) FILTER (WHERE m.movement_type <> 'transfer'), 0), SUM(m.cost)
+		FROM stock_movements m
+		JOIN items i ON i.id = m.item_id
+		WHERE m.created_at <= $1
+		GROUP BY i.id
+		HAVING COALESCE(SUM(m.quantity) FILTER (WHERE m.movement_type <> 'transfer'), 0) <> 0 OR SUM(m.cost) <> 0
+		ORDER BY i.name, i.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query valuation: %w", err)
+	}
+	defer rows.Close()
+
+	valuation := []*model.ItemValuation{}
+	for rows.Next() {
+		var v model.ItemValuation
+		if err := rows.Scan(&v.ItemID, &v.ItemName, &v.SKU, &v.CostingMethod, &v.Quantity, &v.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan valuation: %w", err)
+		}
+
+		valuation = append(valuation, &v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate valuation: %w", err)
+	}
+
+	return valuation, nil
+}
@@ -0,0 +1,96 @@
+package item
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/audit"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+func TestIssuesAreCostedByTheItemsMethod(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRepository(db)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	userID := createTestUsers(t, db, 1)[0]
+
+	ctx := context.Background()
+
+	// Ten units at 2 and ten at 4 are received, then 15 and 5 issued.
+	tests := []struct {
+		method     model.CostingMethod
+		firstIssue string
+		lastIssue  string
+	}{
+		{model.CostingFIFO, "-40", "-20"},
+		{model.CostingLIFO, "-50", "-10"},
+		{model.CostingAverage, "-45", "-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			item := &model.Item{Name: "cost-test-" + string(tt.method), CostPrice: decimal.NewFromInt(1), ListPrice: decimal.NewFromInt(1), CostingMethod: tt.method}
+
+			err := uow.Do(ctx, userID, func(ctx context.Context) error {
+				_, err := repo.CreateItem(ctx, userID, item)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			t.Cleanup(func() {
+				_, _ = db.Master.ExecContext(ctx, `DELETE FROM stock_movements WHERE item_id = $1`, item.ID)
+				_, _ = db.Master.ExecContext(ctx, `DELETE FROM items WHERE id = $1`, item.ID)
+			})
+
+			move := func(movementType model.MovementType, quantity int, unitCost int64) *model.StockMovement {
+				t.Helper()
+
+				m := &model.StockMovement{ItemID: item.ID, Type: movementType, Quantity: quantity, Reason: "test", CreatedBy: &userID}
+				if unitCost > 0 {
+					cost := decimal.NewFromInt(unitCost)
+					m.UnitCost = &cost
+				}
+
+				if err := uow.Do(ctx, userID, func(ctx context.Context) error { return repo.CreateMovement(ctx, m) }); err != nil {
+					t.Fatalf("%s %d: %v", movementType, quantity, err)
+				}
+
+				return m
+			}
+
+			move(model.MovementReceive, 10, 2)
+			move(model.MovementReceive, 10, 4)
+
+			first := move(model.MovementIssue, -15, 0)
+			if !first.Cost.Equal(decimal.RequireFromString(tt.firstIssue)) {
+				t.Fatalf("cost of the first issue = %s, want %s", first.Cost, tt.firstIssue)
+			}
+
+			if got := move(model.MovementIssue, -5, 0).Cost; !got.Equal(decimal.RequireFromString(tt.lastIssue)) {
+				t.Fatalf("cost of the last issue = %s, want %s", got, tt.lastIssue)
+			}
+
+			valuation, err := repo.GetValuation(ctx, first.CreatedAt)
+			if err != nil {
+				t.Fatalf("valuation: %v", err)
+			}
+
+			want := decimal.RequireFromString(tt.lastIssue).Neg()
+			for _, v := range valuation {
+				if v.ItemID == item.ID {
+					if v.Quantity != 5 || !v.Value.Equal(want) {
+						t.Fatalf("valued %d units at %s after the first issue, want 5 at %s", v.Quantity, v.Value, want)
+					}
+
+					return
+				}
+			}
+
+			t.Fatal("item missing from the valuation after the first issue")
+		})
+	}
+}
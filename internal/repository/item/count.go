@@ -0,0 +1,401 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrCountNotFound     = errors.New("count session not found")
+	ErrCountLineNotFound = errors.New("count line not found in the session")
+	ErrCountStatusChange = errors.New("count session status was changed by someone else")
+	ErrSerializedCount   = errors.New("serialized items are counted by their serials, not by count sessions")
+)
+
+// countColumns is the column list scanned by scanCount; it expects count_sessions as cs.
+const countColumns = `
+	cs.id, cs.warehouse_id, cs.status, COALESCE(cs.note, ''), cs.created_by, cs.created_at, cs.updated_at,
+	cs.submitted_at, cs.approved_by, cs.approved_at, cs.cancelled_at
+`
+
+// scanCount scans a row selected with countColumns.
+func scanCount(row rowScanner) (*model.CountSession, error) {
+	var s model.CountSession
+	var createdBy, approvedBy uuid.NullUUID
+	var submittedAt, approvedAt, cancelledAt sql.NullTime
+
+	if err := row.Scan(
+		&s.ID, &s.WarehouseID, &s.Status, &s.Note, &createdBy, &s.CreatedAt, &s.UpdatedAt,
+		&submittedAt, &approvedBy, &approvedAt, &cancelledAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		s.CreatedBy = &createdBy.UUID
+	}
+
+	if approvedBy.Valid {
+		s.ApprovedBy = &approvedBy.UUID
+	}
+
+	s.SubmittedAt = nullTime(submittedAt)
+	s.ApprovedAt = nullTime(approvedAt)
+	s.CancelledAt = nullTime(cancelledAt)
+
+	return &s, nil
+}
+
+// CreateCountSession adds a count session for the places of its warehouse within scope and
+// snapshots the quantity expected at each: the stock of every item in a bin that holds it,
+// and, unless scope names bins, the stock of every item outside any bin. A warehouse given as
+// uuid.Nil is replaced by the default warehouse. Items listed in scope get a line outside the
+// bins even if the warehouse holds none of them; otherwise only places holding stock are counted.
+// Serialized items are left out, and rejected with ErrSerializedCount if listed.
+// Must run within a UnitOfWork.
+func (r *Repository) CreateCountSession(ctx context.Context, s *model.CountSession, scope model.CountScope) error {
+	var err error
+
+	if s.WarehouseID, err = r.warehouseOrDefault(ctx, s.WarehouseID); err != nil {
+		return err
+	}
+
+	itemIDs := make([]string, 0, len(scope.ItemIDs))
+	for _, itemID := range scope.ItemIDs {
+		serialized, err := r.itemSerialized(ctx, itemID)
+		if err != nil {
+			return err
+		}
+
+		if serialized {
+			return ErrSerializedCount
+		}
+
+		itemIDs = append(itemIDs, itemID.String())
+	}
+
+	locationIDs := make([]string, 0, len(scope.Locations))
+	for _, code := range scope.Locations {
+		locationID, err := r.resolveLocation(ctx, s.WarehouseID, code)
+		if err != nil {
+			return err
+		}
+
+		locationIDs = append(locationIDs, locationID.String())
+	}
+
+	query := `
+		INSERT INTO count_sessions (warehouse_id, note, created_by)
+		VALUES ($1, NULLIF($2, ''), $3)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err = r.conn(ctx).QueryRowContext(ctx, query, s.WarehouseID, s.Note, s.CreatedBy).Scan(
+		&s.ID, &s.Status, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "count_sessions_warehouse_id_fkey" {
+			return ErrWarehouseNotFound
+		}
+
+		return fmt.Errorf("failed to create count session: %w", err)
+	}
+
+	binned := `
+		INSERT INTO count_lines (session_id, item_id, location_id, expected)
+		SELECT $1, ls.item_id, ls.location_id, ls.quantity
+		FROM location_stock ls
+		JOIN locations l ON l.id = ls.location_id
+		JOIN items i ON i.id = ls.item_id
+		WHERE l.warehouse_id = $2 AND ls.quantity <> 0 AND NOT i.serialized
+		  AND (cardinality($3::UUID[]) = 0 OR ls.item_id = ANY($3::UUID[]))
+		  AND (cardinality($4::UUID[]) = 0 OR ls.location_id = ANY($4::UUID[]))
+	`
+
+	_, err = r.conn(ctx).ExecContext(ctx, binned, s.ID, s.WarehouseID, pq.Array(itemIDs), pq.Array(locationIDs))
+	if err != nil {
+		return fmt.Errorf("failed to snapshot bin stock: %w", err)
+	}
+
+	if len(locationIDs) == 0 {
+		unbinned := `
+			INSERT INTO count_lines (session_id, item_id, location_id, expected)
+			SELECT $1, i.id, NULL, COALESCE(s.quantity, 0) - (
+			    SELECT COALESCE(SUM(ls.quantity), 0)
+			    FROM location_stock ls
+			    JOIN locations l ON l.id = ls.location_id
+			    WHERE ls.item_id = i.id AND l.warehouse_id = $2
+			)
+			FROM items i
+			LEFT JOIN item_stock s ON s.item_id = i.id AND s.warehouse_id = $2
+			WHERE NOT i.serialized
+			  AND (i.id = ANY($3::UUID[]) OR (cardinality($3::UUID[]) = 0 AND s.quantity <> 0))
+		`
+
+		if _, err := r.conn(ctx).ExecContext(ctx, unbinned, s.ID, s.WarehouseID, pq.Array(itemIDs)); err != nil {
+			return fmt.Errorf("failed to snapshot stock outside bins: %w", err)
+		}
+	}
+
+	return r.loadCountLines(ctx, []*model.CountSession{s})
+}
+
+// GetCountSession retrieves a count session with its lines.
+func (r *Repository) GetCountSession(ctx context.Context, sessionID uuid.UUID) (*model.CountSession, error) {
+	return r.getCountSession(ctx, sessionID, "")
+}
+
+// LockCountSession retrieves a count session with its lines and locks it for the rest of the
+// transaction, so that concurrent counts and status changes of the session are serialized.
+// Must run within a UnitOfWork.
+func (r *Repository) LockCountSession(ctx context.Context, sessionID uuid.UUID) (*model.CountSession, error) {
+	return r.getCountSession(ctx, sessionID, "FOR UPDATE OF cs")
+}
+
+// getCountSession retrieves a count session with its lines, appending lock to the query.
+func (r *Repository) getCountSession(ctx context.Context, sessionID uuid.UUID, lock string) (*model.CountSession, error) {
+	query := `SELECT ` + countColumns + ` FROM count_sessions cs WHERE cs.id = $1 ` + lock
+
+	s, err := scanCount(r.conn(ctx).QueryRowContext(ctx, query, sessionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCountNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get count session: %w", err)
+	}
+
+	if err := r.loadCountLines(ctx, []*model.CountSession{s}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetCountSessions retrieves the count sessions with a status, or all if it is empty, with
+// their lines, newest first.
+func (r *Repository) GetCountSessions(ctx context.Context, status model.CountStatus) ([]*model.CountSession, error) {
+	query := `SELECT ` + countColumns + ` FROM count_sessions cs
+		WHERE ($1 = '' OR cs.status::TEXT = $1)
+		ORDER BY cs.created_at DESC, cs.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, string(status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query count sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*model.CountSession{}
+	for rows.Next() {
+		s, err := scanCount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan count session: %w", err)
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate count sessions: %w", err)
+	}
+
+	if err := r.loadCountLines(ctx, sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// GetCountedItems reports which of the items have a line in a count session that is still
+// counting or in review.
+func (r *Repository) GetCountedItems(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	counted := make(map[uuid.UUID]bool)
+	if len(itemIDs) == 0 {
+		return counted, nil
+	}
+
+	ids := make([]string, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		ids = append(ids, id.String())
+	}
+
+	query := `
+		SELECT DISTINCT cl.item_id
+		FROM count_lines cl
+		JOIN count_sessions cs ON cs.id = cl.session_id
+		WHERE cl.item_id = ANY($1::UUID[]) AND cs.status IN ('counting', 'review')
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query counted items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID uuid.UUID
+		if err := rows.Scan(&itemID); err != nil {
+			return nil, fmt.Errorf("failed to scan counted item: %w", err)
+		}
+
+		counted[itemID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate counted items: %w", err)
+	}
+
+	return counted, nil
+}
+
+// loadCountLines fills in the lines of sessions, ordered by bin and item, with the variance
+// of every counted line.
+func (r *Repository) loadCountLines(ctx context.Context, sessions []*model.CountSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*model.CountSession, len(sessions))
+	ids := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		s.Lines = []*model.CountLine{}
+		byID[s.ID] = s
+		ids = append(ids, s.ID.String())
+	}
+
+	query := `
+		SELECT cl.session_id, cl.id, cl.item_id, i.name, COALESCE(i.sku, ''), cl.location_id, COALESCE(l.code, ''),
+		       cl.expected, cl.counted, cl.counted_by, cl.counted_at
+		FROM count_lines cl
+		JOIN items i ON i.id = cl.item_id
+		LEFT JOIN locations l ON l.id = cl.location_id
+		WHERE cl.session_id = ANY($1::UUID[])
+		ORDER BY l.code NULLS LAST, i.name, cl.item_id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query count lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sessionID uuid.UUID
+		var line model.CountLine
+		var locationID, countedBy uuid.NullUUID
+		var expected int
+		var counted sql.NullInt64
+		var countedAt sql.NullTime
+
+		if err := rows.Scan(
+			&sessionID, &line.ID, &line.ItemID, &line.ItemName, &line.SKU, &locationID, &line.LocationCode,
+			&expected, &counted, &countedBy, &countedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan count line: %w", err)
+		}
+
+		line.Expected = &expected
+
+		if locationID.Valid {
+			line.LocationID = &locationID.UUID
+		}
+
+		if counted.Valid {
+			quantity, variance := int(counted.Int64), int(counted.Int64)-expected
+			line.Counted = &quantity
+			line.Variance = &variance
+		}
+
+		if countedBy.Valid {
+			line.CountedBy = &countedBy.UUID
+		}
+
+		line.CountedAt = nullTime(countedAt)
+
+		byID[sessionID].Lines = append(byID[sessionID].Lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate count lines: %w", err)
+	}
+
+	return nil
+}
+
+// RecordCount records the quantity a user counted at the place of a line of a session,
+// replacing any earlier count of it.
+// Returns ErrCountLineNotFound if the session has no such line.
+func (r *Repository) RecordCount(ctx context.Context, sessionID, userID uuid.UUID, entry model.CountEntry) error {
+	query := `
+		UPDATE count_lines
+		SET counted = $3, counted_by = $4, counted_at = NOW()
+		WHERE id = $1 AND session_id = $2
+	`
+
+	res, err := r.conn(ctx).ExecContext(ctx, query, entry.LineID, sessionID, entry.Quantity, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record count: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrCountLineNotFound
+	}
+
+	return nil
+}
+
+// SetCountStatus moves a count session from its status to status to on behalf of a user and
+// stamps the time of the change; approving also records who approved.
+// Returns ErrCountStatusChange if the session is no longer in the status it was read with.
+func (r *Repository) SetCountStatus(ctx context.Context, s *model.CountSession, to model.CountStatus, userID uuid.UUID) error {
+	query := `
+		UPDATE count_sessions
+		SET status       = $3::count_status,
+		    updated_at   = NOW(),
+		    submitted_at = CASE WHEN $3 = 'review' THEN NOW() ELSE submitted_at END,
+		    approved_by  = CASE WHEN $3 = 'approved' THEN $4 ELSE approved_by END,
+		    approved_at  = CASE WHEN $3 = 'approved' THEN NOW() ELSE approved_at END,
+		    cancelled_at = CASE WHEN $3 = 'cancelled' THEN NOW() ELSE cancelled_at END
+		WHERE id = $1 AND status = $2::count_status
+		RETURNING updated_at, submitted_at, approved_by, approved_at, cancelled_at
+	`
+
+	var approvedBy uuid.NullUUID
+	var submittedAt, approvedAt, cancelledAt sql.NullTime
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, s.ID, string(s.Status), string(to), userID).Scan(
+		&s.UpdatedAt, &submittedAt, &approvedBy, &approvedAt, &cancelledAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCountStatusChange
+		}
+
+		return fmt.Errorf("failed to set count session status: %w", err)
+	}
+
+	s.Status = to
+	s.SubmittedAt = nullTime(submittedAt)
+	s.ApprovedAt = nullTime(approvedAt)
+	s.CancelledAt = nullTime(cancelledAt)
+
+	if approvedBy.Valid {
+		s.ApprovedBy = &approvedBy.UUID
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrLotNotFound = errors.New("lot not found for the item")
+	ErrLotTaken    = errors.New("lot number is already used for the item")
+	ErrLotExpired  = errors.New("lot has expired")
+)
+
+// CreateLot adds a new lot of an item.
+// Returns ErrItemNotFound if the item does not exist and ErrLotTaken if the item already has
+// a lot with the same number.
+func (r *Repository) CreateLot(ctx context.Context, l *model.Lot) error {
+	query := `
+		INSERT INTO lots (item_id, number, expires_on)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, l.ItemID, l.Number, l.ExpiresOn).Scan(&l.ID, &l.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Constraint {
+			case "lots_item_id_fkey":
+				return ErrItemNotFound
+			case "uq_lots_item_number":
+				return ErrLotTaken
+			}
+		}
+
+		return fmt.Errorf("failed to create lot: %w", err)
+	}
+
+	return nil
+}
+
+// GetLots retrieves the lots of an item, first expiring first, with their stock per warehouse.
+func (r *Repository) GetLots(ctx context.Context, itemID uuid.UUID) ([]*model.Lot, error) {
+	query := `
+		SELECT id, item_id, number, expires_on, created_at
+		FROM lots
+		WHERE item_id = $1
+		ORDER BY expires_on NULLS LAST, created_at, number
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lots: %w", err)
+	}
+	defer rows.Close()
+
+	lots := []*model.Lot{}
+	byID := make(map[uuid.UUID]*model.Lot)
+	for rows.Next() {
+		var l model.Lot
+		var expiresOn sql.NullTime
+
+		if err := rows.Scan(&l.ID, &l.ItemID, &l.Number, &expiresOn, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lot: %w", err)
+		}
+
+		l.ExpiresOn = nullTime(expiresOn)
+		lots = append(lots, &l)
+		byID[l.ID] = &l
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate lots: %w", err)
+	}
+
+	stock, err := r.queryLotStock(ctx, `WHERE lt.item_id = $1 AND s.quantity <> 0 ORDER BY w.code`, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range stock {
+		byID[s.LotID].Stock = append(byID[s.LotID].Stock, s)
+	}
+
+	return lots, nil
+}
+
+// GetPickStock retrieves the stock of an item in a warehouse, or in the default warehouse if
+// warehouseID is uuid.Nil, as its lots in first expired first out order and the quantity
+// that is in no lot. It also returns the warehouse used.
+func (r *Repository) GetPickStock(
+	ctx context.Context,
+	itemID, warehouseID uuid.UUID,
+) (uuid.UUID, []*model.LotStock, int, error) {
+	warehouseID, err := r.warehouseOrDefault(ctx, warehouseID)
+	if err != nil {
+		return uuid.Nil, nil, 0, err
+	}
+
+	lots, err := r.queryLotStock(ctx, `
+		WHERE lt.item_id = $1 AND s.warehouse_id = $2 AND s.quantity > 0
+		ORDER BY lt.expires_on NULLS LAST, lt.created_at, lt.number
+	`, itemID, warehouseID)
+	if err != nil {
+		return uuid.Nil, nil, 0, err
+	}
+
+	query := `
+		SELECT COALESCE((SELECT quantity FROM item_stock WHERE item_id = $1 AND warehouse_id = $2), 0)
+		     - COALESCE((SELECT SUM(s.quantity)
+		                 FROM lot_stock s
+		                 JOIN lots lt ON lt.id = s.lot_id
+		                 WHERE lt.item_id = $1 AND s.warehouse_id = $2), 0)
+	`
+
+	var unlotted int
+	if err := r.conn(ctx).QueryRowContext(ctx, query, itemID, warehouseID).Scan(&unlotted); err != nil {
+		return uuid.Nil, nil, 0, fmt.Errorf("failed to get stock in no lot: %w", err)
+	}
+
+	return warehouseID, lots, unlotted, nil
+}
+
+// GetExpiringLots retrieves the stock of all lots that expire on or before until,
+// including lots that have already expired, first expiring first.
+func (r *Repository) GetExpiringLots(ctx context.Context, until time.Time) ([]*model.LotStock, error) {
+	return r.queryLotStock(ctx, `
+		WHERE lt.expires_on <= $1 AND s.quantity > 0
+		ORDER BY lt.expires_on, i.name, lt.number, w.code
+	`, until)
+}
+
+// queryLotStock selects lot stock rows with the given WHERE and ORDER BY clauses.
+func (r *Repository) queryLotStock(ctx context.Context, clauses string, args ...interface{}) ([]*model.LotStock, error) {
+	query := `
+		SELECT s.lot_id, lt.number, lt.expires_on, lt.expires_on < CURRENT_DATE, lt.item_id, i.name,
+		       s.warehouse_id, w.code, s.quantity
+		FROM lot_stock s
+		JOIN lots lt ON lt.id = s.lot_id
+		JOIN items i ON i.id = lt.item_id
+		JOIN warehouses w ON w.id = s.warehouse_id
+	` + clauses
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lot stock: %w", err)
+	}
+	defer rows.Close()
+
+	stock := []*model.LotStock{}
+	for rows.Next() {
+		var s model.LotStock
+		var expiresOn sql.NullTime
+		var expired sql.NullBool
+
+		if err := rows.Scan(
+			&s.LotID, &s.LotNumber, &expiresOn, &expired, &s.ItemID, &s.ItemName,
+			&s.WarehouseID, &s.WarehouseCode, &s.Quantity,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan lot stock: %w", err)
+		}
+
+		s.ExpiresOn = nullTime(expiresOn)
+		s.Expired = expired.Bool
+		stock = append(stock, &s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate lot stock: %w", err)
+	}
+
+	return stock, nil
+}
+
+// applyLotStock changes the stock of the lot m.LotID in m.WarehouseID by m.Quantity.
+func (r *Repository) applyLotStock(ctx context.Context, m *model.StockMovement) error {
+	query := `
+		INSERT INTO lot_stock (lot_id, warehouse_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (lot_id, warehouse_id) DO UPDATE
+		SET quantity = lot_stock.quantity + EXCLUDED.quantity, updated_at = NOW()
+	`
+
+	if m.Quantity < 0 {
+		query = `
+			UPDATE lot_stock
+			SET quantity = quantity + $3, updated_at = NOW()
+			WHERE lot_id = $1 AND warehouse_id = $2 AND quantity + $3 >= 0
+		`
+	}
+
+	res, err := r.conn(ctx).ExecContext(ctx, query, *m.LotID, m.WarehouseID, m.Quantity)
+	if err != nil {
+		return stockError(err, "failed to apply lot stock")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return r.movementRejection(ctx, m.ItemID, m.WarehouseID)
+	}
+
+	return nil
+}
+
+// resolveLot returns the ID of the item's lot with the given number and whether it has expired.
+// Returns ErrLotNotFound if the item has no such lot.
+func (r *Repository) resolveLot(ctx context.Context, itemID uuid.UUID, number string) (uuid.UUID, bool, error) {
+	var id uuid.UUID
+	var expired sql.NullBool
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT id, expires_on < CURRENT_DATE FROM lots WHERE item_id = $1 AND number = $2`, itemID, number,
+	).Scan(&id, &expired)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, false, ErrLotNotFound
+		}
+
+		return uuid.Nil, false, fmt.Errorf("failed to get lot: %w", err)
+	}
+
+	return id, expired.Bool, nil
+}
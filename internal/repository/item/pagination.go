@@ -0,0 +1,173 @@
+package item
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// sortColumn describes a column items can be ordered by.
+type sortColumn struct {
+	cast  string                   // SQL type the cursor value is cast to
+	value func(*model.Item) string // cursor value of an item
+	parse func(string) error       // checks that a cursor value casts to the SQL type
+}
+
+// sortColumns lists the columns accepted by ItemFilter.Sort.
+var sortColumns = map[string]sortColumn{
+	"name":       {cast: "text", value: func(i *model.Item) string { return i.Name }, parse: parseText},
+	"quantity":   {cast: "int", value: func(i *model.Item) string { return strconv.Itoa(i.Quantity) }, parse: parseInt},
+	"cost_price": {cast: "numeric", value: func(i *model.Item) string { return i.CostPrice.String() }, parse: parseNumeric},
+	"list_price": {cast: "numeric", value: func(i *model.Item) string { return i.ListPrice.String() }, parse: parseNumeric},
+	"created_at": {cast: "timestamptz", value: func(i *model.Item) string { return i.CreatedAt.Format(time.RFC3339Nano) }, parse: parseTime},
+	"updated_at": {cast: "timestamptz", value: func(i *model.Item) string { return i.UpdatedAt.Format(time.RFC3339Nano) }, parse: parseTime},
+}
+
+func parseText(string) error { return nil }
+
+func parseInt(v string) error {
+	_, err := strconv.ParseInt(v, 10, 32)
+	return err
+}
+
+func parseNumeric(v string) error {
+	_, err := decimal.NewFromString(v)
+	return err
+}
+
+func parseTime(v string) error {
+	_, err := time.Parse(time.RFC3339Nano, v)
+	return err
+}
+
+// cursor is the decoded form of a page position: the sort key and value of
+// the last item on the previous page, with its ID as a tie-breaker.
+type cursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// sortKey identifies an ordering, e.g. "list_price" or "-list_price".
+func sortKey(f model.ItemFilter) string {
+	if f.Desc {
+		return "-" + f.Sort
+	}
+
+	return f.Sort
+}
+
+// encodeCursor returns the cursor pointing after item in the given ordering.
+func encodeCursor(f model.ItemFilter, item *model.Item) string {
+	data, _ := json.Marshal(cursor{
+		Sort:  sortKey(f),
+		Value: sortColumns[f.Sort].value(item),
+		ID:    item.ID,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor and checks it was issued for the same ordering
+// and holds a value of the sort column's type.
+func decodeCursor(f model.ItemFilter) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sortKey(f) {
+		return nil, ErrInvalidCursor
+	}
+
+	if err := sortColumns[f.Sort].parse(c.Value); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// buildItemsQuery builds the query for one page of items matching f.
+// It selects one row more than the limit to detect whether there is a next page.
+func buildItemsQuery(f model.ItemFilter) (string, []interface{}, error) {
+	col, ok := sortColumns[f.Sort]
+	if !ok {
+		return "", nil, ErrInvalidSort
+	}
+
+	var conds []string
+	var args []interface{}
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.Name != "" {
+		conds = append(conds, fmt.Sprintf("name ILIKE '%%' || %s || '%%'", arg(f.Name)))
+	}
+	if f.Description != "" {
+		conds = append(conds, fmt.Sprintf("description ILIKE '%%' || %s || '%%'", arg(f.Description)))
+	}
+	if f.MinQuantity != nil {
+		conds = append(conds, "quantity >= "+arg(*f.MinQuantity))
+	}
+	if f.MaxQuantity != nil {
+		conds = append(conds, "quantity <= "+arg(*f.MaxQuantity))
+	}
+	if f.MinPrice != nil {
+		conds = append(conds, "list_price >= "+arg(*f.MinPrice))
+	}
+	if f.MaxPrice != nil {
+		conds = append(conds, "list_price <= "+arg(*f.MaxPrice))
+	}
+	if f.UpdatedSince != nil {
+		conds = append(conds, "updated_at >= "+arg(*f.UpdatedSince))
+	}
+
+	direction, cmp := "ASC", ">"
+	if f.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if f.Cursor != "" {
+		c, err := decodeCursor(f)
+		if err != nil {
+			return "", nil, err
+		}
+
+		conds = append(conds, fmt.Sprintf(
+			"(%s, id) %s (%s::%s, %s)", f.Sort, cmp, arg(c.Value), col.cast, arg(c.ID),
+		))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM items
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT %s
+	`, itemColumns, where, f.Sort, direction, direction, arg(f.Limit+1))
+
+	return query, args, nil
+}
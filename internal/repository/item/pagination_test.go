@@ -0,0 +1,188 @@
+package item
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// normalizeSpace collapses runs of whitespace, so that queries can be compared regardless of layout.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func TestBuildItemsQueryOrdersBySortAndDirection(t *testing.T) {
+	item := &model.Item{
+		ID:        uuid.New(),
+		Name:      "bolt",
+		Quantity:  7,
+		CostPrice: decimal.RequireFromString("1.25"),
+		ListPrice: decimal.RequireFromString("2.50"),
+		CreatedAt: time.Date(2025, 10, 1, 12, 0, 0, 123, time.UTC),
+		UpdatedAt: time.Date(2025, 10, 2, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		sort      string
+		desc      bool
+		order     string
+		after     string
+		cast      string
+		wantValue string
+	}{
+		{"name", false, "ORDER BY name ASC, id ASC", "(name, id) > ($1::text, $2)", "text", "bolt"},
+		{"name", true, "ORDER BY name DESC, id DESC", "(name, id) < ($1::text, $2)", "text", "bolt"},
+		{"quantity", false, "ORDER BY quantity ASC, id ASC", "(quantity, id) > ($1::int, $2)", "int", "7"},
+		{"quantity", true, "ORDER BY quantity DESC, id DESC", "(quantity, id) < ($1::int, $2)", "int", "7"},
+		{"cost_price", false, "ORDER BY cost_price ASC, id ASC", "(cost_price, id) > ($1::numeric, $2)", "numeric", "1.25"},
+		{"list_price", true, "ORDER BY list_price DESC, id DESC", "(list_price, id) < ($1::numeric, $2)", "numeric", "2.5"},
+		{"created_at", false, "ORDER BY created_at ASC, id ASC", "(created_at, id) > ($1::timestamptz, $2)", "timestamptz", "2025-10-01T12:00:00.000000123Z"},
+		{"updated_at", true, "ORDER BY updated_at DESC, id DESC", "(updated_at, id) < ($1::timestamptz, $2)", "timestamptz", "2025-10-02T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		filter := model.ItemFilter{Sort: tt.sort, Desc: tt.desc, Limit: 10}
+
+		query, args, err := buildItemsQuery(filter)
+		if err != nil {
+			t.Fatalf("%s desc=%t: %v", tt.sort, tt.desc, err)
+		}
+
+		if q := normalizeSpace(query); !strings.Contains(q, tt.order) || strings.Contains(q, "WHERE") {
+			t.Errorf("%s desc=%t: query %q, want %q and no conditions", tt.sort, tt.desc, q, tt.order)
+		}
+
+		if len(args) != 1 || args[0] != 11 {
+			t.Errorf("%s desc=%t: args %v, want the limit plus one", tt.sort, tt.desc, args)
+		}
+
+		// The cursor of the last item on this page continues after it in the same ordering.
+		filter.Cursor = encodeCursor(filter, item)
+
+		query, args, err = buildItemsQuery(filter)
+		if err != nil {
+			t.Fatalf("%s desc=%t with cursor: %v", tt.sort, tt.desc, err)
+		}
+
+		if q := normalizeSpace(query); !strings.Contains(q, "WHERE "+tt.after) {
+			t.Errorf("%s desc=%t with cursor: query %q, want condition %q", tt.sort, tt.desc, q, tt.after)
+		}
+
+		if len(args) != 3 || args[0] != tt.wantValue || args[1] != item.ID || args[2] != 11 {
+			t.Errorf("%s desc=%t with cursor: args %v, want [%s %s 11]", tt.sort, tt.desc, args, tt.wantValue, item.ID)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	item := &model.Item{ID: uuid.New(), Name: "bolt", Quantity: 7}
+	byQuantity := model.ItemFilter{Sort: "quantity"}
+
+	raw := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		filter := byQuantity
+		filter.Cursor = encodeCursor(byQuantity, item)
+
+		c, err := decodeCursor(filter)
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+
+		if c.Sort != "quantity" || c.Value != "7" || c.ID != item.ID {
+			t.Fatalf("decoded %+v, want quantity 7 of %s", c, item.ID)
+		}
+	})
+
+	invalid := []struct {
+		name   string
+		filter model.ItemFilter
+		cursor string
+	}{
+		{"other sort", byQuantity, encodeCursor(model.ItemFilter{Sort: "name"}, item)},
+		{"other direction", byQuantity, encodeCursor(model.ItemFilter{Sort: "quantity", Desc: true}, item)},
+		{"not base64", byQuantity, "!!!"},
+		{"not JSON", byQuantity, raw("quantity")},
+		{"value not an int", byQuantity, raw(`{"s":"quantity","v":"abc","id":"` + item.ID.String() + `"}`)},
+		{"value out of range", byQuantity, raw(`{"s":"quantity","v":"99999999999","id":"` + item.ID.String() + `"}`)},
+		{"value not a number", model.ItemFilter{Sort: "list_price"}, raw(`{"s":"list_price","v":"1,5","id":"` + item.ID.String() + `"}`)},
+		{"value not a time", model.ItemFilter{Sort: "created_at"}, raw(`{"s":"created_at","v":"yesterday","id":"` + item.ID.String() + `"}`)},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			filter.Cursor = tt.cursor
+
+			if _, err := decodeCursor(filter); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("decode: got error %v, want %v", err, ErrInvalidCursor)
+			}
+
+			if _, _, err := buildItemsQuery(filter); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("build: got error %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestBuildItemsQueryFilters(t *testing.T) {
+	minQuantity, maxQuantity := 1, 10
+	minPrice, maxPrice := decimal.RequireFromString("0.5"), decimal.RequireFromString("99.9")
+	since := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	filter := model.ItemFilter{
+		Name:         "bolt",
+		Description:  "steel",
+		MinQuantity:  &minQuantity,
+		MaxQuantity:  &maxQuantity,
+		MinPrice:     &minPrice,
+		MaxPrice:     &maxPrice,
+		UpdatedSince: &since,
+		Sort:         "name",
+		Limit:        20,
+	}
+
+	query, args, err := buildItemsQuery(filter)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	want := "WHERE name ILIKE '%' || $1 || '%' AND description ILIKE '%' || $2 || '%'" +
+		" AND quantity >= $3 AND quantity <= $4 AND list_price >= $5 AND list_price <= $6" +
+		" AND updated_at >= $7 ORDER BY name ASC, id ASC LIMIT $8"
+	if q := normalizeSpace(query); !strings.Contains(q, want) {
+		t.Fatalf("query %q, want %q", q, want)
+	}
+
+	wantArgs := []interface{}{"bolt", "steel", 1, 10, minPrice, maxPrice, since, 21}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("args %v, want %v", args, wantArgs)
+	}
+
+	for i, arg := range args {
+		if d, ok := arg.(decimal.Decimal); ok {
+			if !d.Equal(wantArgs[i].(decimal.Decimal)) {
+				t.Errorf("arg %d = %v, want %v", i+1, arg, wantArgs[i])
+			}
+
+			continue
+		}
+
+		if arg != wantArgs[i] {
+			t.Errorf("arg %d = %v, want %v", i+1, arg, wantArgs[i])
+		}
+	}
+
+	if _, _, err := buildItemsQuery(model.ItemFilter{Sort: "price; DROP TABLE items"}); !errors.Is(err, ErrInvalidSort) {
+		t.Fatalf("unknown sort: got error %v, want %v", err, ErrInvalidSort)
+	}
+}
@@ -0,0 +1,121 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var ErrNoExchangeRate = errors.New("no exchange rate in effect for the currency of the item's prices")
+
+// basePrices returns the cost and list price of an item in the base currency, converted at the
+// exchange rate of their currency in effect now and rounded to cents.
+// Returns ErrNoExchangeRate if their currency has no rate in effect.
+func (r *Repository) basePrices(ctx context.Context, itemID uuid.UUID) (decimal.Decimal, decimal.Decimal, error) {
+	query := `
+		SELECT i.cost_price, i.list_price, i.currency IS NULL, er.rate
+		FROM items i
+		LEFT JOIN LATERAL (
+			SELECT rate
+			FROM exchange_rates
+			WHERE currency = i.currency AND effective_from <= NOW()
+			ORDER BY effective_from DESC
+			LIMIT 1
+		) er ON TRUE
+		WHERE i.id = $1
+	`
+
+	var costPrice, listPrice decimal.Decimal
+	var inBase bool
+	var rate decimal.NullDecimal
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, itemID).Scan(&costPrice, &listPrice, &inBase, &rate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, decimal.Zero, ErrItemNotFound
+		}
+
+		return decimal.Zero, decimal.Zero, fmt.Errorf("failed to get item prices: %w", err)
+	}
+
+	if inBase {
+		return costPrice, listPrice, nil
+	}
+
+	if !rate.Valid {
+		return decimal.Zero, decimal.Zero, ErrNoExchangeRate
+	}
+
+	return costPrice.Mul(rate.Decimal).Round(2), listPrice.Mul(rate.Decimal).Round(2), nil
+}
+
+// recordPrices ends the prices of an item in effect and records its current ones, set by
+// userID, as in effect from now on.
+func (r *Repository) recordPrices(ctx context.Context, userID uuid.UUID, item *model.Item) error {
+	_, err := r.conn(ctx).ExecContext(
+		ctx, `UPDATE item_prices SET effective_to = NOW() WHERE item_id = $1 AND effective_to IS NULL`, item.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to end item prices: %w", err)
+	}
+
+	query := `
+		INSERT INTO item_prices (item_id, cost_price, list_price, currency, effective_from, changed_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NOW(), $5)
+	`
+
+	_, err = r.conn(ctx).ExecContext(ctx, query, item.ID, item.CostPrice, item.ListPrice, item.Currency, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record item prices: %w", err)
+	}
+
+	return nil
+}
+
+// GetItemPrices retrieves the prices of an item that were in effect at some time at or after
+// from and before to, oldest first. A nil bound leaves that side of the range open.
+func (r *Repository) GetItemPrices(ctx context.Context, itemID uuid.UUID, from, to *time.Time) ([]*model.ItemPrice, error) {
+	query := `
+		SELECT item_id, cost_price, list_price, COALESCE(currency, ''), effective_from, effective_to, changed_by
+		FROM item_prices
+		WHERE item_id = $1
+		  AND ($2::TIMESTAMPTZ IS NULL OR effective_to IS NULL OR effective_to > $2)
+		  AND ($3::TIMESTAMPTZ IS NULL OR effective_from < $3)
+		ORDER BY effective_from
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []*model.ItemPrice{}
+	for rows.Next() {
+		var p model.ItemPrice
+		var changedBy uuid.NullUUID
+
+		if err := rows.Scan(&p.ItemID, &p.CostPrice, &p.ListPrice, &p.Currency, &p.EffectiveFrom, &p.EffectiveTo, &changedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan item price: %w", err)
+		}
+
+		if changedBy.Valid {
+			p.ChangedBy = &changedBy.UUID
+		}
+
+		prices = append(prices, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate item prices: %w", err)
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,360 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrPurchaseNotFound     = errors.New("purchase order not found")
+	ErrPurchaseStatusChange = errors.New("purchase order status was changed by someone else")
+	ErrSupplierNotFound     = errors.New("supplier not found")
+	ErrUnitCostRequired     = errors.New("unit cost is required for items the supplier has no cost for")
+)
+
+// purchaseColumns is the column list scanned by scanPurchase; it expects purchase_orders as o,
+// suppliers as s and the destination location as l.
+const purchaseColumns = `
+	o.id, o.supplier_id, s.name, o.warehouse_id, COALESCE(l.code, ''), o.status, o.expected_on,
+	COALESCE(o.note, ''), o.created_by, o.created_at, o.updated_at, o.ordered_at, o.received_at, o.cancelled_at
+`
+
+// purchaseFrom joins the supplier and location scanned with purchaseColumns.
+const purchaseFrom = `
+	FROM purchase_orders o
+	JOIN suppliers s ON s.id = o.supplier_id
+	LEFT JOIN locations l ON l.id = o.location_id
+`
+
+// scanPurchase scans a row selected with purchaseColumns.
+func scanPurchase(row rowScanner) (*model.PurchaseOrder, error) {
+	var o model.PurchaseOrder
+	var expectedOn time.Time
+	var createdBy uuid.NullUUID
+	var orderedAt, receivedAt, cancelledAt sql.NullTime
+
+	if err := row.Scan(
+		&o.ID, &o.SupplierID, &o.SupplierName, &o.WarehouseID, &o.Location, &o.Status, &expectedOn,
+		&o.Note, &createdBy, &o.CreatedAt, &o.UpdatedAt, &orderedAt, &receivedAt, &cancelledAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		o.CreatedBy = &createdBy.UUID
+	}
+
+	o.ExpectedOn = &expectedOn
+	o.OrderedAt = nullTime(orderedAt)
+	o.ReceivedAt = nullTime(receivedAt)
+	o.CancelledAt = nullTime(cancelledAt)
+
+	return &o, nil
+}
+
+// CreatePurchaseOrder adds a draft purchase order with its lines. A warehouse given as uuid.Nil
+// is replaced by the default warehouse and a location code is resolved to a bin of it.
+// Without an expected date the order is expected after the supplier's lead time, and lines
+// without a unit cost take the cost recorded for the item with the supplier
+// (ErrUnitCostRequired if there is none).
+// Must run within a UnitOfWork.
+func (r *Repository) CreatePurchaseOrder(ctx context.Context, o *model.PurchaseOrder) error {
+	var leadTime int
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT lead_time_days FROM suppliers WHERE id = $1`, o.SupplierID,
+	).Scan(&leadTime)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSupplierNotFound
+		}
+
+		return fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	if o.WarehouseID, err = r.warehouseOrDefault(ctx, o.WarehouseID); err != nil {
+		return err
+	}
+
+	locationID, err := r.optionalLocation(ctx, o.WarehouseID, o.Location)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO purchase_orders (supplier_id, warehouse_id, location_id, expected_on, note, created_by)
+		VALUES ($1, $2, $3, COALESCE($4::DATE, CURRENT_DATE + $5::INT), NULLIF($6, ''), $7)
+		RETURNING id, status, expected_on, created_at, updated_at,
+		          (SELECT name FROM suppliers WHERE id = $1)
+	`
+
+	var expectedOn time.Time
+
+	err = r.conn(ctx).QueryRowContext(
+		ctx, query, o.SupplierID, o.WarehouseID, locationID, o.ExpectedOn, leadTime, o.Note, o.CreatedBy,
+	).Scan(&o.ID, &o.Status, &expectedOn, &o.CreatedAt, &o.UpdatedAt, &o.SupplierName)
+	if err != nil {
+		return purchaseError(err, "failed to create purchase order")
+	}
+
+	o.ExpectedOn = &expectedOn
+
+	for _, line := range o.Lines {
+		if line.UnitCost == nil {
+			cost, err := r.supplierCost(ctx, o.SupplierID, line.ItemID)
+			if err != nil {
+				return err
+			}
+
+			line.UnitCost = &cost
+		}
+
+		_, err = r.conn(ctx).ExecContext(ctx, `
+			INSERT INTO purchase_order_lines (purchase_order_id, item_id, quantity, unit_cost)
+			VALUES ($1, $2, $3, $4)
+		`, o.ID, line.ItemID, line.Quantity, *line.UnitCost)
+		if err != nil {
+			return purchaseError(err, "failed to create purchase order line")
+		}
+	}
+
+	return r.loadPurchaseLines(ctx, []*model.PurchaseOrder{o})
+}
+
+// supplierCost retrieves the unit cost of an item recorded with a supplier.
+// Returns ErrItemNotFound if the item does not exist and ErrUnitCostRequired if the
+// supplier has no cost for it.
+func (r *Repository) supplierCost(ctx context.Context, supplierID, itemID uuid.UUID) (decimal.Decimal, error) {
+	var cost decimal.NullDecimal
+
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT (SELECT cost FROM supplier_items WHERE supplier_id = $1 AND item_id = i.id)
+		FROM items i
+		WHERE i.id = $2
+	`, supplierID, itemID).Scan(&cost)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, ErrItemNotFound
+		}
+
+		return decimal.Zero, fmt.Errorf("failed to get supplier cost: %w", err)
+	}
+
+	if !cost.Valid {
+		return decimal.Zero, ErrUnitCostRequired
+	}
+
+	return cost.Decimal, nil
+}
+
+// GetPurchaseOrder retrieves a purchase order with its lines.
+func (r *Repository) GetPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	return r.getPurchaseOrder(ctx, orderID, "")
+}
+
+// LockPurchaseOrder retrieves a purchase order with its lines and locks it for the rest of
+// the transaction, so that concurrent deliveries and status changes of the order are serialized.
+// Must run within a UnitOfWork.
+func (r *Repository) LockPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	return r.getPurchaseOrder(ctx, orderID, "FOR UPDATE OF o")
+}
+
+// getPurchaseOrder retrieves a purchase order with its lines, appending lock to the query.
+func (r *Repository) getPurchaseOrder(ctx context.Context, orderID uuid.UUID, lock string) (*model.PurchaseOrder, error) {
+	query := `SELECT ` + purchaseColumns + purchaseFrom + ` WHERE o.id = $1 ` + lock
+
+	o, err := scanPurchase(r.conn(ctx).QueryRowContext(ctx, query, orderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPurchaseNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get purchase order: %w", err)
+	}
+
+	if err := r.loadPurchaseLines(ctx, []*model.PurchaseOrder{o}); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// GetPurchaseOrders retrieves the purchase orders matching filter with their lines, the ones
+// expected soonest first.
+func (r *Repository) GetPurchaseOrders(ctx context.Context, filter model.PurchaseFilter) ([]*model.PurchaseOrder, error) {
+	query := `SELECT ` + purchaseColumns + purchaseFrom + `
+		WHERE ($1 = '' OR o.status::TEXT = $1)
+		  AND ($2 = '00000000-0000-0000-0000-000000000000'::UUID OR o.supplier_id = $2)
+		  AND ($3 = '00000000-0000-0000-0000-000000000000'::UUID
+		       OR EXISTS (SELECT 1 FROM purchase_order_lines pl WHERE pl.purchase_order_id = o.id AND pl.item_id = $3))
+		ORDER BY o.expected_on, o.created_at, o.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, string(filter.Status), filter.SupplierID, filter.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query purchase orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []*model.PurchaseOrder{}
+	for rows.Next() {
+		o, err := scanPurchase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan purchase order: %w", err)
+		}
+
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate purchase orders: %w", err)
+	}
+
+	if err := r.loadPurchaseLines(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadPurchaseLines fills in the lines of orders with the supplier's SKU of each item.
+func (r *Repository) loadPurchaseLines(ctx context.Context, orders []*model.PurchaseOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*model.PurchaseOrder, len(orders))
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		o.Lines = []*model.PurchaseLine{}
+		byID[o.ID] = o
+		ids = append(ids, o.ID.String())
+	}
+
+	query := `
+		SELECT pl.purchase_order_id, pl.item_id, i.name, COALESCE(si.supplier_sku, ''),
+		       pl.quantity, pl.received_quantity, pl.unit_cost
+		FROM purchase_order_lines pl
+		JOIN purchase_orders o ON o.id = pl.purchase_order_id
+		JOIN items i ON i.id = pl.item_id
+		LEFT JOIN supplier_items si ON si.supplier_id = o.supplier_id AND si.item_id = pl.item_id
+		WHERE pl.purchase_order_id = ANY($1::UUID[])
+		ORDER BY i.name, pl.item_id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query purchase order lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID uuid.UUID
+		var line model.PurchaseLine
+		var unitCost decimal.Decimal
+
+		if err := rows.Scan(
+			&orderID, &line.ItemID, &line.ItemName, &line.SupplierSKU, &line.Quantity, &line.ReceivedQuantity, &unitCost,
+		); err != nil {
+			return fmt.Errorf("failed to scan purchase order line: %w", err)
+		}
+
+		line.UnitCost = &unitCost
+		byID[orderID].Lines = append(byID[orderID].Lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate purchase order lines: %w", err)
+	}
+
+	return nil
+}
+
+// AddPurchaseReceived adds quantity to the received quantity of an item's line of an order.
+// Must run within a UnitOfWork that locked the order.
+func (r *Repository) AddPurchaseReceived(ctx context.Context, orderID, itemID uuid.UUID, quantity int) error {
+	query := `
+		UPDATE purchase_order_lines
+		SET received_quantity = received_quantity + $3
+		WHERE purchase_order_id = $1 AND item_id = $2
+	`
+
+	res, err := r.conn(ctx).ExecContext(ctx, query, orderID, itemID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update received quantity: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrPurchaseNotFound
+	}
+
+	return nil
+}
+
+// SetPurchaseStatus moves a purchase order from its status to status to and stamps the time
+// of the change. An order may stay in its status, e.g. after another partial delivery.
+// Returns ErrPurchaseStatusChange if the order is no longer in the status it was read with.
+func (r *Repository) SetPurchaseStatus(ctx context.Context, o *model.PurchaseOrder, to model.PurchaseStatus) error {
+	query := `
+		UPDATE purchase_orders
+		SET status       = $3::purchase_status,
+		    updated_at   = NOW(),
+		    ordered_at   = CASE WHEN $3 = 'ordered' THEN NOW() ELSE ordered_at END,
+		    received_at  = CASE WHEN $3 = 'received' THEN NOW() ELSE received_at END,
+		    cancelled_at = CASE WHEN $3 = 'cancelled' THEN NOW() ELSE cancelled_at END
+		WHERE id = $1 AND status = $2::purchase_status
+		RETURNING updated_at, ordered_at, received_at, cancelled_at
+	`
+
+	var orderedAt, receivedAt, cancelledAt sql.NullTime
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, o.ID, string(o.Status), string(to)).Scan(
+		&o.UpdatedAt, &orderedAt, &receivedAt, &cancelledAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPurchaseStatusChange
+		}
+
+		return fmt.Errorf("failed to set purchase order status: %w", err)
+	}
+
+	o.Status = to
+	o.OrderedAt = nullTime(orderedAt)
+	o.ReceivedAt = nullTime(receivedAt)
+	o.CancelledAt = nullTime(cancelledAt)
+
+	return nil
+}
+
+// purchaseError maps constraint violations of a purchase order write to the matching errors
+// and wraps any other error with msg.
+func purchaseError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Constraint {
+		case "purchase_orders_supplier_id_fkey":
+			return ErrSupplierNotFound
+		case "purchase_orders_warehouse_id_fkey":
+			return ErrWarehouseNotFound
+		case "purchase_order_lines_item_id_fkey":
+			return ErrItemNotFound
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
@@ -0,0 +1,487 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrReceiptNotFound     = errors.New("goods receipt not found")
+	ErrReceiptPosted       = errors.New("goods receipt is posted and cannot be changed")
+	ErrReceiptReversed     = errors.New("goods receipt has already been reversed")
+	ErrDiscrepancyNotFound = errors.New("discrepancy not found")
+	ErrDiscrepancyResolved = errors.New("discrepancy is already resolved")
+)
+
+// receiptColumns is the column list scanned by scanReceipt; it expects goods_receipts as g
+// and the location as l.
+const receiptColumns = `
+	g.id, g.purchase_order_id, COALESCE(g.asn_number, ''), g.warehouse_id, COALESCE(l.code, ''), g.status,
+	g.reverses_id, (SELECT rv.id FROM goods_receipts rv WHERE rv.reverses_id = g.id), COALESCE(g.note, ''),
+	g.created_by, g.created_at, g.updated_at, g.posted_by, g.posted_at
+`
+
+// receiptFrom joins the location scanned with receiptColumns.
+const receiptFrom = `
+	FROM goods_receipts g
+	LEFT JOIN locations l ON l.id = g.location_id
+`
+
+// scanReceipt scans a row selected with receiptColumns.
+func scanReceipt(row rowScanner) (*model.GoodsReceipt, error) {
+	var g model.GoodsReceipt
+	var purchaseOrderID, reversesID, reversedByID, createdBy, postedBy uuid.NullUUID
+	var postedAt sql.NullTime
+
+	if err := row.Scan(
+		&g.ID, &purchaseOrderID, &g.ASNNumber, &g.WarehouseID, &g.Location, &g.Status,
+		&reversesID, &reversedByID, &g.Note, &createdBy, &g.CreatedAt, &g.UpdatedAt, &postedBy, &postedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	g.PurchaseOrderID = nullUUID(purchaseOrderID)
+	g.ReversesID = nullUUID(reversesID)
+	g.ReversedByID = nullUUID(reversedByID)
+	g.CreatedBy = nullUUID(createdBy)
+	g.PostedBy = nullUUID(postedBy)
+	g.PostedAt = nullTime(postedAt)
+
+	return &g, nil
+}
+
+// CreateGoodsReceipt adds a draft goods receipt with its lines. A warehouse given as uuid.Nil is
+// replaced by the default warehouse and a location code is resolved to a bin of it. Every line
+// must carry its expected quantity.
+// Returns ErrReceiptReversed if g reverses a receipt that already has a reversal.
+// Must run within a UnitOfWork.
+func (r *Repository) CreateGoodsReceipt(ctx context.Context, g *model.GoodsReceipt) error {
+	var err error
+
+	if g.WarehouseID, err = r.warehouseOrDefault(ctx, g.WarehouseID); err != nil {
+		return err
+	}
+
+	locationID, err := r.optionalLocation(ctx, g.WarehouseID, g.Location)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO goods_receipts (
+			purchase_order_id, asn_number, warehouse_id, location_id, reverses_id, note, created_by
+		)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, NULLIF($6, ''), $7)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err = r.conn(ctx).QueryRowContext(
+		ctx, query, g.PurchaseOrderID, g.ASNNumber, g.WarehouseID, locationID, g.ReversesID, g.Note, g.CreatedBy,
+	).Scan(&g.ID, &g.Status, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return receiptError(err, "failed to create goods receipt")
+	}
+
+	for _, line := range g.Lines {
+		_, err = r.conn(ctx).ExecContext(ctx, `
+			INSERT INTO goods_receipt_lines (
+				receipt_id, item_id, expected_quantity, received_quantity, damaged_quantity, lot, serials
+			)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+		`, g.ID, line.ItemID, *line.ExpectedQuantity, line.ReceivedQuantity, line.DamagedQuantity, line.Lot,
+			pq.Array(line.Serials))
+		if err != nil {
+			return receiptError(err, "failed to create goods receipt line")
+		}
+	}
+
+	g.Discrepancies = []*model.Discrepancy{}
+
+	return r.loadReceiptLines(ctx, []*model.GoodsReceipt{g})
+}
+
+// GetGoodsReceipt retrieves a goods receipt with its lines and discrepancies.
+func (r *Repository) GetGoodsReceipt(ctx context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error) {
+	return r.getGoodsReceipt(ctx, receiptID, "")
+}
+
+// LockGoodsReceipt retrieves a goods receipt with its lines and discrepancies and locks it for
+// the rest of the transaction, so that it is posted, reversed or deleted only once.
+// Must run within a UnitOfWork.
+func (r *Repository) LockGoodsReceipt(ctx context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error) {
+	return r.getGoodsReceipt(ctx, receiptID, "FOR UPDATE OF g")
+}
+
+// getGoodsReceipt retrieves a goods receipt with its lines and discrepancies, appending lock to the query.
+func (r *Repository) getGoodsReceipt(ctx context.Context, receiptID uuid.UUID, lock string) (*model.GoodsReceipt, error) {
+	query := `SELECT ` + receiptColumns + receiptFrom + ` WHERE g.id = $1 ` + lock
+
+	g, err := scanReceipt(r.conn(ctx).QueryRowContext(ctx, query, receiptID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReceiptNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get goods receipt: %w", err)
+	}
+
+	receipts := []*model.GoodsReceipt{g}
+	if err := r.loadReceiptLines(ctx, receipts); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadReceiptDiscrepancies(ctx, receipts); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// GetGoodsReceipts retrieves the goods receipts matching filter with their lines and
+// discrepancies, newest first.
+func (r *Repository) GetGoodsReceipts(ctx context.Context, filter model.ReceiptFilter) ([]*model.GoodsReceipt, error) {
+	query := `SELECT ` + receiptColumns + receiptFrom + `
+		WHERE ($1 = '' OR g.status::TEXT = $1)
+		  AND ($2 = '00000000-0000-0000-0000-000000000000'::UUID OR g.purchase_order_id = $2)
+		ORDER BY g.created_at DESC, g.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, string(filter.Status), filter.PurchaseOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goods receipts: %w", err)
+	}
+	defer rows.Close()
+
+	receipts := []*model.GoodsReceipt{}
+	for rows.Next() {
+		g, err := scanReceipt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goods receipt: %w", err)
+		}
+
+		receipts = append(receipts, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate goods receipts: %w", err)
+	}
+
+	if err := r.loadReceiptLines(ctx, receipts); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadReceiptDiscrepancies(ctx, receipts); err != nil {
+		return nil, err
+	}
+
+	return receipts, nil
+}
+
+// loadReceiptLines fills in the lines of receipts.
+func (r *Repository) loadReceiptLines(ctx context.Context, receipts []*model.GoodsReceipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*model.GoodsReceipt, len(receipts))
+	ids := make([]string, 0, len(receipts))
+	for _, g := range receipts {
+		g.Lines = []*model.ReceiptLine{}
+		byID[g.ID] = g
+		ids = append(ids, g.ID.String())
+	}
+
+	query := `
+		SELECT gl.receipt_id, gl.item_id, i.name, gl.expected_quantity, gl.received_quantity, gl.damaged_quantity,
+		       gl.purchase_quantity, COALESCE(gl.lot, ''), gl.serials
+		FROM goods_receipt_lines gl
+		JOIN items i ON i.id = gl.item_id
+		WHERE gl.receipt_id = ANY($1::UUID[])
+		ORDER BY i.name, gl.item_id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query goods receipt lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var receiptID uuid.UUID
+		var line model.ReceiptLine
+		var expected int
+		var serials pq.StringArray
+
+		if err := rows.Scan(
+			&receiptID, &line.ItemID, &line.ItemName, &expected, &line.ReceivedQuantity, &line.DamagedQuantity,
+			&line.PurchaseQuantity, &line.Lot, &serials,
+		); err != nil {
+			return fmt.Errorf("failed to scan goods receipt line: %w", err)
+		}
+
+		line.ExpectedQuantity = &expected
+		line.Serials = serials
+		byID[receiptID].Lines = append(byID[receiptID].Lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate goods receipt lines: %w", err)
+	}
+
+	return nil
+}
+
+// loadReceiptDiscrepancies fills in the discrepancies of receipts.
+func (r *Repository) loadReceiptDiscrepancies(ctx context.Context, receipts []*model.GoodsReceipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*model.GoodsReceipt, len(receipts))
+	ids := make([]string, 0, len(receipts))
+	for _, g := range receipts {
+		g.Discrepancies = []*model.Discrepancy{}
+		byID[g.ID] = g
+		ids = append(ids, g.ID.String())
+	}
+
+	discrepancies, err := r.queryDiscrepancies(ctx, `WHERE d.receipt_id = ANY($1::UUID[])`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range discrepancies {
+		byID[d.ReceiptID].Discrepancies = append(byID[d.ReceiptID].Discrepancies, d)
+	}
+
+	return nil
+}
+
+// SetReceiptPurchaseQuantity records how much of an item's line of a draft receipt is credited
+// to the receipt's purchase order. Must run within a UnitOfWork that locked the receipt.
+func (r *Repository) SetReceiptPurchaseQuantity(ctx context.Context, receiptID, itemID uuid.UUID, quantity int) error {
+	_, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE goods_receipt_lines
+		SET purchase_quantity = $3
+		WHERE receipt_id = $1 AND item_id = $2
+	`, receiptID, itemID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to set purchase quantity: %w", err)
+	}
+
+	return nil
+}
+
+// PostGoodsReceipt marks a draft receipt as posted by the transaction's user. From then on the
+// database rejects any change to the receipt and its lines.
+// Returns ErrReceiptPosted if the receipt is posted already.
+// Must run within a UnitOfWork.
+func (r *Repository) PostGoodsReceipt(ctx context.Context, g *model.GoodsReceipt) error {
+	query := `
+		UPDATE goods_receipts
+		SET status     = 'posted',
+		    updated_at = NOW(),
+		    posted_by  = current_setting('app.current_user_id')::UUID,
+		    posted_at  = NOW()
+		WHERE id = $1 AND status = 'draft'
+		RETURNING status, updated_at, posted_by, posted_at
+	`
+
+	var postedBy uuid.NullUUID
+	var postedAt sql.NullTime
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, g.ID).Scan(&g.Status, &g.UpdatedAt, &postedBy, &postedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReceiptPosted
+		}
+
+		return fmt.Errorf("failed to post goods receipt: %w", err)
+	}
+
+	g.PostedBy = nullUUID(postedBy)
+	g.PostedAt = nullTime(postedAt)
+
+	return nil
+}
+
+// DeleteGoodsReceipt deletes a draft goods receipt.
+// Returns ErrReceiptPosted if the receipt is posted and ErrReceiptNotFound if it does not exist.
+func (r *Repository) DeleteGoodsReceipt(ctx context.Context, receiptID uuid.UUID) error {
+	res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM goods_receipts WHERE id = $1 AND status = 'draft'`, receiptID)
+	if err != nil {
+		return fmt.Errorf("failed to delete goods receipt: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		if _, err := r.GetGoodsReceipt(ctx, receiptID); err != nil {
+			return err
+		}
+
+		return ErrReceiptPosted
+	}
+
+	return nil
+}
+
+// InsertDiscrepancy records a discrepancy found by a receipt and sets its ID and creation time.
+func (r *Repository) InsertDiscrepancy(ctx context.Context, d *model.Discrepancy) error {
+	query := `
+		INSERT INTO receipt_discrepancies (receipt_id, item_id, kind, quantity)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, d.ReceiptID, d.ItemID, string(d.Kind), d.Quantity).Scan(
+		&d.ID, &d.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert discrepancy: %w", err)
+	}
+
+	return nil
+}
+
+// GetDiscrepancies retrieves the discrepancies matching filter, oldest first.
+func (r *Repository) GetDiscrepancies(ctx context.Context, filter model.DiscrepancyFilter) ([]*model.Discrepancy, error) {
+	return r.queryDiscrepancies(ctx, `
+		WHERE ($1 OR d.resolved_at IS NULL)
+		  AND ($2 = '00000000-0000-0000-0000-000000000000'::UUID OR d.receipt_id = $2)
+	`, filter.Resolved, filter.ReceiptID)
+}
+
+// ResolveDiscrepancy marks an open discrepancy as resolved by the transaction's user with
+// a note on how. Returns ErrDiscrepancyNotFound if it does not exist and
+// ErrDiscrepancyResolved if it is resolved already.
+// Must run within a UnitOfWork.
+func (r *Repository) ResolveDiscrepancy(ctx context.Context, discrepancyID uuid.UUID, resolution string) (*model.Discrepancy, error) {
+	res, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE receipt_discrepancies
+		SET resolution  = $2,
+		    resolved_by = current_setting('app.current_user_id')::UUID,
+		    resolved_at = NOW()
+		WHERE id = $1 AND resolved_at IS NULL
+	`, discrepancyID, resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve discrepancy: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	discrepancies, err := r.queryDiscrepancies(ctx, `WHERE d.id = $1`, discrepancyID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(discrepancies) == 0:
+		return nil, ErrDiscrepancyNotFound
+	case rowsAffected == 0:
+		return nil, ErrDiscrepancyResolved
+	}
+
+	return discrepancies[0], nil
+}
+
+// ResolveReceiptDiscrepancies resolves the open discrepancies of a receipt like ResolveDiscrepancy.
+// Must run within a UnitOfWork.
+func (r *Repository) ResolveReceiptDiscrepancies(ctx context.Context, receiptID uuid.UUID, resolution string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE receipt_discrepancies
+		SET resolution  = $2,
+		    resolved_by = current_setting('app.current_user_id')::UUID,
+		    resolved_at = NOW()
+		WHERE receipt_id = $1 AND resolved_at IS NULL
+	`, receiptID, resolution)
+	if err != nil {
+		return fmt.Errorf("failed to resolve receipt discrepancies: %w", err)
+	}
+
+	return nil
+}
+
+// queryDiscrepancies retrieves the discrepancies selected by where, oldest first.
+func (r *Repository) queryDiscrepancies(ctx context.Context, where string, args ...interface{}) ([]*model.Discrepancy, error) {
+	query := `
+		SELECT d.id, d.receipt_id, d.item_id, i.name, d.kind, d.quantity, d.created_at,
+		       COALESCE(d.resolution, ''), d.resolved_by, d.resolved_at
+		FROM receipt_discrepancies d
+		JOIN items i ON i.id = d.item_id
+	` + where + `
+		ORDER BY d.created_at, d.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discrepancies: %w", err)
+	}
+	defer rows.Close()
+
+	discrepancies := []*model.Discrepancy{}
+	for rows.Next() {
+		var d model.Discrepancy
+		var resolvedBy uuid.NullUUID
+		var resolvedAt sql.NullTime
+
+		if err := rows.Scan(
+			&d.ID, &d.ReceiptID, &d.ItemID, &d.ItemName, &d.Kind, &d.Quantity, &d.CreatedAt,
+			&d.Resolution, &resolvedBy, &resolvedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan discrepancy: %w", err)
+		}
+
+		d.ResolvedBy = nullUUID(resolvedBy)
+		d.ResolvedAt = nullTime(resolvedAt)
+		discrepancies = append(discrepancies, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate discrepancies: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+// receiptError maps constraint violations of a goods receipt write to the matching errors
+// and wraps any other error with msg.
+func receiptError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Constraint {
+		case "goods_receipts_purchase_order_id_fkey":
+			return ErrPurchaseNotFound
+		case "goods_receipts_warehouse_id_fkey":
+			return ErrWarehouseNotFound
+		case "goods_receipts_reverses_id_key":
+			return ErrReceiptReversed
+		case "goods_receipt_lines_item_id_fkey":
+			return ErrItemNotFound
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// nullUUID returns a pointer to the UUID in id, or nil if it is NULL.
+func nullUUID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+
+	return &id.UUID
+}
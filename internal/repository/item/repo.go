@@ -8,15 +8,60 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 	"github.com/wb-go/wbf/dbpg"
 
 	"github.com/aliskhannn/warehouse-control/internal/model"
 )
 
 var (
-	ErrItemNotFound = errors.New("item not found")
+	ErrItemNotFound      = errors.New("item not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrVersionConflict   = errors.New("item was modified by someone else")
+	ErrSKUTaken          = errors.New("sku is already used by another item")
+	ErrBarcodeTaken      = errors.New("barcode is already used by another item")
+	ErrItemReferenced    = errors.New("item has stock movements or documents and cannot be deleted")
 )
 
+const (
+	// initialStockReason is the reason of the movement recording the quantity an item is created with.
+	initialStockReason = "initial_stock"
+
+	// manualEditReason is the reason of the movement recording a quantity changed by editing an item.
+	manualEditReason = "manual_edit"
+)
+
+// itemColumns is the column list scanned by scanItem.
+const itemColumns = `
+	id, name, sku, barcodes, description, quantity, cost_price, list_price, COALESCE(currency, ''),
+	costing_method, serialized, reorder_point, reorder_quantity, version, created_at, updated_at
+`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanItem scans a row selected with itemColumns.
+func scanItem(row rowScanner) (*model.Item, error) {
+	var i model.Item
+	var sku sql.NullString
+	var barcodes pq.StringArray
+
+	if err := row.Scan(
+		&i.ID, &i.Name, &sku, &barcodes, &i.Description, &i.Quantity, &i.CostPrice, &i.ListPrice, &i.Currency,
+		&i.CostingMethod, &i.Serialized, &i.ReorderPoint, &i.ReorderQuantity, &i.Version, &i.CreatedAt, &i.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	i.SKU = sku.String
+	i.Barcodes = barcodes
+
+	return &i, nil
+}
+
 // Repository provides methods to interact with items table.
 type Repository struct {
 	db *dbpg.DB
@@ -28,18 +73,72 @@ func NewRepository(db *dbpg.DB) *Repository {
 }
 
 // CreateItem adds a new item to the database.
-func (r *Repository) CreateItem(ctx context.Context, item *model.Item) (uuid.UUID, error) {
+// A non-zero initial quantity is put into the default warehouse and recorded as a receive movement;
+// serialized items must start without stock, as their units are received by serial.
+// Items are costed FIFO unless item.CostingMethod says otherwise. Their prices start the
+// item's price history.
+// Must run within a UnitOfWork, as the item's stock and barcodes are written by further statements.
+func (r *Repository) CreateItem(ctx context.Context, userID uuid.UUID, item *model.Item) (uuid.UUID, error) {
+	if item.Serialized && item.Quantity != 0 {
+		return uuid.Nil, ErrSerialsRequired
+	}
+
+	if item.CostingMethod == "" {
+		item.CostingMethod = model.CostingFIFO
+	}
+
+	warehouseID, reason := uuid.Nil, ""
+	if item.Quantity != 0 {
+		var err error
+		if warehouseID, err = r.warehouseOrDefault(ctx, uuid.Nil); err != nil {
+			return uuid.Nil, err
+		}
+
+		reason = initialStockReason
+	}
+
+	if err := r.setChange(ctx, warehouseID, reason, "", ""); err != nil {
+		return uuid.Nil, err
+	}
+
 	query := `
-		INSERT INTO items (name, description, quantity, price)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at, updated_at
+		INSERT INTO items (
+			name, sku, barcodes, description, quantity, cost_price, list_price, currency, costing_method,
+			serialized, reorder_point, reorder_quantity
+		)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12)
+		RETURNING id, version, created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(
-		ctx, query, item.Name, item.Description, item.Quantity, item.Price,
-	).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+	err := r.conn(ctx).QueryRowContext(
+		ctx, query, item.Name, item.SKU, pq.Array(barcodesOrEmpty(item.Barcodes)), item.Description, item.Quantity,
+		item.CostPrice, item.ListPrice, item.Currency, item.CostingMethod, item.Serialized, item.ReorderPoint,
+		item.ReorderQuantity,
+	).Scan(&item.ID, &item.Version, &item.CreatedAt, &item.UpdatedAt)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to create item: %w", err)
+		return uuid.Nil, identifierError(err, "failed to create item")
+	}
+
+	if err := r.recordPrices(ctx, userID, item); err != nil {
+		return uuid.Nil, err
+	}
+
+	if item.Quantity != 0 {
+		err := r.applyStock(ctx, &model.StockMovement{
+			ItemID:      item.ID,
+			WarehouseID: warehouseID,
+			Type:        model.MovementReceive,
+			Quantity:    item.Quantity,
+			Reason:      initialStockReason,
+			CreatedBy:   &userID,
+		}, false)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	if err := r.syncBarcodes(ctx, item.ID, item.Barcodes); err != nil {
+		return uuid.Nil, err
 	}
 
 	return item.ID, nil
@@ -47,16 +146,9 @@ func (r *Repository) CreateItem(ctx context.Context, item *model.Item) (uuid.UUI
 
 // GetItemByID retrieves an item by id.
 func (r *Repository) GetItemByID(ctx context.Context, itemID uuid.UUID) (*model.Item, error) {
-	query := `
-        SELECT id, name, description, quantity, price, created_at, updated_at
-        FROM items
-        WHERE id = $1
-    `
+	query := `SELECT ` + itemColumns + ` FROM items WHERE id = $1`
 
-	var i model.Item
-	err := r.db.QueryRowContext(ctx, query, itemID).Scan(
-		&i.ID, &i.Name, &i.Description, &i.Quantity, &i.Price, &i.CreatedAt, &i.UpdatedAt,
-	)
+	i, err := scanItem(r.conn(ctx).QueryRowContext(ctx, query, itemID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrItemNotFound
@@ -65,80 +157,248 @@ func (r *Repository) GetItemByID(ctx context.Context, itemID uuid.UUID) (*model.
 		return nil, fmt.Errorf("query item by id: %w", err)
 	}
 
-	return &i, nil
+	return i, nil
+}
+
+// GetItemBySKU retrieves an item by its SKU.
+func (r *Repository) GetItemBySKU(ctx context.Context, sku string) (*model.Item, error) {
+	query := `SELECT ` + itemColumns + ` FROM items WHERE sku = $1`
+
+	i, err := scanItem(r.conn(ctx).QueryRowContext(ctx, query, sku))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+
+		return nil, fmt.Errorf("query item by sku: %w", err)
+	}
+
+	return i, nil
 }
 
-// GetAllItems retrieves all items, optionally filtered by name.
-func (r *Repository) GetAllItems(ctx context.Context, nameFilter string) ([]*model.Item, error) {
+// GetItemByBarcode retrieves an item by one of its barcodes, given as a 14-digit GTIN.
+func (r *Repository) GetItemByBarcode(ctx context.Context, gtin string) (*model.Item, error) {
 	query := `
-		SELECT id, name, description, quantity, price, created_at, updated_at
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE ($1 = '' OR name ILIKE '%' || $1 || '%')
-		ORDER BY created_at DESC
+		WHERE id = (SELECT item_id FROM item_barcodes WHERE code = $1)
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, nameFilter)
+	i, err := scanItem(r.conn(ctx).QueryRowContext(ctx, query, gtin))
 	if err != nil {
-		return nil, fmt.Errorf("failed to query items: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+
+		return nil, fmt.Errorf("query item by barcode: %w", err)
+	}
+
+	return i, nil
+}
+
+// GetAllItems retrieves one page of items matching the filter, in the filter's order.
+// It returns the cursor of the next page, or an empty string if this is the last page.
+func (r *Repository) GetAllItems(ctx context.Context, filter model.ItemFilter) ([]*model.Item, string, error) {
+	query, args, err := buildItemsQuery(filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query items: %w", err)
 	}
 	defer rows.Close()
 
 	var items []*model.Item
 	for rows.Next() {
-		var i model.Item
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.Quantity,
-			&i.Price,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan item: %w", err)
+		i, err := scanItem(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan item: %w", err)
 		}
 
-		items = append(items, &i)
+		items = append(items, i)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate items: %w", err)
+		return nil, "", fmt.Errorf("failed to iterate items: %w", err)
 	}
 
-	return items, nil
+	if len(items) <= filter.Limit {
+		return items, "", nil
+	}
+
+	items = items[:filter.Limit]
+
+	return items, encodeCursor(filter, items[len(items)-1]), nil
 }
 
-// UpdateItem updates an existing item in the database.
-func (r *Repository) UpdateItem(ctx context.Context, item *model.Item) error {
+// UpdateItem updates an existing item in the database if its version still equals item.Version.
+// On success item.Version holds the new version. Returns ErrVersionConflict if the item was
+// changed since that version was read.
+// A change of quantity is applied to the default warehouse and recorded as an adjust movement
+// with the difference and item.ReasonCode, which it requires (ErrReasonCodeRequired,
+// ErrUnknownReasonCode); ErrInsufficientStock is returned if that warehouse holds too little.
+// The quantity of serialized items cannot be edited (ErrSerialsRequired), and an item can only
+// become serialized or stop being so while it has no stock (ErrSerializedChange). Its costing
+// method is kept if item.CostingMethod is empty and can only change while it owns no stock,
+// in transit included (ErrCostingMethodChange). A change of either price or of their currency
+// is recorded in the item's price history.
+// Must run within a UnitOfWork, as the item is locked and written by several statements.
+func (r *Repository) UpdateItem(ctx context.Context, userID uuid.UUID, item *model.Item) error {
+	var oldQuantity int
+	var oldSerialized bool
+	var oldMethod model.CostingMethod
+	var oldCostPrice, oldListPrice decimal.Decimal
+	var oldCurrency string
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `
+			SELECT quantity, serialized, costing_method, cost_price, list_price, COALESCE(currency, '')
+			FROM items
+			WHERE id = $1 AND version = $2
+			FOR UPDATE
+		`,
+		item.ID, item.Version,
+	).Scan(&oldQuantity, &oldSerialized, &oldMethod, &oldCostPrice, &oldListPrice, &oldCurrency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.versionRejection(ctx, item.ID)
+		}
+
+		return fmt.Errorf("failed to lock item: %w", err)
+	}
+
+	delta := item.Quantity - oldQuantity
+
+	if delta != 0 && (oldSerialized || item.Serialized) {
+		return ErrSerialsRequired
+	}
+
+	if item.Serialized != oldSerialized && oldQuantity != 0 {
+		return ErrSerializedChange
+	}
+
+	if item.CostingMethod == "" {
+		item.CostingMethod = oldMethod
+	}
+
+	if item.CostingMethod != oldMethod {
+		owned, _, err := r.ownedStock(ctx, item.ID)
+		if err != nil {
+			return err
+		}
+
+		if owned != 0 {
+			return ErrCostingMethodChange
+		}
+	}
+
+	warehouseID, reason, reasonCode := uuid.Nil, "", ""
+	if delta != 0 {
+		if item.ReasonCode == "" {
+			return ErrReasonCodeRequired
+		}
+
+		if err := r.checkReasonCode(ctx, item.ReasonCode); err != nil {
+			return err
+		}
+
+		if warehouseID, err = r.warehouseOrDefault(ctx, uuid.Nil); err != nil {
+			return err
+		}
+
+		reason, reasonCode = manualEditReason, item.ReasonCode
+	}
+
+	if err := r.setChange(ctx, warehouseID, reason, reasonCode, ""); err != nil {
+		return err
+	}
+
+	if delta != 0 {
+		err := r.applyStock(ctx, &model.StockMovement{
+			ItemID:      item.ID,
+			WarehouseID: warehouseID,
+			Type:        model.MovementAdjust,
+			Quantity:    delta,
+			Reason:      manualEditReason,
+			ReasonCode:  reasonCode,
+			CreatedBy:   &userID,
+		}, false)
+		if err != nil {
+			return err
+		}
+	}
+
 	query := `
 		UPDATE items
-		SET name = $1, description = $2, quantity = $3, price = $4, updated_at = NOW()
-		WHERE id = $5
+		SET name = $1, sku = NULLIF($2, ''), barcodes = $3, description = $4, quantity = $5, cost_price = $6,
+		    list_price = $7, currency = NULLIF($8, ''), costing_method = $9, serialized = $10, reorder_point = $11,
+		    reorder_quantity = $12, version = version + 1, updated_at = NOW()
+		WHERE id = $13
+		RETURNING version
 	`
 
-	res, err := r.db.ExecContext(ctx, query, item.Name, item.Description, item.Quantity, item.Price, item.ID)
+	err = r.conn(ctx).QueryRowContext(
+		ctx, query, item.Name, item.SKU, pq.Array(barcodesOrEmpty(item.Barcodes)), item.Description, item.Quantity,
+		item.CostPrice, item.ListPrice, item.Currency, item.CostingMethod, item.Serialized, item.ReorderPoint,
+		item.ReorderQuantity, item.ID,
+	).Scan(&item.Version)
 	if err != nil {
-		return fmt.Errorf("failed to update item: %w", err)
+		return identifierError(err, "failed to update item")
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	if !item.CostPrice.Equal(oldCostPrice) || !item.ListPrice.Equal(oldListPrice) || item.Currency != oldCurrency {
+		if err := r.recordPrices(ctx, userID, item); err != nil {
+			return err
+		}
+	}
+
+	return r.syncBarcodes(ctx, item.ID, item.Barcodes)
+}
+
+// syncBarcodes makes item_barcodes hold exactly the given barcodes for the item.
+// Returns ErrBarcodeTaken if one of them belongs to another item.
+func (r *Repository) syncBarcodes(ctx context.Context, itemID uuid.UUID, barcodes []string) error {
+	codes := pq.Array(barcodesOrEmpty(barcodes))
+
+	_, err := r.conn(ctx).ExecContext(
+		ctx, `DELETE FROM item_barcodes WHERE item_id = $1 AND NOT (code = ANY($2))`, itemID, codes,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to delete barcodes: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrItemNotFound
+	query := `
+		INSERT INTO item_barcodes (code, item_id)
+		SELECT u.code, $1
+		FROM unnest($2::TEXT[]) AS u(code)
+		WHERE NOT EXISTS (SELECT 1 FROM item_barcodes b WHERE b.code = u.code AND b.item_id = $1)
+	`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, itemID, codes); err != nil {
+		return identifierError(err, "failed to insert barcodes")
 	}
 
 	return nil
 }
 
-// DeleteItem deletes an item by id.
-func (r *Repository) DeleteItem(ctx context.Context, itemID uuid.UUID) error {
-	query := `DELETE FROM items WHERE id = $1`
+// DeleteItem deletes an item by id if its version still equals version.
+// Returns ErrVersionConflict if the item was changed since that version was read and
+// ErrItemReferenced if it has stock movements or documents such as transfer orders refer to it.
+func (r *Repository) DeleteItem(ctx context.Context, itemID uuid.UUID, version int) error {
+	if err := r.setChange(ctx, uuid.Nil, "", "", ""); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM items WHERE id = $1 AND version = $2`
 
-	res, err := r.db.ExecContext(ctx, query, itemID)
+	res, err := r.conn(ctx).ExecContext(ctx, query, itemID, version)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrItemReferenced
+		}
+
 		return fmt.Errorf("failed to delete item: %w", err)
 	}
 
@@ -148,22 +408,217 @@ func (r *Repository) DeleteItem(ctx context.Context, itemID uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return ErrItemNotFound
+		return r.versionRejection(ctx, itemID)
+	}
+
+	return nil
+}
+
+// CreateMovement applies a signed stock movement to an item in m.WarehouseID, or in the
+// default warehouse if it is uuid.Nil, and sets m.WarehouseID to the warehouse used.
+// If m.LocationCode is set, the movement also applies to that bin of the warehouse, and if
+// m.LotNumber is set, to that lot of the item. Issues from an expired lot are rejected with
+// ErrLotExpired unless m.ExpiryOverride is set; it is kept only on issues it was needed for.
+// Movements of serialized items must name one serial per unit in m.Serials (see applySerials).
+// A reason code in m.ReasonCode must be an active one (ErrUnknownReasonCode).
+// The quantities are changed relative to their current values, so concurrent movements
+// never lose updates.
+// Returns ErrInsufficientStock if the movement would make the warehouse's quantity negative or
+// take stock that is reserved.
+// The warehouse's stock, the movement and the item's quantity change in one statement (see
+// applyStock). Must run within a UnitOfWork, as bins, lots, serials and cost layers are written
+// by separate statements.
+func (r *Repository) CreateMovement(ctx context.Context, m *model.StockMovement) error {
+	warehouseID, err := r.warehouseOrDefault(ctx, m.WarehouseID)
+	if err != nil {
+		return err
+	}
+
+	m.WarehouseID = warehouseID
+
+	if m.LocationCode != "" {
+		locationID, err := r.resolveLocation(ctx, m.WarehouseID, m.LocationCode)
+		if err != nil {
+			return err
+		}
+
+		m.LocationID = &locationID
+	}
+
+	if m.LotNumber != "" {
+		lotID, expired, err := r.resolveLot(ctx, m.ItemID, m.LotNumber)
+		if err != nil {
+			return err
+		}
+
+		if m.Type == model.MovementIssue && expired && !m.ExpiryOverride {
+			return ErrLotExpired
+		}
+
+		m.LotID = &lotID
+		m.ExpiryOverride = m.ExpiryOverride && m.Type == model.MovementIssue && expired
+	}
+
+	serialized, err := r.itemSerialized(ctx, m.ItemID)
+	if err != nil {
+		return err
+	}
+
+	if !serialized && len(m.Serials) > 0 {
+		return ErrNotSerialized
+	}
+
+	if serialized && len(m.Serials) != max(m.Quantity, -m.Quantity) {
+		return ErrSerialsRequired
+	}
+
+	if m.ReasonCode != "" {
+		if err := r.checkReasonCode(ctx, m.ReasonCode); err != nil {
+			return err
+		}
+	}
+
+	if err := r.setChange(ctx, m.WarehouseID, m.Reason, m.ReasonCode, m.Reference); err != nil {
+		return err
+	}
+
+	if err := r.applyStock(ctx, m, true); err != nil {
+		return err
+	}
+
+	if serialized {
+		if err := r.applySerials(ctx, m); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// GetMovements retrieves the stock movements of an item, newest first.
+func (r *Repository) GetMovements(ctx context.Context, itemID uuid.UUID) ([]*model.StockMovement, error) {
+	query := `
+		SELECT m.id, m.item_id, m.warehouse_id, m.location_id, COALESCE(l.code, ''), m.lot_id, COALESCE(lt.number, ''),
+		       m.expiry_override, m.movement_type, m.quantity, m.balance_after, m.reason, COALESCE(m.reason_code, ''),
+		       m.cost, m.reference, m.created_by,
+		       m.created_at,
+		       ARRAY(SELECT s.serial
+		             FROM movement_serials ms
+		             JOIN serials s ON s.id = ms.serial_id
+		             WHERE ms.movement_id = m.id
+		             ORDER BY s.serial)
+		FROM stock_movements m
+		LEFT JOIN locations l ON l.id = m.location_id
+		LEFT JOIN lots lt ON lt.id = m.lot_id
+		WHERE m.item_id = $1
+		ORDER BY m.created_at DESC
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []*model.StockMovement
+	for rows.Next() {
+		var m model.StockMovement
+		var reference sql.NullString
+		var locationID, lotID, createdBy uuid.NullUUID
+		var serials pq.StringArray
+
+		if err := rows.Scan(
+			&m.ID, &m.ItemID, &m.WarehouseID, &locationID, &m.LocationCode, &lotID, &m.LotNumber, &m.ExpiryOverride,
+			&m.Type, &m.Quantity, &m.BalanceAfter, &m.Reason, &m.ReasonCode, &m.Cost, &reference, &createdBy, &m.CreatedAt,
+			&serials,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan movement: %w", err)
+		}
+
+		m.Reference = reference.String
+		m.Serials = serials
+		if locationID.Valid {
+			m.LocationID = &locationID.UUID
+		}
+
+		if lotID.Valid {
+			m.LotID = &lotID.UUID
+		}
+
+		if createdBy.Valid {
+			m.CreatedBy = &createdBy.UUID
+		}
+
+		movements = append(movements, &m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// movementRejection explains why a guarded stock update matched no rows:
+// either the item or the warehouse does not exist or there is not enough stock.
+func (r *Repository) movementRejection(ctx context.Context, itemID, warehouseID uuid.UUID) error {
+	exists, err := r.itemExists(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrItemNotFound
+	}
+
+	if exists, err = r.warehouseExists(ctx, warehouseID); err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrWarehouseNotFound
+	}
+
+	return ErrInsufficientStock
+}
+
+// versionRejection explains why a compare-and-swap on the item version matched no rows:
+// either the item does not exist or its version has changed.
+func (r *Repository) versionRejection(ctx context.Context, itemID uuid.UUID) error {
+	exists, err := r.itemExists(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrItemNotFound
+	}
+
+	return ErrVersionConflict
+}
+
+// itemExists checks if an item with the given id exists.
+func (r *Repository) itemExists(ctx context.Context, itemID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.conn(ctx).QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM items WHERE id = $1)`, itemID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if item exists: %w", err)
+	}
+
+	return exists, nil
+}
+
 // GetItemHistory retrieves change history for an item.
 func (r *Repository) GetItemHistory(ctx context.Context, itemID uuid.UUID) ([]*model.ItemHistory, error) {
 	query := `
-		SELECT id, item_id, action, changed_by, changed_at, old_data, new_data
+		SELECT id, item_id, action, changed_by, changed_at, actor_role, request_id, client_ip, warehouse_id,
+		       COALESCE(reason, ''), COALESCE(reason_code, ''), COALESCE(reference, ''), old_data, new_data, diff
 		FROM item_history
 		WHERE item_id = $1
 		ORDER BY changed_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, itemID)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query item history: %w", err)
 	}
@@ -172,14 +627,25 @@ func (r *Repository) GetItemHistory(ctx context.Context, itemID uuid.UUID) ([]*m
 	var history []*model.ItemHistory
 	for rows.Next() {
 		var h model.ItemHistory
-		var oldData, newData sql.NullString
+		var actorRole, requestID, clientIP sql.NullString
+		var warehouseID uuid.NullUUID
+		var oldData, newData, diff sql.NullString
 
 		if err := rows.Scan(
-			&h.ID, &h.ItemID, &h.Action, &h.ChangedBy, &h.ChangedAt, &oldData, &newData,
+			&h.ID, &h.ItemID, &h.Action, &h.ChangedBy, &h.ChangedAt,
+			&actorRole, &requestID, &clientIP, &warehouseID, &h.Reason, &h.ReasonCode, &h.Reference, &oldData, &newData,
+			&diff,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan item history: %w", err)
 		}
 
+		h.ActorRole = actorRole.String
+		h.RequestID = requestID.String
+		h.ClientIP = clientIP.String
+		if warehouseID.Valid {
+			h.WarehouseID = &warehouseID.UUID
+		}
+
 		// Convert into json.RawMessage
 		if oldData.Valid {
 			h.OldData = json.RawMessage(oldData.String)
@@ -193,12 +659,70 @@ func (r *Repository) GetItemHistory(ctx context.Context, itemID uuid.UUID) ([]*m
 			h.NewData = json.RawMessage(`{}`)
 		}
 
+		if diff.Valid {
+			h.Diff = json.RawMessage(diff.String)
+		}
+
 		history = append(history, &h)
 	}
 
 	return history, nil
 }
 
+// InsertItemHistory adds a row to item_history. Used when history is written by the
+// application instead of the database triggers. Like the triggers, it takes the warehouse,
+// the reason, its code and the reference from the transaction (see setChange) and sets
+// h.WarehouseID, h.Reason, h.ReasonCode and h.Reference accordingly.
+func (r *Repository) InsertItemHistory(ctx context.Context, h *model.ItemHistory) error {
+	query := `
+		INSERT INTO item_history (
+			item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id, reason, reason_code,
+			reference, old_data, new_data, diff
+		)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''),
+		        NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+		        NULLIF(current_setting('app.reason', true), ''),
+		        NULLIF(current_setting('app.reason_code', true), ''),
+		        NULLIF(current_setting('app.reference', true), ''), $7, $8, $9)
+		RETURNING id, changed_at, warehouse_id, COALESCE(reason, ''), COALESCE(reason_code, ''),
+		          COALESCE(reference, '')
+	`
+
+	var warehouseID uuid.NullUUID
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, query,
+		h.ItemID, h.Action, h.ChangedBy, h.ActorRole, h.RequestID, h.ClientIP,
+		nullJSON(h.OldData), nullJSON(h.NewData), nullJSON(h.Diff),
+	).Scan(&h.ID, &h.ChangedAt, &warehouseID, &h.Reason, &h.ReasonCode, &h.Reference)
+	if err != nil {
+		return fmt.Errorf("failed to insert item history: %w", err)
+	}
+
+	if warehouseID.Valid {
+		h.WarehouseID = &warehouseID.UUID
+	}
+
+	return nil
+}
+
+// SnapshotItem returns the item row as JSON, in the same shape the triggers store in item_history.
+// The row is locked until the end of the transaction so the snapshot stays accurate.
+func (r *Repository) SnapshotItem(ctx context.Context, itemID uuid.UUID) (json.RawMessage, error) {
+	query := `SELECT to_jsonb(i) - 'search_vector' FROM items i WHERE id = $1 FOR UPDATE`
+
+	var data []byte
+	if err := r.conn(ctx).QueryRowContext(ctx, query, itemID).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+
+		return nil, fmt.Errorf("failed to snapshot item: %w", err)
+	}
+
+	return data, nil
+}
+
 // CompareVersions decodes old and new JSONB data from history and returns them as maps.
 func (r *Repository) CompareVersions(oldData, newData json.RawMessage) (map[string]interface{}, map[string]interface{}, error) {
 	var oldMap, newMap map[string]interface{}
@@ -218,12 +742,37 @@ func (r *Repository) CompareVersions(oldData, newData json.RawMessage) (map[stri
 	return oldMap, newMap, nil
 }
 
-// SetCurrentUser sets the current user in the PostgreSQL session for auditing.
-func (r *Repository) SetCurrentUser(ctx context.Context, userID uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx, "SELECT set_config('app.current_user_id', $1, false)", userID.String())
-	if err != nil {
-		return fmt.Errorf("failed to set current_user_id: %w", err)
+// nullJSON converts empty JSON into a SQL NULL.
+func nullJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
 	}
 
-	return nil
+	// Passed as a string: lib/pq would encode []byte as bytea.
+	return string(data)
+}
+
+// barcodesOrEmpty returns an empty slice for nil, as barcodes cannot be NULL.
+func barcodesOrEmpty(barcodes []string) []string {
+	if barcodes == nil {
+		return []string{}
+	}
+
+	return barcodes
+}
+
+// identifierError maps unique violations of the SKU or barcodes to ErrSKUTaken and
+// ErrBarcodeTaken, and wraps any other error with msg.
+func identifierError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "items_sku_key":
+			return ErrSKUTaken
+		case "item_barcodes_pkey":
+			return ErrBarcodeTaken
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
 }
@@ -0,0 +1,207 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotActive = errors.New("reservation has already been released or has expired")
+)
+
+// reservationColumns is the column list scanned by scanReservation; it expects
+// stock_reservations as rv.
+const reservationColumns = `
+	rv.id, rv.item_id, rv.warehouse_id, rv.owner, rv.quantity, rv.status, rv.expires_at, rv.created_by,
+	rv.created_at, rv.released_by, rv.released_at
+`
+
+// scanReservation scans a row selected with reservationColumns.
+func scanReservation(row rowScanner) (*model.Reservation, error) {
+	var rv model.Reservation
+	var createdBy, releasedBy uuid.NullUUID
+	var releasedAt sql.NullTime
+
+	if err := row.Scan(
+		&rv.ID, &rv.ItemID, &rv.WarehouseID, &rv.Owner, &rv.Quantity, &rv.Status, &rv.ExpiresAt, &createdBy,
+		&rv.CreatedAt, &releasedBy, &releasedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		rv.CreatedBy = &createdBy.UUID
+	}
+
+	if releasedBy.Valid {
+		rv.ReleasedBy = &releasedBy.UUID
+	}
+
+	rv.ReleasedAt = nullTime(releasedAt)
+
+	return &rv, nil
+}
+
+// CreateReservation reserves rv.Quantity units of an item in rv.WarehouseID, or in the default
+// warehouse if it is uuid.Nil, for ttl from now. The warehouse used, the expiry and the other
+// generated fields are set on rv. The stock is reserved in full or not at all.
+// Returns ErrInsufficientStock if less than rv.Quantity units are available to promise.
+func (r *Repository) CreateReservation(ctx context.Context, rv *model.Reservation, ttl time.Duration) error {
+	var err error
+
+	if rv.WarehouseID, err = r.warehouseOrDefault(ctx, rv.WarehouseID); err != nil {
+		return err
+	}
+
+	// The reservation is only inserted if the guarded update of the stock matched.
+	query := `
+		WITH st AS (
+			UPDATE item_stock
+			SET reserved = reserved + $4
+			WHERE item_id = $1 AND warehouse_id = $2 AND quantity - reserved >= $4
+			RETURNING item_id
+		)
+		INSERT INTO stock_reservations (item_id, warehouse_id, owner, quantity, expires_at, created_by)
+		SELECT $1, $2, $3, $4, NOW() + make_interval(secs => $5), $6
+		FROM st
+		RETURNING id, status, expires_at, created_at
+	`
+
+	err = r.conn(ctx).QueryRowContext(
+		ctx, query, rv.ItemID, rv.WarehouseID, rv.Owner, rv.Quantity, ttl.Seconds(), rv.CreatedBy,
+	).Scan(&rv.ID, &rv.Status, &rv.ExpiresAt, &rv.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.movementRejection(ctx, rv.ItemID, rv.WarehouseID)
+		}
+
+		return fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	return nil
+}
+
+// GetReservations retrieves the reservations of an item, newest first. Only reservations
+// with the given status are returned unless it is empty.
+func (r *Repository) GetReservations(
+	ctx context.Context,
+	itemID uuid.UUID,
+	status model.ReservationStatus,
+) ([]*model.Reservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM stock_reservations rv
+		WHERE rv.item_id = $1 AND ($2 = '' OR rv.status::TEXT = $2)
+		ORDER BY rv.created_at DESC
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []*model.Reservation
+	for rows.Next() {
+		rv, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+
+		reservations = append(reservations, rv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// ReleaseReservation releases an active reservation on behalf of a user and gives its stock back.
+// Returns ErrReservationNotFound if there is no such reservation and ErrReservationNotActive if
+// it was already released or has expired.
+func (r *Repository) ReleaseReservation(ctx context.Context, reservationID, userID uuid.UUID) (*model.Reservation, error) {
+	query := `
+		WITH released AS (
+			UPDATE stock_reservations rv
+			SET status = 'released', released_by = $2, released_at = NOW()
+			WHERE rv.id = $1 AND rv.status = 'active'
+			RETURNING rv.*
+		), st AS (
+			UPDATE item_stock s
+			SET reserved = s.reserved - released.quantity
+			FROM released
+			WHERE s.item_id = released.item_id AND s.warehouse_id = released.warehouse_id
+		)
+		SELECT ` + reservationColumns + `
+		FROM released rv
+	`
+
+	rv, err := scanReservation(r.conn(ctx).QueryRowContext(ctx, query, reservationID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.reservationRejection(ctx, reservationID)
+		}
+
+		return nil, fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	return rv, nil
+}
+
+// ExpireReservations expires the active reservations whose expiry has passed, gives their
+// stock back and returns how many were expired.
+func (r *Repository) ExpireReservations(ctx context.Context) (int, error) {
+	query := `
+		WITH expired AS (
+			UPDATE stock_reservations
+			SET status = 'expired', released_at = NOW()
+			WHERE status = 'active' AND expires_at <= NOW()
+			RETURNING item_id, warehouse_id, quantity
+		), totals AS (
+			SELECT item_id, warehouse_id, SUM(quantity) AS quantity
+			FROM expired
+			GROUP BY item_id, warehouse_id
+		), st AS (
+			UPDATE item_stock s
+			SET reserved = s.reserved - t.quantity
+			FROM totals t
+			WHERE s.item_id = t.item_id AND s.warehouse_id = t.warehouse_id
+		)
+		SELECT COUNT(*) FROM expired
+	`
+
+	var expired int
+	if err := r.conn(ctx).QueryRowContext(ctx, query).Scan(&expired); err != nil {
+		return 0, fmt.Errorf("failed to expire reservations: %w", err)
+	}
+
+	return expired, nil
+}
+
+// reservationRejection explains why the release of a reservation matched no rows: either the
+// reservation does not exist or it is no longer active.
+func (r *Repository) reservationRejection(ctx context.Context, reservationID uuid.UUID) error {
+	var exists bool
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT EXISTS(SELECT 1 FROM stock_reservations WHERE id = $1)`, reservationID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check if reservation exists: %w", err)
+	}
+
+	if !exists {
+		return ErrReservationNotFound
+	}
+
+	return ErrReservationNotActive
+}
@@ -0,0 +1,553 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrSalesNotFound     = errors.New("sales order not found")
+	ErrSalesStatusChange = errors.New("sales order status was changed by someone else")
+)
+
+// salesColumns is the column list scanned by scanSales; it expects sales_orders as o.
+const salesColumns = `
+	o.id, o.customer, COALESCE(o.ship_to, ''), o.warehouse_id, o.status, COALESCE(o.note, ''), o.created_by,
+	o.created_at, o.updated_at, o.allocated_at, o.picked_at, o.packed_at, o.shipped_at, o.cancelled_at
+`
+
+// scanSales scans a row selected with salesColumns.
+func scanSales(row rowScanner) (*model.SalesOrder, error) {
+	var o model.SalesOrder
+	var createdBy uuid.NullUUID
+	var allocatedAt, pickedAt, packedAt, shippedAt, cancelledAt sql.NullTime
+
+	if err := row.Scan(
+		&o.ID, &o.Customer, &o.ShipTo, &o.WarehouseID, &o.Status, &o.Note, &createdBy,
+		&o.CreatedAt, &o.UpdatedAt, &allocatedAt, &pickedAt, &packedAt, &shippedAt, &cancelledAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		o.CreatedBy = &createdBy.UUID
+	}
+
+	o.AllocatedAt = nullTime(allocatedAt)
+	o.PickedAt = nullTime(pickedAt)
+	o.PackedAt = nullTime(packedAt)
+	o.ShippedAt = nullTime(shippedAt)
+	o.CancelledAt = nullTime(cancelledAt)
+
+	return &o, nil
+}
+
+// CreateSalesOrder adds a draft sales order with its lines. A warehouse given as uuid.Nil is
+// replaced by the default warehouse, and lines without a unit price take the item's list price,
+// in the base currency (ErrNoExchangeRate if it cannot be converted).
+// Serialized items cannot be sold by order, as lines do not name serials (ErrSerializedSale).
+// Must run within a UnitOfWork.
+func (r *Repository) CreateSalesOrder(ctx context.Context, o *model.SalesOrder) error {
+	var err error
+
+	if o.WarehouseID, err = r.warehouseOrDefault(ctx, o.WarehouseID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO sales_orders (customer, ship_to, warehouse_id, note, created_by)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err = r.conn(ctx).QueryRowContext(ctx, query, o.Customer, o.ShipTo, o.WarehouseID, o.Note, o.CreatedBy).Scan(
+		&o.ID, &o.Status, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return salesError(err, "failed to create sales order")
+	}
+
+	for _, line := range o.Lines {
+		serialized, err := r.itemSerialized(ctx, line.ItemID)
+		if err != nil {
+			return err
+		}
+
+		if serialized {
+			return ErrSerializedSale
+		}
+
+		if line.UnitPrice == nil {
+			_, listPrice, err := r.basePrices(ctx, line.ItemID)
+			if err != nil {
+				return err
+			}
+
+			line.UnitPrice = &listPrice
+		}
+
+		_, err = r.conn(ctx).ExecContext(ctx, `
+			INSERT INTO sales_order_lines (sales_order_id, item_id, quantity, unit_price)
+			VALUES ($1, $2, $3, $4)
+		`, o.ID, line.ItemID, line.Quantity, line.UnitPrice)
+		if err != nil {
+			return salesError(err, "failed to create sales order line")
+		}
+	}
+
+	return r.loadSalesLines(ctx, []*model.SalesOrder{o})
+}
+
+// GetSalesOrder retrieves a sales order with its lines.
+func (r *Repository) GetSalesOrder(ctx context.Context, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return r.getSalesOrder(ctx, orderID, "")
+}
+
+// LockSalesOrder retrieves a sales order with its lines and locks it for the rest of the
+// transaction, so that concurrent allocations and status changes of the order are serialized.
+// Must run within a UnitOfWork.
+func (r *Repository) LockSalesOrder(ctx context.Context, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return r.getSalesOrder(ctx, orderID, "FOR UPDATE OF o")
+}
+
+// getSalesOrder retrieves a sales order with its lines, appending lock to the query.
+func (r *Repository) getSalesOrder(ctx context.Context, orderID uuid.UUID, lock string) (*model.SalesOrder, error) {
+	query := `SELECT ` + salesColumns + ` FROM sales_orders o WHERE o.id = $1 ` + lock
+
+	o, err := scanSales(r.conn(ctx).QueryRowContext(ctx, query, orderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSalesNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get sales order: %w", err)
+	}
+
+	if err := r.loadSalesLines(ctx, []*model.SalesOrder{o}); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// GetSalesOrders retrieves the sales orders matching filter with their lines, oldest first.
+func (r *Repository) GetSalesOrders(ctx context.Context, filter model.SalesFilter) ([]*model.SalesOrder, error) {
+	query := `SELECT ` + salesColumns + ` FROM sales_orders o
+		WHERE ($1 = '' OR o.status::TEXT = $1)
+		  AND ($2 = '00000000-0000-0000-0000-000000000000'::UUID
+		       OR EXISTS (SELECT 1 FROM sales_order_lines sl WHERE sl.sales_order_id = o.id AND sl.item_id = $2))
+		ORDER BY o.created_at, o.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, string(filter.Status), filter.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sales orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []*model.SalesOrder{}
+	for rows.Next() {
+		o, err := scanSales(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sales order: %w", err)
+		}
+
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sales orders: %w", err)
+	}
+
+	if err := r.loadSalesLines(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadSalesLines fills in the lines of orders.
+func (r *Repository) loadSalesLines(ctx context.Context, orders []*model.SalesOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*model.SalesOrder, len(orders))
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		o.Lines = []*model.SalesLine{}
+		byID[o.ID] = o
+		ids = append(ids, o.ID.String())
+	}
+
+	query := `
+		SELECT sl.sales_order_id, sl.item_id, i.name, sl.quantity, sl.allocated_quantity, sl.unit_price
+		FROM sales_order_lines sl
+		JOIN items i ON i.id = sl.item_id
+		WHERE sl.sales_order_id = ANY($1::UUID[])
+		ORDER BY i.name, sl.item_id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query sales order lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID uuid.UUID
+		var line model.SalesLine
+		var unitPrice decimal.Decimal
+
+		if err := rows.Scan(
+			&orderID, &line.ItemID, &line.ItemName, &line.Quantity, &line.AllocatedQuantity, &unitPrice,
+		); err != nil {
+			return fmt.Errorf("failed to scan sales order line: %w", err)
+		}
+
+		line.UnitPrice = &unitPrice
+		byID[orderID].Lines = append(byID[orderID].Lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate sales order lines: %w", err)
+	}
+
+	return nil
+}
+
+// AddSalesAllocated adds a signed quantity to the allocated quantity of an item's line of an order.
+// Must run within a UnitOfWork that locked the order.
+func (r *Repository) AddSalesAllocated(ctx context.Context, orderID, itemID uuid.UUID, quantity int) error {
+	query := `
+		UPDATE sales_order_lines
+		SET allocated_quantity = allocated_quantity + $3
+		WHERE sales_order_id = $1 AND item_id = $2
+	`
+
+	res, err := r.conn(ctx).ExecContext(ctx, query, orderID, itemID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update allocated quantity: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrSalesNotFound
+	}
+
+	return nil
+}
+
+// ReserveStock reserves up to quantity units of an item's stock in a warehouse that are not
+// reserved yet and returns how many it reserved, which is 0 if none are available.
+// Must run within a UnitOfWork, as the stock is locked before it is reserved.
+func (r *Repository) ReserveStock(ctx context.Context, itemID, warehouseID uuid.UUID, quantity int) (int, error) {
+	var available int
+
+	err := r.conn(ctx).QueryRowContext(ctx, `
+		SELECT quantity - reserved
+		FROM item_stock
+		WHERE item_id = $1 AND warehouse_id = $2
+		FOR UPDATE
+	`, itemID, warehouseID).Scan(&available)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("failed to get available stock: %w", err)
+	}
+
+	reserved := min(quantity, available)
+	if reserved <= 0 {
+		return 0, nil
+	}
+
+	_, err = r.conn(ctx).ExecContext(ctx, `
+		UPDATE item_stock
+		SET reserved = reserved + $3
+		WHERE item_id = $1 AND warehouse_id = $2
+	`, itemID, warehouseID, reserved)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+
+	return reserved, nil
+}
+
+// ReleaseStock returns quantity reserved units of an item's stock in a warehouse to the
+// stock available to promise.
+func (r *Repository) ReleaseStock(ctx context.Context, itemID, warehouseID uuid.UUID, quantity int) error {
+	res, err := r.conn(ctx).ExecContext(ctx, `
+		UPDATE item_stock
+		SET reserved = reserved - $3
+		WHERE item_id = $1 AND warehouse_id = $2 AND reserved >= $3
+	`, itemID, warehouseID, quantity)
+	if err != nil {
+		return fmt.Errorf("failed to release stock: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("failed to release stock: fewer than %d units of item %s are reserved", quantity, itemID)
+	}
+
+	return nil
+}
+
+// GetReservedQuantity retrieves how much of an item's stock is reserved over all warehouses.
+func (r *Repository) GetReservedQuantity(ctx context.Context, itemID uuid.UUID) (int, error) {
+	var reserved int
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT COALESCE(SUM(reserved), 0) FROM item_stock WHERE item_id = $1`, itemID,
+	).Scan(&reserved)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get reserved quantity: %w", err)
+	}
+
+	return reserved, nil
+}
+
+// pickedElsewhere sums the quantity of item $1 that the pick lists of other open orders than
+// $3 take from the pick lines matching cond.
+const pickedElsewhere = `
+	COALESCE((SELECT SUM(p.quantity)
+	          FROM sales_pick_lines p
+	          JOIN sales_orders so ON so.id = p.sales_order_id
+	          WHERE p.item_id = $1 AND so.id <> $3 AND so.status IN ('picking', 'packed') AND %s), 0)
+`
+
+// GetPickSources retrieves what the pick list of an order may take of an item in a warehouse:
+// its bins in the order of their codes and its lots that have not expired, first expiring
+// first, each followed by the stock in no bin or no lot. Stock on the pick lists of other
+// open orders is left out.
+func (r *Repository) GetPickSources(
+	ctx context.Context,
+	itemID, warehouseID, orderID uuid.UUID,
+) ([]*model.PickSource, []*model.PickSource, error) {
+	binPicked := fmt.Sprintf(pickedElsewhere, "p.location_id = ls.location_id")
+	unbinnedPicked := fmt.Sprintf(pickedElsewhere, "p.location_id IS NULL")
+	lotPicked := fmt.Sprintf(pickedElsewhere, "p.lot_id = s.lot_id")
+	unlottedPicked := fmt.Sprintf(pickedElsewhere, "p.lot_id IS NULL")
+
+	bins, err := r.queryPickSources(ctx, `
+		SELECT id, code, quantity
+		FROM (
+			SELECT ls.location_id AS id, l.code, ls.quantity - `+binPicked+` AS quantity
+			FROM location_stock ls
+			JOIN locations l ON l.id = ls.location_id
+			WHERE ls.item_id = $1 AND l.warehouse_id = $2 AND ls.quantity > 0
+			UNION ALL
+			SELECT NULL, '',
+			       COALESCE((SELECT quantity FROM item_stock WHERE item_id = $1 AND warehouse_id = $2), 0)
+			       - COALESCE((SELECT SUM(ls.quantity)
+			                   FROM location_stock ls
+			                   JOIN locations l ON l.id = ls.location_id
+			                   WHERE ls.item_id = $1 AND l.warehouse_id = $2), 0)
+			       - `+unbinnedPicked+`
+		) src
+		ORDER BY id IS NULL, code
+	`, itemID, warehouseID, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lots, err := r.queryPickSources(ctx, `
+		SELECT id, code, quantity
+		FROM (
+			SELECT s.lot_id AS id, lt.number AS code, s.quantity - `+lotPicked+` AS quantity,
+			       lt.expires_on, lt.created_at
+			FROM lot_stock s
+			JOIN lots lt ON lt.id = s.lot_id
+			WHERE lt.item_id = $1 AND s.warehouse_id = $2 AND s.quantity > 0
+			  AND (lt.expires_on IS NULL OR lt.expires_on >= CURRENT_DATE)
+			UNION ALL
+			SELECT NULL, '',
+			       COALESCE((SELECT quantity FROM item_stock WHERE item_id = $1 AND warehouse_id = $2), 0)
+			       - COALESCE((SELECT SUM(s.quantity)
+			                   FROM lot_stock s
+			                   JOIN lots lt ON lt.id = s.lot_id
+			                   WHERE lt.item_id = $1 AND s.warehouse_id = $2), 0)
+			       - `+unlottedPicked+`,
+			       NULL, NULL
+		) src
+		ORDER BY id IS NULL, expires_on NULLS LAST, created_at, code
+	`, itemID, warehouseID, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bins, lots, nil
+}
+
+// queryPickSources runs a query selecting the ID, code and quantity of pick sources and
+// scans the ones with stock left.
+func (r *Repository) queryPickSources(ctx context.Context, query string, args ...interface{}) ([]*model.PickSource, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pick sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []*model.PickSource{}
+	for rows.Next() {
+		var s model.PickSource
+		var id uuid.NullUUID
+
+		if err := rows.Scan(&id, &s.Code, &s.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan pick source: %w", err)
+		}
+
+		if s.Quantity <= 0 {
+			continue
+		}
+
+		if id.Valid {
+			s.ID = &id.UUID
+		}
+
+		sources = append(sources, &s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pick sources: %w", err)
+	}
+
+	return sources, nil
+}
+
+// InsertPickLines adds lines to the pick list of an order.
+// Must run within a UnitOfWork that locked the order.
+func (r *Repository) InsertPickLines(ctx context.Context, orderID uuid.UUID, lines []*model.PickListLine) error {
+	for _, line := range lines {
+		_, err := r.conn(ctx).ExecContext(ctx, `
+			INSERT INTO sales_pick_lines (sales_order_id, item_id, location_id, lot_id, quantity)
+			VALUES ($1, $2, $3, $4, $5)
+		`, orderID, line.ItemID, line.LocationID, line.LotID, line.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to insert pick line: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetPickLines retrieves the pick list of an order sorted by bin, with the stock in no bin last.
+func (r *Repository) GetPickLines(ctx context.Context, orderID uuid.UUID) ([]*model.PickListLine, error) {
+	query := `
+		SELECT p.item_id, i.name, p.location_id, COALESCE(l.code, ''), p.lot_id, COALESCE(lt.number, ''), p.quantity
+		FROM sales_pick_lines p
+		JOIN items i ON i.id = p.item_id
+		LEFT JOIN locations l ON l.id = p.location_id
+		LEFT JOIN lots lt ON lt.id = p.lot_id
+		WHERE p.sales_order_id = $1
+		ORDER BY l.code NULLS LAST, i.name, p.item_id, lt.expires_on NULLS LAST, lt.number NULLS LAST
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pick lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []*model.PickListLine{}
+	for rows.Next() {
+		var line model.PickListLine
+		var locationID, lotID uuid.NullUUID
+
+		if err := rows.Scan(
+			&line.ItemID, &line.ItemName, &locationID, &line.LocationCode, &lotID, &line.LotNumber, &line.Quantity,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan pick line: %w", err)
+		}
+
+		if locationID.Valid {
+			line.LocationID = &locationID.UUID
+		}
+
+		if lotID.Valid {
+			line.LotID = &lotID.UUID
+		}
+
+		lines = append(lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pick lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+// SetSalesStatus moves a sales order from its status to status to and stamps the time of the
+// change. An order may stay in its status, e.g. after another partial allocation.
+// Returns ErrSalesStatusChange if the order is no longer in the status it was read with.
+func (r *Repository) SetSalesStatus(ctx context.Context, o *model.SalesOrder, to model.SalesStatus) error {
+	query := `
+		UPDATE sales_orders
+		SET status       = $3::sales_status,
+		    updated_at   = NOW(),
+		    allocated_at = CASE WHEN $3 = 'allocated' THEN NOW() ELSE allocated_at END,
+		    picked_at    = CASE WHEN $3 = 'picking' THEN NOW() ELSE picked_at END,
+		    packed_at    = CASE WHEN $3 = 'packed' THEN NOW() ELSE packed_at END,
+		    shipped_at   = CASE WHEN $3 = 'shipped' THEN NOW() ELSE shipped_at END,
+		    cancelled_at = CASE WHEN $3 = 'cancelled' THEN NOW() ELSE cancelled_at END
+		WHERE id = $1 AND status = $2::sales_status
+		RETURNING updated_at, allocated_at, picked_at, packed_at, shipped_at, cancelled_at
+	`
+
+	var allocatedAt, pickedAt, packedAt, shippedAt, cancelledAt sql.NullTime
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, o.ID, string(o.Status), string(to)).Scan(
+		&o.UpdatedAt, &allocatedAt, &pickedAt, &packedAt, &shippedAt, &cancelledAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSalesStatusChange
+		}
+
+		return fmt.Errorf("failed to set sales order status: %w", err)
+	}
+
+	o.Status = to
+	o.AllocatedAt = nullTime(allocatedAt)
+	o.PickedAt = nullTime(pickedAt)
+	o.PackedAt = nullTime(packedAt)
+	o.ShippedAt = nullTime(shippedAt)
+	o.CancelledAt = nullTime(cancelledAt)
+
+	return nil
+}
+
+// salesError maps constraint violations of a sales order write to the matching errors
+// and wraps any other error with msg.
+func salesError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Constraint {
+		case "sales_orders_warehouse_id_fkey":
+			return ErrWarehouseNotFound
+		case "sales_order_lines_item_id_fkey":
+			return ErrItemNotFound
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
@@ -0,0 +1,252 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrSerialsRequired    = errors.New("movements of serialized items must name one serial per unit")
+	ErrNotSerialized      = errors.New("item is not serialized")
+	ErrSerializedChange   = errors.New("an item can only become serialized or stop being so without stock")
+	ErrSerializedTransfer = errors.New("serialized items cannot be moved by transfer orders")
+	ErrSerializedSale     = errors.New("serialized items cannot be sold by sales orders")
+	ErrSerialNotFound     = errors.New("serial not found")
+	ErrSerialTaken        = errors.New("serial is already used by another item")
+	ErrSerialInStock      = errors.New("serial is already in stock")
+	ErrSerialNotInStock   = errors.New("serial is not in stock at the place")
+	ErrSerialScrapped     = errors.New("serial has been scrapped")
+)
+
+// GetSerial retrieves a unit of a serialized item by its serial.
+func (r *Repository) GetSerial(ctx context.Context, serial string) (*model.Serial, error) {
+	query := `
+		SELECT s.id, s.item_id, i.name, s.serial, s.status, s.warehouse_id, COALESCE(w.code, ''), s.location_id,
+		       COALESCE(l.code, ''), s.created_at, s.updated_at
+		FROM serials s
+		JOIN items i ON i.id = s.item_id
+		LEFT JOIN warehouses w ON w.id = s.warehouse_id
+		LEFT JOIN locations l ON l.id = s.location_id
+		WHERE s.serial = $1
+	`
+
+	var s model.Serial
+	var warehouseID, locationID uuid.NullUUID
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, serial).Scan(
+		&s.ID, &s.ItemID, &s.ItemName, &s.Serial, &s.Status, &warehouseID, &s.WarehouseCode, &locationID,
+		&s.LocationCode, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSerialNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get serial: %w", err)
+	}
+
+	if warehouseID.Valid {
+		s.WarehouseID = &warehouseID.UUID
+	}
+
+	if locationID.Valid {
+		s.LocationID = &locationID.UUID
+	}
+
+	return &s, nil
+}
+
+// GetSerialHistory retrieves the movements of a unit, oldest first.
+func (r *Repository) GetSerialHistory(ctx context.Context, serialID uuid.UUID) ([]*model.SerialEvent, error) {
+	query := `
+		SELECT m.id, m.movement_type, ms.status, m.warehouse_id, w.code, COALESCE(l.code, ''), m.reason, m.reference,
+		       m.created_by, m.created_at
+		FROM movement_serials ms
+		JOIN stock_movements m ON m.id = ms.movement_id
+		JOIN warehouses w ON w.id = m.warehouse_id
+		LEFT JOIN locations l ON l.id = m.location_id
+		WHERE ms.serial_id = $1
+		ORDER BY m.created_at, m.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, serialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query serial history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*model.SerialEvent{}
+	for rows.Next() {
+		var e model.SerialEvent
+		var reference sql.NullString
+		var createdBy uuid.NullUUID
+
+		if err := rows.Scan(
+			&e.MovementID, &e.Type, &e.Status, &e.WarehouseID, &e.WarehouseCode, &e.LocationCode, &e.Reason,
+			&reference, &createdBy, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan serial event: %w", err)
+		}
+
+		e.Reference = reference.String
+		if createdBy.Valid {
+			e.CreatedBy = &createdBy.UUID
+		}
+
+		history = append(history, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate serial history: %w", err)
+	}
+
+	return history, nil
+}
+
+// applySerials moves the units m.Serials of a serialized item with the recorded movement m
+// and links them to it. Movements adding stock register new serials as in stock and take
+// back units that are not on hand: issued units become returned, units in transit keep their
+// status. Movements removing stock take units on hand at the movement's warehouse and bin:
+// issues mark them issued, adjustments scrapped, and transfers leave them in transit.
+// Must run within a UnitOfWork, after applyStock has recorded m.
+func (r *Repository) applySerials(ctx context.Context, m *model.StockMovement) error {
+	for _, serial := range m.Serials {
+		var serialID uuid.UUID
+		var status model.SerialStatus
+		var err error
+
+		if m.Quantity > 0 {
+			serialID, status, err = r.addSerial(ctx, m, serial)
+		} else {
+			serialID, status, err = r.removeSerial(ctx, m, serial)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = r.conn(ctx).ExecContext(ctx, `
+			INSERT INTO movement_serials (movement_id, serial_id, status)
+			VALUES ($1, $2, $3)
+		`, m.ID, serialID, status)
+		if err != nil {
+			return fmt.Errorf("failed to link serial to movement: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// addSerial puts a unit into the movement's warehouse and bin and returns its ID and new status.
+func (r *Repository) addSerial(ctx context.Context, m *model.StockMovement, serial string) (uuid.UUID, model.SerialStatus, error) {
+	query := `
+		INSERT INTO serials (item_id, serial, warehouse_id, location_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (serial) DO UPDATE
+		SET status = CASE WHEN serials.status = 'issued' THEN 'returned' ELSE serials.status END,
+		    warehouse_id = EXCLUDED.warehouse_id, location_id = EXCLUDED.location_id, updated_at = NOW()
+		WHERE serials.item_id = EXCLUDED.item_id AND serials.warehouse_id IS NULL AND serials.status <> 'scrapped'
+		RETURNING id, status
+	`
+
+	var id uuid.UUID
+	var status model.SerialStatus
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, m.ItemID, serial, m.WarehouseID, m.LocationID).Scan(&id, &status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, "", r.serialRejection(ctx, m.ItemID, serial, true)
+		}
+
+		return uuid.Nil, "", fmt.Errorf("failed to add serial: %w", err)
+	}
+
+	return id, status, nil
+}
+
+// removeSerial takes a unit out of the movement's warehouse and bin and returns its ID and new status.
+func (r *Repository) removeSerial(ctx context.Context, m *model.StockMovement, serial string) (uuid.UUID, model.SerialStatus, error) {
+	var status model.SerialStatus // unchanged for transfers
+	switch m.Type {
+	case model.MovementIssue:
+		status = model.SerialIssued
+	case model.MovementAdjust:
+		status = model.SerialScrapped
+	}
+
+	query := `
+		UPDATE serials
+		SET status = COALESCE(NULLIF($5, '')::serial_status, status),
+		    warehouse_id = NULL, location_id = NULL, updated_at = NOW()
+		WHERE item_id = $1 AND serial = $2 AND warehouse_id = $3 AND location_id IS NOT DISTINCT FROM $4
+		RETURNING id, status
+	`
+
+	var id uuid.UUID
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, query, m.ItemID, serial, m.WarehouseID, m.LocationID, string(status),
+	).Scan(&id, &status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, "", r.serialRejection(ctx, m.ItemID, serial, false)
+		}
+
+		return uuid.Nil, "", fmt.Errorf("failed to remove serial: %w", err)
+	}
+
+	return id, status, nil
+}
+
+// serialRejection explains why a unit could not be moved: it is unknown, belongs to another
+// item or has been scrapped, or it is already on hand when adding stock or not on hand at the
+// movement's place when removing it.
+func (r *Repository) serialRejection(ctx context.Context, itemID uuid.UUID, serial string, adding bool) error {
+	var ownerID uuid.UUID
+	var status model.SerialStatus
+	var onHand bool
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT item_id, status, warehouse_id IS NOT NULL FROM serials WHERE serial = $1`, serial,
+	).Scan(&ownerID, &status, &onHand)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %q", ErrSerialNotFound, serial)
+		}
+
+		return fmt.Errorf("failed to get serial: %w", err)
+	}
+
+	switch {
+	case ownerID != itemID:
+		return fmt.Errorf("%w: %q", ErrSerialTaken, serial)
+	case status == model.SerialScrapped:
+		return fmt.Errorf("%w: %q", ErrSerialScrapped, serial)
+	case adding && onHand:
+		return fmt.Errorf("%w: %q", ErrSerialInStock, serial)
+	default:
+		return fmt.Errorf("%w: %q", ErrSerialNotInStock, serial)
+	}
+}
+
+// itemSerialized reports whether an item is serialized.
+func (r *Repository) itemSerialized(ctx context.Context, itemID uuid.UUID) (bool, error) {
+	var serialized bool
+
+	err := r.conn(ctx).QueryRowContext(ctx, `SELECT serialized FROM items WHERE id = $1`, itemID).Scan(&serialized)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrItemNotFound
+		}
+
+		return false, fmt.Errorf("failed to check if item is serialized: %w", err)
+	}
+
+	return serialized, nil
+}
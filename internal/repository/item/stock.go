@@ -0,0 +1,324 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrWarehouseNotFound  = errors.New("warehouse not found")
+	ErrNoDefaultWarehouse = errors.New("no default warehouse is configured")
+	ErrLocationNotFound   = errors.New("location not found in the warehouse")
+	ErrNotABin            = errors.New("stock can only be held in bins")
+)
+
+// GetItemStock retrieves the quantity of an item in each warehouse that holds it.
+func (r *Repository) GetItemStock(ctx context.Context, itemID uuid.UUID) ([]*model.StockLevel, error) {
+	query := `
+		SELECT s.item_id, i.name, COALESCE(i.sku, ''), s.warehouse_id, w.code, w.name, s.quantity, s.reserved,
+		       s.updated_at
+		FROM item_stock s
+		JOIN items i ON i.id = s.item_id
+		JOIN warehouses w ON w.id = s.warehouse_id
+		WHERE s.item_id = $1 AND s.quantity <> 0
+		ORDER BY w.code
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item stock: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*model.StockLevel
+	for rows.Next() {
+		var l model.StockLevel
+		if err := rows.Scan(
+			&l.ItemID, &l.ItemName, &l.SKU, &l.WarehouseID, &l.WarehouseCode, &l.WarehouseName, &l.Quantity, &l.Reserved,
+			&l.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan item stock: %w", err)
+		}
+
+		levels = append(levels, &l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate item stock: %w", err)
+	}
+
+	return levels, nil
+}
+
+// GetItemLocations retrieves the quantity of an item in each bin that holds it.
+func (r *Repository) GetItemLocations(ctx context.Context, itemID uuid.UUID) ([]*model.LocationStock, error) {
+	query := `
+		SELECT s.item_id, i.name, s.location_id, l.code, l.warehouse_id, w.code, s.quantity, s.updated_at
+		FROM location_stock s
+		JOIN items i ON i.id = s.item_id
+		JOIN locations l ON l.id = s.location_id
+		JOIN warehouses w ON w.id = l.warehouse_id
+		WHERE s.item_id = $1 AND s.quantity <> 0
+		ORDER BY w.code, l.code
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []*model.LocationStock
+	for rows.Next() {
+		var l model.LocationStock
+		if err := rows.Scan(
+			&l.ItemID, &l.ItemName, &l.LocationID, &l.LocationCode, &l.WarehouseID, &l.WarehouseCode, &l.Quantity, &l.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan item location: %w", err)
+		}
+
+		locations = append(locations, &l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate item locations: %w", err)
+	}
+
+	return locations, nil
+}
+
+// applyStock changes the stock of m.ItemID in m.WarehouseID, and in the bin m.LocationID and
+// the lot m.LotID if they are set, by m.Quantity and records the movement; m.BalanceAfter is
+// the warehouse's new balance. If updateItem is set, items.quantity is changed by the same
+// statement, so that the guarded stock update, the movement and the item's quantity are applied
+// together or not at all; otherwise the caller, which writes the whole item row, sets it itself.
+// Stock outside any bin can only be removed without naming a bin, and
+// stock in a bin only by naming it; the same holds for lots. Reserved stock cannot be removed
+// at all; its reservation must be released first. The movement is valued and its item's cost
+// layers updated as planCost describes.
+// Returns ErrInsufficientStock if there is not enough.
+// Must run within a UnitOfWork, as the cost layers, bins and lots are written separately.
+func (r *Repository) applyStock(ctx context.Context, m *model.StockMovement, updateItem bool) error {
+	plan, err := r.planCost(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	if m.LocationID != nil {
+		if err := r.applyLocationStock(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	if m.LotID != nil {
+		if err := r.applyLotStock(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	// Stock is added with an upsert, as the warehouse may not hold the item yet, and removed
+	// with a guarded update, which matches no row if there is not enough unreserved stock, or
+	// not enough stock outside the bins or outside the lots (after the bin and lot named by
+	// the movement, if any, have been updated above).
+	stock := `
+		INSERT INTO item_stock (item_id, warehouse_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (item_id, warehouse_id) DO UPDATE
+		SET quantity = item_stock.quantity + EXCLUDED.quantity, updated_at = NOW()
+		RETURNING quantity
+	`
+
+	if m.Quantity < 0 {
+		stock = `
+			UPDATE item_stock
+			SET quantity = quantity + $3, updated_at = NOW()
+			WHERE item_id = $1 AND warehouse_id = $2
+			  AND quantity + $3 >= reserved
+			  AND quantity + $3 >= (
+			      SELECT COALESCE(SUM(ls.quantity), 0)
+			      FROM location_stock ls
+			      JOIN locations l ON l.id = ls.location_id
+			      WHERE ls.item_id = $1 AND l.warehouse_id = $2
+			  )
+			  AND quantity + $3 >= (
+			      SELECT COALESCE(SUM(ls.quantity), 0)
+			      FROM lot_stock ls
+			      JOIN lots lt ON lt.id = ls.lot_id
+			      WHERE lt.item_id = $1 AND ls.warehouse_id = $2
+			  )
+			RETURNING quantity
+		`
+	}
+
+	itemQuantity := ""
+	if updateItem {
+		// Joined to st, so that the item only changes if the stock did.
+		itemQuantity = `,
+		it AS (
+			UPDATE items
+			SET quantity = items.quantity + $3, version = items.version + 1, updated_at = NOW()
+			FROM st
+			WHERE items.id = $1
+		)`
+	}
+
+	query := `
+		WITH st AS (` + stock + `)` + itemQuantity + `
+		INSERT INTO stock_movements (
+			item_id, warehouse_id, location_id, lot_id, expiry_override, movement_type, quantity, balance_after,
+			reason, reason_code, cost, reference, created_by
+		)
+		SELECT $1, $2, $8, $9, $10, $4, $3, quantity, $5, NULLIF($11, ''), $12, NULLIF($6, ''), $7
+		FROM st
+		RETURNING id, balance_after, created_at
+	`
+
+	err = r.conn(ctx).QueryRowContext(
+		ctx, query, m.ItemID, m.WarehouseID, m.Quantity, m.Type, m.Reason, m.Reference, m.CreatedBy, m.LocationID,
+		m.LotID, m.ExpiryOverride, m.ReasonCode, m.Cost,
+	).Scan(&m.ID, &m.BalanceAfter, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.movementRejection(ctx, m.ItemID, m.WarehouseID)
+		}
+
+		return stockError(err, "failed to apply stock")
+	}
+
+	return r.applyCost(ctx, m, plan)
+}
+
+// applyLocationStock changes the stock of m.ItemID in the bin m.LocationID by m.Quantity.
+func (r *Repository) applyLocationStock(ctx context.Context, m *model.StockMovement) error {
+	query := `
+		INSERT INTO location_stock (item_id, location_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (item_id, location_id) DO UPDATE
+		SET quantity = location_stock.quantity + EXCLUDED.quantity, updated_at = NOW()
+	`
+
+	if m.Quantity < 0 {
+		query = `
+			UPDATE location_stock
+			SET quantity = quantity + $3, updated_at = NOW()
+			WHERE item_id = $1 AND location_id = $2 AND quantity + $3 >= 0
+		`
+	}
+
+	res, err := r.conn(ctx).ExecContext(ctx, query, m.ItemID, *m.LocationID, m.Quantity)
+	if err != nil {
+		return stockError(err, "failed to apply location stock")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return r.movementRejection(ctx, m.ItemID, m.WarehouseID)
+	}
+
+	return nil
+}
+
+// resolveLocation returns the ID of the bin with the given code in a warehouse.
+// Returns ErrLocationNotFound if there is none and ErrNotABin if the location is not a bin.
+func (r *Repository) resolveLocation(ctx context.Context, warehouseID uuid.UUID, code string) (uuid.UUID, error) {
+	var id uuid.UUID
+	var kind model.LocationKind
+
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT id, kind FROM locations WHERE warehouse_id = $1 AND code = $2`, warehouseID, code,
+	).Scan(&id, &kind)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrLocationNotFound
+		}
+
+		return uuid.Nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	if kind != model.LocationBin {
+		return uuid.Nil, ErrNotABin
+	}
+
+	return id, nil
+}
+
+// stockError maps foreign key violations of a stock write to ErrItemNotFound and
+// ErrWarehouseNotFound, and wraps any other error with msg.
+func stockError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		switch pqErr.Constraint {
+		case "item_stock_item_id_fkey", "location_stock_item_id_fkey":
+			return ErrItemNotFound
+		case "item_stock_warehouse_id_fkey", "lot_stock_warehouse_id_fkey", "stock_movements_warehouse_id_fkey":
+			return ErrWarehouseNotFound
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// warehouseOrDefault returns warehouseID, or the default warehouse if it is uuid.Nil.
+func (r *Repository) warehouseOrDefault(ctx context.Context, warehouseID uuid.UUID) (uuid.UUID, error) {
+	if warehouseID != uuid.Nil {
+		return warehouseID, nil
+	}
+
+	err := r.conn(ctx).QueryRowContext(ctx, `SELECT id FROM warehouses WHERE is_default`).Scan(&warehouseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrNoDefaultWarehouse
+		}
+
+		return uuid.Nil, fmt.Errorf("failed to get default warehouse: %w", err)
+	}
+
+	return warehouseID, nil
+}
+
+// setChange records in the transaction which warehouse the following item changes affect, why
+// they were made, with which adjustment reason code and for which document, so that their
+// history rows carry all of it; uuid.Nil marks changes that do not touch stock and empty
+// strings changes made without a reason, a code or a document.
+func (r *Repository) setChange(ctx context.Context, warehouseID uuid.UUID, reason, reasonCode, reference string) error {
+	value := ""
+	if warehouseID != uuid.Nil {
+		value = warehouseID.String()
+	}
+
+	_, err := r.conn(ctx).ExecContext(
+		ctx,
+		`SELECT set_config('app.warehouse_id', $1, true), set_config('app.reason', $2, true),
+		        set_config('app.reason_code', $3, true), set_config('app.reference', $4, true)`,
+		value, reason, reasonCode, reference,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set change context: %w", err)
+	}
+
+	return nil
+}
+
+// warehouseExists checks if a warehouse with the given id exists.
+func (r *Repository) warehouseExists(ctx context.Context, warehouseID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.conn(ctx).QueryRowContext(
+		ctx, `SELECT EXISTS(SELECT 1 FROM warehouses WHERE id = $1)`, warehouseID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if warehouse exists: %w", err)
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,137 @@
+package item
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/audit"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repowarehouse "github.com/aliskhannn/warehouse-control/internal/repository/warehouse"
+	servicewarehouse "github.com/aliskhannn/warehouse-control/internal/service/warehouse"
+)
+
+func TestStockIsKeptPerWarehouse(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRepository(db)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	warehouses := servicewarehouse.NewService(repowarehouse.NewRepository(db))
+
+	ctx := context.Background()
+
+	// Registered before the users, so that it is removed after their movements and history.
+	code := "t-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:8]
+	second, err := warehouses.Create(ctx, code, "Stock test warehouse", "")
+	if err != nil {
+		t.Fatalf("create warehouse: %v", err)
+	}
+
+	t.Cleanup(func() { _, _ = db.Master.ExecContext(ctx, `DELETE FROM warehouses WHERE id = $1`, second.ID) })
+
+	if second.Code != strings.ToUpper(code) || second.IsDefault {
+		t.Fatalf("created warehouse %q default=%t, want %q not default", second.Code, second.IsDefault, strings.ToUpper(code))
+	}
+
+	userID := createTestUsers(t, db, 1)[0]
+
+	// The initial quantity goes to the default warehouse.
+	item := &model.Item{Name: "stock-test", Quantity: 5, CostPrice: decimal.NewFromInt(1), ListPrice: decimal.NewFromInt(2)}
+
+	err = uow.Do(ctx, userID, func(ctx context.Context) error {
+		_, err := repo.CreateItem(ctx, userID, item)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("create item: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_, _ = db.Master.ExecContext(ctx, `DELETE FROM stock_movements WHERE item_id = $1`, item.ID)
+		_, _ = db.Master.ExecContext(ctx, `DELETE FROM items WHERE id = $1`, item.ID)
+	})
+
+	move := func(warehouseID uuid.UUID, movementType model.MovementType, quantity int) (*model.StockMovement, error) {
+		m := &model.StockMovement{
+			ItemID: item.ID, WarehouseID: warehouseID, Type: movementType, Quantity: quantity, Reason: "test", CreatedBy: &userID,
+		}
+
+		return m, uow.Do(ctx, userID, func(ctx context.Context) error { return repo.CreateMovement(ctx, m) })
+	}
+
+	t.Run("receive into a non-default warehouse", func(t *testing.T) {
+		m, err := move(second.ID, model.MovementReceive, 3)
+		if err != nil {
+			t.Fatalf("receive: %v", err)
+		}
+
+		if m.WarehouseID != second.ID || m.BalanceAfter != 3 {
+			t.Fatalf("received into %s with balance %d, want %s with 3", m.WarehouseID, m.BalanceAfter, second.ID)
+		}
+
+		levels, err := warehouses.GetItems(ctx, second.ID)
+		if err != nil {
+			t.Fatalf("warehouse items: %v", err)
+		}
+
+		if len(levels) != 1 || levels[0].ItemID != item.ID || levels[0].Quantity != 3 {
+			t.Fatalf("warehouse holds %+v, want 3 of the item", levels)
+		}
+	})
+
+	t.Run("issue beyond one warehouse's stock", func(t *testing.T) {
+		// The item has 8 in total, but only 3 of them in the second warehouse.
+		if _, err := move(second.ID, model.MovementIssue, -4); !errors.Is(err, ErrInsufficientStock) {
+			t.Fatalf("got error %v, want %v", err, ErrInsufficientStock)
+		}
+	})
+
+	t.Run("unknown warehouse", func(t *testing.T) {
+		unknown := uuid.New()
+
+		if _, err := move(unknown, model.MovementReceive, 1); !errors.Is(err, ErrWarehouseNotFound) {
+			t.Fatalf("receive: got error %v, want %v", err, ErrWarehouseNotFound)
+		}
+
+		if _, err := move(unknown, model.MovementIssue, -1); !errors.Is(err, ErrWarehouseNotFound) {
+			t.Fatalf("issue: got error %v, want %v", err, ErrWarehouseNotFound)
+		}
+
+		if _, err := warehouses.GetItems(ctx, unknown); !errors.Is(err, repowarehouse.ErrWarehouseNotFound) {
+			t.Fatalf("warehouse items: got error %v, want %v", err, repowarehouse.ErrWarehouseNotFound)
+		}
+	})
+
+	t.Run("item quantity is the sum of its stock", func(t *testing.T) {
+		if _, err := move(uuid.Nil, model.MovementIssue, -2); err != nil {
+			t.Fatalf("issue from the default warehouse: %v", err)
+		}
+
+		stock, err := repo.GetItemStock(ctx, item.ID)
+		if err != nil {
+			t.Fatalf("item stock: %v", err)
+		}
+
+		sum, held := 0, map[uuid.UUID]int{}
+		for _, l := range stock {
+			sum += l.Quantity
+			held[l.WarehouseID] = l.Quantity
+		}
+
+		if len(stock) != 2 || held[second.ID] != 3 {
+			t.Fatalf("item stock %v, want 3 in %s and the rest in the default warehouse", held, second.ID)
+		}
+
+		got, err := repo.GetItemByID(ctx, item.ID)
+		if err != nil {
+			t.Fatalf("get item: %v", err)
+		}
+
+		if got.Quantity != 6 || got.Quantity != sum {
+			t.Fatalf("item quantity = %d, stock sums to %d, want both 6", got.Quantity, sum)
+		}
+	})
+}
@@ -0,0 +1,373 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrTransferNotFound     = errors.New("transfer order not found")
+	ErrTransferSamePlace    = errors.New("transfer source and destination are the same")
+	ErrTransferStatusChange = errors.New("transfer order status was changed by someone else")
+)
+
+// transferColumns is the column list scanned by scanTransfer; it expects transfer_orders
+// as o and the source and destination locations as sl and dl.
+const transferColumns = `
+	o.id, o.source_warehouse_id, COALESCE(sl.code, ''), o.destination_warehouse_id, COALESCE(dl.code, ''),
+	o.status, COALESCE(o.note, ''), o.created_by, o.created_at, o.updated_at, o.shipped_at, o.received_at, o.cancelled_at
+`
+
+// transferFrom joins the locations scanned with transferColumns.
+const transferFrom = `
+	FROM transfer_orders o
+	LEFT JOIN locations sl ON sl.id = o.source_location_id
+	LEFT JOIN locations dl ON dl.id = o.destination_location_id
+`
+
+// scanTransfer scans a row selected with transferColumns.
+func scanTransfer(row rowScanner) (*model.TransferOrder, error) {
+	var o model.TransferOrder
+	var createdBy uuid.NullUUID
+	var shippedAt, receivedAt, cancelledAt sql.NullTime
+
+	if err := row.Scan(
+		&o.ID, &o.SourceWarehouseID, &o.SourceLocation, &o.DestinationWarehouseID, &o.DestinationLocation,
+		&o.Status, &o.Note, &createdBy, &o.CreatedAt, &o.UpdatedAt, &shippedAt, &receivedAt, &cancelledAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		o.CreatedBy = &createdBy.UUID
+	}
+
+	o.ShippedAt = nullTime(shippedAt)
+	o.ReceivedAt = nullTime(receivedAt)
+	o.CancelledAt = nullTime(cancelledAt)
+
+	return &o, nil
+}
+
+// CreateTransferOrder adds a draft transfer order with its lines and records its creation in
+// the order's history. Warehouses given as uuid.Nil are replaced by the default warehouse and
+// location codes are resolved to bins of their warehouse. Serialized items cannot be transferred
+// by order, as lines do not name serials (ErrSerializedTransfer).
+// Must run within a UnitOfWork, which attributes the history entry.
+func (r *Repository) CreateTransferOrder(ctx context.Context, o *model.TransferOrder) error {
+	var err error
+
+	if o.SourceWarehouseID, err = r.warehouseOrDefault(ctx, o.SourceWarehouseID); err != nil {
+		return err
+	}
+
+	if o.DestinationWarehouseID, err = r.warehouseOrDefault(ctx, o.DestinationWarehouseID); err != nil {
+		return err
+	}
+
+	sourceLocation, err := r.optionalLocation(ctx, o.SourceWarehouseID, o.SourceLocation)
+	if err != nil {
+		return err
+	}
+
+	destinationLocation, err := r.optionalLocation(ctx, o.DestinationWarehouseID, o.DestinationLocation)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO transfer_orders (
+			source_warehouse_id, source_location_id, destination_warehouse_id, destination_location_id, note, created_by
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err = r.conn(ctx).QueryRowContext(
+		ctx, query, o.SourceWarehouseID, sourceLocation, o.DestinationWarehouseID, destinationLocation, o.Note, o.CreatedBy,
+	).Scan(&o.ID, &o.Status, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return transferError(err, "failed to create transfer order")
+	}
+
+	for _, line := range o.Lines {
+		serialized, err := r.itemSerialized(ctx, line.ItemID)
+		if err != nil {
+			return err
+		}
+
+		if serialized {
+			return ErrSerializedTransfer
+		}
+
+		_, err = r.conn(ctx).ExecContext(ctx, `
+			INSERT INTO transfer_order_lines (transfer_order_id, item_id, quantity)
+			VALUES ($1, $2, $3)
+		`, o.ID, line.ItemID, line.Quantity)
+		if err != nil {
+			return transferError(err, "failed to create transfer order line")
+		}
+	}
+
+	return r.insertTransferEvent(ctx, o.ID, "", o.Status)
+}
+
+// GetTransferOrder retrieves a transfer order with its lines.
+func (r *Repository) GetTransferOrder(ctx context.Context, orderID uuid.UUID) (*model.TransferOrder, error) {
+	return r.getTransferOrder(ctx, orderID, "")
+}
+
+// LockTransferOrder retrieves a transfer order with its lines and locks it for the rest of
+// the transaction, so that concurrent status changes of the order are serialized.
+// Must run within a UnitOfWork.
+func (r *Repository) LockTransferOrder(ctx context.Context, orderID uuid.UUID) (*model.TransferOrder, error) {
+	return r.getTransferOrder(ctx, orderID, "FOR UPDATE OF o")
+}
+
+// getTransferOrder retrieves a transfer order with its lines, appending lock to the query.
+func (r *Repository) getTransferOrder(ctx context.Context, orderID uuid.UUID, lock string) (*model.TransferOrder, error) {
+	query := `SELECT ` + transferColumns + transferFrom + ` WHERE o.id = $1 ` + lock
+
+	o, err := scanTransfer(r.conn(ctx).QueryRowContext(ctx, query, orderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransferNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get transfer order: %w", err)
+	}
+
+	if err := r.loadTransferLines(ctx, []*model.TransferOrder{o}); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// GetTransferOrders retrieves the transfer orders matching filter with their lines, newest first.
+func (r *Repository) GetTransferOrders(ctx context.Context, filter model.TransferFilter) ([]*model.TransferOrder, error) {
+	query := `SELECT ` + transferColumns + transferFrom + `
+		WHERE ($1 = '' OR o.status::TEXT = $1)
+		  AND ($2 = '00000000-0000-0000-0000-000000000000'::UUID
+		       OR EXISTS (SELECT 1 FROM transfer_order_lines l WHERE l.transfer_order_id = o.id AND l.item_id = $2))
+		  AND ($3 = '00000000-0000-0000-0000-000000000000'::UUID
+		       OR o.source_warehouse_id = $3 OR o.destination_warehouse_id = $3)
+		ORDER BY o.created_at DESC, o.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, string(filter.Status), filter.ItemID, filter.WarehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transfer orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []*model.TransferOrder{}
+	for rows.Next() {
+		o, err := scanTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transfer order: %w", err)
+		}
+
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transfer orders: %w", err)
+	}
+
+	if err := r.loadTransferLines(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadTransferLines fills in the lines of orders.
+func (r *Repository) loadTransferLines(ctx context.Context, orders []*model.TransferOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*model.TransferOrder, len(orders))
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		o.Lines = []*model.TransferLine{}
+		byID[o.ID] = o
+		ids = append(ids, o.ID.String())
+	}
+
+	query := `
+		SELECT l.transfer_order_id, l.item_id, i.name, l.quantity
+		FROM transfer_order_lines l
+		JOIN items i ON i.id = l.item_id
+		WHERE l.transfer_order_id = ANY($1::UUID[])
+		ORDER BY i.name, l.item_id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query transfer order lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID uuid.UUID
+		var line model.TransferLine
+
+		if err := rows.Scan(&orderID, &line.ItemID, &line.ItemName, &line.Quantity); err != nil {
+			return fmt.Errorf("failed to scan transfer order line: %w", err)
+		}
+
+		byID[orderID].Lines = append(byID[orderID].Lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate transfer order lines: %w", err)
+	}
+
+	return nil
+}
+
+// SetTransferStatus moves a transfer order from status from to status to, stamps the time of
+// the change and records it in the order's history.
+// Returns ErrTransferStatusChange if the order is no longer in status from.
+// Must run within a UnitOfWork, which attributes the history entry.
+func (r *Repository) SetTransferStatus(ctx context.Context, o *model.TransferOrder, to model.TransferStatus) error {
+	query := `
+		UPDATE transfer_orders
+		SET status       = $3::transfer_status,
+		    updated_at   = NOW(),
+		    shipped_at   = CASE WHEN $3 = 'in_transit' THEN NOW() ELSE shipped_at END,
+		    received_at  = CASE WHEN $3 = 'received' THEN NOW() ELSE received_at END,
+		    cancelled_at = CASE WHEN $3 = 'cancelled' THEN NOW() ELSE cancelled_at END
+		WHERE id = $1 AND status = $2::transfer_status
+		RETURNING updated_at, shipped_at, received_at, cancelled_at
+	`
+
+	var shippedAt, receivedAt, cancelledAt sql.NullTime
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, o.ID, string(o.Status), string(to)).Scan(
+		&o.UpdatedAt, &shippedAt, &receivedAt, &cancelledAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTransferStatusChange
+		}
+
+		return fmt.Errorf("failed to set transfer order status: %w", err)
+	}
+
+	from := o.Status
+	o.Status = to
+	o.ShippedAt = nullTime(shippedAt)
+	o.ReceivedAt = nullTime(receivedAt)
+	o.CancelledAt = nullTime(cancelledAt)
+
+	return r.insertTransferEvent(ctx, o.ID, from, to)
+}
+
+// insertTransferEvent records a status change of a transfer order, attributed to the user,
+// role, request and client IP set for the transaction; from is empty for the creation.
+func (r *Repository) insertTransferEvent(ctx context.Context, orderID uuid.UUID, from, to model.TransferStatus) error {
+	query := `
+		INSERT INTO transfer_order_history (
+			transfer_order_id, from_status, to_status, changed_by, actor_role, request_id, client_ip
+		)
+		VALUES ($1, NULLIF($2, '')::transfer_status, $3::transfer_status,
+		        current_setting('app.current_user_id')::UUID,
+		        NULLIF(current_setting('app.current_role', true), ''),
+		        NULLIF(current_setting('app.request_id', true), ''),
+		        NULLIF(current_setting('app.client_ip', true), ''))
+	`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, orderID, string(from), string(to)); err != nil {
+		return fmt.Errorf("failed to insert transfer order history: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransferHistory retrieves the status changes of a transfer order, oldest first.
+func (r *Repository) GetTransferHistory(ctx context.Context, orderID uuid.UUID) ([]*model.TransferEvent, error) {
+	query := `
+		SELECT id, transfer_order_id, COALESCE(from_status::TEXT, ''), to_status, changed_by, changed_at,
+		       COALESCE(actor_role, ''), COALESCE(request_id, ''), COALESCE(client_ip, '')
+		FROM transfer_order_history
+		WHERE transfer_order_id = $1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transfer order history: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.TransferEvent
+	for rows.Next() {
+		var e model.TransferEvent
+		if err := rows.Scan(
+			&e.ID, &e.TransferOrderID, &e.FromStatus, &e.ToStatus, &e.ChangedBy, &e.ChangedAt,
+			&e.ActorRole, &e.RequestID, &e.ClientIP,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer order history: %w", err)
+		}
+
+		events = append(events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate transfer order history: %w", err)
+	}
+
+	return events, nil
+}
+
+// optionalLocation resolves the code of a bin in a warehouse, returning nil if code is empty.
+func (r *Repository) optionalLocation(ctx context.Context, warehouseID uuid.UUID, code string) (*uuid.UUID, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	id, err := r.resolveLocation(ctx, warehouseID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// transferError maps constraint violations of a transfer order write to the matching errors
+// and wraps any other error with msg.
+func transferError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Constraint {
+		case "transfer_orders_source_warehouse_id_fkey", "transfer_orders_destination_warehouse_id_fkey":
+			return ErrWarehouseNotFound
+		case "transfer_order_lines_item_id_fkey":
+			return ErrItemNotFound
+		case "chk_transfer_orders_distinct_places":
+			return ErrTransferSamePlace
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// nullTime returns a pointer to the time in t, or nil if it is NULL.
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
@@ -0,0 +1,141 @@
+package item
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/audit"
+)
+
+// txKey is the context key under which the current transaction is stored.
+type txKey struct{}
+
+// txUserKey is the context key under which the user the current transaction is attributed
+// to is stored.
+type txUserKey struct{}
+
+// afterCommitKey is the context key under which the functions to run once the current
+// transaction has been committed are stored.
+type afterCommitKey struct{}
+
+// executor is the set of query methods shared by *dbpg.DB and *sql.Tx.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var ErrUserMismatch = errors.New("unit of work joins a transaction attributed to another user")
+
+// UnitOfWork groups repository calls into a single transaction attributed to a user.
+type UnitOfWork struct {
+	db        *dbpg.DB
+	auditMode string
+}
+
+// NewUnitOfWork creates a new unit of work on the master database.
+// auditMode is audit.ModeTrigger or audit.ModeApp; in app mode the triggers are
+// told to skip writing history because the service writes it.
+func NewUnitOfWork(db *dbpg.DB, auditMode string) *UnitOfWork {
+	return &UnitOfWork{
+		db:        db,
+		auditMode: auditMode,
+	}
+}
+
+// Do opens a transaction, sets app.current_user_id for that transaction only and
+// runs fn with a context carrying the transaction. Repository calls made with this
+// context run on the same *sql.Tx, so the audit triggers always see the right user.
+// The actor's role, request ID and client IP from ctx are set the same way.
+// The transaction is committed if fn returns nil and rolled back otherwise, also when fn
+// panics, in which case the panic is passed on once the transaction is rolled back.
+// If ctx already carries a transaction, fn joins it instead of opening a new one. Its
+// changes are attributed to the user of that transaction, so joining it for another user
+// is refused with ErrUserMismatch.
+func (u *UnitOfWork) Do(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		if txUser, _ := ctx.Value(txUserKey{}).(uuid.UUID); txUser != userID {
+			return ErrUserMismatch
+		}
+
+		return fn(ctx)
+	}
+
+	tx, err := u.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	actor, _ := audit.ActorFromContext(ctx)
+
+	query := `
+		SELECT set_config('app.current_user_id', $1, true),
+		       set_config('app.current_role', $2, true),
+		       set_config('app.request_id', $3, true),
+		       set_config('app.client_ip', $4, true),
+		       set_config('app.audit_mode', $5, true)
+	`
+
+	_, err = tx.ExecContext(ctx, query, userID.String(), actor.Role, actor.RequestID, actor.ClientIP, u.auditMode)
+	if err != nil {
+		return fmt.Errorf("failed to set audit settings: %w", err)
+	}
+
+	var afterCommit []func()
+
+	ctx = context.WithValue(ctx, txKey{}, tx)
+	ctx = context.WithValue(ctx, txUserKey{}, userID)
+	ctx = context.WithValue(ctx, afterCommitKey{}, &afterCommit)
+
+	if err = fn(ctx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, f := range afterCommit {
+		f()
+	}
+
+	return nil
+}
+
+// AfterCommit runs f once the transaction carried by ctx has been committed, so that f sees
+// its changes; f is dropped if the transaction is rolled back. Within nested units of work
+// f waits for the outermost one. Without a transaction f runs immediately.
+func (u *UnitOfWork) AfterCommit(ctx context.Context, f func()) {
+	afterCommit, ok := ctx.Value(afterCommitKey{}).(*[]func())
+	if !ok {
+		f()
+		return
+	}
+
+	*afterCommit = append(*afterCommit, f)
+}
+
+// conn returns the transaction carried by ctx, or the database pool if there is none.
+func (r *Repository) conn(ctx context.Context) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return r.db
+}
@@ -0,0 +1,308 @@
+package item
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/audit"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// openTestDB connects to the migrated database given by TEST_DATABASE_DSN,
+// skipping the test if it is not set.
+func openTestDB(t *testing.T) *dbpg.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	// A small pool makes goroutines share connections, which is what used to
+	// leak app.current_user_id from one request into another.
+	db, err := dbpg.New(dsn, nil, &dbpg.Options{MaxOpenConns: 3, MaxIdleConns: 3})
+	if err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+
+	t.Cleanup(func() { _ = db.Master.Close() })
+
+	return db
+}
+
+// createTestUsers inserts n users and removes them together with their history when the test ends.
+func createTestUsers(t *testing.T, db *dbpg.DB, n int) []uuid.UUID {
+	t.Helper()
+
+	ctx := context.Background()
+	users := make([]uuid.UUID, n)
+
+	for i := range users {
+		err := db.Master.QueryRowContext(ctx, `
+			INSERT INTO users (username, password_hash, role)
+			VALUES ($1, 'x', 'manager')
+			RETURNING id
+		`, fmt.Sprintf("uow-test-%s", uuid.NewString())).Scan(&users[i])
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	t.Cleanup(func() {
+		for _, id := range users {
+			_, _ = db.Master.ExecContext(ctx, `DELETE FROM item_prices WHERE changed_by = $1`, id)
+			_, _ = db.Master.ExecContext(ctx, `DELETE FROM item_history WHERE changed_by = $1`, id)
+			_, _ = db.Master.ExecContext(ctx, `DELETE FROM stock_movements WHERE created_by = $1`, id)
+			_, _ = db.Master.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+		}
+	})
+
+	return users
+}
+
+func TestUnitOfWorkAttributesConcurrentWritesToTheirUser(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRepository(db)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	users := createTestUsers(t, db, 20)
+
+	ctx := context.Background()
+	itemIDs := make([]uuid.UUID, len(users))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(users))
+
+	for i, userID := range users {
+		wg.Add(1)
+
+		go func(i int, userID uuid.UUID) {
+			defer wg.Done()
+
+			item := &model.Item{
+				Name:      fmt.Sprintf("uow-test-item-%d", i),
+				CostPrice: decimal.NewFromInt(10),
+				ListPrice: decimal.NewFromInt(10),
+			}
+
+			err := uow.Do(ctx, userID, func(ctx context.Context) error {
+				if _, err := repo.CreateItem(ctx, userID, item); err != nil {
+					return err
+				}
+
+				return nil
+			})
+			if err != nil {
+				errs <- fmt.Errorf("create: %w", err)
+				return
+			}
+
+			itemIDs[i] = item.ID
+
+			// Without stock, so that no movements keep the item from being deleted.
+			item.Description = "updated"
+			err = uow.Do(ctx, userID, func(ctx context.Context) error {
+				return repo.UpdateItem(ctx, userID, item)
+			})
+			if err != nil {
+				errs <- fmt.Errorf("update: %w", err)
+				return
+			}
+
+			err = uow.Do(ctx, userID, func(ctx context.Context) error {
+				return repo.DeleteItem(ctx, item.ID, item.Version)
+			})
+			if err != nil {
+				errs <- fmt.Errorf("delete: %w", err)
+			}
+		}(i, userID)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	for i, itemID := range itemIDs {
+		history, err := repo.GetItemHistory(ctx, itemID)
+		if err != nil {
+			t.Fatalf("get history: %v", err)
+		}
+
+		if len(history) != 3 {
+			t.Fatalf("item %d: got %d history rows, want 3", i, len(history))
+		}
+
+		for _, h := range history {
+			if h.ChangedBy != users[i] {
+				t.Errorf("item %d: %s row changed_by = %s, want %s", i, h.Action, h.ChangedBy, users[i])
+			}
+		}
+	}
+}
+
+func TestUnitOfWorkRollsBackOnError(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRepository(db)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	userID := createTestUsers(t, db, 1)[0]
+
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+	item := &model.Item{Name: "uow-test-rollback", ListPrice: decimal.NewFromInt(1)}
+
+	err := uow.Do(ctx, userID, func(ctx context.Context) error {
+		if _, err := repo.CreateItem(ctx, userID, item); err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("got error %v, want %v", err, errAbort)
+	}
+
+	if _, err := repo.GetItemByID(ctx, item.ID); !errors.Is(err, ErrItemNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrItemNotFound)
+	}
+
+	history, err := repo.GetItemHistory(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+
+	if len(history) != 0 {
+		t.Fatalf("got %d history rows, want 0", len(history))
+	}
+}
+
+func TestUnitOfWorkRollsBackOnPanic(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRepository(db)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	userID := createTestUsers(t, db, 1)[0]
+
+	ctx := context.Background()
+	item := &model.Item{Name: "uow-test-panic", ListPrice: decimal.NewFromInt(1)}
+
+	func() {
+		defer func() {
+			if p := recover(); p != "abort" {
+				t.Fatalf("recovered %v, want the panic passed on", p)
+			}
+		}()
+
+		_ = uow.Do(ctx, userID, func(ctx context.Context) error {
+			if _, err := repo.CreateItem(ctx, userID, item); err != nil {
+				return err
+			}
+
+			panic("abort")
+		})
+	}()
+
+	if _, err := repo.GetItemByID(ctx, item.ID); !errors.Is(err, ErrItemNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrItemNotFound)
+	}
+}
+
+func TestUnitOfWorkRefusesToJoinForAnotherUser(t *testing.T) {
+	db := openTestDB(t)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	users := createTestUsers(t, db, 2)
+
+	ctx := context.Background()
+
+	err := uow.Do(ctx, users[0], func(ctx context.Context) error {
+		if err := uow.Do(ctx, users[0], func(ctx context.Context) error { return nil }); err != nil {
+			return fmt.Errorf("same user: %w", err)
+		}
+
+		return uow.Do(ctx, users[1], func(ctx context.Context) error { return nil })
+	})
+	if !errors.Is(err, ErrUserMismatch) {
+		t.Fatalf("got error %v, want %v", err, ErrUserMismatch)
+	}
+}
+
+func TestUnitOfWorkSettingDoesNotLeakOutsideTransaction(t *testing.T) {
+	db := openTestDB(t)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	userID := createTestUsers(t, db, 1)[0]
+
+	ctx := context.Background()
+
+	// Pin a single connection so the check below runs where the transaction ran.
+	db.Master.SetMaxOpenConns(1)
+
+	err := uow.Do(ctx, userID, func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	var current string
+	err = db.Master.QueryRowContext(ctx, `SELECT COALESCE(current_setting('app.current_user_id', true), '')`).Scan(&current)
+	if err != nil {
+		t.Fatalf("read setting: %v", err)
+	}
+
+	if current != "" {
+		t.Fatalf("app.current_user_id = %q after commit, want it unset", current)
+	}
+}
+
+func TestUpdateItemRejectsStaleVersion(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewRepository(db)
+	uow := NewUnitOfWork(db, audit.ModeTrigger)
+	userID := createTestUsers(t, db, 1)[0]
+
+	ctx := context.Background()
+	item := &model.Item{Name: "uow-test-version", ListPrice: decimal.NewFromInt(1)}
+
+	write := func(fn func(ctx context.Context) error) error {
+		return uow.Do(ctx, userID, fn)
+	}
+
+	if err := write(func(ctx context.Context) error {
+		_, err := repo.CreateItem(ctx, userID, item)
+		return err
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	stale := *item
+
+	item.Name = "first edit"
+	if err := write(func(ctx context.Context) error { return repo.UpdateItem(ctx, userID, item) }); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+
+	if item.Version != stale.Version+1 {
+		t.Fatalf("version = %d, want %d", item.Version, stale.Version+1)
+	}
+
+	stale.Name = "second edit"
+	err := write(func(ctx context.Context) error { return repo.UpdateItem(ctx, userID, &stale) })
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("stale update: got error %v, want %v", err, ErrVersionConflict)
+	}
+
+	err = write(func(ctx context.Context) error { return repo.DeleteItem(ctx, item.ID, stale.Version) })
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("stale delete: got error %v, want %v", err, ErrVersionConflict)
+	}
+
+	if err := write(func(ctx context.Context) error { return repo.DeleteItem(ctx, item.ID, item.Version) }); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+}
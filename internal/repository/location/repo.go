@@ -0,0 +1,219 @@
+package location
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrLocationNotFound  = errors.New("location not found")
+	ErrCodeTaken         = errors.New("location code is already used in the warehouse")
+	ErrLocationInUse     = errors.New("location has child locations or holds stock")
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+)
+
+// Repository provides methods to interact with the locations and location_stock tables.
+type Repository struct {
+	db *dbpg.DB
+}
+
+// NewRepository creates a new location repository.
+func NewRepository(db *dbpg.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// locationColumns is the column list scanned by scanLocation.
+const locationColumns = `id, warehouse_id, parent_id, kind, code, COALESCE(name, ''), created_at, updated_at`
+
+// scanLocation scans a row selected with locationColumns into a location.
+func scanLocation(row interface{ Scan(...any) error }) (*model.Location, error) {
+	var l model.Location
+	if err := row.Scan(&l.ID, &l.WarehouseID, &l.ParentID, &l.Kind, &l.Code, &l.Name, &l.CreatedAt, &l.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// CreateLocation adds a new location to the database.
+func (r *Repository) CreateLocation(ctx context.Context, l *model.Location) (uuid.UUID, error) {
+	query := `
+		INSERT INTO locations (warehouse_id, parent_id, kind, code, name)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, l.WarehouseID, l.ParentID, l.Kind, l.Code, l.Name).Scan(
+		&l.ID, &l.CreatedAt, &l.UpdatedAt,
+	)
+	if err != nil {
+		return uuid.Nil, writeError(err, "failed to create location")
+	}
+
+	return l.ID, nil
+}
+
+// GetLocationByID retrieves a location by id.
+func (r *Repository) GetLocationByID(ctx context.Context, locationID uuid.UUID) (*model.Location, error) {
+	query := `SELECT ` + locationColumns + ` FROM locations WHERE id = $1`
+
+	l, err := scanLocation(r.db.QueryRowContext(ctx, query, locationID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLocationNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	return l, nil
+}
+
+// GetLocations retrieves all locations of a warehouse ordered by code.
+func (r *Repository) GetLocations(ctx context.Context, warehouseID uuid.UUID) ([]*model.Location, error) {
+	query := `SELECT ` + locationColumns + ` FROM locations WHERE warehouse_id = $1 ORDER BY code`
+
+	rows, err := r.db.QueryContext(ctx, query, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []*model.Location
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+
+		locations = append(locations, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate locations: %w", err)
+	}
+
+	return locations, nil
+}
+
+// UpdateLocation updates the code and name of a location.
+func (r *Repository) UpdateLocation(ctx context.Context, l *model.Location) error {
+	query := `
+		UPDATE locations
+		SET code = $1, name = NULLIF($2, ''), updated_at = NOW()
+		WHERE id = $3
+		RETURNING warehouse_id, parent_id, kind, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, l.Code, l.Name, l.ID).Scan(
+		&l.WarehouseID, &l.ParentID, &l.Kind, &l.CreatedAt, &l.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLocationNotFound
+		}
+
+		return writeError(err, "failed to update location")
+	}
+
+	return nil
+}
+
+// DeleteLocation deletes a location that has no child locations and holds no stock.
+// Returns ErrLocationInUse if it has either.
+func (r *Repository) DeleteLocation(ctx context.Context, locationID uuid.UUID) error {
+	query := `
+		DELETE FROM locations l
+		WHERE l.id = $1
+		  AND NOT EXISTS (SELECT 1 FROM locations c WHERE c.parent_id = l.id)
+		  AND NOT EXISTS (SELECT 1 FROM location_stock s WHERE s.location_id = l.id AND s.quantity <> 0)
+	`
+
+	res, err := r.db.ExecContext(ctx, query, locationID)
+	if err != nil {
+		return fmt.Errorf("failed to delete location: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		if _, err := r.GetLocationByID(ctx, locationID); err != nil {
+			return err
+		}
+
+		return ErrLocationInUse
+	}
+
+	return nil
+}
+
+// GetLocationStock retrieves the quantity of each item held in the bins of a location's
+// subtree, ordered by bin code and item name.
+func (r *Repository) GetLocationStock(ctx context.Context, locationID uuid.UUID) ([]*model.LocationStock, error) {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM locations WHERE id = $1
+			UNION ALL
+			SELECT l.id FROM locations l JOIN subtree t ON l.parent_id = t.id
+		)
+		SELECT s.item_id, i.name, s.location_id, l.code, l.warehouse_id, w.code, s.quantity, s.updated_at
+		FROM location_stock s
+		JOIN subtree t ON t.id = s.location_id
+		JOIN items i ON i.id = s.item_id
+		JOIN locations l ON l.id = s.location_id
+		JOIN warehouses w ON w.id = l.warehouse_id
+		WHERE s.quantity <> 0
+		ORDER BY l.code, i.name, i.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query location stock: %w", err)
+	}
+	defer rows.Close()
+
+	var stock []*model.LocationStock
+	for rows.Next() {
+		var s model.LocationStock
+		if err := rows.Scan(
+			&s.ItemID, &s.ItemName, &s.LocationID, &s.LocationCode, &s.WarehouseID, &s.WarehouseCode, &s.Quantity, &s.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan location stock: %w", err)
+		}
+
+		stock = append(stock, &s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate location stock: %w", err)
+	}
+
+	return stock, nil
+}
+
+// writeError maps a unique violation of the location code to ErrCodeTaken and a missing
+// warehouse to ErrWarehouseNotFound, and wraps any other error with msg.
+func writeError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505" && pqErr.Constraint == "uq_locations_warehouse_code":
+			return ErrCodeTaken
+		case pqErr.Code == "23503" && pqErr.Constraint == "locations_warehouse_id_fkey":
+			return ErrWarehouseNotFound
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
@@ -0,0 +1,134 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// markStart and markStop delimit the matches in a headline until it has been HTML-escaped.
+// They are control characters that item text is stripped of, so it cannot forge matches.
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+// markReplacer turns the match delimiters of an escaped headline into <mark></mark>.
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// Repository provides full-text and fuzzy search over the items table.
+type Repository struct {
+	db *dbpg.DB
+}
+
+// NewRepository creates a new search repository.
+func NewRepository(db *dbpg.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// SearchItems finds items whose name or description match the query as words,
+// or whose name is similar to it (trigram word similarity, which tolerates typos).
+// Results are ordered by relevance. Their snippets are HTML-escaped, so that only the <mark>
+// tags around the matches are markup.
+func (r *Repository) SearchItems(ctx context.Context, q string, limit int) ([]*model.ItemSearchResult, error) {
+	query := `
+		WITH q AS (
+			SELECT websearch_to_tsquery('simple', $1) AS tsq
+		)
+		SELECT i.id, i.name, i.sku, i.barcodes, i.description, i.quantity, i.cost_price, i.list_price,
+		       COALESCE(i.currency, ''), i.version,
+		       i.created_at, i.updated_at,
+		       ts_rank(i.search_vector, q.tsq) + word_similarity($1, i.name) AS rank,
+		       ts_headline(
+		           'simple',
+		           translate(i.name || ' ' || COALESCE(i.description, ''), $3::text || $4::text, ''),
+		           q.tsq,
+		           'StartSel="' || $3 || '", StopSel="' || $4 || '", MaxWords=30, MinWords=10'
+		       ) AS snippet
+		FROM items i, q
+		WHERE i.search_vector @@ q.tsq OR $1 <% i.name
+		ORDER BY rank DESC, i.id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, q, limit, markStart, markStop)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search items: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*model.ItemSearchResult
+	for rows.Next() {
+		var res model.ItemSearchResult
+		var sku sql.NullString
+		var barcodes pq.StringArray
+		if err := rows.Scan(
+			&res.ID, &res.Name, &sku, &barcodes, &res.Description, &res.Quantity, &res.CostPrice, &res.ListPrice,
+			&res.Currency, &res.Version, &res.CreatedAt, &res.UpdatedAt, &res.Rank, &res.Snippet,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+
+		res.SKU = sku.String
+		res.Barcodes = barcodes
+		res.Snippet = markMatches(res.Snippet)
+
+		results = append(results, &res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// SuggestItems returns items whose name starts with prefix, ordered by name.
+func (r *Repository) SuggestItems(ctx context.Context, prefix string, limit int) ([]*model.ItemSuggestion, error) {
+	query := `
+		SELECT id, name
+		FROM items
+		WHERE name ILIKE $1 || '%'
+		ORDER BY name, id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, escapeLike(prefix), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []*model.ItemSuggestion
+	for rows.Next() {
+		var s model.ItemSuggestion
+		if err := rows.Scan(&s.ID, &s.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %w", err)
+		}
+
+		suggestions = append(suggestions, &s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// markMatches HTML-escapes a headline and wraps its matches in <mark></mark>.
+func markMatches(headline string) string {
+	return markReplacer.Replace(html.EscapeString(headline))
+}
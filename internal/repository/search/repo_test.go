@@ -0,0 +1,23 @@
+package search
+
+import "testing"
+
+func TestMarkMatchesEscapesItemText(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"steel " + markStart + "bolt" + markStop + " M8", "steel <mark>bolt</mark> M8"},
+		{
+			markStart + "bolt" + markStop + ` <img src=x onerror="alert(1)">`,
+			`<mark>bolt</mark> &lt;img src=x onerror=&#34;alert(1)&#34;&gt;`,
+		},
+		{markStart + "<mark>" + markStop, "<mark>&lt;mark&gt;</mark>"},
+	}
+
+	for _, tt := range tests {
+		if got := markMatches(tt.headline); got != tt.want {
+			t.Errorf("markMatches(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package supplier
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrSupplierNotFound     = errors.New("supplier not found")
+	ErrNameTaken            = errors.New("supplier name is already used")
+	ErrItemNotFound         = errors.New("item not found")
+	ErrSupplierItemNotFound = errors.New("supplier does not sell this item")
+)
+
+// supplierColumns is the column list scanned by scanSupplier.
+const supplierColumns = `
+	id, name, COALESCE(contact_name, ''), COALESCE(email, ''), COALESCE(phone, ''), lead_time_days,
+	created_at, updated_at
+`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSupplier scans a row selected with supplierColumns.
+func scanSupplier(row rowScanner) (*model.Supplier, error) {
+	var s model.Supplier
+	if err := row.Scan(
+		&s.ID, &s.Name, &s.ContactName, &s.Email, &s.Phone, &s.LeadTimeDays, &s.CreatedAt, &s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Repository provides methods to interact with the suppliers and supplier_items tables.
+type Repository struct {
+	db *dbpg.DB
+}
+
+// NewRepository creates a new supplier repository.
+func NewRepository(db *dbpg.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateSupplier adds a new supplier to the database.
+func (r *Repository) CreateSupplier(ctx context.Context, s *model.Supplier) (uuid.UUID, error) {
+	query := `
+		INSERT INTO suppliers (name, contact_name, email, phone, lead_time_days)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query, s.Name, s.ContactName, s.Email, s.Phone, s.LeadTimeDays).Scan(
+		&s.ID, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return uuid.Nil, supplierError(err, "failed to create supplier")
+	}
+
+	return s.ID, nil
+}
+
+// GetSupplierByID retrieves a supplier by id.
+func (r *Repository) GetSupplierByID(ctx context.Context, supplierID uuid.UUID) (*model.Supplier, error) {
+	query := `SELECT ` + supplierColumns + ` FROM suppliers WHERE id = $1`
+
+	s, err := scanSupplier(r.db.QueryRowContext(ctx, query, supplierID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSupplierNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	return s, nil
+}
+
+// GetAllSuppliers retrieves all suppliers ordered by name.
+func (r *Repository) GetAllSuppliers(ctx context.Context) ([]*model.Supplier, error) {
+	query := `SELECT ` + supplierColumns + ` FROM suppliers ORDER BY name, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppliers: %w", err)
+	}
+	defer rows.Close()
+
+	suppliers := []*model.Supplier{}
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan supplier: %w", err)
+		}
+
+		suppliers = append(suppliers, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate suppliers: %w", err)
+	}
+
+	return suppliers, nil
+}
+
+// UpdateSupplier updates the name, contact details and lead time of a supplier.
+func (r *Repository) UpdateSupplier(ctx context.Context, s *model.Supplier) error {
+	query := `
+		UPDATE suppliers
+		SET name           = $1,
+		    contact_name   = NULLIF($2, ''),
+		    email          = NULLIF($3, ''),
+		    phone          = NULLIF($4, ''),
+		    lead_time_days = $5,
+		    updated_at     = NOW()
+		WHERE id = $6
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.Master.QueryRowContext(
+		ctx, query, s.Name, s.ContactName, s.Email, s.Phone, s.LeadTimeDays, s.ID,
+	).Scan(&s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSupplierNotFound
+		}
+
+		return supplierError(err, "failed to update supplier")
+	}
+
+	return nil
+}
+
+// SetSupplierItem records the SKU and unit cost an item is bought at from a supplier,
+// replacing any earlier terms.
+func (r *Repository) SetSupplierItem(ctx context.Context, si *model.SupplierItem) error {
+	query := `
+		INSERT INTO supplier_items (supplier_id, item_id, supplier_sku, cost)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		ON CONFLICT (supplier_id, item_id) DO UPDATE
+		SET supplier_sku = EXCLUDED.supplier_sku, cost = EXCLUDED.cost, updated_at = NOW()
+		RETURNING updated_at, (SELECT name FROM items WHERE id = $2)
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query, si.SupplierID, si.ItemID, si.SupplierSKU, si.Cost).Scan(
+		&si.UpdatedAt, &si.ItemName,
+	)
+	if err != nil {
+		return supplierError(err, "failed to set supplier item")
+	}
+
+	return nil
+}
+
+// DeleteSupplierItem removes an item from the items a supplier sells.
+func (r *Repository) DeleteSupplierItem(ctx context.Context, supplierID, itemID uuid.UUID) error {
+	query := `DELETE FROM supplier_items WHERE supplier_id = $1 AND item_id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, supplierID, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete supplier item: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrSupplierItemNotFound
+	}
+
+	return nil
+}
+
+// GetSupplierItems retrieves the items a supplier sells, ordered by item name.
+func (r *Repository) GetSupplierItems(ctx context.Context, supplierID uuid.UUID) ([]*model.SupplierItem, error) {
+	query := `
+		SELECT si.supplier_id, si.item_id, i.name, COALESCE(si.supplier_sku, ''), si.cost, si.updated_at
+		FROM supplier_items si
+		JOIN items i ON i.id = si.item_id
+		WHERE si.supplier_id = $1
+		ORDER BY i.name, i.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query supplier items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*model.SupplierItem{}
+	for rows.Next() {
+		var si model.SupplierItem
+		if err := rows.Scan(&si.SupplierID, &si.ItemID, &si.ItemName, &si.SupplierSKU, &si.Cost, &si.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan supplier item: %w", err)
+		}
+
+		items = append(items, &si)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate supplier items: %w", err)
+	}
+
+	return items, nil
+}
+
+// supplierError maps constraint violations of a supplier write to the matching errors
+// and wraps any other error with msg.
+func supplierError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Constraint {
+		case "suppliers_name_key":
+			return ErrNameTaken
+		case "supplier_items_supplier_id_fkey":
+			return ErrSupplierNotFound
+		case "supplier_items_item_id_fkey":
+			return ErrItemNotFound
+		}
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
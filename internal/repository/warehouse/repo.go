@@ -0,0 +1,173 @@
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+	ErrCodeTaken         = errors.New("warehouse code is already used")
+)
+
+// Repository provides methods to interact with the warehouses and item_stock tables.
+type Repository struct {
+	db *dbpg.DB
+}
+
+// NewRepository creates a new warehouse repository.
+func NewRepository(db *dbpg.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateWarehouse adds a new warehouse to the database.
+func (r *Repository) CreateWarehouse(ctx context.Context, w *model.Warehouse) (uuid.UUID, error) {
+	query := `
+		INSERT INTO warehouses (code, name, address)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING id, is_default, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, w.Code, w.Name, w.Address).Scan(
+		&w.ID, &w.IsDefault, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		return uuid.Nil, codeError(err, "failed to create warehouse")
+	}
+
+	return w.ID, nil
+}
+
+// GetWarehouseByID retrieves a warehouse by id.
+func (r *Repository) GetWarehouseByID(ctx context.Context, warehouseID uuid.UUID) (*model.Warehouse, error) {
+	query := `
+		SELECT id, code, name, COALESCE(address, ''), is_default, created_at, updated_at
+		FROM warehouses
+		WHERE id = $1
+	`
+
+	var w model.Warehouse
+	err := r.db.QueryRowContext(ctx, query, warehouseID).Scan(
+		&w.ID, &w.Code, &w.Name, &w.Address, &w.IsDefault, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWarehouseNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	return &w, nil
+}
+
+// GetAllWarehouses retrieves all warehouses ordered by code.
+func (r *Repository) GetAllWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	query := `
+		SELECT id, code, name, COALESCE(address, ''), is_default, created_at, updated_at
+		FROM warehouses
+		ORDER BY code
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query warehouses: %w", err)
+	}
+	defer rows.Close()
+
+	var warehouses []*model.Warehouse
+	for rows.Next() {
+		var w model.Warehouse
+		if err := rows.Scan(&w.ID, &w.Code, &w.Name, &w.Address, &w.IsDefault, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse: %w", err)
+		}
+
+		warehouses = append(warehouses, &w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate warehouses: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+// UpdateWarehouse updates the code, name and address of a warehouse.
+func (r *Repository) UpdateWarehouse(ctx context.Context, w *model.Warehouse) error {
+	query := `
+		UPDATE warehouses
+		SET code = $1, name = $2, address = NULLIF($3, ''), updated_at = NOW()
+		WHERE id = $4
+		RETURNING is_default, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, w.Code, w.Name, w.Address, w.ID).Scan(
+		&w.IsDefault, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWarehouseNotFound
+		}
+
+		return codeError(err, "failed to update warehouse")
+	}
+
+	return nil
+}
+
+// GetWarehouseStock retrieves the quantity of each item held in a warehouse, ordered by item name.
+func (r *Repository) GetWarehouseStock(ctx context.Context, warehouseID uuid.UUID) ([]*model.StockLevel, error) {
+	query := `
+		SELECT s.item_id, i.name, COALESCE(i.sku, ''), s.warehouse_id, w.code, w.name, s.quantity, s.reserved,
+		       s.updated_at
+		FROM item_stock s
+		JOIN items i ON i.id = s.item_id
+		JOIN warehouses w ON w.id = s.warehouse_id
+		WHERE s.warehouse_id = $1 AND s.quantity <> 0
+		ORDER BY i.name, i.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query warehouse stock: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*model.StockLevel
+	for rows.Next() {
+		var l model.StockLevel
+		if err := rows.Scan(
+			&l.ItemID, &l.ItemName, &l.SKU, &l.WarehouseID, &l.WarehouseCode, &l.WarehouseName, &l.Quantity, &l.Reserved,
+			&l.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse stock: %w", err)
+		}
+
+		levels = append(levels, &l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate warehouse stock: %w", err)
+	}
+
+	return levels, nil
+}
+
+// codeError maps a unique violation of the warehouse code to ErrCodeTaken
+// and wraps any other error with msg.
+func codeError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "warehouses_code_key" {
+		return ErrCodeTaken
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
@@ -0,0 +1,77 @@
+package adjustment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// repository defines the interface for adjustment reason data access.
+type repository interface {
+	// CreateReason adds a new adjustment reason to the catalogue.
+	CreateReason(ctx context.Context, ar *model.AdjustmentReason) error
+
+	// GetAllReasons retrieves the adjustment reasons, or only the active ones.
+	GetAllReasons(ctx context.Context, activeOnly bool) ([]*model.AdjustmentReason, error)
+
+	// UpdateReason updates the name of an adjustment reason and whether it can be used.
+	UpdateReason(ctx context.Context, ar *model.AdjustmentReason) error
+}
+
+// Service provides business logic for the catalogue of adjustment reason codes.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new adjustment reason service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create adds an active adjustment reason. Codes are stored in lower case.
+func (s *Service) Create(ctx context.Context, code, name string) (*model.AdjustmentReason, error) {
+	ar := &model.AdjustmentReason{
+		Code:   NormalizeCode(code),
+		Name:   strings.TrimSpace(name),
+		Active: true,
+	}
+
+	if err := s.repository.CreateReason(ctx, ar); err != nil {
+		return nil, fmt.Errorf("create adjustment reason: %w", err)
+	}
+
+	return ar, nil
+}
+
+// GetAll retrieves the adjustment reasons ordered by code, or only the active ones.
+func (s *Service) GetAll(ctx context.Context, activeOnly bool) ([]*model.AdjustmentReason, error) {
+	reasons, err := s.repository.GetAllReasons(ctx, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("get adjustment reasons: %w", err)
+	}
+
+	return reasons, nil
+}
+
+// Update renames an adjustment reason and activates or deactivates it. Deactivated codes stay
+// on the movements made with them.
+func (s *Service) Update(ctx context.Context, code, name string, active bool) (*model.AdjustmentReason, error) {
+	ar := &model.AdjustmentReason{
+		Code:   NormalizeCode(code),
+		Name:   strings.TrimSpace(name),
+		Active: active,
+	}
+
+	if err := s.repository.UpdateReason(ctx, ar); err != nil {
+		return nil, fmt.Errorf("update adjustment reason: %w", err)
+	}
+
+	return ar, nil
+}
+
+// NormalizeCode trims an adjustment reason code and converts it to lower case.
+func NormalizeCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
@@ -0,0 +1,98 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+const (
+	// DefaultCheckInterval is how often the checker sweeps all items when no interval is configured.
+	DefaultCheckInterval = 5 * time.Minute
+
+	// changeQueueSize is the number of changed items that can wait for a check.
+	changeQueueSize = 1024
+)
+
+// syncer brings low-stock alerts in line with stock.
+type syncer interface {
+	// SyncLowStockAlerts resolves and raises the alerts of an item, or of all items if itemID
+	// is uuid.Nil, and returns the alerts raised.
+	SyncLowStockAlerts(ctx context.Context, itemID uuid.UUID) ([]*model.LowStockAlert, error)
+}
+
+// Checker raises and resolves low-stock alerts in the background: for every item whose stock
+// changed, and for all items periodically to catch changes it was not told about.
+type Checker struct {
+	syncer   syncer
+	notifier Notifier
+	interval time.Duration
+	changed  chan uuid.UUID
+}
+
+// NewChecker creates a checker that sweeps all items every interval, or every
+// DefaultCheckInterval if interval is not positive, and delivers raised alerts through n.
+func NewChecker(s syncer, n Notifier, interval time.Duration) *Checker {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	return &Checker{
+		syncer:   s,
+		notifier: n,
+		interval: interval,
+		changed:  make(chan uuid.UUID, changeQueueSize),
+	}
+}
+
+// StockChanged queues an item for a check without blocking. If the queue is full the item
+// is left to the next sweep.
+func (c *Checker) StockChanged(itemID uuid.UUID) {
+	select {
+	case c.changed <- itemID:
+	default:
+		zlog.Logger.Warn().Str("itemID", itemID.String()).Msg("low-stock check queue is full")
+	}
+}
+
+// Run checks all items, then each queued item and all items every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.check(ctx, uuid.Nil)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case itemID := <-c.changed:
+			c.check(ctx, itemID)
+		case <-ticker.C:
+			c.check(ctx, uuid.Nil)
+		}
+	}
+}
+
+// check syncs the alerts of an item, or of all items if itemID is uuid.Nil, and delivers
+// the alerts raised. Errors are logged; the next sweep retries.
+func (c *Checker) check(ctx context.Context, itemID uuid.UUID) {
+	alerts, err := c.syncer.SyncLowStockAlerts(ctx, itemID)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Logger.Error().Err(err).Str("itemID", itemID.String()).Msg("failed to check low stock")
+		}
+
+		return
+	}
+
+	for _, a := range alerts {
+		if err := c.notifier.Notify(ctx, a); err != nil {
+			zlog.Logger.Error().Err(err).Str("alertID", a.ID.String()).Msg("failed to deliver low-stock alert")
+		}
+	}
+}
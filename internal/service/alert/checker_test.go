@@ -0,0 +1,90 @@
+package alert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// fakeSyncer raises one alert for every item it is asked about and reports the requests.
+type fakeSyncer struct {
+	checked chan uuid.UUID
+}
+
+func (f *fakeSyncer) SyncLowStockAlerts(_ context.Context, itemID uuid.UUID) ([]*model.LowStockAlert, error) {
+	f.checked <- itemID
+
+	if itemID == uuid.Nil {
+		return nil, nil
+	}
+
+	return []*model.LowStockAlert{{ID: uuid.New(), ItemID: itemID}}, nil
+}
+
+// fakeNotifier reports the alerts it delivers.
+type fakeNotifier struct {
+	notified chan *model.LowStockAlert
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, a *model.LowStockAlert) error {
+	f.notified <- a
+	return nil
+}
+
+func TestCheckerRun(t *testing.T) {
+	syncer := &fakeSyncer{checked: make(chan uuid.UUID, 10)}
+	notifier := &fakeNotifier{notified: make(chan *model.LowStockAlert, 10)}
+	c := NewChecker(syncer, notifier, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go c.Run(ctx)
+
+	if got := receive(t, syncer.checked); got != uuid.Nil {
+		t.Fatalf("first check = %s, want a sweep of all items", got)
+	}
+
+	itemID := uuid.New()
+	c.StockChanged(itemID)
+
+	if got := receive(t, syncer.checked); got != itemID {
+		t.Fatalf("checked %s, want %s", got, itemID)
+	}
+
+	if a := receive(t, notifier.notified); a.ItemID != itemID {
+		t.Fatalf("notified about %s, want %s", a.ItemID, itemID)
+	}
+}
+
+func TestStockChangedDoesNotBlock(t *testing.T) {
+	c := &Checker{changed: make(chan uuid.UUID, 1)}
+
+	done := make(chan struct{})
+	go func() {
+		c.StockChanged(uuid.New())
+		c.StockChanged(uuid.New()) // the queue is full: dropped
+		close(done)
+	}()
+
+	receive(t, done)
+}
+
+// receive waits for a value from ch, failing the test after a second.
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	var zero T
+	return zero
+}
@@ -0,0 +1,32 @@
+package alert
+
+import (
+	"context"
+
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// Notifier delivers newly raised low-stock alerts, e.g. by e-mail or to a chat.
+// Each alert is delivered once, when it is raised.
+type Notifier interface {
+	Notify(ctx context.Context, a *model.LowStockAlert) error
+}
+
+// LogNotifier delivers alerts to the application log.
+type LogNotifier struct{}
+
+// Notify logs the alert as a warning.
+func (LogNotifier) Notify(_ context.Context, a *model.LowStockAlert) error {
+	zlog.Logger.Warn().
+		Str("alertID", a.ID.String()).
+		Str("itemID", a.ItemID.String()).
+		Str("item", a.ItemName).
+		Int("quantity", a.Quantity).
+		Int("reorderPoint", a.ReorderPoint).
+		Int("reorderQuantity", a.ReorderQuantity).
+		Msg("item is low on stock")
+
+	return nil
+}
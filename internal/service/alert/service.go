@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// repository defines the interface for low-stock alert data access.
+type repository interface {
+	// GetLowStockAlerts retrieves the alerts matching the filter, newest first.
+	GetLowStockAlerts(ctx context.Context, filter model.AlertFilter) ([]*model.LowStockAlert, error)
+
+	// AcknowledgeLowStockAlert records that a user is taking care of an open alert.
+	AcknowledgeLowStockAlert(ctx context.Context, alertID, userID uuid.UUID) (*model.LowStockAlert, error)
+}
+
+// Service provides business logic for low-stock alerts.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new alert service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// GetLowStock retrieves the low-stock alerts matching the filter, newest first.
+func (s *Service) GetLowStock(ctx context.Context, filter model.AlertFilter) ([]*model.LowStockAlert, error) {
+	alerts, err := s.repository.GetLowStockAlerts(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get low-stock alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// Acknowledge records that a user is taking care of an open low-stock alert.
+// The alert stays open until the item is restocked above its reorder point.
+func (s *Service) Acknowledge(ctx context.Context, userID, alertID uuid.UUID) (*model.LowStockAlert, error) {
+	a, err := s.repository.AcknowledgeLowStockAlert(ctx, alertID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("acknowledge low-stock alert: %w", err)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,274 @@
+package count
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+var (
+	ErrNothingToCount    = errors.New("count session has no stock to count")
+	ErrNoEntries         = errors.New("at least one count is required")
+	ErrInvalidQuantity   = errors.New("counted quantity cannot be negative")
+	ErrDuplicateLine     = errors.New("count line is listed more than once")
+	ErrUncountedLines    = errors.New("count session has lines that have not been counted")
+	ErrInvalidTransition = errors.New("count session cannot change to this status")
+)
+
+// repository defines the interface for count session data access.
+type repository interface {
+	// CreateCountSession adds a count session and snapshots the quantities it expects to count.
+	CreateCountSession(ctx context.Context, s *model.CountSession, scope model.CountScope) error
+
+	// GetCountSession retrieves a count session with its lines.
+	GetCountSession(ctx context.Context, sessionID uuid.UUID) (*model.CountSession, error)
+
+	// LockCountSession retrieves a count session and locks it for the rest of the transaction.
+	LockCountSession(ctx context.Context, sessionID uuid.UUID) (*model.CountSession, error)
+
+	// GetCountSessions retrieves the count sessions with a status, or all if it is empty.
+	GetCountSessions(ctx context.Context, status model.CountStatus) ([]*model.CountSession, error)
+
+	// GetCountedItems reports which of the items have a line in a count session that is still open.
+	GetCountedItems(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+
+	// RecordCount records the quantity a user counted at the place of a line of a session.
+	RecordCount(ctx context.Context, sessionID, userID uuid.UUID, entry model.CountEntry) error
+
+	// SetCountStatus moves a count session to a new status on behalf of a user.
+	SetCountStatus(ctx context.Context, s *model.CountSession, to model.CountStatus, userID uuid.UUID) error
+}
+
+// unitOfWork runs a group of repository calls in one transaction attributed to a user.
+type unitOfWork interface {
+	// Do runs fn in a transaction; repository calls must use the context passed to fn.
+	Do(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) error
+}
+
+// stock moves item stock; it is implemented by the item service.
+type stock interface {
+	// Adjust corrects an item's stock at a place by a signed delta with an adjustment reason code.
+	Adjust(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, delta int, code, reason, reference string) (*model.StockMovement, error)
+}
+
+// Service provides business logic for cycle count sessions.
+type Service struct {
+	repository repository
+	uow        unitOfWork
+	stock      stock
+}
+
+// NewService creates a new count session service.
+func NewService(r repository, uow unitOfWork, s stock) *Service {
+	return &Service{
+		repository: r,
+		uow:        uow,
+		stock:      s,
+	}
+}
+
+// Create starts a count session for the places of a warehouse within scope, snapshotting the
+// quantity expected at each. Returns ErrNothingToCount if the scope holds no stock to count.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, cs *model.CountSession, scope model.CountScope) (*model.CountSession, error) {
+	cs.CreatedBy = &userID
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		if err := s.repository.CreateCountSession(ctx, cs, scope); err != nil {
+			return err
+		}
+
+		if len(cs.Lines) == 0 {
+			return ErrNothingToCount
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create count session: %w", err)
+	}
+
+	return cs, nil
+}
+
+// GetByID retrieves a count session by its ID.
+func (s *Service) GetByID(ctx context.Context, sessionID uuid.UUID) (*model.CountSession, error) {
+	cs, err := s.repository.GetCountSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("get count session: %w", err)
+	}
+
+	return cs, nil
+}
+
+// GetAll retrieves the count sessions with a status, or all of them if it is empty, newest first.
+func (s *Service) GetAll(ctx context.Context, status model.CountStatus) ([]*model.CountSession, error) {
+	sessions, err := s.repository.GetCountSessions(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("get count sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Counted reports which of the items are being counted: they have a line in a session that is
+// counting or in review, so that their quantities can be kept from those who count blind.
+func (s *Service) Counted(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	counted, err := s.repository.GetCountedItems(ctx, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get counted items: %w", err)
+	}
+
+	return counted, nil
+}
+
+// Record records what a counter found at the places of some lines of a session that is still
+// being counted. A line counted again keeps the latest count.
+func (s *Service) Record(ctx context.Context, userID, sessionID uuid.UUID, entries []model.CountEntry) (*model.CountSession, error) {
+	if len(entries) == 0 {
+		return nil, ErrNoEntries
+	}
+
+	seen := make(map[uuid.UUID]bool, len(entries))
+	for _, entry := range entries {
+		if entry.Quantity < 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		if seen[entry.LineID] {
+			return nil, ErrDuplicateLine
+		}
+
+		seen[entry.LineID] = true
+	}
+
+	return s.apply(ctx, userID, sessionID, "record counts of", func(ctx context.Context, cs *model.CountSession) error {
+		if cs.Status != model.CountCounting {
+			return fmt.Errorf("%w: session is %s", ErrInvalidTransition, cs.Status)
+		}
+
+		for _, entry := range entries {
+			if err := s.repository.RecordCount(ctx, cs.ID, userID, entry); err != nil {
+				return err
+			}
+		}
+
+		counted, err := s.repository.GetCountSession(ctx, cs.ID)
+		if err != nil {
+			return err
+		}
+
+		*cs = *counted
+		return nil
+	})
+}
+
+// Submit closes counting and hands a session to a manager for review once every line has
+// been counted.
+func (s *Service) Submit(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error) {
+	return s.apply(ctx, userID, sessionID, "submit", func(ctx context.Context, cs *model.CountSession) error {
+		if cs.Status != model.CountCounting {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, cs.Status, model.CountReview)
+		}
+
+		for _, line := range cs.Lines {
+			if line.Counted == nil {
+				return ErrUncountedLines
+			}
+		}
+
+		return s.repository.SetCountStatus(ctx, cs, model.CountReview, userID)
+	})
+}
+
+// Approve accepts the counts of a session under review and posts each variance as an adjust
+// movement of the counted place, with the session's ID as its reason and reference.
+// Shortfalls are adjusted with the reason code shortageCode and surpluses with surplusCode;
+// each is required if the session has such variances (repoitem.ErrReasonCodeRequired).
+func (s *Service) Approve(ctx context.Context, userID, sessionID uuid.UUID, shortageCode, surplusCode string) (*model.CountSession, error) {
+	return s.apply(ctx, userID, sessionID, "approve", func(ctx context.Context, cs *model.CountSession) error {
+		if cs.Status != model.CountReview {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, cs.Status, model.CountApproved)
+		}
+
+		for _, line := range cs.Lines {
+			if line.Variance == nil || *line.Variance == 0 {
+				continue
+			}
+
+			code := surplusCode
+			if *line.Variance < 0 {
+				code = shortageCode
+			}
+
+			if code == "" {
+				return fmt.Errorf("%w: item %s has a variance of %d", repoitem.ErrReasonCodeRequired, line.ItemID, *line.Variance)
+			}
+
+			place := model.StockPlace{WarehouseID: cs.WarehouseID, Location: line.LocationCode}
+
+			_, err := s.stock.Adjust(ctx, userID, line.ItemID, place, *line.Variance, code, cs.ID.String(), cs.ID.String())
+			if err != nil {
+				return fmt.Errorf("adjust item %s: %w", line.ItemID, err)
+			}
+		}
+
+		return s.repository.SetCountStatus(ctx, cs, model.CountApproved, userID)
+	})
+}
+
+// Reject sends a session under review back to counting, keeping its counts so that the
+// places in doubt can be recounted.
+func (s *Service) Reject(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error) {
+	return s.apply(ctx, userID, sessionID, "reject", func(ctx context.Context, cs *model.CountSession) error {
+		if cs.Status != model.CountReview {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, cs.Status, model.CountCounting)
+		}
+
+		return s.repository.SetCountStatus(ctx, cs, model.CountCounting, userID)
+	})
+}
+
+// Cancel abandons a session that has not been approved; nothing is adjusted.
+func (s *Service) Cancel(ctx context.Context, userID, sessionID uuid.UUID) (*model.CountSession, error) {
+	return s.apply(ctx, userID, sessionID, "cancel", func(ctx context.Context, cs *model.CountSession) error {
+		if cs.Status != model.CountCounting && cs.Status != model.CountReview {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, cs.Status, model.CountCancelled)
+		}
+
+		return s.repository.SetCountStatus(ctx, cs, model.CountCancelled, userID)
+	})
+}
+
+// apply locks a count session in a unit of work and runs fn on it. action names the step in errors.
+func (s *Service) apply(
+	ctx context.Context,
+	userID, sessionID uuid.UUID,
+	action string,
+	fn func(ctx context.Context, cs *model.CountSession) error,
+) (*model.CountSession, error) {
+	var session *model.CountSession
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		cs, err := s.repository.LockCountSession(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(ctx, cs); err != nil {
+			return err
+		}
+
+		session = cs
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s count session: %w", action, err)
+	}
+
+	return session, nil
+}
@@ -0,0 +1,257 @@
+package count
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+// fakeRepository keeps count sessions in memory. New sessions get one line per expected
+// quantity, all outside any bin.
+type fakeRepository struct {
+	sessions map[uuid.UUID]*model.CountSession
+	expected map[uuid.UUID]int
+}
+
+func (r *fakeRepository) CreateCountSession(_ context.Context, s *model.CountSession, _ model.CountScope) error {
+	s.ID = uuid.New()
+	s.Status = model.CountCounting
+	s.Lines = nil
+
+	for itemID, expected := range r.expected {
+		s.Lines = append(s.Lines, &model.CountLine{ID: uuid.New(), ItemID: itemID, Expected: &expected})
+	}
+
+	r.sessions[s.ID] = s
+	return nil
+}
+
+func (r *fakeRepository) GetCountSession(_ context.Context, sessionID uuid.UUID) (*model.CountSession, error) {
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, repoitem.ErrCountNotFound
+	}
+
+	copied := *s
+	copied.Lines = nil
+	for _, line := range s.Lines {
+		l := *line
+		copied.Lines = append(copied.Lines, &l)
+	}
+
+	return &copied, nil
+}
+
+func (r *fakeRepository) LockCountSession(ctx context.Context, sessionID uuid.UUID) (*model.CountSession, error) {
+	return r.GetCountSession(ctx, sessionID)
+}
+
+func (r *fakeRepository) GetCountSessions(context.Context, model.CountStatus) ([]*model.CountSession, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) GetCountedItems(_ context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	counted := map[uuid.UUID]bool{}
+	for _, s := range r.sessions {
+		if s.Status != model.CountCounting && s.Status != model.CountReview {
+			continue
+		}
+
+		for _, line := range s.Lines {
+			if slices.Contains(itemIDs, line.ItemID) {
+				counted[line.ItemID] = true
+			}
+		}
+	}
+
+	return counted, nil
+}
+
+func (r *fakeRepository) RecordCount(_ context.Context, sessionID, userID uuid.UUID, entry model.CountEntry) error {
+	for _, line := range r.sessions[sessionID].Lines {
+		if line.ID == entry.LineID {
+			counted, variance := entry.Quantity, entry.Quantity-*line.Expected
+			line.Counted, line.Variance, line.CountedBy = &counted, &variance, &userID
+			return nil
+		}
+	}
+
+	return repoitem.ErrCountLineNotFound
+}
+
+func (r *fakeRepository) SetCountStatus(_ context.Context, s *model.CountSession, to model.CountStatus, _ uuid.UUID) error {
+	if r.sessions[s.ID].Status != s.Status {
+		return repoitem.ErrCountStatusChange
+	}
+
+	s.Status = to
+	r.sessions[s.ID].Status = to
+	return nil
+}
+
+// fakeUnitOfWork runs fn without a transaction.
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeStock records the adjustments it is asked for.
+type fakeStock struct {
+	adjustments []*model.StockMovement
+}
+
+func (s *fakeStock) Adjust(
+	_ context.Context, _, itemID uuid.UUID, _ model.StockPlace, delta int, code, reason, reference string,
+) (*model.StockMovement, error) {
+	m := &model.StockMovement{ItemID: itemID, Quantity: delta, ReasonCode: code, Reason: reason, Reference: reference}
+	s.adjustments = append(s.adjustments, m)
+	return m, nil
+}
+
+func TestCountApproval(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	short, exact := uuid.New(), uuid.New()
+
+	repo := &fakeRepository{
+		sessions: map[uuid.UUID]*model.CountSession{},
+		expected: map[uuid.UUID]int{short: 10, exact: 4},
+	}
+	stock := &fakeStock{}
+	s := NewService(repo, fakeUnitOfWork{}, stock)
+
+	cs, err := s.Create(ctx, userID, &model.CountSession{}, model.CountScope{})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	lines := map[uuid.UUID]uuid.UUID{}
+	for _, line := range cs.Lines {
+		lines[line.ItemID] = line.ID
+	}
+
+	if _, err := s.Record(ctx, userID, cs.ID, []model.CountEntry{{LineID: lines[short], Quantity: 7}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if _, err := s.Submit(ctx, userID, cs.ID); !errors.Is(err, ErrUncountedLines) {
+		t.Fatalf("Submit with an uncounted line: got %v, want ErrUncountedLines", err)
+	}
+
+	if _, err := s.Record(ctx, userID, cs.ID, []model.CountEntry{{LineID: lines[exact], Quantity: 4}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if _, err := s.Approve(ctx, userID, cs.ID, "theft", "found"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Approve before submitting: got %v, want ErrInvalidTransition", err)
+	}
+
+	if _, err := s.Submit(ctx, userID, cs.ID); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if _, err := s.Record(ctx, userID, cs.ID, []model.CountEntry{{LineID: lines[short], Quantity: 8}}); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Record under review: got %v, want ErrInvalidTransition", err)
+	}
+
+	if len(stock.adjustments) != 0 {
+		t.Fatalf("adjusted %d times before approval", len(stock.adjustments))
+	}
+
+	if _, err := s.Approve(ctx, userID, cs.ID, "", "found"); !errors.Is(err, repoitem.ErrReasonCodeRequired) {
+		t.Fatalf("Approve a shortfall without a code: got %v, want ErrReasonCodeRequired", err)
+	}
+
+	if cs, err = s.Approve(ctx, userID, cs.ID, "theft", ""); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	if cs.Status != model.CountApproved {
+		t.Fatalf("status = %s, want approved", cs.Status)
+	}
+
+	if len(stock.adjustments) != 1 {
+		t.Fatalf("adjusted %d times, want once for the variance", len(stock.adjustments))
+	}
+
+	m := stock.adjustments[0]
+	if m.ItemID != short || m.Quantity != -3 || m.ReasonCode != "theft" || m.Reason != cs.ID.String() ||
+		m.Reference != cs.ID.String() {
+		t.Fatalf("adjustment = %+v, want -3 of %s for session %s", m, short, cs.ID)
+	}
+}
+
+func TestItemsAreCountedWhileTheSessionIsOpen(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	listed, other := uuid.New(), uuid.New()
+
+	repo := &fakeRepository{sessions: map[uuid.UUID]*model.CountSession{}, expected: map[uuid.UUID]int{listed: 2}}
+	s := NewService(repo, fakeUnitOfWork{}, &fakeStock{})
+
+	cs, err := s.Create(ctx, userID, &model.CountSession{}, model.CountScope{})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	check := func(when string, want bool) {
+		t.Helper()
+
+		counted, err := s.Counted(ctx, []uuid.UUID{listed, other})
+		if err != nil {
+			t.Fatalf("Counted %s: %v", when, err)
+		}
+
+		if counted[listed] != want || counted[other] {
+			t.Fatalf("counted %s = %v, want %s counted %t and %s not", when, counted, listed, want, other)
+		}
+	}
+
+	check("while counting", true)
+
+	if _, err := s.Record(ctx, userID, cs.ID, []model.CountEntry{{LineID: cs.Lines[0].ID, Quantity: 2}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if _, err := s.Submit(ctx, userID, cs.ID); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	check("under review", true)
+
+	if _, err := s.Cancel(ctx, userID, cs.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	check("after cancelling", false)
+}
+
+func TestRecordValidation(t *testing.T) {
+	s := NewService(&fakeRepository{}, fakeUnitOfWork{}, &fakeStock{})
+	lineID := uuid.New()
+
+	tests := []struct {
+		name    string
+		entries []model.CountEntry
+		want    error
+	}{
+		{"no entries", nil, ErrNoEntries},
+		{"negative", []model.CountEntry{{LineID: lineID, Quantity: -1}}, ErrInvalidQuantity},
+		{"duplicate", []model.CountEntry{{LineID: lineID, Quantity: 1}, {LineID: lineID, Quantity: 2}}, ErrDuplicateLine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Record(context.Background(), uuid.New(), uuid.New(), tt.entries); !errors.Is(err, tt.want) {
+				t.Fatalf("Record: got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/currency"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrInvalidRate  = errors.New("exchange rate must be positive")
+	ErrBaseCurrency = errors.New("the base currency has no exchange rates")
+)
+
+// repository defines the interface for exchange rate data access.
+type repository interface {
+	// CreateRate adds an exchange rate.
+	CreateRate(ctx context.Context, rate *model.ExchangeRate) error
+
+	// GetAllRates retrieves the exchange rates of a currency, or of all currencies if it is empty.
+	GetAllRates(ctx context.Context, currency string) ([]*model.ExchangeRate, error)
+
+	// GetRatesAt retrieves the exchange rate of each currency in effect at a point in time.
+	GetRatesAt(ctx context.Context, at time.Time) (map[string]decimal.Decimal, error)
+
+	// DeleteRate removes an exchange rate.
+	DeleteRate(ctx context.Context, rateID uuid.UUID) error
+}
+
+// Service provides the exchange rates and converts amounts between currencies with them.
+type Service struct {
+	repository repository
+	base       string
+	rounding   map[string]currency.Rounding
+}
+
+// NewService creates a new currency service. Prices without a currency are in base, and
+// amounts converted into a currency are rounded by its rule in rounding, keyed by currency
+// code in any case, or by currency.DefaultRounding if it has none.
+func NewService(r repository, base string, rounding map[string]currency.Rounding) *Service {
+	rules := make(map[string]currency.Rounding, len(rounding))
+	for code, rule := range rounding {
+		rules[strings.ToUpper(code)] = rule
+	}
+
+	return &Service{
+		repository: r,
+		base:       strings.ToUpper(base),
+		rounding:   rules,
+	}
+}
+
+// Base returns the code of the base currency.
+func (s *Service) Base() string {
+	return s.base
+}
+
+// Create adds an exchange rate of a currency other than the base currency, taking effect at
+// rate.EffectiveFrom or now if it is zero.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, rate *model.ExchangeRate) error {
+	code, err := currency.Normalize(rate.Currency)
+	if err != nil {
+		return err
+	}
+
+	if code == s.base {
+		return ErrBaseCurrency
+	}
+
+	if !rate.Rate.IsPositive() {
+		return ErrInvalidRate
+	}
+
+	if rate.EffectiveFrom.IsZero() {
+		rate.EffectiveFrom = time.Now().UTC()
+	}
+
+	rate.Currency = code
+	rate.CreatedBy = &userID
+
+	if err := s.repository.CreateRate(ctx, rate); err != nil {
+		return fmt.Errorf("create exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+// GetAll retrieves the exchange rates of a currency, or of all currencies if it is empty, in
+// order of currency and the newest first.
+func (s *Service) GetAll(ctx context.Context, code string) ([]*model.ExchangeRate, error) {
+	if code != "" {
+		var err error
+		if code, err = currency.Normalize(code); err != nil {
+			return nil, err
+		}
+	}
+
+	rates, err := s.repository.GetAllRates(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("get exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// Delete removes an exchange rate entered by mistake.
+func (s *Service) Delete(ctx context.Context, rateID uuid.UUID) error {
+	if err := s.repository.DeleteRate(ctx, rateID); err != nil {
+		return fmt.Errorf("delete exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+// ConverterAt returns a converter into the currency to at the exchange rates in effect at at,
+// which rounds by the rule of to. Returns an error wrapping currency.ErrInvalidCode if to is not
+// a currency code and currency.ErrRateNotFound if it has no rate in effect.
+func (s *Service) ConverterAt(ctx context.Context, to string, at time.Time) (*currency.Converter, error) {
+	to, err := currency.Normalize(to)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.repository.GetRatesAt(ctx, at)
+	if err != nil {
+		return nil, fmt.Errorf("get exchange rates: %w", err)
+	}
+
+	rounding, ok := s.rounding[to]
+	if !ok {
+		rounding = currency.DefaultRounding
+	}
+
+	return currency.NewConverter(s.base, to, rates, rounding)
+}
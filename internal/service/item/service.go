@@ -3,30 +3,87 @@ package item
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
+	"github.com/aliskhannn/warehouse-control/internal/audit"
+	"github.com/aliskhannn/warehouse-control/internal/currency"
+	"github.com/aliskhannn/warehouse-control/internal/gs1"
 	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+const (
+	// DefaultPageSize is the number of items returned by GetAll when no limit is given.
+	DefaultPageSize = 50
+
+	// MaxPageSize is the largest number of items GetAll returns at once.
+	MaxPageSize = 500
+
+	// DefaultMaxPriceChange is the largest change of an item price, in percent, made without
+	// the admin role when none is configured.
+	DefaultMaxPriceChange = 20
+)
+
+var (
+	ErrInvalidQuantity   = errors.New("quantity must be positive")
+	ErrZeroAdjustment    = errors.New("adjustment quantity must not be zero")
+	ErrReferenceRequired = errors.New("reference is required for transfers")
+	ErrInvalidBarcode    = errors.New("barcode is not a valid GTIN")
+	ErrDuplicateSerial   = errors.New("serial is listed more than once")
+
+	ErrInvalidCostingMethod = errors.New("costing method must be fifo, lifo or average")
+	ErrInvalidUnitCost      = errors.New("unit cost must not be negative")
+
+	ErrPriceChangeDenied = errors.New("price change exceeds the limit allowed without the admin role")
+	ErrInvalidPriceRange = errors.New("from must be before to")
 )
 
 // repository defines the interface for item-related data access.
 type repository interface {
 	// CreateItem adds a new item to the database and returns its ID.
-	CreateItem(ctx context.Context, item *model.Item) (uuid.UUID, error)
+	CreateItem(ctx context.Context, userID uuid.UUID, item *model.Item) (uuid.UUID, error)
 
 	// GetItemByID retrieves an item by its ID.
 	GetItemByID(ctx context.Context, itemID uuid.UUID) (*model.Item, error)
 
-	// GetAllItems retrieves all items, optionally filtered by name.
-	GetAllItems(ctx context.Context, nameFilter string) ([]*model.Item, error)
+	// GetItemBySKU retrieves an item by its SKU.
+	GetItemBySKU(ctx context.Context, sku string) (*model.Item, error)
+
+	// GetItemByBarcode retrieves an item by one of its barcodes, given as a 14-digit GTIN.
+	GetItemByBarcode(ctx context.Context, gtin string) (*model.Item, error)
+
+	// GetAllItems retrieves one page of items matching the filter and the cursor of the next page.
+	GetAllItems(ctx context.Context, filter model.ItemFilter) ([]*model.Item, string, error)
+
+	// UpdateItem updates an existing item if its version still equals item.Version.
+	UpdateItem(ctx context.Context, userID uuid.UUID, item *model.Item) error
+
+	// DeleteItem removes an item by its ID if its version still equals version.
+	DeleteItem(ctx context.Context, itemID uuid.UUID, version int) error
+
+	// CreateMovement applies a signed stock movement to an item.
+	CreateMovement(ctx context.Context, m *model.StockMovement) error
+
+	// GetMovements retrieves the stock movements of an item.
+	GetMovements(ctx context.Context, itemID uuid.UUID) ([]*model.StockMovement, error)
+
+	// GetItemStock retrieves the quantity of an item in each warehouse that holds it.
+	GetItemStock(ctx context.Context, itemID uuid.UUID) ([]*model.StockLevel, error)
+
+	// GetItemLocations retrieves the quantity of an item in each bin that holds it.
+	GetItemLocations(ctx context.Context, itemID uuid.UUID) ([]*model.LocationStock, error)
 
-	// UpdateItem updates an existing item in the database.
-	UpdateItem(ctx context.Context, item *model.Item) error
+	// GetReservedQuantity retrieves how much of an item's stock is reserved over all warehouses.
+	GetReservedQuantity(ctx context.Context, itemID uuid.UUID) (int, error)
 
-	// DeleteItem removes an item by its ID.
-	DeleteItem(ctx context.Context, itemID uuid.UUID) error
+	// GetItemPrices retrieves the prices of an item in effect at some time in [from, to), oldest first.
+	GetItemPrices(ctx context.Context, itemID uuid.UUID, from, to *time.Time) ([]*model.ItemPrice, error)
 
 	// GetItemHistory retrieves change history for an item.
 	GetItemHistory(ctx context.Context, itemID uuid.UUID) ([]*model.ItemHistory, error)
@@ -34,98 +91,759 @@ type repository interface {
 	// CompareVersions decodes old and new JSONB data from history and returns them as maps.
 	CompareVersions(oldData, newData json.RawMessage) (map[string]interface{}, map[string]interface{}, error)
 
-	// SetCurrentUser sets the current user in the PostgreSQL session for auditing.
-	SetCurrentUser(ctx context.Context, userID uuid.UUID) error
+	// SnapshotItem returns the item row as JSON and locks it for the rest of the transaction.
+	SnapshotItem(ctx context.Context, itemID uuid.UUID) (json.RawMessage, error)
+}
+
+// unitOfWork runs a group of repository calls in one transaction attributed to a user.
+type unitOfWork interface {
+	// Do runs fn in a transaction; repository calls must use the context passed to fn.
+	Do(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) error
+
+	// AfterCommit runs f once the transaction carried by ctx has been committed.
+	AfterCommit(ctx context.Context, f func())
+}
+
+// converter converts prices between currencies.
+type converter interface {
+	// Base returns the code of the base currency.
+	Base() string
+
+	// ConverterAt returns a converter into a currency at the exchange rates in effect at a point in time.
+	ConverterAt(ctx context.Context, to string, at time.Time) (*currency.Converter, error)
+}
+
+// stockWatcher is told about items whose stock may have changed.
+type stockWatcher interface {
+	// StockChanged reports that an item's stock may have changed. It must not block.
+	StockChanged(itemID uuid.UUID)
 }
 
 // Service provides business logic for items and item history.
 type Service struct {
-	repository repository
+	repository  repository
+	uow         unitOfWork
+	auditWriter audit.AuditWriter
+	watcher     stockWatcher
+	converter   converter
+
+	maxPriceChange decimal.Decimal
 }
 
 // NewService creates a new item service.
-func NewService(r repository) *Service {
-	return &Service{repository: r}
+// w writes item history in app audit mode; it is nil in trigger mode,
+// where the database triggers write history instead.
+// watcher, if not nil, is told about every item written once the change is committed.
+// Prices change by at most maxPriceChange percent without the admin role, or by
+// DefaultMaxPriceChange if it is not positive. c converts them between currencies.
+func NewService(
+	r repository, uow unitOfWork, w audit.AuditWriter, watcher stockWatcher, maxPriceChange float64, c converter,
+) *Service {
+	if maxPriceChange <= 0 {
+		maxPriceChange = DefaultMaxPriceChange
+	}
+
+	return &Service{
+		repository:     r,
+		uow:            uow,
+		auditWriter:    w,
+		watcher:        watcher,
+		converter:      c,
+		maxPriceChange: decimal.NewFromFloat(maxPriceChange),
+	}
 }
 
-// Create adds a new item with the specified fields.
-func (s *Service) Create(ctx context.Context, userID uuid.UUID, name, description string, quantity int, price decimal.Decimal) (uuid.UUID, error) {
-	// Set current user.
-	if err := s.repository.SetCurrentUser(ctx, userID); err != nil {
-		return uuid.Nil, fmt.Errorf("set current user: %w", err)
+// Create adds a new item. Its barcodes are validated and stored as 14-digit GTINs.
+// Items are costed FIFO unless another costing method is given. Prices in a currency other
+// than the base currency need an exchange rate in effect.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, item *model.Item) (uuid.UUID, error) {
+	if err := validateCostingMethod(item.CostingMethod); err != nil {
+		return uuid.Nil, fmt.Errorf("create item: %w", err)
+	}
+
+	if err := normalizeIdentifiers(item); err != nil {
+		return uuid.Nil, fmt.Errorf("create item: %w", err)
+	}
+
+	if err := s.normalizeCurrency(item); err != nil {
+		return uuid.Nil, fmt.Errorf("create item: %w", err)
 	}
 
-	item := &model.Item{
-		Name:        name,
-		Description: description,
-		Quantity:    quantity,
-		Price:       price,
+	if err := s.checkRate(ctx, item.Currency); err != nil {
+		return uuid.Nil, fmt.Errorf("create item: %w", err)
 	}
 
-	id, err := s.repository.CreateItem(ctx, item)
+	err := s.audited(ctx, userID, uuid.Nil, model.ActionInsert, func(ctx context.Context) (uuid.UUID, error) {
+		return s.repository.CreateItem(ctx, userID, item)
+	})
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("create item: %w", err)
 	}
 
-	return id, nil
+	return item.ID, nil
 }
 
-// GetByID retrieves an item by its ID.
+// GetByID retrieves an item by its ID with its stock reserved for orders and the quantity
+// available to promise, which is the rest.
 func (s *Service) GetByID(ctx context.Context, itemID uuid.UUID) (*model.Item, error) {
 	item, err := s.repository.GetItemByID(ctx, itemID)
 	if err != nil {
 		return nil, fmt.Errorf("get item by id: %w", err)
 	}
 
+	reserved, err := s.repository.GetReservedQuantity(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get reserved quantity: %w", err)
+	}
+
+	available := item.Quantity - reserved
+	item.Reserved = &reserved
+	item.AvailableToPromise = &available
+
+	return item, nil
+}
+
+// GetBySKU retrieves an item by its SKU.
+func (s *Service) GetBySKU(ctx context.Context, sku string) (*model.Item, error) {
+	item, err := s.repository.GetItemBySKU(ctx, strings.TrimSpace(sku))
+	if err != nil {
+		return nil, fmt.Errorf("get item by sku: %w", err)
+	}
+
 	return item, nil
 }
 
-// GetAll retrieves all items, optionally filtered by name.
-func (s *Service) GetAll(ctx context.Context, nameFilter string) ([]*model.Item, error) {
-	items, err := s.repository.GetAllItems(ctx, nameFilter)
+// GetByBarcode retrieves an item by one of its barcodes. The code may be a GTIN-8, -12, -13 or -14.
+func (s *Service) GetByBarcode(ctx context.Context, code string) (*model.Item, error) {
+	gtin, err := gs1.NormalizeGTIN(code)
 	if err != nil {
-		return nil, fmt.Errorf("get all items: %w", err)
+		return nil, ErrInvalidBarcode
+	}
+
+	item, err := s.repository.GetItemByBarcode(ctx, gtin)
+	if err != nil {
+		return nil, fmt.Errorf("get item by barcode: %w", err)
+	}
+
+	return item, nil
+}
+
+// GetAll retrieves one page of items matching the filter and the cursor of the next page.
+// Items are ordered by creation time, newest first, unless the filter specifies a sort.
+// The page size defaults to DefaultPageSize and is capped at MaxPageSize.
+func (s *Service) GetAll(ctx context.Context, filter model.ItemFilter) ([]*model.Item, string, error) {
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+		filter.Desc = true
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+
+	items, nextCursor, err := s.repository.GetAllItems(ctx, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("get all items: %w", err)
+	}
+
+	return items, nextCursor, nil
+}
+
+// Update replaces the fields of an existing item if it is still at item.Version and returns
+// its new version. An empty costing method keeps the current one, while an empty currency
+// puts the prices in the base currency.
+// A price may change by more than the configured limit only if ctx carries WithLargePriceChanges.
+func (s *Service) Update(ctx context.Context, userID uuid.UUID, item *model.Item) (int, error) {
+	if err := validateCostingMethod(item.CostingMethod); err != nil {
+		return 0, fmt.Errorf("update item: %w", err)
+	}
+
+	if err := normalizeIdentifiers(item); err != nil {
+		return 0, fmt.Errorf("update item: %w", err)
+	}
+
+	if err := s.normalizeCurrency(item); err != nil {
+		return 0, fmt.Errorf("update item: %w", err)
+	}
+
+	err := s.audited(ctx, userID, item.ID, model.ActionUpdate, func(ctx context.Context) (uuid.UUID, error) {
+		current, err := s.repository.GetItemByID(ctx, item.ID)
+		if err != nil {
+			return item.ID, err
+		}
+
+		if err := s.checkPriceChange(ctx, current, item); err != nil {
+			return item.ID, err
+		}
+
+		if item.Currency != current.Currency {
+			if err := s.checkRate(ctx, item.Currency); err != nil {
+				return item.ID, err
+			}
+		}
+
+		return item.ID, s.repository.UpdateItem(ctx, userID, item)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("update item: %w", err)
+	}
+
+	return item.Version, nil
+}
+
+// Patch applies a partial update to an item if it is still at the given version and returns
+// its new version. Only the fields present in the patch are changed, in a single write
+// that produces a single history entry. An empty patch changes nothing.
+// Prices are limited as in Update.
+func (s *Service) Patch(ctx context.Context, userID, itemID uuid.UUID, version int, patch model.ItemPatch) (int, error) {
+	if patch.CostingMethod != nil {
+		if err := validateCostingMethod(*patch.CostingMethod); err != nil {
+			return 0, fmt.Errorf("patch item: %w", err)
+		}
+	}
+
+	if patch.IsEmpty() {
+		item, err := s.repository.GetItemByID(ctx, itemID)
+		if err != nil {
+			return 0, fmt.Errorf("get item by id: %w", err)
+		}
+
+		if item.Version != version {
+			return 0, fmt.Errorf("patch item: %w", repoitem.ErrVersionConflict)
+		}
+
+		return version, nil
+	}
+
+	var newVersion int
+
+	err := s.audited(ctx, userID, itemID, model.ActionUpdate, func(ctx context.Context) (uuid.UUID, error) {
+		item, err := s.repository.GetItemByID(ctx, itemID)
+		if err != nil {
+			return itemID, err
+		}
+
+		current := *item
+
+		patch.Apply(item)
+		item.Version = version
+
+		if err := normalizeIdentifiers(item); err != nil {
+			return itemID, err
+		}
+
+		if err := s.normalizeCurrency(item); err != nil {
+			return itemID, err
+		}
+
+		if err := s.checkPriceChange(ctx, &current, item); err != nil {
+			return itemID, err
+		}
+
+		if item.Currency != current.Currency {
+			if err := s.checkRate(ctx, item.Currency); err != nil {
+				return itemID, err
+			}
+		}
+
+		if err := s.repository.UpdateItem(ctx, userID, item); err != nil {
+			return itemID, err
+		}
+
+		newVersion = item.Version
+
+		return itemID, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("patch item: %w", err)
+	}
+
+	return newVersion, nil
+}
+
+// checkPriceChange returns ErrPriceChangeDenied if the cost or list price of current changes
+// in updated by more than the configured percentage, or the prices change currency, unless ctx
+// carries WithLargePriceChanges. Prices that were zero may be set to anything.
+func (s *Service) checkPriceChange(ctx context.Context, current, updated *model.Item) error {
+	if largePriceChanges(ctx) {
+		return nil
+	}
+
+	if updated.Currency != current.Currency {
+		if current.CostPrice.IsZero() && current.ListPrice.IsZero() {
+			return nil
+		}
+
+		return fmt.Errorf("%w: the prices change currency", ErrPriceChangeDenied)
+	}
+
+	if priceChange(current.CostPrice, updated.CostPrice).GreaterThan(s.maxPriceChange) ||
+		priceChange(current.ListPrice, updated.ListPrice).GreaterThan(s.maxPriceChange) {
+		return fmt.Errorf("%w of %s%%", ErrPriceChangeDenied, s.maxPriceChange)
+	}
+
+	return nil
+}
+
+// priceChange returns how much a price changes from old to new, in percent of old.
+// A change from zero is counted as none.
+func priceChange(old, new decimal.Decimal) decimal.Decimal {
+	if old.IsZero() {
+		return decimal.Zero
+	}
+
+	return new.Sub(old).Abs().Mul(decimal.NewFromInt(100)).Div(old.Abs())
+}
+
+// normalizeCurrency converts the currency of the item's prices to upper case, and clears it
+// if it is the base currency. Returns an error wrapping currency.ErrInvalidCode if it is not a
+// currency code.
+func (s *Service) normalizeCurrency(item *model.Item) error {
+	if strings.TrimSpace(item.Currency) == "" {
+		item.Currency = ""
+		return nil
+	}
+
+	code, err := currency.Normalize(item.Currency)
+	if err != nil {
+		return err
+	}
+
+	if s.converter != nil && code == s.converter.Base() {
+		code = ""
+	}
+
+	item.Currency = code
+
+	return nil
+}
+
+// checkRate returns an error wrapping currency.ErrRateNotFound if prices in the currency code,
+// unless it is empty for the base currency, cannot be converted as there is no exchange rate in
+// effect for it.
+func (s *Service) checkRate(ctx context.Context, code string) error {
+	if code == "" || s.converter == nil {
+		return nil
+	}
+
+	if _, err := s.converter.ConverterAt(ctx, code, time.Now()); err != nil {
+		return err
 	}
 
-	return items, nil
+	return nil
 }
 
-// Update modifies an existing item.
-func (s *Service) Update(ctx context.Context, userID, itemID uuid.UUID, name, description string, quantity int, price decimal.Decimal) error {
-	// Set current user.
-	if err := s.repository.SetCurrentUser(ctx, userID); err != nil {
-		return fmt.Errorf("set current user: %w", err)
+// ConvertPrices converts the prices of items into the currency code at the exchange rates in
+// effect now. Nothing is converted if code is empty.
+func (s *Service) ConvertPrices(ctx context.Context, code string, items ...*model.Item) error {
+	if code == "" {
+		return nil
 	}
 
-	item := &model.Item{
-		ID:          itemID,
-		Name:        name,
-		Description: description,
-		Quantity:    quantity,
-		Price:       price,
+	c, err := s.converter.ConverterAt(ctx, code, time.Now())
+	if err != nil {
+		return fmt.Errorf("convert prices: %w", err)
 	}
 
-	if err := s.repository.UpdateItem(ctx, item); err != nil {
-		return fmt.Errorf("update item: %w", err)
+	for _, item := range items {
+		if item.CostPrice, err = c.Convert(item.CostPrice, item.Currency); err != nil {
+			return fmt.Errorf("convert prices: %w", err)
+		}
+
+		if item.ListPrice, err = c.Convert(item.ListPrice, item.Currency); err != nil {
+			return fmt.Errorf("convert prices: %w", err)
+		}
+
+		item.Currency = c.Currency()
 	}
 
 	return nil
 }
 
-// Delete removes an item by its ID.
-func (s *Service) Delete(ctx context.Context, userID, itemID uuid.UUID) error {
-	// Set current user.
-	if err := s.repository.SetCurrentUser(ctx, userID); err != nil {
-		return fmt.Errorf("set current user: %w", err)
+// validateCostingMethod returns ErrInvalidCostingMethod unless method is empty or a known one.
+func validateCostingMethod(method model.CostingMethod) error {
+	switch method {
+	case "", model.CostingFIFO, model.CostingLIFO, model.CostingAverage:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidCostingMethod, method)
 	}
+}
+
+// normalizeIdentifiers trims the item's SKU, converts its reason code to lower case and its
+// barcodes to 14-digit GTINs, dropping duplicates. Returns ErrInvalidBarcode if a barcode has
+// a wrong length or check digit.
+func normalizeIdentifiers(item *model.Item) error {
+	item.SKU = strings.TrimSpace(item.SKU)
+	item.ReasonCode = strings.ToLower(strings.TrimSpace(item.ReasonCode))
+
+	barcodes := make([]string, 0, len(item.Barcodes))
+	seen := make(map[string]bool, len(item.Barcodes))
+
+	for _, code := range item.Barcodes {
+		gtin, err := gs1.NormalizeGTIN(code)
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidBarcode, code)
+		}
+
+		if !seen[gtin] {
+			seen[gtin] = true
+			barcodes = append(barcodes, gtin)
+		}
+	}
+
+	item.Barcodes = barcodes
 
-	if err := s.repository.DeleteItem(ctx, itemID); err != nil {
+	return nil
+}
+
+// Delete removes an item by its ID if it is still at the given version.
+func (s *Service) Delete(ctx context.Context, userID, itemID uuid.UUID, version int) error {
+	err := s.audited(ctx, userID, itemID, model.ActionDelete, func(ctx context.Context) (uuid.UUID, error) {
+		return itemID, s.repository.DeleteItem(ctx, itemID, version)
+	})
+	if err != nil {
 		return fmt.Errorf("delete item: %w", err)
 	}
 
 	return nil
 }
 
+// Receive adds quantity units of stock to an item at a place: a warehouse, uuid.Nil for the
+// default one, and optionally a bin in it and a lot of the item. The same holds for the
+// other movements.
+func (s *Service) Receive(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	return s.move(ctx, userID, itemID, place, model.MovementReceive, quantity, "", reason, reference)
+}
+
+// Issue removes quantity units of stock from an item in a warehouse.
+// Issues from an expired lot are rejected unless ctx carries WithExpiryOverride.
+func (s *Service) Issue(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	return s.move(ctx, userID, itemID, place, model.MovementIssue, -quantity, "", reason, reference)
+}
+
+// Adjust corrects an item's stock in a warehouse by a signed delta, e.g. after a count or damage.
+// code is the adjustment reason code explaining it; it is required.
+func (s *Service) Adjust(
+	ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, delta int, code, reason, reference string,
+) (*model.StockMovement, error) {
+	if delta == 0 {
+		return nil, ErrZeroAdjustment
+	}
+
+	if code == "" {
+		return nil, repoitem.ErrReasonCodeRequired
+	}
+
+	return s.move(ctx, userID, itemID, place, model.MovementAdjust, delta, code, reason, reference)
+}
+
+// ReverseReceipt takes back quantity units of stock that a receipt added by mistake. It is
+// recorded as an adjustment, but as the correction of a receipt needs no reason code.
+func (s *Service) ReverseReceipt(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	return s.move(ctx, userID, itemID, place, model.MovementAdjust, -quantity, "", reason, reference)
+}
+
+// Transfer moves stock of an item in a warehouse to or from another site by a signed delta.
+// The reference identifies the counterpart of the transfer.
+func (s *Service) Transfer(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, delta int, reason, reference string) (*model.StockMovement, error) {
+	if delta == 0 {
+		return nil, ErrZeroAdjustment
+	}
+
+	if reference == "" {
+		return nil, ErrReferenceRequired
+	}
+
+	return s.move(ctx, userID, itemID, place, model.MovementTransfer, delta, "", reason, reference)
+}
+
+// Increment raises an item's quantity in a warehouse by amount relative to its current value.
+// Like Adjust, it requires an adjustment reason code.
+func (s *Service) Increment(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, amount int, code, reason string) (*model.StockMovement, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	if code == "" {
+		return nil, repoitem.ErrReasonCodeRequired
+	}
+
+	if reason == "" {
+		reason = "increment"
+	}
+
+	return s.move(ctx, userID, itemID, place, model.MovementAdjust, amount, code, reason, "")
+}
+
+// Decrement lowers an item's quantity in a warehouse by amount relative to its current value.
+// Like Adjust, it requires an adjustment reason code.
+// Returns an error wrapping repoitem.ErrInsufficientStock if stock would become negative.
+func (s *Service) Decrement(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, amount int, code, reason string) (*model.StockMovement, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	if code == "" {
+		return nil, repoitem.ErrReasonCodeRequired
+	}
+
+	if reason == "" {
+		reason = "decrement"
+	}
+
+	return s.move(ctx, userID, itemID, place, model.MovementAdjust, -amount, code, reason, "")
+}
+
+// GetMovements retrieves the stock movements of an item.
+func (s *Service) GetMovements(ctx context.Context, itemID uuid.UUID) ([]*model.StockMovement, error) {
+	movements, err := s.repository.GetMovements(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// GetStock retrieves the quantity of an item in each warehouse that holds it.
+func (s *Service) GetStock(ctx context.Context, itemID uuid.UUID) ([]*model.StockLevel, error) {
+	if _, err := s.repository.GetItemByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("get item by id: %w", err)
+	}
+
+	levels, err := s.repository.GetItemStock(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get item stock: %w", err)
+	}
+
+	return levels, nil
+}
+
+// GetLocations retrieves the quantity of an item in each bin that holds it.
+func (s *Service) GetLocations(ctx context.Context, itemID uuid.UUID) ([]*model.LocationStock, error) {
+	locations, err := s.repository.GetItemLocations(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get item locations: %w", err)
+	}
+
+	return locations, nil
+}
+
+// GetPrices retrieves the prices of an item in effect at some time at or after from and
+// before to, oldest first. A nil bound leaves that side of the range open. Unless code is
+// empty, the prices are converted into that currency at the exchange rates in effect when
+// they took effect.
+func (s *Service) GetPrices(
+	ctx context.Context, itemID uuid.UUID, from, to *time.Time, code string,
+) ([]*model.ItemPrice, error) {
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, ErrInvalidPriceRange
+	}
+
+	if _, err := s.repository.GetItemByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("get item by id: %w", err)
+	}
+
+	prices, err := s.repository.GetItemPrices(ctx, itemID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get item prices: %w", err)
+	}
+
+	if code == "" {
+		return prices, nil
+	}
+
+	for _, p := range prices {
+		c, err := s.converter.ConverterAt(ctx, code, p.EffectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("convert prices: %w", err)
+		}
+
+		if p.CostPrice, err = c.Convert(p.CostPrice, p.Currency); err != nil {
+			return nil, fmt.Errorf("convert prices: %w", err)
+		}
+
+		if p.ListPrice, err = c.Convert(p.ListPrice, p.Currency); err != nil {
+			return nil, fmt.Errorf("convert prices: %w", err)
+		}
+
+		p.Currency = c.Currency()
+	}
+
+	return prices, nil
+}
+
+// expiryOverrideKey is the context key that allows issues from expired lots.
+type expiryOverrideKey struct{}
+
+// WithExpiryOverride returns a context in which issue movements may take stock from expired
+// lots. Only admins may override expiry; callers must check the role before using it.
+func WithExpiryOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, expiryOverrideKey{}, true)
+}
+
+// expiryOverride reports whether ctx allows issues from expired lots.
+func expiryOverride(ctx context.Context) bool {
+	allowed, _ := ctx.Value(expiryOverrideKey{}).(bool)
+	return allowed
+}
+
+// largePriceChangesKey is the context key that allows price changes beyond the configured limit.
+type largePriceChangesKey struct{}
+
+// WithLargePriceChanges returns a context in which item prices may change by more than the
+// configured limit. Only admins may make such changes; callers must check the role before using it.
+func WithLargePriceChanges(ctx context.Context) context.Context {
+	return context.WithValue(ctx, largePriceChangesKey{}, true)
+}
+
+// largePriceChanges reports whether ctx allows price changes beyond the configured limit.
+func largePriceChanges(ctx context.Context) bool {
+	allowed, _ := ctx.Value(largePriceChangesKey{}).(bool)
+	return allowed
+}
+
+// normalizeSerials trims the serials of a movement.
+// Returns ErrDuplicateSerial if a unit is listed twice.
+func normalizeSerials(serials []string) ([]string, error) {
+	normalized := make([]string, 0, len(serials))
+	seen := make(map[string]bool, len(serials))
+
+	for _, serial := range serials {
+		serial = strings.TrimSpace(serial)
+		if seen[serial] {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateSerial, serial)
+		}
+
+		seen[serial] = true
+		normalized = append(normalized, serial)
+	}
+
+	return normalized, nil
+}
+
+// move records a signed movement and updates the item's quantity accordingly.
+// Serialized items must name one serial per unit moved. A unit cost the place names is
+// used only by movements adding stock and must not be negative.
+func (s *Service) move(
+	ctx context.Context,
+	userID, itemID uuid.UUID,
+	place model.StockPlace,
+	movementType model.MovementType,
+	delta int,
+	code, reason, reference string,
+) (*model.StockMovement, error) {
+	if place.UnitCost != nil && place.UnitCost.IsNegative() {
+		return nil, ErrInvalidUnitCost
+	}
+
+	serials, err := normalizeSerials(place.Serials)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &model.StockMovement{
+		ItemID:         itemID,
+		WarehouseID:    place.WarehouseID,
+		LocationCode:   strings.ToUpper(strings.TrimSpace(place.Location)),
+		LotNumber:      strings.TrimSpace(place.Lot),
+		ExpiryOverride: expiryOverride(ctx),
+		Serials:        serials,
+		Type:           movementType,
+		Quantity:       delta,
+		Reason:         reason,
+		ReasonCode:     strings.ToLower(strings.TrimSpace(code)),
+		Reference:      reference,
+		CreatedBy:      &userID,
+		UnitCost:       place.UnitCost,
+	}
+
+	err = s.audited(ctx, userID, itemID, model.ActionUpdate, func(ctx context.Context) (uuid.UUID, error) {
+		return itemID, s.repository.CreateMovement(ctx, m)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create movement: %w", err)
+	}
+
+	return m, nil
+}
+
+// audited runs write in a unit of work attributed to userID. In app audit mode it also
+// snapshots the item before and after write and records the change through the audit
+// writer in the same transaction. itemID is uuid.Nil for inserts, where write returns
+// the ID of the new item. The stock watcher learns about the item after the commit.
+func (s *Service) audited(
+	ctx context.Context,
+	userID, itemID uuid.UUID,
+	action model.ItemAction,
+	write func(ctx context.Context) (uuid.UUID, error),
+) error {
+	if s.watcher != nil {
+		unwatched := write
+		write = func(ctx context.Context) (uuid.UUID, error) {
+			id, err := unwatched(ctx)
+			if err == nil {
+				s.uow.AfterCommit(ctx, func() { s.watcher.StockChanged(id) })
+			}
+
+			return id, err
+		}
+	}
+
+	return s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		if s.auditWriter == nil {
+			_, err := write(ctx)
+			return err
+		}
+
+		var oldData, newData json.RawMessage
+		var err error
+
+		if action != model.ActionInsert {
+			if oldData, err = s.repository.SnapshotItem(ctx, itemID); err != nil {
+				return err
+			}
+		}
+
+		if itemID, err = write(ctx); err != nil {
+			return err
+		}
+
+		if action != model.ActionDelete {
+			if newData, err = s.repository.SnapshotItem(ctx, itemID); err != nil {
+				return err
+			}
+		}
+
+		actor, _ := audit.ActorFromContext(ctx)
+		actor.UserID = userID
+
+		return s.auditWriter.Write(ctx, audit.Entry{
+			ItemID:  itemID,
+			Action:  action,
+			Actor:   actor,
+			OldData: oldData,
+			NewData: newData,
+		})
+	})
+}
+
 // GetHistory retrieves the change history for a given item.
 func (s *Service) GetHistory(ctx context.Context, itemID uuid.UUID) ([]*model.ItemHistory, error) {
 	history, err := s.repository.GetItemHistory(ctx, itemID)
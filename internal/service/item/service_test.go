@@ -0,0 +1,293 @@
+package item
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/warehouse-control/internal/audit"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+// openTestDB connects to the migrated database given by TEST_DATABASE_DSN,
+// skipping the test if it is not set.
+func openTestDB(t *testing.T) *dbpg.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := dbpg.New(dsn, nil, nil)
+	if err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+
+	t.Cleanup(func() { _ = db.Master.Close() })
+
+	return db
+}
+
+// createTestUser inserts a user and removes it together with its history when the test ends.
+func createTestUser(t *testing.T, db *dbpg.DB) uuid.UUID {
+	t.Helper()
+
+	ctx := context.Background()
+
+	var id uuid.UUID
+	err := db.Master.QueryRowContext(ctx, `
+		INSERT INTO users (username, password_hash, role)
+		VALUES ($1, 'x', 'manager')
+		RETURNING id
+	`, fmt.Sprintf("audit-test-%s", uuid.NewString())).Scan(&id)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_, _ = db.Master.ExecContext(ctx, `DELETE FROM item_history WHERE changed_by = $1`, id)
+		_, _ = db.Master.ExecContext(ctx, `DELETE FROM stock_movements WHERE created_by = $1`, id)
+		_, _ = db.Master.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	})
+
+	return id
+}
+
+// runAuditScenario creates, updates and moves an item, then creates and deletes one without stock,
+// in the given audit mode and returns their history, oldest first.
+func runAuditScenario(t *testing.T, db *dbpg.DB, userID uuid.UUID, mode string) []*model.ItemHistory {
+	t.Helper()
+
+	repo := repoitem.NewRepository(db)
+
+	var w audit.AuditWriter
+	if mode == audit.ModeApp {
+		w = audit.NewWriter(repo)
+	}
+
+	s := NewService(repo, repoitem.NewUnitOfWork(db, mode), w, nil, 0, nil)
+
+	ctx := audit.WithActor(context.Background(), audit.Actor{
+		UserID:    userID,
+		Role:      "manager",
+		RequestID: "request-1",
+		ClientIP:  "10.0.0.1",
+	})
+
+	item := &model.Item{
+		Name:        "audit-test",
+		Barcodes:    []string{"4006381333931"},
+		Description: "first",
+		Quantity:    3,
+		CostPrice:   decimal.RequireFromString("5.49"),
+		ListPrice:   decimal.RequireFromString("9.99"),
+	}
+
+	itemID, err := s.Create(ctx, userID, item)
+	if err != nil {
+		t.Fatalf("%s: create: %v", mode, err)
+	}
+
+	t.Cleanup(func() {
+		_, _ = db.Master.ExecContext(context.Background(), `DELETE FROM stock_movements WHERE item_id = $1`, itemID)
+		_, _ = db.Master.ExecContext(context.Background(), `DELETE FROM items WHERE id = $1`, itemID)
+	})
+
+	item.Description = "second"
+	item.Quantity = 5
+	item.ReasonCode = "found"
+	item.Barcodes = append(item.Barcodes, "036000291452")
+
+	version, err := s.Update(ctx, userID, item)
+	if err != nil {
+		t.Fatalf("%s: update: %v", mode, err)
+	}
+
+	if _, err := s.Issue(ctx, userID, itemID, model.StockPlace{}, 2, "sale", ""); err != nil {
+		t.Fatalf("%s: issue: %v", mode, err)
+	}
+
+	// The movements keep the item from being deleted.
+	if err := s.Delete(ctx, userID, itemID, version+1); !errors.Is(err, repoitem.ErrItemReferenced) {
+		t.Fatalf("%s: delete with movements: got error %v, want %v", mode, err, repoitem.ErrItemReferenced)
+	}
+
+	empty := &model.Item{Name: "audit-test-empty", CostPrice: decimal.NewFromInt(1), ListPrice: decimal.NewFromInt(2)}
+
+	emptyID, err := s.Create(ctx, userID, empty)
+	if err != nil {
+		t.Fatalf("%s: create without stock: %v", mode, err)
+	}
+
+	if err := s.Delete(ctx, userID, emptyID, empty.Version); err != nil {
+		t.Fatalf("%s: delete: %v", mode, err)
+	}
+
+	var history []*model.ItemHistory
+	for _, id := range []uuid.UUID{itemID, emptyID} {
+		rows, err := s.GetHistory(context.Background(), id)
+		if err != nil {
+			t.Fatalf("%s: get history: %v", mode, err)
+		}
+
+		// GetHistory returns newest first.
+		for i := len(rows) - 1; i >= 0; i-- {
+			history = append(history, rows[i])
+		}
+	}
+
+	return history
+}
+
+// stableFields strips the values that necessarily differ between two runs
+// (row IDs, item IDs and timestamps) from a history row.
+func stableFields(t *testing.T, h *model.ItemHistory) map[string]interface{} {
+	t.Helper()
+
+	volatile := []string{"id", "created_at", "updated_at"}
+
+	decode := func(data json.RawMessage) map[string]interface{} {
+		m := make(map[string]interface{})
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatalf("decode %s: %v", data, err)
+			}
+		}
+
+		for _, key := range volatile {
+			delete(m, key)
+		}
+
+		return m
+	}
+
+	return map[string]interface{}{
+		"action":     h.Action,
+		"changed_by": h.ChangedBy,
+		"actor_role": h.ActorRole,
+		"request_id": h.RequestID,
+		"client_ip":  h.ClientIP,
+		"warehouse":  h.WarehouseID,
+		"reason":     h.Reason,
+		"code":       h.ReasonCode,
+		"old_data":   decode(h.OldData),
+		"new_data":   decode(h.NewData),
+		"diff":       decode(h.Diff),
+	}
+}
+
+func TestAuditModesProduceIdenticalHistory(t *testing.T) {
+	db := openTestDB(t)
+	userID := createTestUser(t, db)
+
+	triggerHistory := runAuditScenario(t, db, userID, audit.ModeTrigger)
+	appHistory := runAuditScenario(t, db, userID, audit.ModeApp)
+
+	if len(triggerHistory) != 5 {
+		t.Fatalf("trigger mode: got %d history rows, want 5", len(triggerHistory))
+	}
+
+	if len(appHistory) != len(triggerHistory) {
+		t.Fatalf("app mode: got %d history rows, want %d", len(appHistory), len(triggerHistory))
+	}
+
+	for i := range triggerHistory {
+		want := stableFields(t, triggerHistory[i])
+		got := stableFields(t, appHistory[i])
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("row %d differs:\napp:     %v\ntrigger: %v", i, got, want)
+		}
+	}
+}
+
+func TestAdjustmentsRequireReasonCode(t *testing.T) {
+	s := NewService(nil, nil, nil, nil, 0, nil)
+	ctx := context.Background()
+	userID, itemID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		adjust func() error
+	}{
+		{"adjust", func() error {
+			_, err := s.Adjust(ctx, userID, itemID, model.StockPlace{}, -1, "", "broken", "")
+			return err
+		}},
+		{"increment", func() error {
+			_, err := s.Increment(ctx, userID, itemID, model.StockPlace{}, 1, "", "")
+			return err
+		}},
+		{"decrement", func() error {
+			_, err := s.Decrement(ctx, userID, itemID, model.StockPlace{}, 1, "", "")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.adjust(); !errors.Is(err, repoitem.ErrReasonCodeRequired) {
+				t.Fatalf("got %v, want ErrReasonCodeRequired", err)
+			}
+		})
+	}
+}
+
+func TestPriceChangesBeyondTheLimitRequireAdmin(t *testing.T) {
+	s := NewService(nil, nil, nil, nil, 20, nil)
+	current := &model.Item{CostPrice: decimal.NewFromInt(50), ListPrice: decimal.NewFromInt(100)}
+
+	tests := []struct {
+		name      string
+		costPrice string
+		listPrice string
+		admin     bool
+		denied    bool
+	}{
+		{"unchanged", "50", "100", false, false},
+		{"at the limit", "40", "120", false, false},
+		{"list price above the limit", "50", "120.01", false, true},
+		{"cost price below the limit", "39.99", "100", false, true},
+		{"admin", "10", "500", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.admin {
+				ctx = WithLargePriceChanges(ctx)
+			}
+
+			updated := &model.Item{
+				CostPrice: decimal.RequireFromString(tt.costPrice),
+				ListPrice: decimal.RequireFromString(tt.listPrice),
+			}
+
+			err := s.checkPriceChange(ctx, current, updated)
+			if got := errors.Is(err, ErrPriceChangeDenied); got != tt.denied {
+				t.Fatalf("denied = %v (%v), want %v", got, err, tt.denied)
+			}
+		})
+	}
+
+	if err := s.checkPriceChange(context.Background(), &model.Item{}, current); err != nil {
+		t.Fatalf("setting a zero price: %v", err)
+	}
+
+	inEUR := *current
+	inEUR.Currency = "EUR"
+
+	if err := s.checkPriceChange(context.Background(), current, &inEUR); !errors.Is(err, ErrPriceChangeDenied) {
+		t.Fatalf("changing the currency: %v, want ErrPriceChangeDenied", err)
+	}
+}
@@ -0,0 +1,173 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrInvalidKind   = errors.New("invalid location kind")
+	ErrInvalidParent = errors.New("invalid parent location")
+)
+
+// repository defines the interface for location data access.
+type repository interface {
+	// CreateLocation adds a new location and returns its ID.
+	CreateLocation(ctx context.Context, l *model.Location) (uuid.UUID, error)
+
+	// GetLocationByID retrieves a location by its ID.
+	GetLocationByID(ctx context.Context, locationID uuid.UUID) (*model.Location, error)
+
+	// GetLocations retrieves all locations of a warehouse.
+	GetLocations(ctx context.Context, warehouseID uuid.UUID) ([]*model.Location, error)
+
+	// UpdateLocation updates the code and name of a location.
+	UpdateLocation(ctx context.Context, l *model.Location) error
+
+	// DeleteLocation deletes a location without child locations or stock.
+	DeleteLocation(ctx context.Context, locationID uuid.UUID) error
+
+	// GetLocationStock retrieves the quantity of each item held in the bins under a location.
+	GetLocationStock(ctx context.Context, locationID uuid.UUID) ([]*model.LocationStock, error)
+}
+
+// Service provides business logic for storage locations.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new location service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create adds a new location to a warehouse. Zones have no parent; aisles, racks and bins
+// must be placed in a zone, aisle and rack of the same warehouse respectively.
+// Codes are stored in upper case.
+func (s *Service) Create(ctx context.Context, l *model.Location) (*model.Location, error) {
+	parentKind := l.Kind.ParentKind()
+	if parentKind == "" && l.Kind != model.LocationZone {
+		return nil, ErrInvalidKind
+	}
+
+	if parentKind == "" {
+		if l.ParentID != nil {
+			return nil, fmt.Errorf("%w: zones have no parent", ErrInvalidParent)
+		}
+	} else {
+		if l.ParentID == nil {
+			return nil, fmt.Errorf("%w: a %s must be placed in a %s", ErrInvalidParent, l.Kind, parentKind)
+		}
+
+		parent, err := s.repository.GetLocationByID(ctx, *l.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("get parent location: %w", err)
+		}
+
+		if parent.WarehouseID != l.WarehouseID || parent.Kind != parentKind {
+			return nil, fmt.Errorf("%w: a %s must be placed in a %s of the same warehouse", ErrInvalidParent, l.Kind, parentKind)
+		}
+	}
+
+	l.Code = normalizeCode(l.Code)
+
+	if _, err := s.repository.CreateLocation(ctx, l); err != nil {
+		return nil, fmt.Errorf("create location: %w", err)
+	}
+
+	return l, nil
+}
+
+// GetByID retrieves a location by its ID.
+func (s *Service) GetByID(ctx context.Context, locationID uuid.UUID) (*model.Location, error) {
+	l, err := s.repository.GetLocationByID(ctx, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("get location by id: %w", err)
+	}
+
+	return l, nil
+}
+
+// GetTree retrieves the locations of a warehouse as a tree: its zones, each with its
+// aisles as children, and so on down to the bins.
+func (s *Service) GetTree(ctx context.Context, warehouseID uuid.UUID) ([]*model.Location, error) {
+	locations, err := s.repository.GetLocations(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("get locations: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]*model.Location, len(locations))
+	for _, l := range locations {
+		byID[l.ID] = l
+	}
+
+	roots := []*model.Location{}
+	for _, l := range locations {
+		if parent, ok := byID[derefID(l.ParentID)]; ok {
+			parent.Children = append(parent.Children, l)
+			continue
+		}
+
+		roots = append(roots, l)
+	}
+
+	return roots, nil
+}
+
+// Update changes the code and name of a location. Its kind and place in the tree are fixed.
+func (s *Service) Update(ctx context.Context, locationID uuid.UUID, code, name string) (*model.Location, error) {
+	l := &model.Location{
+		ID:   locationID,
+		Code: normalizeCode(code),
+		Name: name,
+	}
+
+	if err := s.repository.UpdateLocation(ctx, l); err != nil {
+		return nil, fmt.Errorf("update location: %w", err)
+	}
+
+	return l, nil
+}
+
+// Delete removes a location. Only locations without child locations and stock can be removed.
+func (s *Service) Delete(ctx context.Context, locationID uuid.UUID) error {
+	if err := s.repository.DeleteLocation(ctx, locationID); err != nil {
+		return fmt.Errorf("delete location: %w", err)
+	}
+
+	return nil
+}
+
+// GetItems retrieves the quantity of each item held in the bins under a location.
+func (s *Service) GetItems(ctx context.Context, locationID uuid.UUID) ([]*model.LocationStock, error) {
+	if _, err := s.repository.GetLocationByID(ctx, locationID); err != nil {
+		return nil, fmt.Errorf("get location by id: %w", err)
+	}
+
+	stock, err := s.repository.GetLocationStock(ctx, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("get location stock: %w", err)
+	}
+
+	return stock, nil
+}
+
+// normalizeCode trims a location code and converts it to upper case.
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// derefID returns the ID id points to, or uuid.Nil if it is nil.
+func derefID(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+
+	return *id
+}
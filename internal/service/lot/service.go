@@ -0,0 +1,148 @@
+package lot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrNumberRequired  = errors.New("lot number is required")
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+)
+
+// repository defines the interface for lot data access.
+type repository interface {
+	// GetItemByID retrieves an item by its ID.
+	GetItemByID(ctx context.Context, itemID uuid.UUID) (*model.Item, error)
+
+	// CreateLot adds a new lot of an item.
+	CreateLot(ctx context.Context, l *model.Lot) error
+
+	// GetLots retrieves the lots of an item with their stock per warehouse.
+	GetLots(ctx context.Context, itemID uuid.UUID) ([]*model.Lot, error)
+
+	// GetPickStock retrieves the lots of an item in a warehouse in first expired first out
+	// order, the quantity in no lot and the warehouse used.
+	GetPickStock(ctx context.Context, itemID, warehouseID uuid.UUID) (uuid.UUID, []*model.LotStock, int, error)
+}
+
+// Service provides business logic for lots.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new lot service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create adds a new lot of an item. Only the date of expiresOn is kept.
+func (s *Service) Create(ctx context.Context, itemID uuid.UUID, number string, expiresOn *time.Time) (*model.Lot, error) {
+	l := &model.Lot{
+		ItemID: itemID,
+		Number: strings.TrimSpace(number),
+	}
+
+	if l.Number == "" {
+		return nil, ErrNumberRequired
+	}
+
+	if expiresOn != nil {
+		day := time.Date(expiresOn.Year(), expiresOn.Month(), expiresOn.Day(), 0, 0, 0, 0, time.UTC)
+		l.ExpiresOn = &day
+	}
+
+	if err := s.repository.CreateLot(ctx, l); err != nil {
+		return nil, fmt.Errorf("create lot: %w", err)
+	}
+
+	return l, nil
+}
+
+// GetByItem retrieves the lots of an item with their stock per warehouse.
+func (s *Service) GetByItem(ctx context.Context, itemID uuid.UUID) ([]*model.Lot, error) {
+	if _, err := s.repository.GetItemByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("get item by id: %w", err)
+	}
+
+	lots, err := s.repository.GetLots(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get lots: %w", err)
+	}
+
+	return lots, nil
+}
+
+// SuggestPick proposes the lots to take quantity units of an item from in a warehouse,
+// uuid.Nil for the default one, first expired first out. Expired lots are skipped.
+func (s *Service) SuggestPick(ctx context.Context, itemID, warehouseID uuid.UUID, quantity int) (*model.PickSuggestion, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	if _, err := s.repository.GetItemByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("get item by id: %w", err)
+	}
+
+	warehouseID, lots, unlotted, err := s.repository.GetPickStock(ctx, itemID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("get pick stock: %w", err)
+	}
+
+	suggestion := allocate(lots, unlotted, quantity)
+	suggestion.ItemID = itemID
+	suggestion.WarehouseID = warehouseID
+
+	return suggestion, nil
+}
+
+// allocate spreads quantity over lots, given first expired first, skipping expired lots,
+// and then over the stock in no lot.
+func allocate(lots []*model.LotStock, unlotted, quantity int) *model.PickSuggestion {
+	suggestion := &model.PickSuggestion{
+		Requested: quantity,
+		Lines:     []*model.PickLine{},
+	}
+
+	remaining := quantity
+
+	for _, l := range lots {
+		if remaining == 0 {
+			break
+		}
+
+		if l.Expired || l.Quantity <= 0 {
+			continue
+		}
+
+		take := min(l.Quantity, remaining)
+		lotID := l.LotID
+
+		suggestion.Lines = append(suggestion.Lines, &model.PickLine{
+			LotID:     &lotID,
+			LotNumber: l.LotNumber,
+			ExpiresOn: l.ExpiresOn,
+			Quantity:  take,
+		})
+
+		remaining -= take
+	}
+
+	if remaining > 0 && unlotted > 0 {
+		take := min(unlotted, remaining)
+		suggestion.Lines = append(suggestion.Lines, &model.PickLine{Quantity: take})
+		remaining -= take
+	}
+
+	suggestion.Allocated = quantity - remaining
+	suggestion.Shortfall = remaining
+
+	return suggestion
+}
@@ -0,0 +1,92 @@
+package lot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+func TestAllocate(t *testing.T) {
+	soon := time.Date(2025, time.November, 1, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	expired := &model.LotStock{LotID: uuid.New(), LotNumber: "OLD", Quantity: 50, Expired: true}
+	first := &model.LotStock{LotID: uuid.New(), LotNumber: "A", ExpiresOn: &soon, Quantity: 4}
+	second := &model.LotStock{LotID: uuid.New(), LotNumber: "B", ExpiresOn: &later, Quantity: 10}
+
+	type line struct {
+		lot      string
+		quantity int
+	}
+
+	tests := []struct {
+		name          string
+		lots          []*model.LotStock
+		unlotted      int
+		quantity      int
+		want          []line
+		wantShortfall int
+	}{
+		{
+			name:     "first expiring lot covers everything",
+			lots:     []*model.LotStock{first, second},
+			quantity: 3,
+			want:     []line{{"A", 3}},
+		},
+		{
+			name:     "spills over to the next lot",
+			lots:     []*model.LotStock{first, second},
+			quantity: 9,
+			want:     []line{{"A", 4}, {"B", 5}},
+		},
+		{
+			name:     "expired lots are skipped",
+			lots:     []*model.LotStock{expired, first},
+			quantity: 4,
+			want:     []line{{"A", 4}},
+		},
+		{
+			name:     "stock in no lot comes last",
+			lots:     []*model.LotStock{first},
+			unlotted: 5,
+			quantity: 6,
+			want:     []line{{"A", 4}, {"", 2}},
+		},
+		{
+			name:          "not enough usable stock",
+			lots:          []*model.LotStock{expired, first, second},
+			quantity:      20,
+			want:          []line{{"A", 4}, {"B", 10}},
+			wantShortfall: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocate(tt.lots, tt.unlotted, tt.quantity)
+
+			var lines []line
+			for _, l := range got.Lines {
+				lines = append(lines, line{l.LotNumber, l.Quantity})
+			}
+
+			if len(lines) != len(tt.want) {
+				t.Fatalf("lines = %v, want %v", lines, tt.want)
+			}
+
+			for i := range lines {
+				if lines[i] != tt.want[i] {
+					t.Errorf("lines = %v, want %v", lines, tt.want)
+					break
+				}
+			}
+
+			if got.Shortfall != tt.wantShortfall || got.Allocated != tt.quantity-tt.wantShortfall {
+				t.Errorf("allocated %d, shortfall %d; want shortfall %d", got.Allocated, got.Shortfall, tt.wantShortfall)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrNoLines           = errors.New("purchase order must have at least one line")
+	ErrInvalidQuantity   = errors.New("purchase quantity must be positive")
+	ErrDuplicateItem     = errors.New("purchase order lists an item more than once")
+	ErrNegativeCost      = errors.New("unit cost cannot be negative")
+	ErrItemNotOrdered    = errors.New("item is not on the purchase order")
+	ErrOverReceipt       = errors.New("received quantity exceeds the quantity outstanding")
+	ErrInvalidTransition = errors.New("purchase order cannot change to this status")
+)
+
+// ReasonPurchaseReceipt is the reason recorded on the stock movements of purchase order deliveries.
+const ReasonPurchaseReceipt = "purchase_receipt"
+
+// repository defines the interface for purchase order data access.
+type repository interface {
+	// CreatePurchaseOrder adds a draft purchase order with its lines.
+	CreatePurchaseOrder(ctx context.Context, o *model.PurchaseOrder) error
+
+	// GetPurchaseOrder retrieves a purchase order with its lines.
+	GetPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error)
+
+	// LockPurchaseOrder retrieves a purchase order and locks it for the rest of the transaction.
+	LockPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error)
+
+	// GetPurchaseOrders retrieves the purchase orders matching filter.
+	GetPurchaseOrders(ctx context.Context, filter model.PurchaseFilter) ([]*model.PurchaseOrder, error)
+
+	// AddPurchaseReceived adds quantity to the received quantity of an item's line of an order.
+	AddPurchaseReceived(ctx context.Context, orderID, itemID uuid.UUID, quantity int) error
+
+	// SetPurchaseStatus moves a purchase order to a new status.
+	SetPurchaseStatus(ctx context.Context, o *model.PurchaseOrder, to model.PurchaseStatus) error
+}
+
+// unitOfWork runs a group of repository calls in one transaction attributed to a user.
+type unitOfWork interface {
+	// Do runs fn in a transaction; repository calls must use the context passed to fn.
+	Do(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) error
+}
+
+// stock moves item stock; it is implemented by the item service.
+type stock interface {
+	// Receive adds quantity units of stock to an item at a place.
+	Receive(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error)
+}
+
+// Service provides business logic for purchase orders.
+type Service struct {
+	repository repository
+	uow        unitOfWork
+	stock      stock
+}
+
+// NewService creates a new purchase order service.
+func NewService(r repository, uow unitOfWork, s stock) *Service {
+	return &Service{
+		repository: r,
+		uow:        uow,
+		stock:      s,
+	}
+}
+
+// Create adds a draft purchase order. Lines without a unit cost take the supplier's cost.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, o *model.PurchaseOrder) (*model.PurchaseOrder, error) {
+	if len(o.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+
+	seen := make(map[uuid.UUID]bool, len(o.Lines))
+	for _, line := range o.Lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		if line.UnitCost != nil && line.UnitCost.IsNegative() {
+			return nil, ErrNegativeCost
+		}
+
+		if seen[line.ItemID] {
+			return nil, ErrDuplicateItem
+		}
+
+		seen[line.ItemID] = true
+	}
+
+	o.Location = strings.ToUpper(strings.TrimSpace(o.Location))
+	o.CreatedBy = &userID
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		return s.repository.CreatePurchaseOrder(ctx, o)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create purchase order: %w", err)
+	}
+
+	return o, nil
+}
+
+// GetByID retrieves a purchase order by its ID.
+func (s *Service) GetByID(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	o, err := s.repository.GetPurchaseOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get purchase order: %w", err)
+	}
+
+	return o, nil
+}
+
+// GetAll retrieves the purchase orders matching filter.
+func (s *Service) GetAll(ctx context.Context, filter model.PurchaseFilter) ([]*model.PurchaseOrder, error) {
+	orders, err := s.repository.GetPurchaseOrders(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get purchase orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// Submit places a draft order with its supplier.
+func (s *Service) Submit(ctx context.Context, userID, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	return s.transition(ctx, userID, orderID, model.PurchaseOrdered)
+}
+
+// Cancel cancels an order that has not been received in full. Stock already received stays.
+func (s *Service) Cancel(ctx context.Context, userID, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	return s.transition(ctx, userID, orderID, model.PurchaseCancelled)
+}
+
+// transition moves an order to status to in a transaction that locks the order first.
+func (s *Service) transition(ctx context.Context, userID, orderID uuid.UUID, to model.PurchaseStatus) (*model.PurchaseOrder, error) {
+	var order *model.PurchaseOrder
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		o, err := s.repository.LockPurchaseOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if !canTransition(o.Status, to) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, to)
+		}
+
+		if err := s.repository.SetPurchaseStatus(ctx, o, to); err != nil {
+			return err
+		}
+
+		order = o
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("change purchase order to %s: %w", to, err)
+	}
+
+	return order, nil
+}
+
+// Receive records a delivery against an ordered purchase order. Each delivered item is
+// received into the order's warehouse and bin at its line's unit cost with a receipt movement
+// that references the order, and its line's received quantity grows by the quantity
+// delivered. An empty delivery receives everything outstanding. The order becomes received
+// once nothing is outstanding and partially received until then.
+func (s *Service) Receive(ctx context.Context, userID, orderID uuid.UUID, delivery []model.PurchaseDelivery) (*model.PurchaseOrder, error) {
+	var order *model.PurchaseOrder
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		o, err := s.repository.LockPurchaseOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if o.Status != model.PurchaseOrdered && o.Status != model.PurchasePartiallyReceived {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, model.PurchaseReceived)
+		}
+
+		if len(delivery) == 0 {
+			delivery = outstanding(o)
+		}
+
+		seen := make(map[uuid.UUID]bool, len(delivery))
+		reference := o.ID.String()
+
+		for _, d := range delivery {
+			line := o.Line(d.ItemID)
+			switch {
+			case line == nil:
+				return fmt.Errorf("item %s: %w", d.ItemID, ErrItemNotOrdered)
+			case seen[d.ItemID]:
+				return fmt.Errorf("item %s: %w", d.ItemID, ErrDuplicateItem)
+			case d.Quantity <= 0:
+				return fmt.Errorf("item %s: %w", d.ItemID, ErrInvalidQuantity)
+			case d.Quantity > line.Outstanding():
+				return fmt.Errorf("item %s: %w", d.ItemID, ErrOverReceipt)
+			}
+
+			seen[d.ItemID] = true
+
+			place := o.Destination()
+			place.Lot = d.Lot
+			place.Serials = d.Serials
+			place.UnitCost = line.UnitCost
+
+			if _, err := s.stock.Receive(ctx, userID, d.ItemID, place, d.Quantity, ReasonPurchaseReceipt, reference); err != nil {
+				return fmt.Errorf("item %s: %w", d.ItemID, err)
+			}
+
+			if err := s.repository.AddPurchaseReceived(ctx, o.ID, d.ItemID, d.Quantity); err != nil {
+				return err
+			}
+
+			line.ReceivedQuantity += d.Quantity
+		}
+
+		if err := s.repository.SetPurchaseStatus(ctx, o, o.ReceivingStatus()); err != nil {
+			return err
+		}
+
+		order = o
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("receive purchase order: %w", err)
+	}
+
+	return order, nil
+}
+
+// outstanding returns a delivery of everything on order o that has not been received yet.
+func outstanding(o *model.PurchaseOrder) []model.PurchaseDelivery {
+	var delivery []model.PurchaseDelivery
+	for _, line := range o.Lines {
+		if line.Outstanding() > 0 {
+			delivery = append(delivery, model.PurchaseDelivery{ItemID: line.ItemID, Quantity: line.Outstanding()})
+		}
+	}
+
+	return delivery
+}
+
+// canTransition reports whether an order in status from may be submitted or cancelled.
+// Drafts are submitted; orders are cancelled until they are received in full.
+func canTransition(from, to model.PurchaseStatus) bool {
+	switch to {
+	case model.PurchaseOrdered:
+		return from == model.PurchaseDraft
+	case model.PurchaseCancelled:
+		return from == model.PurchaseDraft || from == model.PurchaseOrdered || from == model.PurchasePartiallyReceived
+	}
+
+	return false
+}
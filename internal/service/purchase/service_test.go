@@ -0,0 +1,148 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// fakeRepository keeps purchase orders in memory.
+type fakeRepository struct {
+	orders map[uuid.UUID]*model.PurchaseOrder
+}
+
+func (r *fakeRepository) CreatePurchaseOrder(_ context.Context, o *model.PurchaseOrder) error {
+	o.ID = uuid.New()
+	o.Status = model.PurchaseDraft
+	r.orders[o.ID] = o
+	return nil
+}
+
+func (r *fakeRepository) GetPurchaseOrder(_ context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	o, ok := r.orders[orderID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	copied := *o
+	copied.Lines = nil
+	for _, line := range o.Lines {
+		l := *line
+		copied.Lines = append(copied.Lines, &l)
+	}
+
+	return &copied, nil
+}
+
+func (r *fakeRepository) LockPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	return r.GetPurchaseOrder(ctx, orderID)
+}
+
+func (r *fakeRepository) GetPurchaseOrders(context.Context, model.PurchaseFilter) ([]*model.PurchaseOrder, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) AddPurchaseReceived(_ context.Context, orderID, itemID uuid.UUID, quantity int) error {
+	r.orders[orderID].Line(itemID).ReceivedQuantity += quantity
+	return nil
+}
+
+func (r *fakeRepository) SetPurchaseStatus(_ context.Context, o *model.PurchaseOrder, to model.PurchaseStatus) error {
+	o.Status = to
+	r.orders[o.ID].Status = to
+	return nil
+}
+
+// fakeUnitOfWork runs fn without a transaction.
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeStock sums the quantities received per item and remembers their references.
+type fakeStock struct {
+	received   map[uuid.UUID]int
+	references []string
+}
+
+func (s *fakeStock) Receive(
+	_ context.Context, _, itemID uuid.UUID, _ model.StockPlace, quantity int, _, reference string,
+) (*model.StockMovement, error) {
+	s.received[itemID] += quantity
+	s.references = append(s.references, reference)
+	return &model.StockMovement{}, nil
+}
+
+func TestReceive(t *testing.T) {
+	ctx := context.Background()
+	bolts, nuts := uuid.New(), uuid.New()
+
+	repo := &fakeRepository{orders: map[uuid.UUID]*model.PurchaseOrder{}}
+	stock := &fakeStock{received: map[uuid.UUID]int{}}
+	s := NewService(repo, fakeUnitOfWork{}, stock)
+
+	o, err := s.Create(ctx, uuid.New(), &model.PurchaseOrder{
+		Lines: []*model.PurchaseLine{{ItemID: bolts, Quantity: 10}, {ItemID: nuts, Quantity: 4}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := s.Receive(ctx, uuid.New(), o.ID, nil); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Receive draft error = %v, want %v", err, ErrInvalidTransition)
+	}
+
+	if _, err := s.Submit(ctx, uuid.New(), o.ID); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	got, err := s.Receive(ctx, uuid.New(), o.ID, []model.PurchaseDelivery{{ItemID: bolts, Quantity: 6}})
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+
+	if got.Status != model.PurchasePartiallyReceived {
+		t.Errorf("status = %s, want %s", got.Status, model.PurchasePartiallyReceived)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		delivery model.PurchaseDelivery
+		want     error
+	}{
+		{name: "more than outstanding", delivery: model.PurchaseDelivery{ItemID: bolts, Quantity: 5}, want: ErrOverReceipt},
+		{name: "item not ordered", delivery: model.PurchaseDelivery{ItemID: uuid.New(), Quantity: 1}, want: ErrItemNotOrdered},
+		{name: "zero quantity", delivery: model.PurchaseDelivery{ItemID: nuts}, want: ErrInvalidQuantity},
+	} {
+		if _, err := s.Receive(ctx, uuid.New(), o.ID, []model.PurchaseDelivery{tt.delivery}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if got, err = s.Receive(ctx, uuid.New(), o.ID, nil); err != nil {
+		t.Fatalf("Receive rest: %v", err)
+	}
+
+	if got.Status != model.PurchaseReceived {
+		t.Errorf("status = %s, want %s", got.Status, model.PurchaseReceived)
+	}
+
+	if stock.received[bolts] != 10 || stock.received[nuts] != 4 {
+		t.Errorf("received = %v, want 10 bolts and 4 nuts", stock.received)
+	}
+
+	for _, reference := range stock.references {
+		if reference != o.ID.String() {
+			t.Errorf("reference = %q, want the order ID %s", reference, o.ID)
+		}
+	}
+
+	if _, err := s.Cancel(ctx, uuid.New(), o.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Cancel received error = %v, want %v", err, ErrInvalidTransition)
+	}
+}
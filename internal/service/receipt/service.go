@@ -0,0 +1,469 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+var (
+	ErrNoSource           = errors.New("goods receipt must name a purchase order or an advance shipping notice")
+	ErrNoLines            = errors.New("goods receipt must have at least one line")
+	ErrDuplicateItem      = errors.New("goods receipt lists an item more than once")
+	ErrInvalidQuantity    = errors.New("received, damaged and expected quantities must not be negative and damaged units must have been received")
+	ErrExpectedRequired   = errors.New("expected quantity is required without a purchase order")
+	ErrPurchaseClosed     = errors.New("purchase order is not open for receiving")
+	ErrNotPosted          = errors.New("only posted goods receipts can be reversed")
+	ErrReversalReversed   = errors.New("a reversal cannot be reversed")
+	ErrSerializedReversal = errors.New("receipts of serial numbers cannot be reversed; adjust the units instead")
+	ErrResolutionRequired = errors.New("resolution is required")
+	ErrEmptyReceiptLine   = errors.New("goods receipt line expects and receives nothing")
+)
+
+// Reasons recorded on the stock movements of goods receipts.
+const (
+	ReasonGoodsReceipt    = "goods_receipt"
+	ReasonReceiptReversal = "receipt_reversal"
+)
+
+// repository defines the interface for goods receipt data access.
+type repository interface {
+	// CreateGoodsReceipt adds a draft goods receipt with its lines.
+	CreateGoodsReceipt(ctx context.Context, g *model.GoodsReceipt) error
+
+	// GetGoodsReceipt retrieves a goods receipt with its lines and discrepancies.
+	GetGoodsReceipt(ctx context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error)
+
+	// LockGoodsReceipt retrieves a goods receipt and locks it for the rest of the transaction.
+	LockGoodsReceipt(ctx context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error)
+
+	// GetGoodsReceipts retrieves the goods receipts matching filter.
+	GetGoodsReceipts(ctx context.Context, filter model.ReceiptFilter) ([]*model.GoodsReceipt, error)
+
+	// SetReceiptPurchaseQuantity records how much of a line of a draft receipt is credited to its purchase order.
+	SetReceiptPurchaseQuantity(ctx context.Context, receiptID, itemID uuid.UUID, quantity int) error
+
+	// PostGoodsReceipt marks a draft receipt as posted.
+	PostGoodsReceipt(ctx context.Context, g *model.GoodsReceipt) error
+
+	// DeleteGoodsReceipt deletes a draft goods receipt.
+	DeleteGoodsReceipt(ctx context.Context, receiptID uuid.UUID) error
+
+	// InsertDiscrepancy records a discrepancy found by a receipt.
+	InsertDiscrepancy(ctx context.Context, d *model.Discrepancy) error
+
+	// GetDiscrepancies retrieves the discrepancies matching filter.
+	GetDiscrepancies(ctx context.Context, filter model.DiscrepancyFilter) ([]*model.Discrepancy, error)
+
+	// ResolveDiscrepancy marks an open discrepancy as resolved.
+	ResolveDiscrepancy(ctx context.Context, discrepancyID uuid.UUID, resolution string) (*model.Discrepancy, error)
+
+	// ResolveReceiptDiscrepancies resolves the open discrepancies of a receipt.
+	ResolveReceiptDiscrepancies(ctx context.Context, receiptID uuid.UUID, resolution string) error
+
+	// GetPurchaseOrder retrieves a purchase order with its lines.
+	GetPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error)
+
+	// LockPurchaseOrder retrieves a purchase order and locks it for the rest of the transaction.
+	LockPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error)
+
+	// AddPurchaseReceived adds quantity to the received quantity of an item's line of an order.
+	AddPurchaseReceived(ctx context.Context, orderID, itemID uuid.UUID, quantity int) error
+
+	// SetPurchaseStatus moves a purchase order to a new status.
+	SetPurchaseStatus(ctx context.Context, o *model.PurchaseOrder, to model.PurchaseStatus) error
+}
+
+// unitOfWork runs a group of repository calls in one transaction attributed to a user.
+type unitOfWork interface {
+	// Do runs fn in a transaction; repository calls must use the context passed to fn.
+	Do(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) error
+}
+
+// stock moves item stock; it is implemented by the item service.
+type stock interface {
+	// Receive adds quantity units of stock to an item at a place.
+	Receive(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error)
+
+	// ReverseReceipt takes back stock that a receipt added by mistake.
+	ReverseReceipt(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error)
+}
+
+// Service provides business logic for goods receipts and their discrepancies.
+type Service struct {
+	repository repository
+	uow        unitOfWork
+	stock      stock
+}
+
+// NewService creates a new goods receipt service.
+func NewService(r repository, uow unitOfWork, s stock) *Service {
+	return &Service{
+		repository: r,
+		uow:        uow,
+		stock:      s,
+	}
+}
+
+// Create adds a draft goods receipt against a purchase order or an advance shipping notice.
+// Against a purchase order the receipt goes to the order's warehouse and bin unless it names
+// its own, and lines expect the quantity outstanding on the order unless they say otherwise;
+// items that are not on the order are expected zero times. Nothing changes stock until the
+// receipt is posted.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, g *model.GoodsReceipt) (*model.GoodsReceipt, error) {
+	g.ASNNumber = strings.TrimSpace(g.ASNNumber)
+	if g.PurchaseOrderID == nil && g.ASNNumber == "" {
+		return nil, ErrNoSource
+	}
+
+	if len(g.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+
+	seen := make(map[uuid.UUID]bool, len(g.Lines))
+	for _, line := range g.Lines {
+		if seen[line.ItemID] {
+			return nil, ErrDuplicateItem
+		}
+
+		seen[line.ItemID] = true
+
+		if line.ReceivedQuantity < 0 || line.DamagedQuantity < 0 || line.DamagedQuantity > line.ReceivedQuantity ||
+			line.ExpectedQuantity != nil && *line.ExpectedQuantity < 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		if line.ExpectedQuantity == nil && g.PurchaseOrderID == nil {
+			return nil, ErrExpectedRequired
+		}
+
+		line.Lot = strings.TrimSpace(line.Lot)
+	}
+
+	g.Location = strings.ToUpper(strings.TrimSpace(g.Location))
+	g.CreatedBy = &userID
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		if g.PurchaseOrderID != nil {
+			o, err := s.repository.GetPurchaseOrder(ctx, *g.PurchaseOrderID)
+			if err != nil {
+				return err
+			}
+
+			if g.WarehouseID == uuid.Nil && g.Location == "" {
+				g.WarehouseID, g.Location = o.WarehouseID, o.Location
+			}
+
+			for _, line := range g.Lines {
+				if line.ExpectedQuantity == nil {
+					expected := 0
+					if ordered := o.Line(line.ItemID); ordered != nil {
+						expected = ordered.Outstanding()
+					}
+
+					line.ExpectedQuantity = &expected
+				}
+			}
+		}
+
+		for _, line := range g.Lines {
+			if *line.ExpectedQuantity == 0 && line.ReceivedQuantity == 0 {
+				return fmt.Errorf("item %s: %w", line.ItemID, ErrEmptyReceiptLine)
+			}
+		}
+
+		return s.repository.CreateGoodsReceipt(ctx, g)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create goods receipt: %w", err)
+	}
+
+	return g, nil
+}
+
+// GetByID retrieves a goods receipt by its ID.
+func (s *Service) GetByID(ctx context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error) {
+	g, err := s.repository.GetGoodsReceipt(ctx, receiptID)
+	if err != nil {
+		return nil, fmt.Errorf("get goods receipt: %w", err)
+	}
+
+	return g, nil
+}
+
+// GetAll retrieves the goods receipts matching filter.
+func (s *Service) GetAll(ctx context.Context, filter model.ReceiptFilter) ([]*model.GoodsReceipt, error) {
+	receipts, err := s.repository.GetGoodsReceipts(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get goods receipts: %w", err)
+	}
+
+	return receipts, nil
+}
+
+// Delete deletes a draft goods receipt. Posted receipts are reversed instead.
+func (s *Service) Delete(ctx context.Context, userID, receiptID uuid.UUID) error {
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		return s.repository.DeleteGoodsReceipt(ctx, receiptID)
+	})
+	if err != nil {
+		return fmt.Errorf("delete goods receipt: %w", err)
+	}
+
+	return nil
+}
+
+// Post posts a draft goods receipt in one transaction. The accepted units of each line, those
+// received and not damaged, are added to stock with a receipt movement that references the
+// receipt; against a purchase order they are valued at the unit cost of the order's line and
+// credited to it up to the quantity outstanding, and the order's status follows. Every difference between the expected
+// and the received quantity and every damaged unit is recorded as an open discrepancy.
+func (s *Service) Post(ctx context.Context, userID, receiptID uuid.UUID) (*model.GoodsReceipt, error) {
+	var receipt *model.GoodsReceipt
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		g, err := s.repository.LockGoodsReceipt(ctx, receiptID)
+		if err != nil {
+			return err
+		}
+
+		if g.Status != model.ReceiptDraft {
+			return repoitem.ErrReceiptPosted
+		}
+
+		var o *model.PurchaseOrder
+		if g.PurchaseOrderID != nil {
+			if o, err = s.repository.LockPurchaseOrder(ctx, *g.PurchaseOrderID); err != nil {
+				return err
+			}
+
+			if o.Status != model.PurchaseOrdered && o.Status != model.PurchasePartiallyReceived {
+				return fmt.Errorf("%w: order is %s", ErrPurchaseClosed, o.Status)
+			}
+		}
+
+		reference := g.ID.String()
+
+		for _, line := range g.Lines {
+			if accepted := line.Accepted(); accepted > 0 {
+				place := g.Destination()
+				place.Lot = line.Lot
+				place.Serials = line.Serials
+
+				if o != nil {
+					if ordered := o.Line(line.ItemID); ordered != nil {
+						place.UnitCost = ordered.UnitCost
+					}
+				}
+
+				if _, err := s.stock.Receive(ctx, userID, line.ItemID, place, accepted, ReasonGoodsReceipt, reference); err != nil {
+					return fmt.Errorf("item %s: %w", line.ItemID, err)
+				}
+			}
+
+			if o != nil {
+				if ordered := o.Line(line.ItemID); ordered != nil {
+					line.PurchaseQuantity = min(line.Accepted(), ordered.Outstanding())
+				}
+
+				if err := s.creditPurchase(ctx, g, o, line, line.PurchaseQuantity); err != nil {
+					return err
+				}
+			}
+
+			for _, d := range discrepancies(line) {
+				d.ReceiptID = g.ID
+				if err := s.repository.InsertDiscrepancy(ctx, d); err != nil {
+					return err
+				}
+
+				g.Discrepancies = append(g.Discrepancies, d)
+			}
+		}
+
+		if o != nil {
+			if err := s.repository.SetPurchaseStatus(ctx, o, o.ReceivingStatus()); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repository.PostGoodsReceipt(ctx, g); err != nil {
+			return err
+		}
+
+		receipt = g
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("post goods receipt: %w", err)
+	}
+
+	return receipt, nil
+}
+
+// Reverse undoes a posted goods receipt with a compensating receipt, posted at once, that names
+// the original in reverses_id and repeats its lines. The accepted units leave stock again with
+// adjustments that reference the reversal, the quantities credited to the purchase order are
+// taken back and the original's open discrepancies are resolved. The original stays unchanged.
+func (s *Service) Reverse(ctx context.Context, userID, receiptID uuid.UUID) (*model.GoodsReceipt, error) {
+	var reversal *model.GoodsReceipt
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		g, err := s.repository.LockGoodsReceipt(ctx, receiptID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case g.Status != model.ReceiptPosted:
+			return ErrNotPosted
+		case g.ReversesID != nil:
+			return ErrReversalReversed
+		case g.ReversedByID != nil:
+			return repoitem.ErrReceiptReversed
+		}
+
+		rv := &model.GoodsReceipt{
+			PurchaseOrderID: g.PurchaseOrderID,
+			ASNNumber:       g.ASNNumber,
+			WarehouseID:     g.WarehouseID,
+			Location:        g.Location,
+			ReversesID:      &g.ID,
+			Note:            fmt.Sprintf("reversal of goods receipt %s", g.ID),
+			CreatedBy:       &userID,
+		}
+
+		for _, line := range g.Lines {
+			if len(line.Serials) > 0 {
+				return fmt.Errorf("item %s: %w", line.ItemID, ErrSerializedReversal)
+			}
+
+			copied := *line
+			rv.Lines = append(rv.Lines, &copied)
+		}
+
+		if err := s.repository.CreateGoodsReceipt(ctx, rv); err != nil {
+			return err
+		}
+
+		var o *model.PurchaseOrder
+		if g.PurchaseOrderID != nil {
+			if o, err = s.repository.LockPurchaseOrder(ctx, *g.PurchaseOrderID); err != nil {
+				return err
+			}
+		}
+
+		reference := rv.ID.String()
+
+		for _, line := range g.Lines {
+			if accepted := line.Accepted(); accepted > 0 {
+				place := g.Destination()
+				place.Lot = line.Lot
+
+				if _, err := s.stock.ReverseReceipt(ctx, userID, line.ItemID, place, accepted, ReasonReceiptReversal, reference); err != nil {
+					return fmt.Errorf("item %s: %w", line.ItemID, err)
+				}
+			}
+
+			if o != nil {
+				if err := s.creditPurchase(ctx, rv, o, line, -line.PurchaseQuantity); err != nil {
+					return err
+				}
+			}
+		}
+
+		// A cancelled order stays cancelled; any other goes back to where its deliveries leave it.
+		if o != nil && o.Status != model.PurchaseCancelled {
+			if err := s.repository.SetPurchaseStatus(ctx, o, o.ReceivingStatus()); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repository.ResolveReceiptDiscrepancies(ctx, g.ID, "reversed by goods receipt "+reference); err != nil {
+			return err
+		}
+
+		if err := s.repository.PostGoodsReceipt(ctx, rv); err != nil {
+			return err
+		}
+
+		reversal = rv
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reverse goods receipt: %w", err)
+	}
+
+	return reversal, nil
+}
+
+// creditPurchase adds a signed quantity of a receipt line to the received quantity of the
+// purchase order's line for the item and records it on the receipt's line.
+func (s *Service) creditPurchase(ctx context.Context, g *model.GoodsReceipt, o *model.PurchaseOrder, line *model.ReceiptLine, quantity int) error {
+	if quantity == 0 {
+		return nil
+	}
+
+	if err := s.repository.AddPurchaseReceived(ctx, o.ID, line.ItemID, quantity); err != nil {
+		return err
+	}
+
+	o.Line(line.ItemID).ReceivedQuantity += quantity
+
+	return s.repository.SetReceiptPurchaseQuantity(ctx, g.ID, line.ItemID, max(quantity, -quantity))
+}
+
+// discrepancies returns the discrepancies of a receipt line: units received beyond or short
+// of the expected quantity and damaged units.
+func discrepancies(line *model.ReceiptLine) []*model.Discrepancy {
+	var found []*model.Discrepancy
+
+	add := func(kind model.DiscrepancyKind, quantity int) {
+		if quantity > 0 {
+			found = append(found, &model.Discrepancy{ItemID: line.ItemID, ItemName: line.ItemName, Kind: kind, Quantity: quantity})
+		}
+	}
+
+	add(model.DiscrepancyOver, line.ReceivedQuantity-*line.ExpectedQuantity)
+	add(model.DiscrepancyShort, *line.ExpectedQuantity-line.ReceivedQuantity)
+	add(model.DiscrepancyDamaged, line.DamagedQuantity)
+
+	return found
+}
+
+// GetDiscrepancies retrieves the discrepancies matching filter.
+func (s *Service) GetDiscrepancies(ctx context.Context, filter model.DiscrepancyFilter) ([]*model.Discrepancy, error) {
+	discrepancies, err := s.repository.GetDiscrepancies(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get discrepancies: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+// ResolveDiscrepancy marks an open discrepancy as resolved by userID with a note on how.
+func (s *Service) ResolveDiscrepancy(ctx context.Context, userID, discrepancyID uuid.UUID, resolution string) (*model.Discrepancy, error) {
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" {
+		return nil, ErrResolutionRequired
+	}
+
+	var d *model.Discrepancy
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		var err error
+		d, err = s.repository.ResolveDiscrepancy(ctx, discrepancyID, resolution)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resolve discrepancy: %w", err)
+	}
+
+	return d, nil
+}
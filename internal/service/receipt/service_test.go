@@ -0,0 +1,298 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+// fakeRepository keeps goods receipts, their discrepancies and purchase orders in memory.
+type fakeRepository struct {
+	receipts      map[uuid.UUID]*model.GoodsReceipt
+	orders        map[uuid.UUID]*model.PurchaseOrder
+	discrepancies []*model.Discrepancy
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		receipts: map[uuid.UUID]*model.GoodsReceipt{},
+		orders:   map[uuid.UUID]*model.PurchaseOrder{},
+	}
+}
+
+func (r *fakeRepository) CreateGoodsReceipt(_ context.Context, g *model.GoodsReceipt) error {
+	if g.ReversesID != nil {
+		r.receipts[*g.ReversesID].ReversedByID = &g.ID
+	}
+
+	g.ID = uuid.New()
+	g.Status = model.ReceiptDraft
+	r.receipts[g.ID] = g
+	return nil
+}
+
+func (r *fakeRepository) GetGoodsReceipt(_ context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error) {
+	g, ok := r.receipts[receiptID]
+	if !ok {
+		return nil, repoitem.ErrReceiptNotFound
+	}
+
+	copied := *g
+	copied.Lines = nil
+	for _, line := range g.Lines {
+		l := *line
+		copied.Lines = append(copied.Lines, &l)
+	}
+
+	return &copied, nil
+}
+
+func (r *fakeRepository) LockGoodsReceipt(ctx context.Context, receiptID uuid.UUID) (*model.GoodsReceipt, error) {
+	return r.GetGoodsReceipt(ctx, receiptID)
+}
+
+func (r *fakeRepository) GetGoodsReceipts(context.Context, model.ReceiptFilter) ([]*model.GoodsReceipt, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) SetReceiptPurchaseQuantity(_ context.Context, receiptID, itemID uuid.UUID, quantity int) error {
+	for _, line := range r.receipts[receiptID].Lines {
+		if line.ItemID == itemID {
+			line.PurchaseQuantity = quantity
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeRepository) PostGoodsReceipt(_ context.Context, g *model.GoodsReceipt) error {
+	g.Status = model.ReceiptPosted
+	r.receipts[g.ID].Status = model.ReceiptPosted
+	return nil
+}
+
+func (r *fakeRepository) DeleteGoodsReceipt(_ context.Context, receiptID uuid.UUID) error {
+	delete(r.receipts, receiptID)
+	return nil
+}
+
+func (r *fakeRepository) InsertDiscrepancy(_ context.Context, d *model.Discrepancy) error {
+	d.ID = uuid.New()
+	r.discrepancies = append(r.discrepancies, d)
+	return nil
+}
+
+func (r *fakeRepository) GetDiscrepancies(context.Context, model.DiscrepancyFilter) ([]*model.Discrepancy, error) {
+	return r.discrepancies, nil
+}
+
+func (r *fakeRepository) ResolveDiscrepancy(context.Context, uuid.UUID, string) (*model.Discrepancy, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) ResolveReceiptDiscrepancies(_ context.Context, receiptID uuid.UUID, resolution string) error {
+	for _, d := range r.discrepancies {
+		if d.ReceiptID == receiptID {
+			d.Resolution = resolution
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeRepository) GetPurchaseOrder(_ context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	o := *r.orders[orderID]
+	o.Lines = nil
+	for _, line := range r.orders[orderID].Lines {
+		l := *line
+		o.Lines = append(o.Lines, &l)
+	}
+
+	return &o, nil
+}
+
+func (r *fakeRepository) LockPurchaseOrder(ctx context.Context, orderID uuid.UUID) (*model.PurchaseOrder, error) {
+	return r.GetPurchaseOrder(ctx, orderID)
+}
+
+func (r *fakeRepository) AddPurchaseReceived(_ context.Context, orderID, itemID uuid.UUID, quantity int) error {
+	r.orders[orderID].Line(itemID).ReceivedQuantity += quantity
+	return nil
+}
+
+func (r *fakeRepository) SetPurchaseStatus(_ context.Context, o *model.PurchaseOrder, to model.PurchaseStatus) error {
+	o.Status = to
+	r.orders[o.ID].Status = to
+	return nil
+}
+
+// fakeUnitOfWork runs fn without a transaction.
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeStock keeps the quantity of each item.
+type fakeStock struct {
+	quantities map[uuid.UUID]int
+}
+
+func (s *fakeStock) Receive(
+	_ context.Context, _, itemID uuid.UUID, _ model.StockPlace, quantity int, _, _ string,
+) (*model.StockMovement, error) {
+	s.quantities[itemID] += quantity
+	return &model.StockMovement{}, nil
+}
+
+func (s *fakeStock) ReverseReceipt(
+	_ context.Context, _, itemID uuid.UUID, _ model.StockPlace, quantity int, _, _ string,
+) (*model.StockMovement, error) {
+	s.quantities[itemID] -= quantity
+	return &model.StockMovement{}, nil
+}
+
+func TestPostAndReverse(t *testing.T) {
+	ctx := context.Background()
+	bolts, nuts, washers := uuid.New(), uuid.New(), uuid.New()
+
+	repo := newFakeRepository()
+	order := &model.PurchaseOrder{
+		ID:     uuid.New(),
+		Status: model.PurchaseOrdered,
+		Lines: []*model.PurchaseLine{
+			{ItemID: bolts, Quantity: 10},
+			{ItemID: nuts, Quantity: 5},
+		},
+	}
+	repo.orders[order.ID] = order
+
+	stock := &fakeStock{quantities: map[uuid.UUID]int{}}
+	s := NewService(repo, fakeUnitOfWork{}, stock)
+
+	g, err := s.Create(ctx, uuid.New(), &model.GoodsReceipt{
+		PurchaseOrderID: &order.ID,
+		Lines: []*model.ReceiptLine{
+			{ItemID: bolts, ReceivedQuantity: 12, DamagedQuantity: 1}, // 2 over, 1 damaged
+			{ItemID: nuts, ReceivedQuantity: 3},                       // 2 short
+			{ItemID: washers, ReceivedQuantity: 4},                    // not ordered
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if g, err = s.Post(ctx, uuid.New(), g.ID); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	wantStock := map[uuid.UUID]int{bolts: 11, nuts: 3, washers: 4}
+	if !reflect.DeepEqual(stock.quantities, wantStock) {
+		t.Errorf("stock = %v, want %v", stock.quantities, wantStock)
+	}
+
+	got := map[model.DiscrepancyKind]int{}
+	for _, d := range g.Discrepancies {
+		got[d.Kind] += d.Quantity
+	}
+
+	want := map[model.DiscrepancyKind]int{model.DiscrepancyOver: 6, model.DiscrepancyShort: 2, model.DiscrepancyDamaged: 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("discrepancies = %v, want %v", got, want)
+	}
+
+	if order.Line(bolts).ReceivedQuantity != 10 || order.Line(nuts).ReceivedQuantity != 3 {
+		t.Errorf("order received bolts %d and nuts %d, want 10 and 3",
+			order.Line(bolts).ReceivedQuantity, order.Line(nuts).ReceivedQuantity)
+	}
+
+	if order.Status != model.PurchasePartiallyReceived {
+		t.Errorf("order status = %s, want %s", order.Status, model.PurchasePartiallyReceived)
+	}
+
+	if _, err := s.Post(ctx, uuid.New(), g.ID); !errors.Is(err, repoitem.ErrReceiptPosted) {
+		t.Errorf("Post again error = %v, want %v", err, repoitem.ErrReceiptPosted)
+	}
+
+	reversal, err := s.Reverse(ctx, uuid.New(), g.ID)
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+
+	if *reversal.ReversesID != g.ID || reversal.Status != model.ReceiptPosted {
+		t.Errorf("reversal reverses %v with status %s, want %s posted", reversal.ReversesID, reversal.Status, g.ID)
+	}
+
+	for item, quantity := range stock.quantities {
+		if quantity != 0 {
+			t.Errorf("stock of %s after reversal = %d, want 0", item, quantity)
+		}
+	}
+
+	if order.Line(bolts).ReceivedQuantity != 0 || order.Line(nuts).ReceivedQuantity != 0 || order.Status != model.PurchaseOrdered {
+		t.Errorf("order after reversal = %+v, want nothing received and ordered", order)
+	}
+
+	for _, d := range repo.discrepancies {
+		if d.Resolution == "" {
+			t.Errorf("discrepancy %s %s left open after reversal", d.Kind, d.ItemID)
+		}
+	}
+
+	if _, err := s.Reverse(ctx, uuid.New(), g.ID); !errors.Is(err, repoitem.ErrReceiptReversed) {
+		t.Errorf("Reverse again error = %v, want %v", err, repoitem.ErrReceiptReversed)
+	}
+
+	if _, err := s.Reverse(ctx, uuid.New(), reversal.ID); !errors.Is(err, ErrReversalReversed) {
+		t.Errorf("Reverse reversal error = %v, want %v", err, ErrReversalReversed)
+	}
+}
+
+func TestCreateValidation(t *testing.T) {
+	itemID := uuid.New()
+	five := 5
+
+	tests := []struct {
+		name string
+		g    *model.GoodsReceipt
+		want error
+	}{
+		{name: "no source", g: &model.GoodsReceipt{}, want: ErrNoSource},
+		{name: "no lines", g: &model.GoodsReceipt{ASNNumber: "ASN-1"}, want: ErrNoLines},
+		{
+			name: "expected quantity missing without order",
+			g:    &model.GoodsReceipt{ASNNumber: "ASN-1", Lines: []*model.ReceiptLine{{ItemID: itemID, ReceivedQuantity: 1}}},
+			want: ErrExpectedRequired,
+		},
+		{
+			name: "more damaged than received",
+			g: &model.GoodsReceipt{ASNNumber: "ASN-1", Lines: []*model.ReceiptLine{
+				{ItemID: itemID, ExpectedQuantity: &five, ReceivedQuantity: 1, DamagedQuantity: 2},
+			}},
+			want: ErrInvalidQuantity,
+		},
+		{
+			name: "duplicate item",
+			g: &model.GoodsReceipt{ASNNumber: "ASN-1", Lines: []*model.ReceiptLine{
+				{ItemID: itemID, ExpectedQuantity: &five}, {ItemID: itemID, ExpectedQuantity: &five},
+			}},
+			want: ErrDuplicateItem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(newFakeRepository(), fakeUnitOfWork{}, &fakeStock{})
+
+			if _, err := s.Create(context.Background(), uuid.New(), tt.g); !errors.Is(err, tt.want) {
+				t.Errorf("Create() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/currency"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("period must be day, week, month, quarter or year")
+	ErrInvalidRange  = errors.New("from must be before to")
+)
+
+// repository defines the interface for report data access.
+type repository interface {
+	// GetExpiringLots retrieves the stock of all lots that expire on or before until.
+	GetExpiringLots(ctx context.Context, until time.Time) ([]*model.LotStock, error)
+
+	// GetWriteOffs sums the movements made with an adjustment reason code by period and code.
+	GetWriteOffs(ctx context.Context, from, to time.Time, period model.ReportPeriod, warehouseID uuid.UUID) ([]*model.WriteOff, error)
+
+	// GetValuation retrieves the stock each item owned at a point in time and its value.
+	GetValuation(ctx context.Context, asOf time.Time) ([]*model.ItemValuation, error)
+}
+
+// converter converts amounts between currencies.
+type converter interface {
+	// Base returns the code of the base currency.
+	Base() string
+
+	// ConverterAt returns a converter into a currency at the exchange rates in effect at a point in time.
+	ConverterAt(ctx context.Context, to string, at time.Time) (*currency.Converter, error)
+}
+
+// Service provides reports over stock.
+type Service struct {
+	repository repository
+	converter  converter
+}
+
+// NewService creates a new report service. Values are in the base currency unless c converts
+// them into another.
+func NewService(r repository, c converter) *Service {
+	return &Service{repository: r, converter: c}
+}
+
+// Expiring retrieves the lot stock that expires within the given period from today,
+// including stock of lots that have already expired.
+func (s *Service) Expiring(ctx context.Context, within time.Duration) ([]*model.LotStock, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	stock, err := s.repository.GetExpiringLots(ctx, today.Add(within))
+	if err != nil {
+		return nil, fmt.Errorf("get expiring lots: %w", err)
+	}
+
+	return stock, nil
+}
+
+// WriteOffs sums the stock adjusted with each reason code from from up to (not including) to,
+// by period of the given length, and values it at its cost. warehouseID
+// limits the report to one warehouse unless it is uuid.Nil. Unless code is empty, the values
+// are converted into that currency at the exchange rates in effect at the start of each period.
+func (s *Service) WriteOffs(
+	ctx context.Context, from, to time.Time, period model.ReportPeriod, warehouseID uuid.UUID, code string,
+) ([]*model.WriteOff, error) {
+	switch period {
+	case model.PeriodDay, model.PeriodWeek, model.PeriodMonth, model.PeriodQuarter, model.PeriodYear:
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	if !from.Before(to) {
+		return nil, ErrInvalidRange
+	}
+
+	writeOffs, err := s.repository.GetWriteOffs(ctx, from, to, period, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("get write-offs: %w", err)
+	}
+
+	converters := make(map[time.Time]*currency.Converter)
+	for _, w := range writeOffs {
+		w.Currency = s.converter.Base()
+
+		if code == "" {
+			continue
+		}
+
+		c, ok := converters[w.Period]
+		if !ok {
+			if c, err = s.converter.ConverterAt(ctx, code, w.Period); err != nil {
+				return nil, fmt.Errorf("convert write-offs: %w", err)
+			}
+
+			converters[w.Period] = c
+		}
+
+		if w.Value, err = c.Convert(w.Value, ""); err != nil {
+			return nil, fmt.Errorf("convert write-offs: %w", err)
+		}
+
+		w.Currency = c.Currency()
+	}
+
+	return writeOffs, nil
+}
+
+// Valuation values the stock owned at asOf, in the warehouses or in transit between them, at
+// the cost of the movements that brought it there, and totals it. Unless code is empty, the
+// values are converted into that currency at the exchange rates in effect at asOf.
+func (s *Service) Valuation(ctx context.Context, asOf time.Time, code string) (*model.Valuation, error) {
+	var c *currency.Converter
+	if code != "" {
+		var err error
+		if c, err = s.converter.ConverterAt(ctx, code, asOf); err != nil {
+			return nil, fmt.Errorf("convert valuation: %w", err)
+		}
+	}
+
+	items, err := s.repository.GetValuation(ctx, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("get valuation: %w", err)
+	}
+
+	v := &model.Valuation{AsOf: asOf, Currency: s.converter.Base(), Value: decimal.Zero, Items: items}
+	for _, item := range items {
+		if item.Quantity != 0 {
+			item.UnitCost = item.Value.Div(decimal.NewFromInt(int64(item.Quantity))).Round(2)
+		}
+
+		if c != nil {
+			if item.Value, err = c.Convert(item.Value, ""); err != nil {
+				return nil, fmt.Errorf("convert valuation: %w", err)
+			}
+
+			if item.UnitCost, err = c.Convert(item.UnitCost, ""); err != nil {
+				return nil, fmt.Errorf("convert valuation: %w", err)
+			}
+
+			v.Currency = c.Currency()
+		}
+
+		v.Value = v.Value.Add(item.Value)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,72 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+// DefaultExpiryInterval is how often the expirer looks for expired reservations when no
+// interval is configured.
+const DefaultExpiryInterval = time.Minute
+
+// expirer releases the stock of reservations past their expiry.
+type expirer interface {
+	// ExpireReservations expires the active reservations whose expiry has passed and returns
+	// how many were expired.
+	ExpireReservations(ctx context.Context) (int, error)
+}
+
+// Expirer expires reservations in the background. A reservation keeps its stock reserved
+// until the first run after its expiry, so it may hold it up to one interval longer.
+type Expirer struct {
+	expirer  expirer
+	interval time.Duration
+}
+
+// NewExpirer creates an expirer that runs every interval, or every DefaultExpiryInterval if
+// interval is not positive.
+func NewExpirer(e expirer, interval time.Duration) *Expirer {
+	if interval <= 0 {
+		interval = DefaultExpiryInterval
+	}
+
+	return &Expirer{
+		expirer:  e,
+		interval: interval,
+	}
+}
+
+// Run expires reservations now and every interval until ctx is done.
+func (e *Expirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	e.expire(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.expire(ctx)
+		}
+	}
+}
+
+// expire expires the reservations past their expiry. Errors are logged; the next run retries.
+func (e *Expirer) expire(ctx context.Context) {
+	expired, err := e.expirer.ExpireReservations(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Logger.Error().Err(err).Msg("failed to expire reservations")
+		}
+
+		return
+	}
+
+	if expired > 0 {
+		zlog.Logger.Info().Int("expired", expired).Msg("expired reservations")
+	}
+}
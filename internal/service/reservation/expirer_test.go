@@ -0,0 +1,46 @@
+package reservation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeExpirer reports each run.
+type fakeExpirer struct {
+	runs chan struct{}
+}
+
+func (f *fakeExpirer) ExpireReservations(context.Context) (int, error) {
+	f.runs <- struct{}{}
+	return 1, nil
+}
+
+func TestExpirerRun(t *testing.T) {
+	e := &fakeExpirer{runs: make(chan struct{}, 10)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		NewExpirer(e, 10*time.Millisecond).Run(ctx)
+		close(done)
+	}()
+
+	// The first run happens at once, the next after an interval.
+	for range 2 {
+		select {
+		case <-e.runs:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a run")
+		}
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop with its context")
+	}
+}
@@ -0,0 +1,123 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+const (
+	// DefaultTTL is how long a reservation holds stock when no TTL is given or configured.
+	DefaultTTL = 4 * time.Hour
+
+	// MaxTTL is the longest a reservation may hold stock.
+	MaxTTL = 7 * 24 * time.Hour
+)
+
+var (
+	ErrOwnerRequired   = errors.New("owner is required")
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+	ErrInvalidTTL      = errors.New("ttl must be positive and at most 7 days")
+	ErrInvalidStatus   = errors.New("invalid reservation status")
+)
+
+// repository defines the interface for reservation data access.
+type repository interface {
+	// GetItemByID retrieves an item by its ID.
+	GetItemByID(ctx context.Context, itemID uuid.UUID) (*model.Item, error)
+
+	// CreateReservation reserves stock of an item in a warehouse for ttl from now.
+	CreateReservation(ctx context.Context, rv *model.Reservation, ttl time.Duration) error
+
+	// GetReservations retrieves the reservations of an item with a status, or all if it is empty.
+	GetReservations(ctx context.Context, itemID uuid.UUID, status model.ReservationStatus) ([]*model.Reservation, error)
+
+	// ReleaseReservation releases an active reservation on behalf of a user.
+	ReleaseReservation(ctx context.Context, reservationID, userID uuid.UUID) (*model.Reservation, error)
+}
+
+// Service provides business logic for stock reservations.
+type Service struct {
+	repository repository
+	defaultTTL time.Duration
+}
+
+// NewService creates a new reservation service. Reservations created without a TTL hold
+// stock for defaultTTL, or DefaultTTL if it is not positive.
+func NewService(r repository, defaultTTL time.Duration) *Service {
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultTTL
+	}
+
+	return &Service{
+		repository: r,
+		defaultTTL: defaultTTL,
+	}
+}
+
+// Create reserves rv.Quantity units of an item for rv.Owner in rv.WarehouseID, uuid.Nil for
+// the default warehouse, for ttl from now, or for the default TTL if ttl is zero.
+// The stock must be available to promise in full.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, rv *model.Reservation, ttl time.Duration) (*model.Reservation, error) {
+	rv.Owner = strings.TrimSpace(rv.Owner)
+	if rv.Owner == "" {
+		return nil, ErrOwnerRequired
+	}
+
+	if rv.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	if ttl == 0 {
+		ttl = s.defaultTTL
+	}
+
+	if ttl < 0 || ttl > MaxTTL {
+		return nil, ErrInvalidTTL
+	}
+
+	rv.CreatedBy = &userID
+
+	if err := s.repository.CreateReservation(ctx, rv, ttl); err != nil {
+		return nil, fmt.Errorf("create reservation: %w", err)
+	}
+
+	return rv, nil
+}
+
+// GetByItem retrieves the reservations of an item, newest first, with the given status or
+// all of them if it is empty.
+func (s *Service) GetByItem(ctx context.Context, itemID uuid.UUID, status model.ReservationStatus) ([]*model.Reservation, error) {
+	switch status {
+	case "", model.ReservationActive, model.ReservationReleased, model.ReservationExpired:
+	default:
+		return nil, ErrInvalidStatus
+	}
+
+	if _, err := s.repository.GetItemByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("get item by id: %w", err)
+	}
+
+	reservations, err := s.repository.GetReservations(ctx, itemID, status)
+	if err != nil {
+		return nil, fmt.Errorf("get reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// Release releases an active reservation before it expires, making its stock available again.
+func (s *Service) Release(ctx context.Context, userID, reservationID uuid.UUID) (*model.Reservation, error) {
+	rv, err := s.repository.ReleaseReservation(ctx, reservationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("release reservation: %w", err)
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,80 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// fakeRepository records the TTL of the last reservation created.
+type fakeRepository struct {
+	ttl time.Duration
+}
+
+func (r *fakeRepository) GetItemByID(_ context.Context, itemID uuid.UUID) (*model.Item, error) {
+	return &model.Item{ID: itemID}, nil
+}
+
+func (r *fakeRepository) CreateReservation(_ context.Context, rv *model.Reservation, ttl time.Duration) error {
+	r.ttl = ttl
+	rv.ID = uuid.New()
+	rv.Status = model.ReservationActive
+	return nil
+}
+
+func (r *fakeRepository) GetReservations(
+	context.Context, uuid.UUID, model.ReservationStatus,
+) ([]*model.Reservation, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) ReleaseReservation(context.Context, uuid.UUID, uuid.UUID) (*model.Reservation, error) {
+	return nil, nil
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name     string
+		owner    string
+		quantity int
+		ttl      time.Duration
+		wantTTL  time.Duration
+		wantErr  error
+	}{
+		{"default ttl", "ACME", 2, 0, 2 * time.Hour, nil},
+		{"given ttl", " ACME ", 2, 30 * time.Minute, 30 * time.Minute, nil},
+		{"no owner", " ", 2, 0, 0, ErrOwnerRequired},
+		{"no quantity", "ACME", 0, 0, 0, ErrInvalidQuantity},
+		{"negative ttl", "ACME", 2, -time.Minute, 0, ErrInvalidTTL},
+		{"ttl too long", "ACME", 2, MaxTTL + time.Second, 0, ErrInvalidTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			s := NewService(repo, 2*time.Hour)
+
+			rv, err := s.Create(context.Background(), uuid.New(), &model.Reservation{
+				ItemID:   uuid.New(),
+				Owner:    tt.owner,
+				Quantity: tt.quantity,
+			}, tt.ttl)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create: got %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if repo.ttl != tt.wantTTL || rv.Owner != "ACME" {
+				t.Fatalf("reserved for %s by %q, want %s by ACME", repo.ttl, rv.Owner, tt.wantTTL)
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package sales
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+var (
+	ErrCustomerRequired  = errors.New("customer is required")
+	ErrNoLines           = errors.New("sales order must have at least one line")
+	ErrInvalidQuantity   = errors.New("sales quantity must be positive")
+	ErrDuplicateItem     = errors.New("sales order lists an item more than once")
+	ErrNegativePrice     = errors.New("unit price cannot be negative")
+	ErrInvalidTransition = errors.New("sales order cannot change to this status")
+)
+
+// ReasonSalesShipment is the reason recorded on the stock movements of shipped sales orders.
+const ReasonSalesShipment = "sales_shipment"
+
+// repository defines the interface for sales order data access.
+type repository interface {
+	// CreateSalesOrder adds a draft sales order with its lines.
+	CreateSalesOrder(ctx context.Context, o *model.SalesOrder) error
+
+	// GetSalesOrder retrieves a sales order with its lines.
+	GetSalesOrder(ctx context.Context, orderID uuid.UUID) (*model.SalesOrder, error)
+
+	// LockSalesOrder retrieves a sales order and locks it for the rest of the transaction.
+	LockSalesOrder(ctx context.Context, orderID uuid.UUID) (*model.SalesOrder, error)
+
+	// GetSalesOrders retrieves the sales orders matching filter.
+	GetSalesOrders(ctx context.Context, filter model.SalesFilter) ([]*model.SalesOrder, error)
+
+	// AddSalesAllocated adds a signed quantity to the allocated quantity of an item's line of an order.
+	AddSalesAllocated(ctx context.Context, orderID, itemID uuid.UUID, quantity int) error
+
+	// ReserveStock reserves up to quantity available units of an item in a warehouse and
+	// returns how many it reserved.
+	ReserveStock(ctx context.Context, itemID, warehouseID uuid.UUID, quantity int) (int, error)
+
+	// ReleaseStock returns reserved units of an item in a warehouse to the available stock.
+	ReleaseStock(ctx context.Context, itemID, warehouseID uuid.UUID, quantity int) error
+
+	// GetPickSources retrieves the bins and lots the pick list of an order may take an item from.
+	GetPickSources(ctx context.Context, itemID, warehouseID, orderID uuid.UUID) ([]*model.PickSource, []*model.PickSource, error)
+
+	// InsertPickLines adds lines to the pick list of an order.
+	InsertPickLines(ctx context.Context, orderID uuid.UUID, lines []*model.PickListLine) error
+
+	// GetPickLines retrieves the pick list of an order sorted by bin.
+	GetPickLines(ctx context.Context, orderID uuid.UUID) ([]*model.PickListLine, error)
+
+	// SetSalesStatus moves a sales order to a new status.
+	SetSalesStatus(ctx context.Context, o *model.SalesOrder, to model.SalesStatus) error
+}
+
+// unitOfWork runs a group of repository calls in one transaction attributed to a user.
+type unitOfWork interface {
+	// Do runs fn in a transaction; repository calls must use the context passed to fn.
+	Do(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) error
+}
+
+// stock moves item stock; it is implemented by the item service.
+type stock interface {
+	// Issue removes quantity units of stock from an item at a place.
+	Issue(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, quantity int, reason, reference string) (*model.StockMovement, error)
+}
+
+// Service provides business logic for sales orders.
+type Service struct {
+	repository repository
+	uow        unitOfWork
+	stock      stock
+}
+
+// NewService creates a new sales order service.
+func NewService(r repository, uow unitOfWork, s stock) *Service {
+	return &Service{
+		repository: r,
+		uow:        uow,
+		stock:      s,
+	}
+}
+
+// Create adds a draft sales order. Lines without a unit price take the item's price.
+// It does not allocate any stock yet.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, o *model.SalesOrder) (*model.SalesOrder, error) {
+	o.Customer = strings.TrimSpace(o.Customer)
+	if o.Customer == "" {
+		return nil, ErrCustomerRequired
+	}
+
+	if len(o.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+
+	seen := make(map[uuid.UUID]bool, len(o.Lines))
+	for _, line := range o.Lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		if line.UnitPrice != nil && line.UnitPrice.IsNegative() {
+			return nil, ErrNegativePrice
+		}
+
+		if seen[line.ItemID] {
+			return nil, ErrDuplicateItem
+		}
+
+		seen[line.ItemID] = true
+	}
+
+	o.ShipTo = strings.TrimSpace(o.ShipTo)
+	o.CreatedBy = &userID
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		return s.repository.CreateSalesOrder(ctx, o)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create sales order: %w", err)
+	}
+
+	return o, nil
+}
+
+// GetByID retrieves a sales order by its ID.
+func (s *Service) GetByID(ctx context.Context, orderID uuid.UUID) (*model.SalesOrder, error) {
+	o, err := s.repository.GetSalesOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get sales order: %w", err)
+	}
+
+	return o, nil
+}
+
+// GetAll retrieves the sales orders matching filter.
+func (s *Service) GetAll(ctx context.Context, filter model.SalesFilter) ([]*model.SalesOrder, error) {
+	orders, err := s.repository.GetSalesOrders(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get sales orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// Allocate reserves stock in the order's warehouse for every line that is not allocated in
+// full, as much as is available to promise. The order becomes allocated once every line is,
+// and partially allocated until then; allocating again later takes stock that has arrived
+// in the meantime.
+func (s *Service) Allocate(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return s.apply(ctx, userID, orderID, "allocate", func(ctx context.Context, o *model.SalesOrder) error {
+		if o.Status != model.SalesDraft && o.Status != model.SalesPartiallyAllocated {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, model.SalesAllocated)
+		}
+
+		for _, line := range o.Lines {
+			if line.Unallocated() == 0 {
+				continue
+			}
+
+			reserved, err := s.repository.ReserveStock(ctx, line.ItemID, o.WarehouseID, line.Unallocated())
+			if err != nil {
+				return fmt.Errorf("item %s: %w", line.ItemID, err)
+			}
+
+			if reserved == 0 {
+				continue
+			}
+
+			if err := s.repository.AddSalesAllocated(ctx, o.ID, line.ItemID, reserved); err != nil {
+				return err
+			}
+
+			line.AllocatedQuantity += reserved
+		}
+
+		return s.repository.SetSalesStatus(ctx, o, o.AllocationStatus())
+	})
+}
+
+// Pick plans where the stock of an allocated order is taken from and records it as the
+// order's pick list. Each item is taken from its bins in the order of their codes and from
+// its lots first expiring first, skipping expired lots, and then from the stock in no bin or
+// no lot; stock on the pick lists of other open orders is left alone.
+// Returns an error wrapping repoitem.ErrInsufficientStock if the allocated stock cannot be
+// found in the warehouse.
+func (s *Service) Pick(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return s.apply(ctx, userID, orderID, "pick", func(ctx context.Context, o *model.SalesOrder) error {
+		if o.Status != model.SalesAllocated {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, model.SalesPicking)
+		}
+
+		var lines []*model.PickListLine
+		for _, line := range o.Lines {
+			bins, lots, err := s.repository.GetPickSources(ctx, line.ItemID, o.WarehouseID, o.ID)
+			if err != nil {
+				return err
+			}
+
+			picks, shortfall := plan(line.ItemID, bins, lots, line.AllocatedQuantity)
+			if shortfall > 0 {
+				return fmt.Errorf("item %s: %w", line.ItemID, repoitem.ErrInsufficientStock)
+			}
+
+			lines = append(lines, picks...)
+		}
+
+		if err := s.repository.InsertPickLines(ctx, o.ID, lines); err != nil {
+			return err
+		}
+
+		return s.repository.SetSalesStatus(ctx, o, model.SalesPicking)
+	})
+}
+
+// GetPickList retrieves the pick list of an order grouped by bin. Orders that have not been
+// picked yet have an empty pick list.
+func (s *Service) GetPickList(ctx context.Context, orderID uuid.UUID) (*model.PickList, error) {
+	o, err := s.repository.GetSalesOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get sales order: %w", err)
+	}
+
+	lines, err := s.repository.GetPickLines(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get pick lines: %w", err)
+	}
+
+	return model.NewPickList(o.ID, o.WarehouseID, lines), nil
+}
+
+// Pack confirms that the stock on an order's pick list has been picked and packed.
+func (s *Service) Pack(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return s.apply(ctx, userID, orderID, "pack", func(ctx context.Context, o *model.SalesOrder) error {
+		if o.Status != model.SalesPicking {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, model.SalesPacked)
+		}
+
+		return s.repository.SetSalesStatus(ctx, o, model.SalesPacked)
+	})
+}
+
+// Ship confirms that a packed order has left the warehouse. Its allocation is released and
+// the stock on its pick list is issued with movements that reference the order.
+func (s *Service) Ship(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return s.apply(ctx, userID, orderID, "ship", func(ctx context.Context, o *model.SalesOrder) error {
+		if o.Status != model.SalesPacked {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, model.SalesShipped)
+		}
+
+		if err := s.release(ctx, o, false); err != nil {
+			return err
+		}
+
+		picks, err := s.repository.GetPickLines(ctx, o.ID)
+		if err != nil {
+			return err
+		}
+
+		reference := o.ID.String()
+		for _, p := range picks {
+			place := model.StockPlace{WarehouseID: o.WarehouseID, Location: p.LocationCode, Lot: p.LotNumber}
+			if _, err := s.stock.Issue(ctx, userID, p.ItemID, place, p.Quantity, ReasonSalesShipment, reference); err != nil {
+				return fmt.Errorf("item %s: %w", p.ItemID, err)
+			}
+		}
+
+		return s.repository.SetSalesStatus(ctx, o, model.SalesShipped)
+	})
+}
+
+// Cancel cancels an order that has not been shipped and releases its allocation.
+func (s *Service) Cancel(ctx context.Context, userID, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return s.apply(ctx, userID, orderID, "cancel", func(ctx context.Context, o *model.SalesOrder) error {
+		if o.Status == model.SalesShipped || o.Status == model.SalesCancelled {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, model.SalesCancelled)
+		}
+
+		if err := s.release(ctx, o, true); err != nil {
+			return err
+		}
+
+		return s.repository.SetSalesStatus(ctx, o, model.SalesCancelled)
+	})
+}
+
+// release returns the stock allocated to an order to the stock available to promise. Unless
+// the allocation is consumed by shipping, the lines' allocated quantities go back to zero.
+func (s *Service) release(ctx context.Context, o *model.SalesOrder, deallocate bool) error {
+	for _, line := range o.Lines {
+		if line.AllocatedQuantity == 0 {
+			continue
+		}
+
+		if err := s.repository.ReleaseStock(ctx, line.ItemID, o.WarehouseID, line.AllocatedQuantity); err != nil {
+			return err
+		}
+
+		if !deallocate {
+			continue
+		}
+
+		if err := s.repository.AddSalesAllocated(ctx, o.ID, line.ItemID, -line.AllocatedQuantity); err != nil {
+			return err
+		}
+
+		line.AllocatedQuantity = 0
+	}
+
+	return nil
+}
+
+// apply runs fn on an order in a transaction that locks the order first.
+func (s *Service) apply(
+	ctx context.Context,
+	userID, orderID uuid.UUID,
+	action string,
+	fn func(ctx context.Context, o *model.SalesOrder) error,
+) (*model.SalesOrder, error) {
+	var order *model.SalesOrder
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		o, err := s.repository.LockSalesOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(ctx, o); err != nil {
+			return err
+		}
+
+		order = o
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s sales order: %w", action, err)
+	}
+
+	return order, nil
+}
+
+// plan takes quantity units of an item from bins and lots, both given in the order to take
+// from them, and returns the pick lines and what they cannot cover. Bins and lots partition
+// the same stock independently, so each line pairs the next bin with stock left with the next
+// lot with stock left.
+func plan(itemID uuid.UUID, bins, lots []*model.PickSource, quantity int) ([]*model.PickListLine, int) {
+	var lines []*model.PickListLine
+
+	binLeft, lotLeft := 0, 0
+	b, l := -1, -1
+
+	for quantity > 0 {
+		for binLeft == 0 && b+1 < len(bins) {
+			b++
+			binLeft = bins[b].Quantity
+		}
+
+		for lotLeft == 0 && l+1 < len(lots) {
+			l++
+			lotLeft = lots[l].Quantity
+		}
+
+		if binLeft == 0 || lotLeft == 0 {
+			break
+		}
+
+		take := min(quantity, binLeft, lotLeft)
+		lines = append(lines, &model.PickListLine{
+			ItemID:       itemID,
+			LocationID:   bins[b].ID,
+			LocationCode: bins[b].Code,
+			LotID:        lots[l].ID,
+			LotNumber:    lots[l].Code,
+			Quantity:     take,
+		})
+
+		quantity -= take
+		binLeft -= take
+		lotLeft -= take
+	}
+
+	return lines, quantity
+}
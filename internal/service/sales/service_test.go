@@ -0,0 +1,264 @@
+package sales
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+	repoitem "github.com/aliskhannn/warehouse-control/internal/repository/item"
+)
+
+// fakeRepository keeps sales orders, the stock of one warehouse and its reservations in memory.
+type fakeRepository struct {
+	orders   map[uuid.UUID]*model.SalesOrder
+	onHand   map[uuid.UUID]int
+	reserved map[uuid.UUID]int
+	picks    map[uuid.UUID][]*model.PickListLine
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		orders:   map[uuid.UUID]*model.SalesOrder{},
+		onHand:   map[uuid.UUID]int{},
+		reserved: map[uuid.UUID]int{},
+		picks:    map[uuid.UUID][]*model.PickListLine{},
+	}
+}
+
+func (r *fakeRepository) CreateSalesOrder(_ context.Context, o *model.SalesOrder) error {
+	o.ID = uuid.New()
+	o.Status = model.SalesDraft
+	r.orders[o.ID] = o
+	return nil
+}
+
+func (r *fakeRepository) GetSalesOrder(_ context.Context, orderID uuid.UUID) (*model.SalesOrder, error) {
+	o, ok := r.orders[orderID]
+	if !ok {
+		return nil, repoitem.ErrSalesNotFound
+	}
+
+	copied := *o
+	copied.Lines = nil
+	for _, line := range o.Lines {
+		l := *line
+		copied.Lines = append(copied.Lines, &l)
+	}
+
+	return &copied, nil
+}
+
+func (r *fakeRepository) LockSalesOrder(ctx context.Context, orderID uuid.UUID) (*model.SalesOrder, error) {
+	return r.GetSalesOrder(ctx, orderID)
+}
+
+func (r *fakeRepository) GetSalesOrders(context.Context, model.SalesFilter) ([]*model.SalesOrder, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) AddSalesAllocated(_ context.Context, orderID, itemID uuid.UUID, quantity int) error {
+	for _, line := range r.orders[orderID].Lines {
+		if line.ItemID == itemID {
+			line.AllocatedQuantity += quantity
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeRepository) ReserveStock(_ context.Context, itemID, _ uuid.UUID, quantity int) (int, error) {
+	reserved := max(min(quantity, r.onHand[itemID]-r.reserved[itemID]), 0)
+	r.reserved[itemID] += reserved
+	return reserved, nil
+}
+
+func (r *fakeRepository) ReleaseStock(_ context.Context, itemID, _ uuid.UUID, quantity int) error {
+	if r.reserved[itemID] < quantity {
+		return errors.New("not reserved")
+	}
+
+	r.reserved[itemID] -= quantity
+	return nil
+}
+
+func (r *fakeRepository) GetPickSources(
+	_ context.Context,
+	itemID, _, _ uuid.UUID,
+) ([]*model.PickSource, []*model.PickSource, error) {
+	return []*model.PickSource{{Quantity: r.onHand[itemID]}}, []*model.PickSource{{Quantity: r.onHand[itemID]}}, nil
+}
+
+func (r *fakeRepository) InsertPickLines(_ context.Context, orderID uuid.UUID, lines []*model.PickListLine) error {
+	r.picks[orderID] = append(r.picks[orderID], lines...)
+	return nil
+}
+
+func (r *fakeRepository) GetPickLines(_ context.Context, orderID uuid.UUID) ([]*model.PickListLine, error) {
+	return r.picks[orderID], nil
+}
+
+func (r *fakeRepository) SetSalesStatus(_ context.Context, o *model.SalesOrder, to model.SalesStatus) error {
+	if r.orders[o.ID].Status != o.Status {
+		return repoitem.ErrSalesStatusChange
+	}
+
+	o.Status = to
+	r.orders[o.ID].Status = to
+	return nil
+}
+
+// fakeUnitOfWork runs fn without a transaction.
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeStock issues from the repository's stock.
+type fakeStock struct {
+	repo *fakeRepository
+}
+
+func (s *fakeStock) Issue(
+	_ context.Context, _, itemID uuid.UUID, _ model.StockPlace, quantity int, reason, reference string,
+) (*model.StockMovement, error) {
+	if s.repo.onHand[itemID]-s.repo.reserved[itemID] < quantity {
+		return nil, repoitem.ErrInsufficientStock
+	}
+
+	s.repo.onHand[itemID] -= quantity
+	return &model.StockMovement{ItemID: itemID, Quantity: -quantity, Reason: reason, Reference: reference}, nil
+}
+
+func TestAllocatePickAndShip(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	itemID := uuid.New()
+
+	repo := newFakeRepository()
+	repo.onHand[itemID] = 3
+	s := NewService(repo, fakeUnitOfWork{}, &fakeStock{repo: repo})
+
+	o, err := s.Create(ctx, userID, &model.SalesOrder{
+		Customer: "ACME",
+		Lines:    []*model.SalesLine{{ItemID: itemID, Quantity: 5}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	o, err = s.Allocate(ctx, userID, o.ID)
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+
+	if o.Status != model.SalesPartiallyAllocated || o.Lines[0].AllocatedQuantity != 3 {
+		t.Fatalf("after partial allocation: status %s, allocated %d", o.Status, o.Lines[0].AllocatedQuantity)
+	}
+
+	if _, err := s.Pick(ctx, userID, o.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Pick of a partially allocated order: got %v, want ErrInvalidTransition", err)
+	}
+
+	repo.onHand[itemID] = 6
+
+	if o, err = s.Allocate(ctx, userID, o.ID); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+
+	if o.Status != model.SalesAllocated || repo.reserved[itemID] != 5 {
+		t.Fatalf("after allocation: status %s, reserved %d", o.Status, repo.reserved[itemID])
+	}
+
+	for _, step := range []func(context.Context, uuid.UUID, uuid.UUID) (*model.SalesOrder, error){s.Pick, s.Pack, s.Ship} {
+		if o, err = step(ctx, userID, o.ID); err != nil {
+			t.Fatalf("%s: %v", o.Status, err)
+		}
+	}
+
+	if o.Status != model.SalesShipped {
+		t.Fatalf("status = %s, want shipped", o.Status)
+	}
+
+	if repo.onHand[itemID] != 1 || repo.reserved[itemID] != 0 {
+		t.Fatalf("after shipping: on hand %d, reserved %d; want 1 and 0", repo.onHand[itemID], repo.reserved[itemID])
+	}
+
+	if _, err := s.Cancel(ctx, userID, o.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Cancel of a shipped order: got %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestCancelReleasesAllocation(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	itemID := uuid.New()
+
+	repo := newFakeRepository()
+	repo.onHand[itemID] = 4
+	s := NewService(repo, fakeUnitOfWork{}, &fakeStock{repo: repo})
+
+	o, err := s.Create(ctx, userID, &model.SalesOrder{
+		Customer: "ACME",
+		Lines:    []*model.SalesLine{{ItemID: itemID, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := s.Allocate(ctx, userID, o.ID); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+
+	if o, err = s.Cancel(ctx, userID, o.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if o.Status != model.SalesCancelled || o.Lines[0].AllocatedQuantity != 0 || repo.reserved[itemID] != 0 {
+		t.Fatalf("after cancelling: status %s, allocated %d, reserved %d",
+			o.Status, o.Lines[0].AllocatedQuantity, repo.reserved[itemID])
+	}
+}
+
+func TestPlan(t *testing.T) {
+	itemID := uuid.New()
+	binA, binB, lot1 := uuid.New(), uuid.New(), uuid.New()
+
+	bins := []*model.PickSource{{ID: &binA, Code: "A-01", Quantity: 3}, {ID: &binB, Code: "B-01", Quantity: 2}, {Quantity: 1}}
+	lots := []*model.PickSource{{ID: &lot1, Code: "L1", Quantity: 4}, {Quantity: 2}}
+
+	type pick struct {
+		bin, lot string
+		quantity int
+	}
+
+	tests := []struct {
+		name      string
+		quantity  int
+		want      []pick
+		shortfall int
+	}{
+		{"one bin and lot", 2, []pick{{"A-01", "L1", 2}}, 0},
+		{"pairs bins with lots", 6, []pick{{"A-01", "L1", 3}, {"B-01", "L1", 1}, {"B-01", "", 1}, {"", "", 1}}, 0},
+		{"shortfall", 8, []pick{{"A-01", "L1", 3}, {"B-01", "L1", 1}, {"B-01", "", 1}, {"", "", 1}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, shortfall := plan(itemID, bins, lots, tt.quantity)
+
+			var got []pick
+			for _, line := range lines {
+				got = append(got, pick{line.LocationCode, line.LotNumber, line.Quantity})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) || shortfall != tt.shortfall {
+				t.Fatalf("plan = %v, shortfall %d; want %v, shortfall %d", got, shortfall, tt.want, tt.shortfall)
+			}
+		})
+	}
+}
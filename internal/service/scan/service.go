@@ -0,0 +1,67 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aliskhannn/warehouse-control/internal/gs1"
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var ErrNoGTIN = errors.New("barcode does not contain a GTIN")
+
+// repository defines the interface for looking up scanned items.
+type repository interface {
+	// GetItemByBarcode retrieves an item by one of its barcodes, given as a 14-digit GTIN.
+	GetItemByBarcode(ctx context.Context, gtin string) (*model.Item, error)
+}
+
+// Service resolves scanner input to items.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new scan service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Scan parses raw GS1-128 scanner data and looks up the item with the scanned GTIN.
+// Returns an error wrapping a gs1 error if the data is malformed and ErrNoGTIN if it
+// identifies no trade item.
+func (s *Service) Scan(ctx context.Context, data string) (*model.ScanResult, error) {
+	msg, err := gs1.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse barcode: %w", err)
+	}
+
+	if msg.GTIN == "" {
+		return nil, ErrNoGTIN
+	}
+
+	item, err := s.repository.GetItemByBarcode(ctx, msg.GTIN)
+	if err != nil {
+		return nil, fmt.Errorf("get item by barcode: %w", err)
+	}
+
+	result := &model.ScanResult{
+		Item:     item,
+		GTIN:     msg.GTIN,
+		Lot:      msg.Lot,
+		Serial:   msg.Serial,
+		Expiry:   msg.Expiry,
+		Quantity: 1,
+		Elements: make(map[string]string, len(msg.Elements)),
+	}
+
+	if msg.Count != nil {
+		result.Quantity = *msg.Count
+	}
+
+	for _, e := range msg.Elements {
+		result.Elements[e.AI] = e.Value
+	}
+
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+const (
+	// DefaultLimit is the number of results returned when no limit is given.
+	DefaultLimit = 20
+
+	// MaxLimit is the largest number of results returned at once.
+	MaxLimit = 100
+
+	// MinPrefixLength is the shortest prefix suggestions are looked up for.
+	MinPrefixLength = 2
+)
+
+var ErrEmptyQuery = errors.New("search query is empty")
+
+// repository defines the interface for item search data access.
+type repository interface {
+	// SearchItems finds items matching the query, ordered by relevance.
+	SearchItems(ctx context.Context, q string, limit int) ([]*model.ItemSearchResult, error)
+
+	// SuggestItems returns items whose name starts with prefix.
+	SuggestItems(ctx context.Context, prefix string, limit int) ([]*model.ItemSuggestion, error)
+}
+
+// Service provides item search and typeahead suggestions.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new search service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Search finds items by words in their name or description, tolerating misspelled names.
+func (s *Service) Search(ctx context.Context, q string, limit int) ([]*model.ItemSearchResult, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	results, err := s.repository.SearchItems(ctx, q, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("search items: %w", err)
+	}
+
+	return results, nil
+}
+
+// Suggest returns item names starting with prefix for typeahead.
+// Prefixes shorter than MinPrefixLength yield no suggestions.
+func (s *Service) Suggest(ctx context.Context, prefix string, limit int) ([]*model.ItemSuggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if len([]rune(prefix)) < MinPrefixLength {
+		return []*model.ItemSuggestion{}, nil
+	}
+
+	suggestions, err := s.repository.SuggestItems(ctx, prefix, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("suggest items: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// clampLimit applies the default and maximum number of results.
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+
+	return limit
+}
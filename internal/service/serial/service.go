@@ -0,0 +1,45 @@
+package serial
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// repository defines the interface for serial data access.
+type repository interface {
+	// GetSerial retrieves a unit of a serialized item by its serial.
+	GetSerial(ctx context.Context, serial string) (*model.Serial, error)
+
+	// GetSerialHistory retrieves the movements of a unit, oldest first.
+	GetSerialHistory(ctx context.Context, serialID uuid.UUID) ([]*model.SerialEvent, error)
+}
+
+// Service provides business logic for the units of serialized items.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new serial service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Get retrieves a unit by its serial together with its lifecycle: every movement of the
+// unit and the status it left the unit in, oldest first.
+func (s *Service) Get(ctx context.Context, serial string) (*model.Serial, error) {
+	unit, err := s.repository.GetSerial(ctx, strings.TrimSpace(serial))
+	if err != nil {
+		return nil, fmt.Errorf("get serial: %w", err)
+	}
+
+	if unit.History, err = s.repository.GetSerialHistory(ctx, unit.ID); err != nil {
+		return nil, fmt.Errorf("get serial history: %w", err)
+	}
+
+	return unit, nil
+}
@@ -0,0 +1,142 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var ErrNegativeCost = errors.New("supplier cost cannot be negative")
+
+// repository defines the interface for supplier data access.
+type repository interface {
+	// CreateSupplier adds a new supplier and returns its ID.
+	CreateSupplier(ctx context.Context, s *model.Supplier) (uuid.UUID, error)
+
+	// GetSupplierByID retrieves a supplier by its ID.
+	GetSupplierByID(ctx context.Context, supplierID uuid.UUID) (*model.Supplier, error)
+
+	// GetAllSuppliers retrieves all suppliers.
+	GetAllSuppliers(ctx context.Context) ([]*model.Supplier, error)
+
+	// UpdateSupplier updates the name, contact details and lead time of a supplier.
+	UpdateSupplier(ctx context.Context, s *model.Supplier) error
+
+	// SetSupplierItem records the SKU and unit cost an item is bought at from a supplier.
+	SetSupplierItem(ctx context.Context, si *model.SupplierItem) error
+
+	// DeleteSupplierItem removes an item from the items a supplier sells.
+	DeleteSupplierItem(ctx context.Context, supplierID, itemID uuid.UUID) error
+
+	// GetSupplierItems retrieves the items a supplier sells.
+	GetSupplierItems(ctx context.Context, supplierID uuid.UUID) ([]*model.SupplierItem, error)
+}
+
+// Service provides business logic for suppliers.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new supplier service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create adds a new supplier.
+func (s *Service) Create(ctx context.Context, supplier *model.Supplier) (*model.Supplier, error) {
+	normalize(supplier)
+
+	if _, err := s.repository.CreateSupplier(ctx, supplier); err != nil {
+		return nil, fmt.Errorf("create supplier: %w", err)
+	}
+
+	return supplier, nil
+}
+
+// GetByID retrieves a supplier by its ID.
+func (s *Service) GetByID(ctx context.Context, supplierID uuid.UUID) (*model.Supplier, error) {
+	supplier, err := s.repository.GetSupplierByID(ctx, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("get supplier by id: %w", err)
+	}
+
+	return supplier, nil
+}
+
+// GetAll retrieves all suppliers.
+func (s *Service) GetAll(ctx context.Context) ([]*model.Supplier, error) {
+	suppliers, err := s.repository.GetAllSuppliers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all suppliers: %w", err)
+	}
+
+	return suppliers, nil
+}
+
+// Update changes the name, contact details and lead time of a supplier.
+func (s *Service) Update(ctx context.Context, supplier *model.Supplier) (*model.Supplier, error) {
+	normalize(supplier)
+
+	if err := s.repository.UpdateSupplier(ctx, supplier); err != nil {
+		return nil, fmt.Errorf("update supplier: %w", err)
+	}
+
+	return supplier, nil
+}
+
+// SetItem records the SKU and unit cost an item is bought at from a supplier.
+func (s *Service) SetItem(ctx context.Context, supplierID, itemID uuid.UUID, sku string, cost decimal.Decimal) (*model.SupplierItem, error) {
+	if cost.IsNegative() {
+		return nil, ErrNegativeCost
+	}
+
+	si := &model.SupplierItem{
+		SupplierID:  supplierID,
+		ItemID:      itemID,
+		SupplierSKU: strings.TrimSpace(sku),
+		Cost:        cost,
+	}
+
+	if err := s.repository.SetSupplierItem(ctx, si); err != nil {
+		return nil, fmt.Errorf("set supplier item: %w", err)
+	}
+
+	return si, nil
+}
+
+// DeleteItem removes an item from the items a supplier sells.
+func (s *Service) DeleteItem(ctx context.Context, supplierID, itemID uuid.UUID) error {
+	if err := s.repository.DeleteSupplierItem(ctx, supplierID, itemID); err != nil {
+		return fmt.Errorf("delete supplier item: %w", err)
+	}
+
+	return nil
+}
+
+// GetItems retrieves the items a supplier sells with their SKUs and costs.
+func (s *Service) GetItems(ctx context.Context, supplierID uuid.UUID) ([]*model.SupplierItem, error) {
+	if _, err := s.repository.GetSupplierByID(ctx, supplierID); err != nil {
+		return nil, fmt.Errorf("get supplier by id: %w", err)
+	}
+
+	items, err := s.repository.GetSupplierItems(ctx, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("get supplier items: %w", err)
+	}
+
+	return items, nil
+}
+
+// normalize trims the name and contact details of a supplier.
+func normalize(supplier *model.Supplier) {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	supplier.ContactName = strings.TrimSpace(supplier.ContactName)
+	supplier.Email = strings.TrimSpace(supplier.Email)
+	supplier.Phone = strings.TrimSpace(supplier.Phone)
+}
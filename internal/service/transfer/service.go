@@ -0,0 +1,241 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+var (
+	ErrNoLines           = errors.New("transfer order must have at least one line")
+	ErrInvalidQuantity   = errors.New("transfer quantity must be positive")
+	ErrDuplicateItem     = errors.New("transfer order lists an item more than once")
+	ErrInvalidTransition = errors.New("transfer order cannot change to this status")
+)
+
+// Reasons recorded on the stock movements of transfer orders.
+const (
+	ReasonTransferOut    = "transfer_out"
+	ReasonTransferIn     = "transfer_in"
+	ReasonTransferReturn = "transfer_return"
+)
+
+// repository defines the interface for transfer order data access.
+type repository interface {
+	// CreateTransferOrder adds a draft transfer order with its lines.
+	CreateTransferOrder(ctx context.Context, o *model.TransferOrder) error
+
+	// GetTransferOrder retrieves a transfer order with its lines.
+	GetTransferOrder(ctx context.Context, orderID uuid.UUID) (*model.TransferOrder, error)
+
+	// LockTransferOrder retrieves a transfer order and locks it for the rest of the transaction.
+	LockTransferOrder(ctx context.Context, orderID uuid.UUID) (*model.TransferOrder, error)
+
+	// GetTransferOrders retrieves the transfer orders matching filter.
+	GetTransferOrders(ctx context.Context, filter model.TransferFilter) ([]*model.TransferOrder, error)
+
+	// SetTransferStatus moves a transfer order to a new status and records the change.
+	SetTransferStatus(ctx context.Context, o *model.TransferOrder, to model.TransferStatus) error
+
+	// GetTransferHistory retrieves the status changes of a transfer order.
+	GetTransferHistory(ctx context.Context, orderID uuid.UUID) ([]*model.TransferEvent, error)
+}
+
+// unitOfWork runs a group of repository calls in one transaction attributed to a user.
+type unitOfWork interface {
+	// Do runs fn in a transaction; repository calls must use the context passed to fn.
+	Do(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) error
+}
+
+// stock moves item stock; it is implemented by the item service.
+type stock interface {
+	// Transfer moves stock of an item at a place by a signed delta.
+	Transfer(ctx context.Context, userID, itemID uuid.UUID, place model.StockPlace, delta int, reason, reference string) (*model.StockMovement, error)
+}
+
+// Service provides business logic for transfer orders.
+type Service struct {
+	repository repository
+	uow        unitOfWork
+	stock      stock
+}
+
+// NewService creates a new transfer order service.
+func NewService(r repository, uow unitOfWork, s stock) *Service {
+	return &Service{
+		repository: r,
+		uow:        uow,
+		stock:      s,
+	}
+}
+
+// Create adds a draft transfer order. It does not move any stock yet.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, o *model.TransferOrder) (*model.TransferOrder, error) {
+	if len(o.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+
+	seen := make(map[uuid.UUID]bool, len(o.Lines))
+	for _, line := range o.Lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		if seen[line.ItemID] {
+			return nil, ErrDuplicateItem
+		}
+
+		seen[line.ItemID] = true
+	}
+
+	o.SourceLocation = normalizeLocation(o.SourceLocation)
+	o.DestinationLocation = normalizeLocation(o.DestinationLocation)
+	o.CreatedBy = &userID
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		return s.repository.CreateTransferOrder(ctx, o)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create transfer order: %w", err)
+	}
+
+	return o, nil
+}
+
+// GetByID retrieves a transfer order by its ID.
+func (s *Service) GetByID(ctx context.Context, orderID uuid.UUID) (*model.TransferOrder, error) {
+	o, err := s.repository.GetTransferOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get transfer order: %w", err)
+	}
+
+	return o, nil
+}
+
+// GetAll retrieves the transfer orders matching filter. Orders in transit hold the stock
+// that has left its source warehouse and not yet arrived at its destination.
+func (s *Service) GetAll(ctx context.Context, filter model.TransferFilter) ([]*model.TransferOrder, error) {
+	orders, err := s.repository.GetTransferOrders(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get transfer orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetHistory retrieves the status changes of a transfer order.
+func (s *Service) GetHistory(ctx context.Context, orderID uuid.UUID) ([]*model.TransferEvent, error) {
+	if _, err := s.repository.GetTransferOrder(ctx, orderID); err != nil {
+		return nil, fmt.Errorf("get transfer order: %w", err)
+	}
+
+	history, err := s.repository.GetTransferHistory(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("get transfer history: %w", err)
+	}
+
+	return history, nil
+}
+
+// Ship takes the stock of a draft order between warehouses out of the source warehouse.
+// The stock is in transit until the order is received or cancelled.
+func (s *Service) Ship(ctx context.Context, userID, orderID uuid.UUID) (*model.TransferOrder, error) {
+	return s.transition(ctx, userID, orderID, model.TransferInTransit)
+}
+
+// Receive brings the stock of an order to its destination. An order in transit only credits
+// the destination; a draft order, which is allowed only within a warehouse, is debited from
+// the source and credited to the destination in the same transaction.
+func (s *Service) Receive(ctx context.Context, userID, orderID uuid.UUID) (*model.TransferOrder, error) {
+	return s.transition(ctx, userID, orderID, model.TransferReceived)
+}
+
+// Cancel cancels a draft order or an order in transit, whose stock returns to the source.
+func (s *Service) Cancel(ctx context.Context, userID, orderID uuid.UUID) (*model.TransferOrder, error) {
+	return s.transition(ctx, userID, orderID, model.TransferCancelled)
+}
+
+// transition moves an order to status to together with the stock movements that go with
+// the change, all in one transaction. The order is locked first, so an order can never be
+// shipped, received or cancelled twice.
+func (s *Service) transition(ctx context.Context, userID, orderID uuid.UUID, to model.TransferStatus) (*model.TransferOrder, error) {
+	var order *model.TransferOrder
+
+	err := s.uow.Do(ctx, userID, func(ctx context.Context) error {
+		o, err := s.repository.LockTransferOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if !canTransition(o, to) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, o.Status, to)
+		}
+
+		reference := o.ID.String()
+
+		for _, line := range o.Lines {
+			var err error
+
+			switch {
+			case to == model.TransferInTransit, to == model.TransferReceived && o.Status == model.TransferDraft:
+				_, err = s.stock.Transfer(ctx, userID, line.ItemID, o.Source(), -line.Quantity, ReasonTransferOut, reference)
+			case to == model.TransferCancelled && o.Status == model.TransferInTransit:
+				_, err = s.stock.Transfer(ctx, userID, line.ItemID, o.Source(), line.Quantity, ReasonTransferReturn, reference)
+			}
+
+			if err != nil {
+				return fmt.Errorf("item %s: %w", line.ItemID, err)
+			}
+
+			if to == model.TransferReceived {
+				_, err = s.stock.Transfer(ctx, userID, line.ItemID, o.Destination(), line.Quantity, ReasonTransferIn, reference)
+				if err != nil {
+					return fmt.Errorf("item %s: %w", line.ItemID, err)
+				}
+			}
+		}
+
+		if err := s.repository.SetTransferStatus(ctx, o, to); err != nil {
+			return err
+		}
+
+		order = o
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("change transfer order to %s: %w", to, err)
+	}
+
+	return order, nil
+}
+
+// canTransition reports whether order o may change to status to. Orders between warehouses
+// go draft, in transit, received; orders within a warehouse go from draft to received directly.
+// Orders that have not been received can be cancelled.
+func canTransition(o *model.TransferOrder, to model.TransferStatus) bool {
+	switch o.Status {
+	case model.TransferDraft:
+		switch to {
+		case model.TransferInTransit:
+			return o.CrossSite()
+		case model.TransferReceived:
+			return !o.CrossSite()
+		case model.TransferCancelled:
+			return true
+		}
+	case model.TransferInTransit:
+		return to == model.TransferReceived || to == model.TransferCancelled
+	}
+
+	return false
+}
+
+// normalizeLocation trims a location code and converts it to upper case.
+func normalizeLocation(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
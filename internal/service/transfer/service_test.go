@@ -0,0 +1,220 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// fakeRepository keeps transfer orders in memory.
+type fakeRepository struct {
+	orders map[uuid.UUID]*model.TransferOrder
+}
+
+func (r *fakeRepository) CreateTransferOrder(_ context.Context, o *model.TransferOrder) error {
+	o.ID = uuid.New()
+	o.Status = model.TransferDraft
+	r.orders[o.ID] = o
+	return nil
+}
+
+func (r *fakeRepository) GetTransferOrder(_ context.Context, orderID uuid.UUID) (*model.TransferOrder, error) {
+	o, ok := r.orders[orderID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	copied := *o
+	return &copied, nil
+}
+
+func (r *fakeRepository) LockTransferOrder(ctx context.Context, orderID uuid.UUID) (*model.TransferOrder, error) {
+	return r.GetTransferOrder(ctx, orderID)
+}
+
+func (r *fakeRepository) GetTransferOrders(context.Context, model.TransferFilter) ([]*model.TransferOrder, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) SetTransferStatus(_ context.Context, o *model.TransferOrder, to model.TransferStatus) error {
+	o.Status = to
+	r.orders[o.ID].Status = to
+	return nil
+}
+
+func (r *fakeRepository) GetTransferHistory(context.Context, uuid.UUID) ([]*model.TransferEvent, error) {
+	return nil, nil
+}
+
+// fakeUnitOfWork runs fn without a transaction.
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, _ uuid.UUID, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// move is a stock movement recorded by fakeStock.
+type move struct {
+	place  model.StockPlace
+	delta  int
+	reason string
+}
+
+// fakeStock records the stock movements it is asked for.
+type fakeStock struct {
+	moves []move
+}
+
+func (s *fakeStock) Transfer(
+	_ context.Context, _, _ uuid.UUID, place model.StockPlace, delta int, reason, _ string,
+) (*model.StockMovement, error) {
+	s.moves = append(s.moves, move{place: place, delta: delta, reason: reason})
+	return &model.StockMovement{}, nil
+}
+
+func TestTransitions(t *testing.T) {
+	siteA, siteB := uuid.New(), uuid.New()
+	binA1 := model.StockPlace{WarehouseID: siteA, Location: "A-01"}
+	binA2 := model.StockPlace{WarehouseID: siteA, Location: "A-02"}
+	siteBPlace := model.StockPlace{WarehouseID: siteB}
+
+	tests := []struct {
+		name      string
+		from, to  model.StockPlace
+		steps     []model.TransferStatus
+		wantErr   bool
+		wantMoves []move
+	}{
+		{
+			name:  "between warehouses: ship then receive",
+			from:  binA1,
+			to:    siteBPlace,
+			steps: []model.TransferStatus{model.TransferInTransit, model.TransferReceived},
+			wantMoves: []move{
+				{place: binA1, delta: -5, reason: ReasonTransferOut},
+				{place: siteBPlace, delta: 5, reason: ReasonTransferIn},
+			},
+		},
+		{
+			name:  "between warehouses: cancel in transit returns stock",
+			from:  binA1,
+			to:    siteBPlace,
+			steps: []model.TransferStatus{model.TransferInTransit, model.TransferCancelled},
+			wantMoves: []move{
+				{place: binA1, delta: -5, reason: ReasonTransferOut},
+				{place: binA1, delta: 5, reason: ReasonTransferReturn},
+			},
+		},
+		{
+			name:    "between warehouses: cannot receive a draft",
+			from:    binA1,
+			to:      siteBPlace,
+			steps:   []model.TransferStatus{model.TransferReceived},
+			wantErr: true,
+		},
+		{
+			name:  "within a warehouse: receive a draft at once",
+			from:  binA1,
+			to:    binA2,
+			steps: []model.TransferStatus{model.TransferReceived},
+			wantMoves: []move{
+				{place: binA1, delta: -5, reason: ReasonTransferOut},
+				{place: binA2, delta: 5, reason: ReasonTransferIn},
+			},
+		},
+		{
+			name:    "within a warehouse: cannot ship",
+			from:    binA1,
+			to:      binA2,
+			steps:   []model.TransferStatus{model.TransferInTransit},
+			wantErr: true,
+		},
+		{
+			name:  "cancel a draft moves nothing",
+			from:  binA1,
+			to:    siteBPlace,
+			steps: []model.TransferStatus{model.TransferCancelled},
+		},
+		{
+			name:    "cannot cancel a received order",
+			from:    binA1,
+			to:      binA2,
+			steps:   []model.TransferStatus{model.TransferReceived, model.TransferCancelled},
+			wantErr: true,
+			wantMoves: []move{
+				{place: binA1, delta: -5, reason: ReasonTransferOut},
+				{place: binA2, delta: 5, reason: ReasonTransferIn},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &fakeRepository{orders: map[uuid.UUID]*model.TransferOrder{}}
+			stock := &fakeStock{}
+			s := NewService(repo, fakeUnitOfWork{}, stock)
+
+			o, err := s.Create(ctx, uuid.New(), &model.TransferOrder{
+				SourceWarehouseID:      tt.from.WarehouseID,
+				SourceLocation:         tt.from.Location,
+				DestinationWarehouseID: tt.to.WarehouseID,
+				DestinationLocation:    tt.to.Location,
+				Lines:                  []*model.TransferLine{{ItemID: uuid.New(), Quantity: 5}},
+			})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			for _, step := range tt.steps {
+				_, err = s.transition(ctx, uuid.New(), o.ID, step)
+				if err != nil {
+					break
+				}
+			}
+
+			if gotErr := errors.Is(err, ErrInvalidTransition); gotErr != tt.wantErr {
+				t.Fatalf("error = %v, want invalid transition: %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(stock.moves, tt.wantMoves) {
+				t.Errorf("moves = %+v, want %+v", stock.moves, tt.wantMoves)
+			}
+		})
+	}
+}
+
+func TestCreateValidation(t *testing.T) {
+	itemID := uuid.New()
+
+	tests := []struct {
+		name  string
+		lines []*model.TransferLine
+		want  error
+	}{
+		{name: "no lines", want: ErrNoLines},
+		{name: "zero quantity", lines: []*model.TransferLine{{ItemID: itemID}}, want: ErrInvalidQuantity},
+		{
+			name:  "duplicate item",
+			lines: []*model.TransferLine{{ItemID: itemID, Quantity: 1}, {ItemID: itemID, Quantity: 2}},
+			want:  ErrDuplicateItem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{orders: map[uuid.UUID]*model.TransferOrder{}}
+			s := NewService(repo, fakeUnitOfWork{}, &fakeStock{})
+
+			_, err := s.Create(context.Background(), uuid.New(), &model.TransferOrder{Lines: tt.lines})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Create() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
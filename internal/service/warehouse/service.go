@@ -0,0 +1,109 @@
+package warehouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/warehouse-control/internal/model"
+)
+
+// repository defines the interface for warehouse data access.
+type repository interface {
+	// CreateWarehouse adds a new warehouse and returns its ID.
+	CreateWarehouse(ctx context.Context, w *model.Warehouse) (uuid.UUID, error)
+
+	// GetWarehouseByID retrieves a warehouse by its ID.
+	GetWarehouseByID(ctx context.Context, warehouseID uuid.UUID) (*model.Warehouse, error)
+
+	// GetAllWarehouses retrieves all warehouses.
+	GetAllWarehouses(ctx context.Context) ([]*model.Warehouse, error)
+
+	// UpdateWarehouse updates the code, name and address of a warehouse.
+	UpdateWarehouse(ctx context.Context, w *model.Warehouse) error
+
+	// GetWarehouseStock retrieves the quantity of each item held in a warehouse.
+	GetWarehouseStock(ctx context.Context, warehouseID uuid.UUID) ([]*model.StockLevel, error)
+}
+
+// Service provides business logic for warehouses.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new warehouse service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create adds a new warehouse. Codes are stored in upper case.
+func (s *Service) Create(ctx context.Context, code, name, address string) (*model.Warehouse, error) {
+	w := &model.Warehouse{
+		Code:    normalizeCode(code),
+		Name:    name,
+		Address: address,
+	}
+
+	if _, err := s.repository.CreateWarehouse(ctx, w); err != nil {
+		return nil, fmt.Errorf("create warehouse: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetByID retrieves a warehouse by its ID.
+func (s *Service) GetByID(ctx context.Context, warehouseID uuid.UUID) (*model.Warehouse, error) {
+	w, err := s.repository.GetWarehouseByID(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("get warehouse by id: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetAll retrieves all warehouses.
+func (s *Service) GetAll(ctx context.Context) ([]*model.Warehouse, error) {
+	warehouses, err := s.repository.GetAllWarehouses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all warehouses: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+// Update changes the code, name and address of a warehouse.
+func (s *Service) Update(ctx context.Context, warehouseID uuid.UUID, code, name, address string) (*model.Warehouse, error) {
+	w := &model.Warehouse{
+		ID:      warehouseID,
+		Code:    normalizeCode(code),
+		Name:    name,
+		Address: address,
+	}
+
+	if err := s.repository.UpdateWarehouse(ctx, w); err != nil {
+		return nil, fmt.Errorf("update warehouse: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetItems retrieves the quantity of each item held in a warehouse.
+func (s *Service) GetItems(ctx context.Context, warehouseID uuid.UUID) ([]*model.StockLevel, error) {
+	if _, err := s.repository.GetWarehouseByID(ctx, warehouseID); err != nil {
+		return nil, fmt.Errorf("get warehouse by id: %w", err)
+	}
+
+	levels, err := s.repository.GetWarehouseStock(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("get warehouse stock: %w", err)
+	}
+
+	return levels, nil
+}
+
+// normalizeCode trims a warehouse code and converts it to upper case.
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE movement_type AS ENUM ('receive', 'issue', 'adjust', 'transfer');
+
+CREATE TABLE stock_movements
+(
+    id            UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    item_id       UUID          NOT NULL REFERENCES items (id) ON DELETE RESTRICT,
+    movement_type movement_type NOT NULL,
+    quantity      INT           NOT NULL CHECK (quantity <> 0),
+    balance_after INT           NOT NULL,
+    reason        TEXT          NOT NULL,
+    reference     TEXT,
+    created_by    UUID REFERENCES users (id), -- NULL for system generated rows
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_movements_item_id ON stock_movements (item_id, created_at);
+
+-- Record the current quantity of existing items as an opening balance so that
+-- items.quantity stays equal to the sum of its movements.
+INSERT INTO stock_movements (item_id, movement_type, quantity, balance_after, reason)
+SELECT id, 'adjust', quantity, quantity, 'opening_balance'
+FROM items
+WHERE quantity <> 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_movements;
+DROP TYPE movement_type;
+-- +goose StatementEnd
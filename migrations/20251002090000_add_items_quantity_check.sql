@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE items
+    ADD CONSTRAINT chk_items_quantity_non_negative CHECK (quantity >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS chk_items_quantity_non_negative;
+-- +goose StatementEnd
@@ -0,0 +1,114 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE item_history
+    ADD COLUMN actor_role TEXT,
+    ADD COLUMN request_id TEXT,
+    ADD COLUMN client_ip  TEXT,
+    ADD COLUMN diff       JSONB;
+
+-- item_history_diff returns the keys that differ between two JSON objects as
+-- {"key": {"old": ..., "new": ...}}. It must match audit.Diff in the application.
+CREATE OR REPLACE FUNCTION item_history_diff(old_data JSONB, new_data JSONB) RETURNS JSONB AS
+$$
+SELECT jsonb_object_agg(k.key, jsonb_build_object('old', old_data -> k.key, 'new', new_data -> k.key))
+FROM (SELECT jsonb_object_keys(COALESCE(old_data, '{}'::JSONB) || COALESCE(new_data, '{}'::JSONB)) AS key) k
+WHERE (old_data -> k.key) IS DISTINCT FROM (new_data -> k.key)
+$$ LANGUAGE sql IMMUTABLE;
+
+-- log_item_change writes a history row unless the application writes history itself
+-- (app.audit_mode = 'app').
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_insert() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(NEW.id, 'INSERT', NULL, to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_update() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(NEW.id, 'UPDATE', to_jsonb(OLD), to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_delete() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(OLD.id, 'DELETE', to_jsonb(OLD), NULL);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION log_item_insert() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO item_history(item_id, action, changed_by, old_data, new_data)
+    VALUES (NEW.id,
+            'INSERT',
+            current_setting('app.current_user_id')::UUID,
+            NULL,
+            to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_update() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO item_history(item_id, action, changed_by, old_data, new_data)
+    VALUES (NEW.id,
+            'UPDATE',
+            current_setting('app.current_user_id')::UUID,
+            to_jsonb(OLD),
+            to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_delete() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO item_history(item_id, action, changed_by, old_data, new_data)
+    VALUES (OLD.id,
+            'DELETE',
+            current_setting('app.current_user_id')::UUID,
+            to_jsonb(OLD),
+            NULL);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS log_item_change(UUID, item_action, JSONB, JSONB);
+DROP FUNCTION IF EXISTS item_history_diff(JSONB, JSONB);
+
+ALTER TABLE item_history
+    DROP COLUMN IF EXISTS actor_role,
+    DROP COLUMN IF EXISTS request_id,
+    DROP COLUMN IF EXISTS client_ip,
+    DROP COLUMN IF EXISTS diff;
+-- +goose StatementEnd
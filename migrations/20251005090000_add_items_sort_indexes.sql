@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Keyset pagination orders by (column, id), one index per sortable column.
+CREATE INDEX idx_items_name_id ON items (name, id);
+CREATE INDEX idx_items_quantity_id ON items (quantity, id);
+CREATE INDEX idx_items_price_id ON items (price, id);
+CREATE INDEX idx_items_created_at_id ON items (created_at, id);
+CREATE INDEX idx_items_updated_at_id ON items (updated_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_name_id;
+DROP INDEX IF EXISTS idx_items_quantity_id;
+DROP INDEX IF EXISTS idx_items_price_id;
+DROP INDEX IF EXISTS idx_items_created_at_id;
+DROP INDEX IF EXISTS idx_items_updated_at_id;
+-- +goose StatementEnd
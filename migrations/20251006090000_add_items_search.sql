@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The 'simple' configuration does not stem, which suits product names in any language.
+ALTER TABLE items
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+        ) STORED;
+
+CREATE INDEX idx_items_search_vector ON items USING GIN (search_vector);
+CREATE INDEX idx_items_name_trgm ON items USING GIN (name gin_trgm_ops);
+
+-- search_vector is derived from name and description, keep it out of item history.
+CREATE OR REPLACE FUNCTION log_item_insert() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(NEW.id, 'INSERT', NULL, to_jsonb(NEW) - 'search_vector');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_update() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(NEW.id, 'UPDATE', to_jsonb(OLD) - 'search_vector', to_jsonb(NEW) - 'search_vector');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_delete() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(OLD.id, 'DELETE', to_jsonb(OLD) - 'search_vector', NULL);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION log_item_insert() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(NEW.id, 'INSERT', NULL, to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_update() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(NEW.id, 'UPDATE', to_jsonb(OLD), to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION log_item_delete() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM log_item_change(OLD.id, 'DELETE', to_jsonb(OLD), NULL);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_items_name_trgm;
+DROP INDEX IF EXISTS idx_items_search_vector;
+DROP EXTENSION IF EXISTS pg_trgm;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- barcodes holds the item's GTINs (normalized to 14 digits) so that changes appear in item_history;
+-- item_barcodes indexes them for lookup and keeps every barcode unique across items.
+ALTER TABLE items
+    ADD COLUMN sku      TEXT UNIQUE,
+    ADD COLUMN barcodes TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE item_barcodes
+(
+    code    TEXT PRIMARY KEY,
+    item_id UUID NOT NULL REFERENCES items (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_item_barcodes_item_id ON item_barcodes (item_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS item_barcodes;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS sku,
+    DROP COLUMN IF EXISTS barcodes;
+-- +goose StatementEnd
@@ -0,0 +1,110 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE warehouses
+(
+    id         UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    code       TEXT    NOT NULL UNIQUE,
+    name       TEXT    NOT NULL,
+    address    TEXT,
+    is_default BOOLEAN NOT NULL         DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one warehouse receives stock that is not assigned to a warehouse explicitly.
+CREATE UNIQUE INDEX idx_warehouses_default ON warehouses (is_default) WHERE is_default;
+
+INSERT INTO warehouses (code, name, is_default)
+VALUES ('MAIN', 'Main warehouse', TRUE);
+
+-- item_stock holds the quantity of an item per warehouse; items.quantity is their sum.
+CREATE TABLE item_stock
+(
+    item_id      UUID NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses (id),
+    quantity     INT  NOT NULL            DEFAULT 0,
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (item_id, warehouse_id),
+    CONSTRAINT chk_item_stock_quantity_non_negative CHECK (quantity >= 0)
+);
+
+CREATE INDEX idx_item_stock_warehouse_id ON item_stock (warehouse_id);
+
+-- Existing stock and movements belong to the default warehouse.
+INSERT INTO item_stock (item_id, warehouse_id, quantity)
+SELECT i.id, w.id, i.quantity
+FROM items i,
+     warehouses w
+WHERE w.is_default
+  AND i.quantity <> 0;
+
+ALTER TABLE stock_movements
+    ADD COLUMN warehouse_id UUID REFERENCES warehouses (id);
+
+UPDATE stock_movements
+SET warehouse_id = (SELECT id FROM warehouses WHERE is_default);
+
+ALTER TABLE stock_movements
+    ALTER COLUMN warehouse_id SET NOT NULL;
+
+CREATE INDEX idx_stock_movements_warehouse_id ON stock_movements (warehouse_id, created_at);
+
+-- The warehouse whose stock a change affected, NULL for changes that do not touch stock.
+ALTER TABLE item_history
+    ADD COLUMN warehouse_id UUID REFERENCES warehouses (id);
+
+-- The repository sets app.warehouse_id before writing stock.
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id,
+                             old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE item_history
+    DROP COLUMN IF EXISTS warehouse_id;
+
+ALTER TABLE stock_movements
+    DROP COLUMN IF EXISTS warehouse_id;
+
+DROP TABLE IF EXISTS item_stock;
+DROP TABLE IF EXISTS warehouses;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE location_kind AS ENUM ('zone', 'aisle', 'rack', 'bin');
+
+-- locations form a tree per warehouse: zones contain aisles, aisles racks and racks bins.
+-- code is the full code of a location, e.g. A-03-R2-B05, unique within its warehouse.
+CREATE TABLE locations
+(
+    id           UUID PRIMARY KEY              DEFAULT gen_random_uuid(),
+    warehouse_id UUID          NOT NULL REFERENCES warehouses (id),
+    parent_id    UUID REFERENCES locations (id),
+    kind         location_kind NOT NULL,
+    code         TEXT          NOT NULL,
+    name         TEXT,
+    created_at   TIMESTAMP WITH TIME ZONE      DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE      DEFAULT NOW(),
+    CONSTRAINT uq_locations_warehouse_code UNIQUE (warehouse_id, code)
+);
+
+CREATE INDEX idx_locations_parent_id ON locations (parent_id);
+
+-- location_stock holds the quantity of an item per bin. The stock of a warehouse that is
+-- not in any bin is item_stock.quantity minus the sum over its bins.
+CREATE TABLE location_stock
+(
+    item_id     UUID NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    location_id UUID NOT NULL REFERENCES locations (id) ON DELETE CASCADE,
+    quantity    INT  NOT NULL            DEFAULT 0,
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (item_id, location_id),
+    CONSTRAINT chk_location_stock_quantity_non_negative CHECK (quantity >= 0)
+);
+
+CREATE INDEX idx_location_stock_location_id ON location_stock (location_id);
+
+ALTER TABLE stock_movements
+    ADD COLUMN location_id UUID REFERENCES locations (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_movements
+    DROP COLUMN IF EXISTS location_id;
+
+DROP TABLE IF EXISTS location_stock;
+DROP TABLE IF EXISTS locations;
+DROP TYPE IF EXISTS location_kind;
+-- +goose StatementEnd
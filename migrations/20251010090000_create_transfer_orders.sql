@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE transfer_status AS ENUM ('draft', 'in_transit', 'received', 'cancelled');
+
+-- transfer_orders move stock of one or more items from a source to a destination: a warehouse
+-- and optionally a bin in it. Moves within a warehouse are received in one step; moves between
+-- warehouses are shipped first and the stock is in transit until they are received.
+CREATE TABLE transfer_orders
+(
+    id                       UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    source_warehouse_id      UUID            NOT NULL REFERENCES warehouses (id),
+    source_location_id       UUID REFERENCES locations (id),
+    destination_warehouse_id UUID            NOT NULL REFERENCES warehouses (id),
+    destination_location_id  UUID REFERENCES locations (id),
+    status                   transfer_status NOT NULL DEFAULT 'draft',
+    note                     TEXT,
+    created_by               UUID REFERENCES users (id),
+    created_at               TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at               TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    shipped_at               TIMESTAMP WITH TIME ZONE,
+    received_at              TIMESTAMP WITH TIME ZONE,
+    cancelled_at             TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_transfer_orders_distinct_places CHECK (
+        source_warehouse_id <> destination_warehouse_id
+            OR source_location_id IS DISTINCT FROM destination_location_id
+        )
+);
+
+CREATE INDEX idx_transfer_orders_status ON transfer_orders (status, created_at);
+
+CREATE TABLE transfer_order_lines
+(
+    transfer_order_id UUID NOT NULL REFERENCES transfer_orders (id) ON DELETE CASCADE,
+    item_id           UUID NOT NULL REFERENCES items (id),
+    quantity          INT  NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (transfer_order_id, item_id)
+);
+
+CREATE INDEX idx_transfer_order_lines_item_id ON transfer_order_lines (item_id);
+
+-- transfer_order_history records every status change of a transfer order, attributed like
+-- item_history to the user, role, request and client IP set for the transaction.
+-- from_status is NULL for the creation of the order.
+CREATE TABLE transfer_order_history
+(
+    id                UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    transfer_order_id UUID            NOT NULL REFERENCES transfer_orders (id) ON DELETE CASCADE,
+    from_status       transfer_status,
+    to_status         transfer_status NOT NULL,
+    changed_by        UUID            NOT NULL REFERENCES users (id),
+    changed_at        TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    actor_role        TEXT,
+    request_id        TEXT,
+    client_ip         TEXT
+);
+
+CREATE INDEX idx_transfer_order_history_order_id ON transfer_order_history (transfer_order_id, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transfer_order_history;
+DROP TABLE IF EXISTS transfer_order_lines;
+DROP TABLE IF EXISTS transfer_orders;
+DROP TYPE IF EXISTS transfer_status;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- lots identify batches of an item, e.g. one production run, with an optional expiry date.
+CREATE TABLE lots
+(
+    id         UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    item_id    UUID NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    number     TEXT NOT NULL,
+    expires_on DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT uq_lots_item_number UNIQUE (item_id, number)
+);
+
+CREATE INDEX idx_lots_expires_on ON lots (expires_on) WHERE expires_on IS NOT NULL;
+
+-- lot_stock holds the quantity of a lot per warehouse. Like bins, lots partition the stock
+-- of a warehouse: the stock in no lot is item_stock.quantity minus the sum over its lots.
+CREATE TABLE lot_stock
+(
+    lot_id       UUID NOT NULL REFERENCES lots (id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses (id),
+    quantity     INT  NOT NULL            DEFAULT 0,
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (lot_id, warehouse_id),
+    CONSTRAINT chk_lot_stock_quantity_non_negative CHECK (quantity >= 0)
+);
+
+CREATE INDEX idx_lot_stock_warehouse_id ON lot_stock (warehouse_id);
+
+-- expiry_override marks issues from an expired lot that an admin allowed.
+ALTER TABLE stock_movements
+    ADD COLUMN lot_id          UUID REFERENCES lots (id) ON DELETE SET NULL,
+    ADD COLUMN expiry_override BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_movements
+    DROP COLUMN IF EXISTS expiry_override,
+    DROP COLUMN IF EXISTS lot_id;
+
+DROP TABLE IF EXISTS lot_stock;
+DROP TABLE IF EXISTS lots;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- serialized items are tracked unit by unit: every movement names the serials it moves.
+ALTER TABLE items
+    ADD COLUMN serialized BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TYPE serial_status AS ENUM ('in_stock', 'issued', 'returned', 'scrapped');
+
+-- serials registers the units of serialized items. A unit is on hand while it has a warehouse;
+-- location_id is its bin, if any. Units in transit between warehouses keep their status
+-- but have no warehouse.
+CREATE TABLE serials
+(
+    id           UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    item_id      UUID          NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    serial       TEXT          NOT NULL UNIQUE,
+    status       serial_status NOT NULL   DEFAULT 'in_stock',
+    warehouse_id UUID REFERENCES warehouses (id),
+    location_id  UUID REFERENCES locations (id),
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_serials_item_id ON serials (item_id);
+
+-- movement_serials records which units each movement moved and the status they were left in.
+CREATE TABLE movement_serials
+(
+    movement_id UUID          NOT NULL REFERENCES stock_movements (id) ON DELETE CASCADE,
+    serial_id   UUID          NOT NULL REFERENCES serials (id) ON DELETE CASCADE,
+    status      serial_status NOT NULL,
+    PRIMARY KEY (movement_id, serial_id)
+);
+
+CREATE INDEX idx_movement_serials_serial_id ON movement_serials (serial_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS movement_serials;
+DROP TABLE IF EXISTS serials;
+DROP TYPE IF EXISTS serial_status;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS serialized;
+-- +goose StatementEnd
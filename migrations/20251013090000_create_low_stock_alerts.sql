@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An item is low on stock when its quantity is at or below its reorder point; 0 disables alerts.
+-- reorder_quantity is how much to order when that happens.
+ALTER TABLE items
+    ADD COLUMN reorder_point    INT NOT NULL DEFAULT 0,
+    ADD COLUMN reorder_quantity INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_items_reorder_non_negative CHECK (reorder_point >= 0 AND reorder_quantity >= 0);
+
+-- low_stock_alerts records every time an item fell to its reorder point. An alert stays open
+-- until the item's quantity rises above the reorder point again; acknowledging it only records
+-- that someone is taking care of it.
+CREATE TABLE low_stock_alerts
+(
+    id               UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    item_id          UUID NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    quantity         INT  NOT NULL, -- the item's quantity when the alert was raised
+    reorder_point    INT  NOT NULL,
+    reorder_quantity INT  NOT NULL,
+    created_at       TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    acknowledged_by  UUID REFERENCES users (id),
+    acknowledged_at  TIMESTAMP WITH TIME ZONE,
+    resolved_at      TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one open alert per item, so repeated checks never raise duplicates.
+CREATE UNIQUE INDEX uq_low_stock_alerts_open_item ON low_stock_alerts (item_id) WHERE resolved_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS low_stock_alerts;
+
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS chk_items_reorder_non_negative,
+    DROP COLUMN IF EXISTS reorder_quantity,
+    DROP COLUMN IF EXISTS reorder_point;
+-- +goose StatementEnd
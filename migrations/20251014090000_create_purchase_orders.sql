@@ -0,0 +1,130 @@
+-- +goose Up
+-- +goose StatementBegin
+-- suppliers are the companies items are bought from. lead_time_days is how long they usually
+-- take to deliver an order and sets the expected date of orders that do not give one.
+CREATE TABLE suppliers
+(
+    id             UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    name           TEXT NOT NULL UNIQUE,
+    contact_name   TEXT,
+    email          TEXT,
+    phone          TEXT,
+    lead_time_days INT  NOT NULL            DEFAULT 0 CHECK (lead_time_days >= 0),
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- supplier_items lists the items a supplier sells with the supplier's own SKU and unit cost.
+CREATE TABLE supplier_items
+(
+    supplier_id  UUID           NOT NULL REFERENCES suppliers (id) ON DELETE CASCADE,
+    item_id      UUID           NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    supplier_sku TEXT,
+    cost         NUMERIC(12, 2) NOT NULL CHECK (cost >= 0),
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (supplier_id, item_id)
+);
+
+CREATE INDEX idx_supplier_items_item_id ON supplier_items (item_id);
+
+CREATE TYPE purchase_status AS ENUM ('draft', 'ordered', 'partially_received', 'received', 'cancelled');
+
+-- purchase_orders order items from a supplier for delivery to a warehouse and optionally a bin
+-- in it. Receiving an order adds the received quantities to stock with receipt movements that
+-- reference the order.
+CREATE TABLE purchase_orders
+(
+    id           UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    supplier_id  UUID            NOT NULL REFERENCES suppliers (id),
+    warehouse_id UUID            NOT NULL REFERENCES warehouses (id),
+    location_id  UUID REFERENCES locations (id),
+    status       purchase_status NOT NULL DEFAULT 'draft',
+    expected_on  DATE            NOT NULL,
+    note         TEXT,
+    created_by   UUID REFERENCES users (id),
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    ordered_at   TIMESTAMP WITH TIME ZONE,
+    received_at  TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_purchase_orders_status ON purchase_orders (status, expected_on);
+CREATE INDEX idx_purchase_orders_supplier_id ON purchase_orders (supplier_id, created_at);
+
+CREATE TABLE purchase_order_lines
+(
+    purchase_order_id UUID           NOT NULL REFERENCES purchase_orders (id) ON DELETE CASCADE,
+    item_id           UUID           NOT NULL REFERENCES items (id),
+    quantity          INT            NOT NULL CHECK (quantity > 0),
+    received_quantity INT            NOT NULL DEFAULT 0,
+    unit_cost         NUMERIC(12, 2) NOT NULL CHECK (unit_cost >= 0),
+    PRIMARY KEY (purchase_order_id, item_id),
+    CONSTRAINT chk_purchase_order_lines_received CHECK (received_quantity BETWEEN 0 AND quantity)
+);
+
+CREATE INDEX idx_purchase_order_lines_item_id ON purchase_order_lines (item_id);
+
+-- The document a change was made for, such as a purchase or transfer order, taken like the
+-- warehouse from the reference of the stock movement behind it.
+ALTER TABLE item_history
+    ADD COLUMN reference TEXT;
+
+-- The repository sets app.reference together with app.warehouse_id before writing stock.
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id, reference,
+                             old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+            NULLIF(current_setting('app.reference', true), ''),
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id,
+                             old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE item_history
+    DROP COLUMN IF EXISTS reference;
+
+DROP TABLE IF EXISTS purchase_order_lines;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TYPE IF EXISTS purchase_status;
+DROP TABLE IF EXISTS supplier_items;
+DROP TABLE IF EXISTS suppliers;
+-- +goose StatementEnd
@@ -0,0 +1,117 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE receipt_status AS ENUM ('draft', 'posted');
+
+-- goods_receipts record what a delivery actually contained against a purchase order or an advance
+-- shipping notice. Posting a receipt adds the accepted quantities to stock and records the
+-- discrepancies; posted receipts never change and are undone by a reversal, a posted receipt
+-- that points to the receipt it reverses.
+CREATE TABLE goods_receipts
+(
+    id                UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    purchase_order_id UUID REFERENCES purchase_orders (id),
+    asn_number        TEXT,
+    warehouse_id      UUID           NOT NULL REFERENCES warehouses (id),
+    location_id       UUID REFERENCES locations (id),
+    status            receipt_status NOT NULL DEFAULT 'draft',
+    reverses_id       UUID UNIQUE REFERENCES goods_receipts (id),
+    note              TEXT,
+    created_by        UUID REFERENCES users (id),
+    created_at        TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at        TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    posted_by         UUID REFERENCES users (id),
+    posted_at         TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_goods_receipts_source CHECK (purchase_order_id IS NOT NULL OR asn_number IS NOT NULL)
+);
+
+CREATE INDEX idx_goods_receipts_purchase_order_id ON goods_receipts (purchase_order_id);
+CREATE INDEX idx_goods_receipts_status ON goods_receipts (status, created_at);
+
+-- received_quantity is everything that arrived, damaged units included; only the rest is accepted
+-- into stock. purchase_quantity is the part of it credited to the purchase order's line on posting.
+CREATE TABLE goods_receipt_lines
+(
+    receipt_id        UUID   NOT NULL REFERENCES goods_receipts (id) ON DELETE CASCADE,
+    item_id           UUID   NOT NULL REFERENCES items (id),
+    expected_quantity INT    NOT NULL CHECK (expected_quantity >= 0),
+    received_quantity INT    NOT NULL CHECK (received_quantity >= 0),
+    damaged_quantity  INT    NOT NULL DEFAULT 0,
+    purchase_quantity INT    NOT NULL DEFAULT 0 CHECK (purchase_quantity >= 0),
+    lot               TEXT,
+    serials           TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (receipt_id, item_id),
+    CONSTRAINT chk_goods_receipt_lines_damaged CHECK (damaged_quantity BETWEEN 0 AND received_quantity)
+);
+
+CREATE INDEX idx_goods_receipt_lines_item_id ON goods_receipt_lines (item_id);
+
+CREATE TYPE discrepancy_kind AS ENUM ('over', 'short', 'damaged');
+
+-- receipt_discrepancies are the differences a posted receipt found between what was expected and
+-- what arrived. They stay open until a manager resolves them, e.g. after a claim with the supplier.
+CREATE TABLE receipt_discrepancies
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    receipt_id  UUID             NOT NULL REFERENCES goods_receipts (id),
+    item_id     UUID             NOT NULL REFERENCES items (id),
+    kind        discrepancy_kind NOT NULL,
+    quantity    INT              NOT NULL CHECK (quantity > 0),
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    resolution  TEXT,
+    resolved_by UUID REFERENCES users (id),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_receipt_discrepancies_receipt_id ON receipt_discrepancies (receipt_id);
+CREATE INDEX idx_receipt_discrepancies_open ON receipt_discrepancies (created_at) WHERE resolved_at IS NULL;
+
+-- Posted receipts and their lines are immutable, whatever writes to them.
+CREATE OR REPLACE FUNCTION reject_posted_receipt_change() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF OLD.status = 'posted' THEN
+        RAISE EXCEPTION 'goods receipt % is posted and cannot be changed', OLD.id;
+    END IF;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_goods_receipts_immutable
+    BEFORE UPDATE OR DELETE
+    ON goods_receipts
+    FOR EACH ROW
+EXECUTE FUNCTION reject_posted_receipt_change();
+
+CREATE OR REPLACE FUNCTION reject_posted_receipt_line_change() RETURNS TRIGGER AS
+$$
+DECLARE
+    v_receipt_id UUID := CASE WHEN TG_OP = 'DELETE' THEN OLD.receipt_id ELSE NEW.receipt_id END;
+BEGIN
+    IF (SELECT status FROM goods_receipts WHERE id = v_receipt_id) = 'posted' THEN
+        RAISE EXCEPTION 'goods receipt % is posted and cannot be changed', v_receipt_id;
+    END IF;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_goods_receipt_lines_immutable
+    BEFORE INSERT OR UPDATE OR DELETE
+    ON goods_receipt_lines
+    FOR EACH ROW
+EXECUTE FUNCTION reject_posted_receipt_line_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_goods_receipt_lines_immutable ON goods_receipt_lines;
+DROP TRIGGER IF EXISTS trg_goods_receipts_immutable ON goods_receipts;
+DROP FUNCTION IF EXISTS reject_posted_receipt_line_change();
+DROP FUNCTION IF EXISTS reject_posted_receipt_change();
+DROP TABLE IF EXISTS receipt_discrepancies;
+DROP TYPE IF EXISTS discrepancy_kind;
+DROP TABLE IF EXISTS goods_receipt_lines;
+DROP TABLE IF EXISTS goods_receipts;
+DROP TYPE IF EXISTS receipt_status;
+-- +goose StatementEnd
@@ -0,0 +1,71 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The part of a warehouse's stock that is allocated to orders and no longer available to promise.
+ALTER TABLE item_stock
+    ADD COLUMN reserved INT NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+
+CREATE TYPE sales_status AS ENUM ('draft', 'partially_allocated', 'allocated', 'picking', 'packed', 'shipped', 'cancelled');
+
+-- sales_orders ship items from a warehouse to a customer. Allocating an order reserves stock for
+-- its lines; picking plans where in the warehouse the allocated stock is taken from, and shipping
+-- issues it with movements that reference the order.
+CREATE TABLE sales_orders
+(
+    id           UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    customer     TEXT         NOT NULL,
+    ship_to      TEXT,
+    warehouse_id UUID         NOT NULL REFERENCES warehouses (id),
+    status       sales_status NOT NULL DEFAULT 'draft',
+    note         TEXT,
+    created_by   UUID REFERENCES users (id),
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    allocated_at TIMESTAMP WITH TIME ZONE,
+    picked_at    TIMESTAMP WITH TIME ZONE,
+    packed_at    TIMESTAMP WITH TIME ZONE,
+    shipped_at   TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_sales_orders_status ON sales_orders (status, created_at);
+
+-- allocated_quantity is the part of the line reserved in the order's warehouse.
+CREATE TABLE sales_order_lines
+(
+    sales_order_id     UUID           NOT NULL REFERENCES sales_orders (id) ON DELETE CASCADE,
+    item_id            UUID           NOT NULL REFERENCES items (id),
+    quantity           INT            NOT NULL CHECK (quantity > 0),
+    allocated_quantity INT            NOT NULL DEFAULT 0,
+    unit_price         NUMERIC(12, 2) NOT NULL CHECK (unit_price >= 0),
+    PRIMARY KEY (sales_order_id, item_id),
+    CONSTRAINT chk_sales_order_lines_allocated CHECK (allocated_quantity BETWEEN 0 AND quantity)
+);
+
+CREATE INDEX idx_sales_order_lines_item_id ON sales_order_lines (item_id);
+
+-- sales_pick_lines are the pick list of an order: how much of an item to take from a bin and a
+-- lot of the order's warehouse. A NULL bin or lot stands for the stock in no bin or no lot.
+CREATE TABLE sales_pick_lines
+(
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sales_order_id UUID NOT NULL REFERENCES sales_orders (id) ON DELETE CASCADE,
+    item_id        UUID NOT NULL REFERENCES items (id),
+    location_id    UUID REFERENCES locations (id),
+    lot_id         UUID REFERENCES lots (id),
+    quantity       INT  NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_sales_pick_lines_sales_order_id ON sales_pick_lines (sales_order_id);
+CREATE INDEX idx_sales_pick_lines_item_id ON sales_pick_lines (item_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sales_pick_lines;
+DROP TABLE IF EXISTS sales_order_lines;
+DROP TABLE IF EXISTS sales_orders;
+DROP TYPE IF EXISTS sales_status;
+
+ALTER TABLE item_stock
+    DROP COLUMN IF EXISTS reserved;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE reservation_status AS ENUM ('active', 'released', 'expired');
+
+-- stock_reservations hold stock of a warehouse for an owner, such as a customer, until they
+-- expire. An active reservation counts towards item_stock.reserved like a sales allocation;
+-- releasing or expiring it gives the stock back.
+CREATE TABLE stock_reservations
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    item_id      UUID                     NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    warehouse_id UUID                     NOT NULL REFERENCES warehouses (id),
+    owner        TEXT                     NOT NULL,
+    quantity     INT                      NOT NULL CHECK (quantity > 0),
+    status       reservation_status       NOT NULL DEFAULT 'active',
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by   UUID REFERENCES users (id),
+    created_at   TIMESTAMP WITH TIME ZONE          DEFAULT NOW(),
+    released_by  UUID REFERENCES users (id),
+    released_at  TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_stock_reservations_item_id ON stock_reservations (item_id, created_at);
+CREATE INDEX idx_stock_reservations_expires_at ON stock_reservations (expires_at) WHERE status = 'active';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_reservations;
+DROP TYPE IF EXISTS reservation_status;
+-- +goose StatementEnd
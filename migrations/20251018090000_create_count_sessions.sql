@@ -0,0 +1,103 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE count_status AS ENUM ('counting', 'review', 'approved', 'cancelled');
+
+-- count_sessions are stocktakes of part of a warehouse. Approving a session posts the variances
+-- of its lines as adjust movements whose reason and reference are the session's ID.
+CREATE TABLE count_sessions
+(
+    id           UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    warehouse_id UUID         NOT NULL REFERENCES warehouses (id),
+    status       count_status NOT NULL DEFAULT 'counting',
+    note         TEXT,
+    created_by   UUID REFERENCES users (id),
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    submitted_at TIMESTAMP WITH TIME ZONE,
+    approved_by  UUID REFERENCES users (id),
+    approved_at  TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_count_sessions_status ON count_sessions (status, created_at);
+
+-- count_lines are the places a session counts: the stock of an item in a bin, or outside any
+-- bin if location_id is NULL. expected is the quantity there when the session was created and
+-- counted what was found, NULL until it has been counted.
+CREATE TABLE count_lines
+(
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id  UUID NOT NULL REFERENCES count_sessions (id) ON DELETE CASCADE,
+    item_id     UUID NOT NULL REFERENCES items (id),
+    location_id UUID REFERENCES locations (id),
+    expected    INT  NOT NULL,
+    counted     INT CHECK (counted >= 0),
+    counted_by  UUID REFERENCES users (id),
+    counted_at  TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_count_lines_session_id ON count_lines (session_id);
+CREATE INDEX idx_count_lines_item_id ON count_lines (item_id);
+
+-- Why a change was made, taken like the reference from the stock movement behind it.
+ALTER TABLE item_history
+    ADD COLUMN reason TEXT;
+
+-- The repository sets app.reason together with app.warehouse_id and app.reference before writing stock.
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id, reason,
+                             reference, old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+            NULLIF(current_setting('app.reason', true), ''),
+            NULLIF(current_setting('app.reference', true), ''),
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id, reference,
+                             old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+            NULLIF(current_setting('app.reference', true), ''),
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE item_history
+    DROP COLUMN IF EXISTS reason;
+
+DROP TABLE IF EXISTS count_lines;
+DROP TABLE IF EXISTS count_sessions;
+DROP TYPE IF EXISTS count_status;
+-- +goose StatementEnd
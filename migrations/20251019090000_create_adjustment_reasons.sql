@@ -0,0 +1,92 @@
+-- +goose Up
+-- +goose StatementBegin
+-- adjustment_reasons is the catalogue of codes explaining adjustments of stock, such as damage
+-- or theft. Codes are never deleted, as movements refer to them; inactive codes can no longer
+-- be used.
+CREATE TABLE adjustment_reasons
+(
+    code       TEXT PRIMARY KEY CHECK (code ~ '^[a-z][a-z0-9_]*$'),
+    name       TEXT    NOT NULL,
+    active     BOOLEAN NOT NULL         DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO adjustment_reasons (code, name)
+VALUES ('damage', 'Damaged'),
+       ('theft', 'Stolen'),
+       ('found', 'Found'),
+       ('expiry', 'Expired'),
+       ('sample', 'Taken as a sample');
+
+-- Every adjustment made by a user names a reason code; receipts, shipments, transfers and
+-- reversals of receipts do not.
+ALTER TABLE stock_movements
+    ADD COLUMN reason_code TEXT REFERENCES adjustment_reasons (code);
+
+CREATE INDEX idx_stock_movements_reason_code ON stock_movements (reason_code, created_at) WHERE reason_code IS NOT NULL;
+
+ALTER TABLE item_history
+    ADD COLUMN reason_code TEXT;
+
+-- The repository sets app.reason_code together with app.reason before writing stock.
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id, reason,
+                             reason_code, reference, old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+            NULLIF(current_setting('app.reason', true), ''),
+            NULLIF(current_setting('app.reason_code', true), ''),
+            NULLIF(current_setting('app.reference', true), ''),
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION log_item_change(p_item_id UUID, p_action item_action, p_old JSONB, p_new JSONB) RETURNS VOID AS
+$$
+BEGIN
+    IF current_setting('app.audit_mode', true) = 'app' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO item_history(item_id, action, changed_by, actor_role, request_id, client_ip, warehouse_id, reason,
+                             reference, old_data, new_data, diff)
+    VALUES (p_item_id,
+            p_action,
+            current_setting('app.current_user_id')::UUID,
+            NULLIF(current_setting('app.current_role', true), ''),
+            NULLIF(current_setting('app.request_id', true), ''),
+            NULLIF(current_setting('app.client_ip', true), ''),
+            NULLIF(current_setting('app.warehouse_id', true), '')::UUID,
+            NULLIF(current_setting('app.reason', true), ''),
+            NULLIF(current_setting('app.reference', true), ''),
+            p_old,
+            p_new,
+            item_history_diff(p_old, p_new));
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE item_history
+    DROP COLUMN IF EXISTS reason_code;
+
+ALTER TABLE stock_movements
+    DROP COLUMN IF EXISTS reason_code;
+
+DROP TABLE IF EXISTS adjustment_reasons;
+-- +goose StatementEnd
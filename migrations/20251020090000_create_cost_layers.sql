@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+-- costing_method decides which stock an issue takes its cost from: the oldest receipts (fifo),
+-- the newest (lifo) or the average of all stock held (average).
+ALTER TABLE items
+    ADD COLUMN costing_method TEXT NOT NULL DEFAULT 'fifo' CHECK (costing_method IN ('fifo', 'lifo', 'average'));
+
+-- cost is the value of the stock a movement adds or removes, signed like its quantity; for
+-- issues it is the cost of the goods issued. Transfers move stock the company keeps owning and
+-- carry no cost, so an item's value is the sum of the cost of its movements.
+ALTER TABLE stock_movements
+    ADD COLUMN cost NUMERIC(14, 2) NOT NULL DEFAULT 0;
+
+UPDATE stock_movements m
+SET cost = m.quantity * i.price
+FROM items i
+WHERE i.id = m.item_id
+  AND m.movement_type <> 'transfer';
+
+-- cost_layers is the stock added at one unit cost and what remains of it. Every movement that
+-- adds stock, other than a transfer, opens a layer and every one that removes stock consumes
+-- layers, so that the remaining quantities always add up to the stock the item owns.
+CREATE TABLE cost_layers
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    item_id     UUID           NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    movement_id UUID REFERENCES stock_movements (id) ON DELETE CASCADE, -- NULL for opening balances
+    unit_cost   NUMERIC(12, 2) NOT NULL CHECK (unit_cost >= 0),
+    quantity    INT            NOT NULL CHECK (quantity > 0),
+    remaining   INT            NOT NULL CHECK (remaining >= 0 AND remaining <= quantity),
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_cost_layers_open ON cost_layers (item_id, created_at) WHERE remaining > 0;
+
+-- The stock items already own opens a layer at their price.
+INSERT INTO cost_layers (item_id, unit_cost, quantity, remaining)
+SELECT i.id, GREATEST(i.price, 0), s.owned, s.owned
+FROM items i
+JOIN (
+    SELECT item_id, SUM(quantity) AS owned
+    FROM stock_movements
+    WHERE movement_type <> 'transfer'
+    GROUP BY item_id
+) s ON s.item_id = i.id
+WHERE s.owned > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS cost_layers;
+
+ALTER TABLE stock_movements
+    DROP COLUMN IF EXISTS cost;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS costing_method;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An item's price becomes its list price, what it sells for, next to its cost price, what is
+-- paid for it. Both start out at the old price.
+ALTER TABLE items
+    RENAME COLUMN price TO list_price;
+
+ALTER INDEX idx_items_price_id RENAME TO idx_items_list_price_id;
+
+ALTER TABLE items
+    ADD COLUMN cost_price NUMERIC(12, 2) NOT NULL DEFAULT 0.0;
+
+UPDATE items
+SET cost_price = list_price;
+
+CREATE INDEX idx_items_cost_price_id ON items (cost_price, id);
+
+-- item_prices records the prices of every item over time: each row holds from effective_from
+-- until effective_to, the moment the next row took over, and the row still in effect has none.
+CREATE TABLE item_prices
+(
+    id             UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    item_id        UUID                     NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    cost_price     NUMERIC(12, 2)           NOT NULL,
+    list_price     NUMERIC(12, 2)           NOT NULL,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    effective_to   TIMESTAMP WITH TIME ZONE CHECK (effective_to >= effective_from),
+    changed_by     UUID REFERENCES users (id) -- NULL for rows recorded by this migration
+);
+
+CREATE UNIQUE INDEX idx_item_prices_current ON item_prices (item_id) WHERE effective_to IS NULL;
+CREATE INDEX idx_item_prices_item_id ON item_prices (item_id, effective_from);
+
+INSERT INTO item_prices (item_id, cost_price, list_price, effective_from)
+SELECT id, cost_price, list_price, COALESCE(created_at, NOW())
+FROM items;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS item_prices;
+
+DROP INDEX IF EXISTS idx_items_cost_price_id;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS cost_price;
+
+ALTER INDEX idx_items_list_price_id RENAME TO idx_items_price_id;
+
+ALTER TABLE items
+    RENAME COLUMN list_price TO price;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- exchange_rates holds what one unit of a currency is worth in the base currency, set in the
+-- configuration, from effective_from until the next rate of the currency takes effect. The base
+-- currency has no rates.
+CREATE TABLE exchange_rates
+(
+    id             UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    currency       TEXT                     NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    rate           NUMERIC(20, 10)          NOT NULL CHECK (rate > 0),
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by     UUID REFERENCES users (id),
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (currency, effective_from)
+);
+
+-- Prices without a currency are in the base currency.
+ALTER TABLE items
+    ADD COLUMN currency TEXT CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE item_prices
+    ADD COLUMN currency TEXT CHECK (currency ~ '^[A-Z]{3}$');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE item_prices
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
+-- +goose StatementEnd